@@ -109,6 +109,8 @@ type Backend interface {
 	// Returns the TCP or UNIX socket address the backend RPC server listens on
 	RPCAddress() (string, error)
 	EventBus() *types.EventBus // TODO: doesn't seem to be used, remove it
+	// Returns the fnConsensus reactor, or nil if the reactor isn't running
+	FnConsensusReactor() *fnConsensus.FnConsensusReactor
 }
 
 type TendermintBackend struct {
@@ -120,7 +122,8 @@ type TendermintBackend struct {
 	socketServer      tmcmn.Service
 	genesisValidators []*loom.Validator
 
-	FnRegistry fnConsensus.FnRegistry
+	FnRegistry         fnConsensus.FnRegistry
+	fnConsensusReactor *fnConsensus.FnConsensusReactor
 }
 
 // ParseConfig retrieves the default environment configuration,
@@ -425,6 +428,7 @@ func (b *TendermintBackend) Start(app abci.Application) error {
 			Name:    "FNCONSENSUS",
 			Reactor: fnConsensusReactor,
 		})
+		b.fnConsensusReactor = fnConsensusReactor
 	}
 
 	if b.SocketPath != "" {
@@ -460,6 +464,10 @@ func (b *TendermintBackend) Start(app abci.Application) error {
 	return nil
 }
 
+func (b *TendermintBackend) FnConsensusReactor() *fnConsensus.FnConsensusReactor {
+	return b.fnConsensusReactor
+}
+
 func (b *TendermintBackend) EventBus() *types.EventBus {
 	return b.node.EventBus()
 }
@@ -10,8 +10,11 @@ import (
 
 	"github.com/loomnetwork/go-loom/config"
 	"github.com/loomnetwork/go-loom/util"
+	"github.com/loomnetwork/loomchain/blockwindow"
 	"github.com/loomnetwork/loomchain/eth/utils"
 	"github.com/loomnetwork/loomchain/features"
+	"github.com/loomnetwork/loomchain/feemarket"
+	"github.com/loomnetwork/loomchain/gasmeter"
 	"github.com/loomnetwork/loomchain/registry"
 
 	"github.com/go-kit/kit/metrics"
@@ -48,6 +51,9 @@ type State interface {
 	Context() context.Context
 	WithContext(ctx context.Context) State
 	WithPrefix(prefix []byte) State
+	// WithStore returns a copy of the state that reads from & writes to the given store instead of
+	// the store backing this state.
+	WithStore(kvStore store.KVStore) State
 	SetFeature(string, bool)
 	SetMinBuildNumber(uint64)
 	ChangeConfigSetting(name, value string) error
@@ -200,6 +206,17 @@ func (s *StoreState) GetMinBuildNumber() uint64 {
 // ChangeConfigSetting updates the value of the given on-chain config setting.
 // If an error occurs while trying to update the config the change is discarded.
 func (s *StoreState) ChangeConfigSetting(name, value string) error {
+	// Fee market, gas meter & block window throttle settings are stored separately from the rest
+	// of the on-chain config.
+	if feemarket.IsSetting(name) {
+		return feemarket.ChangeConfigSetting(s.store, name, value)
+	}
+	if gasmeter.IsSetting(name) {
+		return gasmeter.ChangeConfigSetting(s.store, name, value)
+	}
+	if blockwindow.IsSetting(name) {
+		return blockwindow.ChangeConfigSetting(s.store, name, value)
+	}
 	cfg, err := store.LoadOnChainConfig(s.store)
 	if err != nil {
 		panic(err)
@@ -247,6 +264,17 @@ func (s *StoreState) WithPrefix(prefix []byte) State {
 	}
 }
 
+func (s *StoreState) WithStore(kvStore store.KVStore) State {
+	return &StoreState{
+		store:           kvStore,
+		block:           s.block,
+		ctx:             s.ctx,
+		validators:      s.validators,
+		getValidatorSet: s.getValidatorSet,
+		config:          s.config,
+	}
+}
+
 func (s *StoreState) Release() {
 	// noop
 }
@@ -311,6 +339,9 @@ type TxHandlerResult struct {
 	// Tags to associate with the tx that produced this result. Tags can be used to filter txs
 	// via the ABCI query interface (see https://godoc.org/github.com/tendermint/tendermint/libs/pubsub/query)
 	Tags []common.KVPair
+	// Gas consumed by the contracts executed by the tx, only set when gas metering is enabled. It's
+	// reported to Tendermint in the DeliverTx response, EVM tx receipts are left untouched.
+	GasUsed uint64
 }
 
 func (f TxHandlerFunc) ProcessTx(state State, txBytes []byte, isCheckTx bool) (TxHandlerResult, error) {
@@ -333,6 +364,11 @@ type ValidatorsManager interface {
 type ChainConfigManager interface {
 	EnableFeatures(blockHeight int64) error
 	UpdateConfig() (int, error)
+	ApplyPluginUpgrades(blockHeight int64) error
+}
+
+type GovernanceManager interface {
+	ExecuteProposals(blockHeight int64) error
 }
 
 type GetValidatorSet func(state State) (loom.ValidatorSet, error)
@@ -341,6 +377,8 @@ type ValidatorsManagerFactoryFunc func(state State) (ValidatorsManager, error)
 
 type ChainConfigManagerFactoryFunc func(state State) (ChainConfigManager, error)
 
+type GovernanceManagerFactoryFunc func(state State) (GovernanceManager, error)
+
 type CommittedTx struct {
 	result TxHandlerResult
 	txHash []byte
@@ -360,6 +398,9 @@ type Application struct {
 	blockindex.BlockIndexStore
 	CreateValidatorManager   ValidatorsManagerFactoryFunc
 	CreateChainConfigManager ChainConfigManagerFactoryFunc
+	// Callback function used to construct a governance manager at the end of each block, may be nil,
+	// and should return a nil manager when governance is disabled.
+	CreateGovernanceManager GovernanceManagerFactoryFunc
 	// Callback function used to construct a contract upkeep handler at the start of each block,
 	// should return a nil handler when the contract upkeep feature is disabled.
 	CreateContractUpkeepHandler func(state State) (KarmaHandler, error)
@@ -369,6 +410,9 @@ type Application struct {
 	childTxRefs                 []evmaux.ChildTxRef // links Tendermint txs to EVM txs
 	ReceiptsVersion             int32
 	committedTxs                []CommittedTx
+	// Callback function invoked with the height of each block once it's been committed, may be nil,
+	// must not block.
+	OnBlockCommitted func(height int64)
 }
 
 var _ abci.Application = &Application{}
@@ -565,6 +609,10 @@ func (a *Application) BeginBlock(req abci.RequestBeginBlock) abci.ResponseBeginB
 			// invalidate cached config so it's reloaded next time it's accessed
 			a.config = nil
 		}
+
+		if err := chainConfigManager.ApplyPluginUpgrades(a.height()); err != nil {
+			panic(err)
+		}
 	}
 
 	storeTx.Commit()
@@ -602,6 +650,12 @@ func (a *Application) EndBlock(req abci.RequestEndBlock) abci.ResponseEndBlock {
 		a.GetValidatorSet,
 	).WithOnChainConfig(a.config)
 
+	// Proposals are executed before the validator election so that changes to the DPOS params take
+	// effect immediately.
+	if a.CreateGovernanceManager != nil {
+		a.executeGovernanceProposals(storeTx, state)
+	}
+
 	validatorManager, err := a.CreateValidatorManager(state)
 	if err != registry.ErrNotFound {
 		if err != nil {
@@ -623,11 +677,36 @@ func (a *Application) EndBlock(req abci.RequestEndBlock) abci.ResponseEndBlock {
 			ValidatorUpdates: validators,
 		}
 	}
+	storeTx.Commit()
 	return abci.ResponseEndBlock{
 		ValidatorUpdates: []abci.ValidatorUpdate{},
 	}
 }
 
+// executeGovernanceProposals executes the governance proposals whose voting period has ended.
+// Proposals that fail to execute are marked as failed by the governance manager, if processing the
+// proposals fails altogether the error is logged and none of the changes made by the governance
+// manager are persisted, the proposals will be processed again at the end of the next block.
+func (a *Application) executeGovernanceProposals(kvStore store.KVStore, state State) {
+	govStoreTx := store.WrapAtomic(kvStore).BeginTx()
+	governanceManager, err := a.CreateGovernanceManager(state.WithStore(govStoreTx))
+	if err != nil {
+		govStoreTx.Rollback()
+		log.Error(fmt.Sprintf("failed to create governance manager, %v", err.Error()))
+		return
+	}
+	if governanceManager == nil {
+		govStoreTx.Rollback()
+		return
+	}
+	if err := governanceManager.ExecuteProposals(a.height()); err != nil {
+		govStoreTx.Rollback()
+		log.Error(fmt.Sprintf("failed to execute governance proposals, %v", err.Error()))
+		return
+	}
+	govStoreTx.Commit()
+}
+
 func (a *Application) CheckTx(txBytes []byte) abci.ResponseCheckTx {
 	var err error
 	defer func(begin time.Time) {
@@ -713,9 +792,11 @@ func (a *Application) deliverTx(storeTx store.KVStoreTx, txBytes []byte) abci.Re
 	r, err := a.processTx(storeTx, txBytes, false)
 	if err != nil {
 		log.Error("DeliverTx", "tx", hex.EncodeToString(ttypes.Tx(txBytes).Hash()), "err", err)
-		return abci.ResponseDeliverTx{Code: 1, Log: err.Error()}
+		return abci.ResponseDeliverTx{Code: 1, Log: err.Error(), GasUsed: int64(r.GasUsed)}
+	}
+	return abci.ResponseDeliverTx{
+		Code: abci.CodeTypeOK, Data: r.Data, Tags: r.Tags, Info: r.Info, GasUsed: int64(r.GasUsed),
 	}
-	return abci.ResponseDeliverTx{Code: abci.CodeTypeOK, Data: r.Data, Tags: r.Tags, Info: r.Info}
 }
 
 func (a *Application) processTx(storeTx store.KVStoreTx, txBytes []byte, isCheckTx bool) (TxHandlerResult, error) {
@@ -807,7 +888,7 @@ func (a *Application) deliverTx2(storeTx store.KVStoreTx, txBytes []byte) abci.R
 		// FIXME: Really shouldn't be using r.Data if txErr != nil, but need to refactor TxHandler.ProcessTx
 		//        so it only returns r with the correct status code & log fields.
 		// Pass the EVM tx hash (if any) back to Tendermint so it stores it in block results
-		return abci.ResponseDeliverTx{Code: 1, Data: r.Data, Log: txErr.Error()}
+		return abci.ResponseDeliverTx{Code: 1, Data: r.Data, Log: txErr.Error(), GasUsed: int64(r.GasUsed)}
 	}
 
 	a.EventHandler.Commit(uint64(a.curBlockHeader.GetHeight()))
@@ -818,7 +899,9 @@ func (a *Application) deliverTx2(storeTx store.KVStoreTx, txBytes []byte) abci.R
 		txHash: receiptTxHash,
 	})
 
-	return abci.ResponseDeliverTx{Code: abci.CodeTypeOK, Data: r.Data, Tags: r.Tags, Info: r.Info}
+	return abci.ResponseDeliverTx{
+		Code: abci.CodeTypeOK, Data: r.Data, Tags: r.Tags, Info: r.Info, GasUsed: int64(r.GasUsed),
+	}
 }
 
 // Commit commits the current block
@@ -877,6 +960,10 @@ func (a *Application) Commit() abci.ResponseCommit {
 	}(height, a.curBlockHeader, a.committedTxs)
 	a.committedTxs = nil
 
+	if a.OnBlockCommitted != nil {
+		a.OnBlockCommitted(height)
+	}
+
 	if err := a.Store.Prune(); err != nil {
 		log.Error("failed to prune app.db", "err", err)
 	}
@@ -2,6 +2,7 @@ package auth
 
 import (
 	"context"
+	"encoding/binary"
 	"errors"
 	"fmt"
 
@@ -95,6 +96,13 @@ func Nonce(state loomchain.ReadOnlyState, addr loom.Address) uint64 {
 	return loomchain.NewSequence(nonceKey(addr)).Value(state)
 }
 
+// SetNonce overwrites the nonce of the given account in the given store.
+func SetNonce(kvStore store.KVWriter, addr loom.Address, nonce uint64) {
+	nonceBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(nonceBytes, nonce)
+	kvStore.Set(nonceKey(addr), nonceBytes)
+}
+
 type NonceHandler struct {
 	nonceCache map[string]uint64 // stores the next nonce expected to be seen for each account
 	lastHeight int64
@@ -15,6 +15,7 @@ import (
 	"github.com/loomnetwork/loomchain"
 	"github.com/loomnetwork/loomchain/builtin/plugins/address_mapper"
 	"github.com/loomnetwork/loomchain/features"
+	"github.com/loomnetwork/loomchain/feemarket"
 	"github.com/pkg/errors"
 	"golang.org/x/crypto/ed25519"
 )
@@ -67,8 +68,20 @@ func NewMultiChainSignatureTxMiddleware(
 			return r, errors.Wrap(err, "failed to unmarshal NonceTx")
 		}
 
+		// When the fee market is enabled the Transaction is wrapped in a FeeTx, which will be
+		// stripped by the fee market middleware.
+		innerTxBytes := nonceTx.Inner
+		var feeTx *feemarket.FeeTx
+		if state.FeatureEnabled(features.FeeMarketFeature, false) {
+			feeTx = &feemarket.FeeTx{}
+			if err := proto.Unmarshal(nonceTx.Inner, feeTx); err != nil {
+				return r, errors.Wrap(err, "failed to unmarshal FeeTx")
+			}
+			innerTxBytes = feeTx.Inner
+		}
+
 		var tx types.Transaction
-		if err := proto.Unmarshal(nonceTx.Inner, &tx); err != nil {
+		if err := proto.Unmarshal(innerTxBytes, &tx); err != nil {
 			return r, errors.Wrap(err, "failed to unmarshal Transaction")
 		}
 
@@ -131,6 +144,14 @@ func NewMultiChainSignatureTxMiddleware(
 				return r, errors.Wrap(err, "failed to marshal Transaction")
 			}
 
+			if feeTx != nil {
+				feeTx.Inner = txBytes
+				txBytes, err = proto.Marshal(feeTx)
+				if err != nil {
+					return r, errors.Wrap(err, "failed to marshal FeeTx")
+				}
+			}
+
 			nonceTx.Inner = txBytes
 			nonceTxBytes, err := proto.Marshal(&nonceTx)
 			if err != nil {
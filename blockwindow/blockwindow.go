@@ -0,0 +1,89 @@
+package blockwindow
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/loomnetwork/loomchain/store"
+)
+
+const (
+	// SettingPrefix is the prefix of all the block window throttle settings that can be changed via
+	// the ChainConfig contract, e.g. ThrottleBlockWindow.TxLimiterMaxTxsPerSession
+	SettingPrefix = "ThrottleBlockWindow."
+
+	configKey = "blockwindow:config"
+)
+
+type Config = BlockWindowConfig
+
+// DefaultConfig returns the block window config that's used until it's changed via the ChainConfig
+// contract, the block window throttles are disabled until their session lengths are set.
+func DefaultConfig() *Config {
+	return &Config{}
+}
+
+// LoadConfig loads the block window config from the given kv store.
+func LoadConfig(kvStore store.KVReader) (*Config, error) {
+	cfg := DefaultConfig()
+	cfgBytes := kvStore.Get([]byte(configKey))
+	if len(cfgBytes) > 0 {
+		if err := proto.UnmarshalMerge(cfgBytes, cfg); err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
+
+// SaveConfig saves the block window config to the given kv store.
+func SaveConfig(kvStore store.KVWriter, cfg *Config) error {
+	cfgBytes, err := proto.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	kvStore.Set([]byte(configKey), cfgBytes)
+	return nil
+}
+
+// IsSetting returns true if the given config setting name refers to a block window setting.
+func IsSetting(name string) bool {
+	return strings.HasPrefix(name, SettingPrefix)
+}
+
+func settingFields(cfg *Config) map[string]*int64 {
+	return map[string]*int64{
+		"KarmaSessionDurationBlocks":     &cfg.KarmaSessionDurationBlocks,
+		"KarmaMaxCallCount":              &cfg.KarmaMaxCallCount,
+		"TxLimiterSessionDurationBlocks": &cfg.TxLimiterSessionDurationBlocks,
+		"TxLimiterMaxTxsPerSession":      &cfg.TxLimiterMaxTxsPerSession,
+	}
+}
+
+// SetConfigSetting updates a block window config setting, the name of the setting must include
+// the SettingPrefix.
+func SetConfigSetting(cfg *Config, name, value string) error {
+	field, ok := settingFields(cfg)[strings.TrimPrefix(name, SettingPrefix)]
+	if !ok {
+		return fmt.Errorf("unknown block window setting %s", name)
+	}
+	v, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || v < 0 {
+		return fmt.Errorf("invalid value for %s: %s", name, value)
+	}
+	*field = v
+	return nil
+}
+
+// ChangeConfigSetting updates the value of the given block window setting in the kv store.
+func ChangeConfigSetting(kvStore store.KVStore, name, value string) error {
+	cfg, err := LoadConfig(kvStore)
+	if err != nil {
+		return err
+	}
+	if err := SetConfigSetting(cfg, name, value); err != nil {
+		return err
+	}
+	return SaveConfig(kvStore, cfg)
+}
@@ -0,0 +1,29 @@
+package blockwindow
+
+import (
+	"testing"
+
+	"github.com/loomnetwork/loomchain/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangeConfigSetting(t *testing.T) {
+	kvStore := store.NewMemStore()
+	require.True(t, IsSetting("ThrottleBlockWindow.KarmaMaxCallCount"))
+	require.False(t, IsSetting("GasMeter.TxGasLimit"))
+
+	cfg, err := LoadConfig(kvStore)
+	require.NoError(t, err)
+	require.Equal(t, int64(0), cfg.TxLimiterSessionDurationBlocks)
+
+	require.NoError(t, ChangeConfigSetting(kvStore, "ThrottleBlockWindow.TxLimiterSessionDurationBlocks", "10"))
+	require.NoError(t, ChangeConfigSetting(kvStore, "ThrottleBlockWindow.TxLimiterMaxTxsPerSession", "2"))
+	require.Error(t, ChangeConfigSetting(kvStore, "ThrottleBlockWindow.KarmaMaxCallCount", "-1"))
+	require.Error(t, ChangeConfigSetting(kvStore, "ThrottleBlockWindow.Unknown", "1"))
+
+	cfg, err = LoadConfig(kvStore)
+	require.NoError(t, err)
+	require.Equal(t, int64(10), cfg.TxLimiterSessionDurationBlocks)
+	require.Equal(t, int64(2), cfg.TxLimiterMaxTxsPerSession)
+	require.Equal(t, int64(0), cfg.KarmaMaxCallCount)
+}
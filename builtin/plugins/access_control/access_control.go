@@ -0,0 +1,280 @@
+package access_control
+
+import (
+	"github.com/gogo/protobuf/proto"
+	loom "github.com/loomnetwork/go-loom"
+	"github.com/loomnetwork/go-loom/plugin"
+	contract "github.com/loomnetwork/go-loom/plugin/contractpb"
+	"github.com/loomnetwork/go-loom/util"
+	"github.com/pkg/errors"
+)
+
+type (
+	Config                   = AccessControlConfig
+	AllowedAccount           = AccessControlAllowedAccount
+	DeniedAccount            = AccessControlDeniedAccount
+	InitRequest              = AccessControlInitRequest
+	SetAllowlistOnlyRequest  = AccessControlSetAllowlistOnlyRequest
+	AllowAccountRequest      = AccessControlAllowAccountRequest
+	DisallowAccountRequest   = AccessControlDisallowAccountRequest
+	DenyAccountRequest       = AccessControlDenyAccountRequest
+	UndenyAccountRequest     = AccessControlUndenyAccountRequest
+	GetAccountAccessRequest  = AccessControlGetAccountAccessRequest
+	GetAccountAccessResponse = AccessControlGetAccountAccessResponse
+	ListAccessRequest        = AccessControlListAccessRequest
+	ListAccessResponse       = AccessControlListAccessResponse
+)
+
+var (
+	// ErrNotAuthorized indicates that a contract method failed because the caller didn't have
+	// the permission to execute that method.
+	ErrNotAuthorized = errors.New("[AccessControl] not authorized")
+	// ErrInvalidRequest is a generic error that's returned when something is wrong with the
+	// request message, e.g. missing or invalid fields.
+	ErrInvalidRequest = errors.New("[AccessControl] invalid request")
+	// ErrOwnerNotSpecified returned if init request does not have owner address
+	ErrOwnerNotSpecified = errors.New("[AccessControl] owner not specified")
+	// ErrAccountDenied is returned when a tx is sent by an account that's in the deny list
+	ErrAccountDenied = errors.New("[AccessControl] account is not allowed to send this tx")
+	// ErrAccountNotAllowed is returned when allowlist-only mode is enabled and a tx is sent by
+	// an account that's not in the allow list
+	ErrAccountNotAllowed = errors.New("[AccessControl] account is not in the allow list")
+)
+
+const (
+	ownerRole = "owner"
+)
+
+var (
+	modifyPerm = []byte("modp")
+
+	configKey     = []byte("config")
+	allowedPrefix = []byte("allowed")
+	deniedPrefix  = []byte("denied")
+)
+
+func allowedKey(addr loom.Address) []byte {
+	return util.PrefixKey(allowedPrefix, addr.Bytes())
+}
+
+func deniedKey(addr loom.Address) []byte {
+	return util.PrefixKey(deniedPrefix, addr.Bytes())
+}
+
+type AccessControl struct {
+}
+
+func (ac *AccessControl) Meta() (plugin.Meta, error) {
+	return plugin.Meta{
+		Name:    "accesscontrol",
+		Version: "1.0.0",
+	}, nil
+}
+
+func (ac *AccessControl) Init(ctx contract.Context, req *InitRequest) error {
+	if req.Owner == nil {
+		return ErrOwnerNotSpecified
+	}
+	ownerAddr := loom.UnmarshalAddressPB(req.Owner)
+	ctx.GrantPermissionTo(ownerAddr, modifyPerm, ownerRole)
+
+	cfg := &Config{
+		Owner:         req.Owner,
+		AllowlistOnly: req.AllowlistOnly,
+	}
+	if err := ctx.Set(configKey, cfg); err != nil {
+		return err
+	}
+
+	for _, acct := range req.AllowedAccounts {
+		if acct.Account == nil {
+			return ErrInvalidRequest
+		}
+		if err := ctx.Set(allowedKey(loom.UnmarshalAddressPB(acct.Account)), acct); err != nil {
+			return err
+		}
+	}
+	for _, acct := range req.DeniedAccounts {
+		if acct.Account == nil {
+			return ErrInvalidRequest
+		}
+		if err := ctx.Set(deniedKey(loom.UnmarshalAddressPB(acct.Account)), acct); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetAllowlistOnly switches allowlist-only mode on or off, while it's on only the accounts in the
+// allow list (and the contract owner) can send txs.
+func (ac *AccessControl) SetAllowlistOnly(ctx contract.Context, req *SetAllowlistOnlyRequest) error {
+	if ok, _ := ctx.HasPermission(modifyPerm, []string{ownerRole}); !ok {
+		return ErrNotAuthorized
+	}
+	cfg, err := getConfig(ctx)
+	if err != nil {
+		return err
+	}
+	cfg.AllowlistOnly = req.Enabled
+	return ctx.Set(configKey, cfg)
+}
+
+// AllowAccount adds an account to the allow list.
+func (ac *AccessControl) AllowAccount(ctx contract.Context, req *AllowAccountRequest) error {
+	if ok, _ := ctx.HasPermission(modifyPerm, []string{ownerRole}); !ok {
+		return ErrNotAuthorized
+	}
+	if req.Account == nil {
+		return ErrInvalidRequest
+	}
+	return ctx.Set(allowedKey(loom.UnmarshalAddressPB(req.Account)), &AllowedAccount{Account: req.Account})
+}
+
+// DisallowAccount removes an account from the allow list.
+func (ac *AccessControl) DisallowAccount(ctx contract.Context, req *DisallowAccountRequest) error {
+	if ok, _ := ctx.HasPermission(modifyPerm, []string{ownerRole}); !ok {
+		return ErrNotAuthorized
+	}
+	if req.Account == nil {
+		return ErrInvalidRequest
+	}
+	ctx.Delete(allowedKey(loom.UnmarshalAddressPB(req.Account)))
+	return nil
+}
+
+// DenyAccount adds an account to the deny list, if any contracts are specified only calls to those
+// contracts are denied, otherwise all txs from the account are denied. Replaces any existing deny
+// list entry for the account.
+func (ac *AccessControl) DenyAccount(ctx contract.Context, req *DenyAccountRequest) error {
+	if ok, _ := ctx.HasPermission(modifyPerm, []string{ownerRole}); !ok {
+		return ErrNotAuthorized
+	}
+	if req.Account == nil {
+		return ErrInvalidRequest
+	}
+	return ctx.Set(deniedKey(loom.UnmarshalAddressPB(req.Account)), &DeniedAccount{
+		Account:   req.Account,
+		Contracts: req.Contracts,
+	})
+}
+
+// UndenyAccount removes an account from the deny list.
+func (ac *AccessControl) UndenyAccount(ctx contract.Context, req *UndenyAccountRequest) error {
+	if ok, _ := ctx.HasPermission(modifyPerm, []string{ownerRole}); !ok {
+		return ErrNotAuthorized
+	}
+	if req.Account == nil {
+		return ErrInvalidRequest
+	}
+	ctx.Delete(deniedKey(loom.UnmarshalAddressPB(req.Account)))
+	return nil
+}
+
+func (ac *AccessControl) GetAccountAccess(
+	ctx contract.StaticContext, req *GetAccountAccessRequest,
+) (*GetAccountAccessResponse, error) {
+	if req.Account == nil {
+		return nil, ErrInvalidRequest
+	}
+	addr := loom.UnmarshalAddressPB(req.Account)
+	denied, err := getDeniedAccount(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	return &GetAccountAccessResponse{
+		Allowed: ctx.Has(allowedKey(addr)),
+		Denied:  denied,
+	}, nil
+}
+
+func (ac *AccessControl) ListAccess(
+	ctx contract.StaticContext, req *ListAccessRequest,
+) (*ListAccessResponse, error) {
+	cfg, err := getConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := []*AllowedAccount{}
+	for _, entry := range ctx.Range(allowedPrefix) {
+		var acct AllowedAccount
+		if err := proto.Unmarshal(entry.Value, &acct); err != nil {
+			return nil, errors.Wrapf(err, "unmarshal allowed account %x", entry.Key)
+		}
+		allowed = append(allowed, &acct)
+	}
+
+	denied := []*DeniedAccount{}
+	for _, entry := range ctx.Range(deniedPrefix) {
+		var acct DeniedAccount
+		if err := proto.Unmarshal(entry.Value, &acct); err != nil {
+			return nil, errors.Wrapf(err, "unmarshal denied account %x", entry.Key)
+		}
+		denied = append(denied, &acct)
+	}
+
+	return &ListAccessResponse{
+		AllowlistOnly:   cfg.AllowlistOnly,
+		AllowedAccounts: allowed,
+		DeniedAccounts:  denied,
+	}, nil
+}
+
+func getConfig(ctx contract.StaticContext) (*Config, error) {
+	var cfg Config
+	if err := ctx.Get(configKey, &cfg); err != nil && err != contract.ErrNotFound {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+func getDeniedAccount(ctx contract.StaticContext, addr loom.Address) (*DeniedAccount, error) {
+	var acct DeniedAccount
+	err := ctx.Get(deniedKey(addr), &acct)
+	if err == contract.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &acct, nil
+}
+
+// CheckAccess is called by the AccessControl middleware to check if the sender is allowed to send
+// a tx to the given contract. The contract address should be nil for txs that don't target an
+// existing contract.
+func CheckAccess(ctx contract.StaticContext, sender loom.Address, contractAddr *loom.Address) error {
+	cfg, err := getConfig(ctx)
+	if err != nil {
+		return err
+	}
+	// The owner must always be able to send txs, otherwise it wouldn't be able to undo a mistake.
+	if cfg.Owner != nil && loom.UnmarshalAddressPB(cfg.Owner).Compare(sender) == 0 {
+		return nil
+	}
+
+	if cfg.AllowlistOnly && !ctx.Has(allowedKey(sender)) {
+		return ErrAccountNotAllowed
+	}
+
+	denied, err := getDeniedAccount(ctx, sender)
+	if err != nil {
+		return err
+	}
+	if denied == nil {
+		return nil
+	}
+	if len(denied.Contracts) == 0 {
+		return ErrAccountDenied
+	}
+	if contractAddr != nil {
+		for _, c := range denied.Contracts {
+			if loom.UnmarshalAddressPB(c).Compare(*contractAddr) == 0 {
+				return ErrAccountDenied
+			}
+		}
+	}
+	return nil
+}
+
+var Contract plugin.Contract = contract.MakePluginContract(&AccessControl{})
@@ -0,0 +1,88 @@
+package access_control
+
+import (
+	"testing"
+
+	loom "github.com/loomnetwork/go-loom"
+	"github.com/loomnetwork/go-loom/plugin"
+	"github.com/loomnetwork/go-loom/plugin/contractpb"
+	"github.com/loomnetwork/go-loom/types"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	owner     = loom.MustParseAddress("default:0xb16a379ec18d4093666f8f38b11a3071c920207d")
+	addr1     = loom.MustParseAddress("default:0xfa4c7920accfd66b86f5fd0e69682a79f762d49e")
+	addr2     = loom.MustParseAddress("default:0x5cecd1f7261e1f4c684e297be3edf03b825e01c4")
+	contract1 = loom.MustParseAddress("default:0x46ecd1f7261e1f4c684e297be3edf03b825e01c4")
+	contract2 = loom.MustParseAddress("default:0x76ecd1f7261fcf4c684e297be3edf03b825e01c4")
+)
+
+func TestDenyList(t *testing.T) {
+	pctx := plugin.CreateFakeContext(owner, owner)
+	ctx := contractpb.WrapPluginContext(pctx)
+
+	acContract := &AccessControl{}
+	require.NoError(t, acContract.Init(ctx, &InitRequest{Owner: owner.MarshalPB()}))
+
+	require.NoError(t, CheckAccess(ctx, addr1, &contract1))
+
+	// deny calls to a single contract
+	require.NoError(t, acContract.DenyAccount(ctx, &DenyAccountRequest{
+		Account:   addr1.MarshalPB(),
+		Contracts: []*types.Address{contract1.MarshalPB()},
+	}))
+	require.Equal(t, ErrAccountDenied, CheckAccess(ctx, addr1, &contract1))
+	require.NoError(t, CheckAccess(ctx, addr1, &contract2))
+	require.NoError(t, CheckAccess(ctx, addr1, nil))
+	require.NoError(t, CheckAccess(ctx, addr2, &contract1))
+
+	// deny all txs
+	require.NoError(t, acContract.DenyAccount(ctx, &DenyAccountRequest{Account: addr1.MarshalPB()}))
+	require.Equal(t, ErrAccountDenied, CheckAccess(ctx, addr1, &contract2))
+	require.Equal(t, ErrAccountDenied, CheckAccess(ctx, addr1, nil))
+
+	resp, err := acContract.GetAccountAccess(ctx, &GetAccountAccessRequest{Account: addr1.MarshalPB()})
+	require.NoError(t, err)
+	require.False(t, resp.Allowed)
+	require.NotNil(t, resp.Denied)
+
+	// only the owner can modify the lists
+	ctx = contractpb.WrapPluginContext(pctx.WithSender(addr2))
+	require.Equal(t, ErrNotAuthorized, acContract.UndenyAccount(ctx, &UndenyAccountRequest{
+		Account: addr1.MarshalPB(),
+	}))
+
+	ctx = contractpb.WrapPluginContext(pctx.WithSender(owner))
+	require.NoError(t, acContract.UndenyAccount(ctx, &UndenyAccountRequest{Account: addr1.MarshalPB()}))
+	require.NoError(t, CheckAccess(ctx, addr1, &contract1))
+}
+
+func TestAllowlistOnly(t *testing.T) {
+	pctx := plugin.CreateFakeContext(owner, owner)
+	ctx := contractpb.WrapPluginContext(pctx)
+
+	acContract := &AccessControl{}
+	require.NoError(t, acContract.Init(ctx, &InitRequest{
+		Owner:           owner.MarshalPB(),
+		AllowlistOnly:   true,
+		AllowedAccounts: []*AllowedAccount{{Account: addr1.MarshalPB()}},
+	}))
+
+	require.NoError(t, CheckAccess(ctx, owner, nil))
+	require.NoError(t, CheckAccess(ctx, addr1, &contract1))
+	require.Equal(t, ErrAccountNotAllowed, CheckAccess(ctx, addr2, &contract1))
+
+	require.NoError(t, acContract.AllowAccount(ctx, &AllowAccountRequest{Account: addr2.MarshalPB()}))
+	require.NoError(t, CheckAccess(ctx, addr2, &contract1))
+	require.NoError(t, acContract.DisallowAccount(ctx, &DisallowAccountRequest{Account: addr1.MarshalPB()}))
+	require.Equal(t, ErrAccountNotAllowed, CheckAccess(ctx, addr1, &contract1))
+
+	list, err := acContract.ListAccess(ctx, &ListAccessRequest{})
+	require.NoError(t, err)
+	require.True(t, list.AllowlistOnly)
+	require.Len(t, list.AllowedAccounts, 1)
+
+	require.NoError(t, acContract.SetAllowlistOnly(ctx, &SetAllowlistOnlyRequest{Enabled: false}))
+	require.NoError(t, CheckAccess(ctx, addr1, &contract1))
+}
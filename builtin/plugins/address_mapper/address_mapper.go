@@ -6,6 +6,7 @@ import (
 	"bytes"
 	"crypto/ecdsa"
 	"fmt"
+	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/gogo/protobuf/proto"
@@ -14,6 +15,7 @@ import (
 	"github.com/loomnetwork/go-loom/common/evmcompat"
 	"github.com/loomnetwork/go-loom/plugin"
 	contract "github.com/loomnetwork/go-loom/plugin/contractpb"
+	"github.com/loomnetwork/go-loom/types"
 	"github.com/loomnetwork/go-loom/util"
 	"github.com/loomnetwork/loomchain/features"
 	ssha "github.com/miguelmota/go-solidity-sha3"
@@ -23,7 +25,7 @@ import (
 type (
 	AddressMapping = amtypes.AddressMapperMapping
 
-	InitRequest               = amtypes.AddressMapperInitRequest
+	InitRequest               = AddressMapperInitRequest
 	AddIdentityMappingRequest = amtypes.AddressMapperAddIdentityMappingRequest
 	RemoveMappingRequest      = amtypes.AddressMapperRemoveMappingRequest
 	GetMappingRequest         = amtypes.AddressMapperGetMappingRequest
@@ -34,6 +36,19 @@ type (
 
 	ListMappingRequest  = amtypes.AddressMapperListMappingRequest
 	ListMappingResponse = amtypes.AddressMapperListMappingResponse
+
+	SetRecoveryAdminRequest  = AddressMapperSetRecoveryAdminRequest
+	GetRecoveryAdminRequest  = AddressMapperGetRecoveryAdminRequest
+	GetRecoveryAdminResponse = AddressMapperGetRecoveryAdminResponse
+	RotateMappingRequest     = AddressMapperRotateMappingRequest
+	GetRotationNonceRequest  = AddressMapperGetRotationNonceRequest
+	GetRotationNonceResponse = AddressMapperGetRotationNonceResponse
+	RotationNonce            = AddressMapperRotationNonce
+	MappingRotatedEvent      = AddressMapperMappingRotatedEvent
+)
+
+const (
+	MappingRotatedEventTopic = "addressmapper:mappingrotated"
 )
 
 var (
@@ -46,14 +61,26 @@ var (
 	// ErrAlreadyRegistered indicates that from and/or to are already registered in
 	// address mapper contract.
 	ErrAlreadyRegistered = errors.New("[Address Mapper] identity mapping already exists")
+	// ErrMappingNotFound indicates that the mapping that was supposed to be modified doesn't exist.
+	ErrMappingNotFound = errors.New("[Address Mapper] identity mapping doesn't exist")
+	// ErrFeatureNotEnabled indicates that a contract method can't be called yet because the
+	// feature flag that gates it hasn't been enabled.
+	ErrFeatureNotEnabled = errors.New("[Address Mapper] feature not enabled")
 
 	AddressPrefix = "addr"
+
+	recoveryAdminKey    = []byte("recovery-admin")
+	rotationNoncePrefix = []byte("rotation-nonce")
 )
 
 func addressKey(addr loom.Address) []byte {
 	return util.PrefixKey([]byte(AddressPrefix), addr.Bytes())
 }
 
+func rotationNonceKey(addr loom.Address) []byte {
+	return util.PrefixKey(rotationNoncePrefix, addr.Bytes())
+}
+
 type AddressMapper struct {
 }
 
@@ -65,6 +92,9 @@ func (am *AddressMapper) Meta() (plugin.Meta, error) {
 }
 
 func (am *AddressMapper) Init(ctx contract.Context, req *InitRequest) error {
+	if req.RecoveryAdmin != nil {
+		return ctx.Set(recoveryAdminKey, req.RecoveryAdmin)
+	}
 	return nil
 }
 
@@ -83,16 +113,7 @@ func (am *AddressMapper) AddIdentityMapping(ctx contract.Context, req *AddIdenti
 		return ErrInvalidRequest
 	}
 
-	allowedSigTypes := []evmcompat.SignatureType{
-		evmcompat.SignatureType_EIP712,
-		evmcompat.SignatureType_GETH,
-		evmcompat.SignatureType_TREZOR,
-		evmcompat.SignatureType_TRON,
-	}
-	if ctx.FeatureEnabled(features.AddressMapperVersion1_1, false) {
-		allowedSigTypes = append(allowedSigTypes, evmcompat.SignatureType_BINANCE)
-	}
-
+	allowedSigTypes := allowedSignatureTypes(ctx)
 	callerAddr := ctx.Message().Sender
 	if callerAddr.Compare(from) == 0 {
 		if err := verifySig(from, to, to.ChainID, req.Signature, allowedSigTypes); err != nil {
@@ -137,6 +158,159 @@ func (am *AddressMapper) AddIdentityMapping(ctx contract.Context, req *AddIdenti
 	return nil
 }
 
+// RotateMapping re-points the mapping of a DAppChain account from the currently mapped foreign
+// account to a new foreign account, this allows users to recover from a compromised foreign key
+// without losing their DAppChain identity.
+// The caller must be either the DAppChain account itself, in which case the request must be signed
+// by both the old & new foreign keys, or the recovery admin, in which case only the signature from
+// the new foreign key is required.
+func (am *AddressMapper) RotateMapping(ctx contract.Context, req *RotateMappingRequest) error {
+	if !ctx.FeatureEnabled(features.AddressMapperVersion1_2, false) {
+		return ErrFeatureNotEnabled
+	}
+	if req.From == nil || req.OldTo == nil || req.NewTo == nil || len(req.NewSignature) == 0 {
+		return ErrInvalidRequest
+	}
+	from := loom.UnmarshalAddressPB(req.From)
+	oldTo := loom.UnmarshalAddressPB(req.OldTo)
+	newTo := loom.UnmarshalAddressPB(req.NewTo)
+	if from.ChainID == "" || oldTo.ChainID == "" || newTo.ChainID == "" {
+		return ErrInvalidRequest
+	}
+	if oldTo.ChainID != newTo.ChainID || oldTo.Compare(newTo) == 0 || from.Compare(newTo) == 0 {
+		return ErrInvalidRequest
+	}
+
+	callerAddr := ctx.Message().Sender
+	byRecoveryAdmin := false
+	if callerAddr.Compare(from) != 0 {
+		isAdmin, err := isRecoveryAdmin(ctx, callerAddr)
+		if err != nil {
+			return err
+		}
+		if !isAdmin {
+			return ErrNotAuthorized
+		}
+		byRecoveryAdmin = true
+	}
+
+	var existingMapping AddressMapping
+	if err := ctx.Get(addressKey(from), &existingMapping); err != nil {
+		if err == contract.ErrNotFound {
+			return ErrMappingNotFound
+		}
+		return err
+	}
+	if loom.UnmarshalAddressPB(existingMapping.To).Compare(oldTo) != 0 {
+		return ErrMappingNotFound
+	}
+	if err := ctx.Get(addressKey(newTo), &existingMapping); err != contract.ErrNotFound {
+		if err == nil {
+			return ErrAlreadyRegistered
+		}
+		return err
+	}
+
+	nonce, err := getRotationNonce(ctx, from)
+	if err != nil {
+		return err
+	}
+
+	allowedSigTypes := allowedSignatureTypes(ctx)
+	if err := verifyRotationSig(from, oldTo, newTo, nonce, newTo, req.NewSignature, allowedSigTypes); err != nil {
+		return errors.Wrap(err, ErrNotAuthorized.Error())
+	}
+	// The recovery admin is allowed to rotate a mapping without the old key (which may have been
+	// lost), but if the signature is provided it must still be valid.
+	if !byRecoveryAdmin || len(req.OldSignature) > 0 {
+		if len(req.OldSignature) == 0 {
+			return ErrInvalidRequest
+		}
+		if err := verifyRotationSig(from, oldTo, newTo, nonce, oldTo, req.OldSignature, allowedSigTypes); err != nil {
+			return errors.Wrap(err, ErrNotAuthorized.Error())
+		}
+	}
+
+	ctx.Delete(addressKey(oldTo))
+	if err := ctx.Set(addressKey(from), &AddressMapping{
+		From: req.From,
+		To:   req.NewTo,
+	}); err != nil {
+		return err
+	}
+	if err := ctx.Set(addressKey(newTo), &AddressMapping{
+		From: req.NewTo,
+		To:   req.From,
+	}); err != nil {
+		return err
+	}
+	if err := ctx.Set(rotationNonceKey(from), &RotationNonce{Nonce: nonce + 1}); err != nil {
+		return err
+	}
+	return emitMappingRotatedEvent(ctx, req.From, req.OldTo, req.NewTo, byRecoveryAdmin)
+}
+
+// GetRotationNonce returns the nonce that must be included in the signatures of the next
+// RotateMapping request for the given DAppChain account.
+func (am *AddressMapper) GetRotationNonce(
+	ctx contract.StaticContext, req *GetRotationNonceRequest,
+) (*GetRotationNonceResponse, error) {
+	if req.From == nil {
+		return nil, ErrInvalidRequest
+	}
+	nonce, err := getRotationNonce(ctx, loom.UnmarshalAddressPB(req.From))
+	if err != nil {
+		return nil, err
+	}
+	return &GetRotationNonceResponse{Nonce: nonce}, nil
+}
+
+// SetRecoveryAdmin changes the account that's allowed to rotate mappings without a signature from
+// the old mapped key, only the current recovery admin can call this method. If there's no recovery
+// admin it can be set by the AddressMapperRecoveryAdminMigration (migration ID 4).
+func (am *AddressMapper) SetRecoveryAdmin(ctx contract.Context, req *SetRecoveryAdminRequest) error {
+	if !ctx.FeatureEnabled(features.AddressMapperVersion1_2, false) {
+		return ErrFeatureNotEnabled
+	}
+	if req.RecoveryAdmin == nil {
+		return ErrInvalidRequest
+	}
+	isAdmin, err := isRecoveryAdmin(ctx, ctx.Message().Sender)
+	if err != nil {
+		return err
+	}
+	if !isAdmin {
+		return ErrNotAuthorized
+	}
+	return ctx.Set(recoveryAdminKey, req.RecoveryAdmin)
+}
+
+// ResetRecoveryAdmin sets the recovery admin without checking the caller. The recovery admin can
+// only be set by Init, or by the current recovery admin, so this is used by the migration that
+// sets the recovery admin of contracts that were deployed without one, or that lost it.
+func ResetRecoveryAdmin(ctx contract.Context, req *SetRecoveryAdminRequest) error {
+	if !ctx.FeatureEnabled(features.AddressMapperVersion1_2, false) {
+		return ErrFeatureNotEnabled
+	}
+	if req.RecoveryAdmin == nil {
+		return ErrInvalidRequest
+	}
+	return ctx.Set(recoveryAdminKey, req.RecoveryAdmin)
+}
+
+func (am *AddressMapper) GetRecoveryAdmin(
+	ctx contract.StaticContext, req *GetRecoveryAdminRequest,
+) (*GetRecoveryAdminResponse, error) {
+	var admin types.Address
+	if err := ctx.Get(recoveryAdminKey, &admin); err != nil {
+		if err == contract.ErrNotFound {
+			return &GetRecoveryAdminResponse{}, nil
+		}
+		return nil, err
+	}
+	return &GetRecoveryAdminResponse{RecoveryAdmin: &admin}, nil
+}
+
 func (am *AddressMapper) RemoveMapping(ctx contract.StaticContext, req *RemoveMappingRequest) error {
 	// TODO
 	return nil
@@ -201,6 +375,92 @@ func (am *AddressMapper) GetMapping(ctx contract.StaticContext, req *GetMappingR
 	}, nil
 }
 
+func allowedSignatureTypes(ctx contract.StaticContext) []evmcompat.SignatureType {
+	allowedSigTypes := []evmcompat.SignatureType{
+		evmcompat.SignatureType_EIP712,
+		evmcompat.SignatureType_GETH,
+		evmcompat.SignatureType_TREZOR,
+		evmcompat.SignatureType_TRON,
+	}
+	if ctx.FeatureEnabled(features.AddressMapperVersion1_1, false) {
+		allowedSigTypes = append(allowedSigTypes, evmcompat.SignatureType_BINANCE)
+	}
+	return allowedSigTypes
+}
+
+func isRecoveryAdmin(ctx contract.StaticContext, addr loom.Address) (bool, error) {
+	var admin types.Address
+	if err := ctx.Get(recoveryAdminKey, &admin); err != nil {
+		if err == contract.ErrNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	return loom.UnmarshalAddressPB(&admin).Compare(addr) == 0, nil
+}
+
+func getRotationNonce(ctx contract.StaticContext, addr loom.Address) (uint64, error) {
+	var nonce RotationNonce
+	if err := ctx.Get(rotationNonceKey(addr), &nonce); err != nil {
+		if err == contract.ErrNotFound {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return nonce.Nonce, nil
+}
+
+func emitMappingRotatedEvent(
+	ctx contract.Context, from, oldTo, newTo *types.Address, byRecoveryAdmin bool,
+) error {
+	marshalled, err := proto.Marshal(&MappingRotatedEvent{
+		From:            from,
+		OldTo:           oldTo,
+		NewTo:           newTo,
+		ByRecoveryAdmin: byRecoveryAdmin,
+	})
+	if err != nil {
+		return err
+	}
+	ctx.EmitTopics(marshalled, MappingRotatedEventTopic)
+	return nil
+}
+
+func rotationHash(from, oldTo, newTo loom.Address, nonce uint64, sigType evmcompat.SignatureType) []byte {
+	nonceBytes := common.LeftPadBytes(new(big.Int).SetUint64(nonce).Bytes(), 32)
+	if sigType == evmcompat.SignatureType_BINANCE {
+		return evmcompat.GenSHA256(
+			ssha.Address(common.BytesToAddress(from.Local)),
+			ssha.Address(common.BytesToAddress(oldTo.Local)),
+			ssha.Address(common.BytesToAddress(newTo.Local)),
+			nonceBytes,
+		)
+	}
+	return ssha.SoliditySHA3(
+		ssha.Address(common.BytesToAddress(from.Local)),
+		ssha.Address(common.BytesToAddress(oldTo.Local)),
+		ssha.Address(common.BytesToAddress(newTo.Local)),
+		nonceBytes,
+	)
+}
+
+// verifyRotationSig checks that the given signature was created by the signer over the rotation of
+// the from mapping from oldTo to newTo.
+func verifyRotationSig(
+	from, oldTo, newTo loom.Address, nonce uint64, signer loom.Address, sig []byte,
+	allowedSigTypes []evmcompat.SignatureType,
+) error {
+	hash := rotationHash(from, oldTo, newTo, nonce, evmcompat.SignatureType(sig[0]))
+	signerAddr, err := evmcompat.RecoverAddressFromTypedSig(hash, sig, allowedSigTypes)
+	if err != nil {
+		return err
+	}
+	if bytes.Compare(signerAddr.Bytes(), signer.Local) != 0 {
+		return fmt.Errorf("signer address doesn't match, %s != %s", signerAddr.Hex(), signer.Local.String())
+	}
+	return nil
+}
+
 func verifySig(from, to loom.Address, chainID string, sig []byte, allowedSigTypes []evmcompat.SignatureType) error {
 	if (chainID != from.ChainID) && (chainID != to.ChainID) {
 		return fmt.Errorf("chain ID %s doesn't match either address", chainID)
@@ -250,4 +510,17 @@ func SignIdentityMapping(from, to loom.Address, key *ecdsa.PrivateKey, sigType e
 	return evmcompat.GenerateTypedSig(hash, key, sigType)
 }
 
+// SignMappingRotation generates a signature that can be used to rotate the mapping of a DAppChain
+// account from oldTo to newTo, the nonce must match the current rotation nonce of the
+// DAppChain account.
+func SignMappingRotation(
+	from, oldTo, newTo loom.Address, nonce uint64, key *ecdsa.PrivateKey, sigType evmcompat.SignatureType,
+) ([]byte, error) {
+	hash := rotationHash(from, oldTo, newTo, nonce, sigType)
+	if sigType == evmcompat.SignatureType_TRON {
+		hash = evmcompat.PrefixHeader(hash, evmcompat.SignatureType_TRON)
+	}
+	return evmcompat.GenerateTypedSig(hash, key, sigType)
+}
+
 var Contract plugin.Contract = contract.MakePluginContract(&AddressMapper{})
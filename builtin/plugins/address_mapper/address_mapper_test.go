@@ -342,6 +342,163 @@ func (s *AddressMapperTestSuite) TestAddressMapperAddNewInvalidIdentityMapping()
 	}), "Should error if local chain address doesn't match caller address")
 }
 
+func (s *AddressMapperTestSuite) TestRotateMapping() {
+	r := s.Require()
+	fakeCtx := plugin.CreateFakeContext(s.validDAppAddr /*caller*/, loom.RootAddress("chain") /*contract*/)
+	ctx := contract.WrapPluginContext(fakeCtx)
+
+	amContract := &AddressMapper{}
+	r.NoError(amContract.Init(ctx, &InitRequest{}))
+
+	sig, err := SignIdentityMapping(s.validEthAddr, s.validDAppAddr, s.validEthKey, sigType)
+	r.NoError(err)
+	r.NoError(amContract.AddIdentityMapping(ctx, &AddIdentityMappingRequest{
+		From:      s.validEthAddr.MarshalPB(),
+		To:        s.validDAppAddr.MarshalPB(),
+		Signature: sig,
+	}))
+
+	newEthKey, err := crypto.GenerateKey()
+	r.NoError(err)
+	newEthLocalAddr, err := loom.LocalAddressFromHexString(crypto.PubkeyToAddress(newEthKey.PublicKey).Hex())
+	r.NoError(err)
+	newEthAddr := loom.Address{ChainID: "eth", Local: newEthLocalAddr}
+
+	oldSig, err := SignMappingRotation(s.validDAppAddr, s.validEthAddr, newEthAddr, 0, s.validEthKey, sigType)
+	r.NoError(err)
+	newSig, err := SignMappingRotation(s.validDAppAddr, s.validEthAddr, newEthAddr, 0, newEthKey, sigType)
+	r.NoError(err)
+	req := &RotateMappingRequest{
+		From:         s.validDAppAddr.MarshalPB(),
+		OldTo:        s.validEthAddr.MarshalPB(),
+		NewTo:        newEthAddr.MarshalPB(),
+		OldSignature: oldSig,
+		NewSignature: newSig,
+	}
+
+	r.Equal(ErrFeatureNotEnabled, amContract.RotateMapping(ctx, req), "should error if feature is disabled")
+	fakeCtx.SetFeature(features.AddressMapperVersion1_2, true)
+
+	r.Error(amContract.RotateMapping(ctx, &RotateMappingRequest{
+		From:         s.validDAppAddr.MarshalPB(),
+		OldTo:        s.validEthAddr.MarshalPB(),
+		NewTo:        newEthAddr.MarshalPB(),
+		OldSignature: newSig,
+		NewSignature: newSig,
+	}), "should error if old signature wasn't created by the old key")
+	r.Equal(ErrInvalidRequest, amContract.RotateMapping(ctx, &RotateMappingRequest{
+		From:         s.validDAppAddr.MarshalPB(),
+		OldTo:        s.validEthAddr.MarshalPB(),
+		NewTo:        newEthAddr.MarshalPB(),
+		NewSignature: newSig,
+	}), "should error if old signature is missing and caller isn't the recovery admin")
+
+	otherCtx := contract.WrapPluginContext(fakeCtx.WithSender(addr2))
+	r.Equal(ErrNotAuthorized, amContract.RotateMapping(otherCtx, req), "should error if caller isn't the DAppChain account")
+
+	r.NoError(amContract.RotateMapping(ctx, req))
+
+	resp, err := amContract.GetMapping(ctx, &GetMappingRequest{
+		From: s.validDAppAddr.MarshalPB(),
+	})
+	r.NoError(err)
+	s.Equal(newEthAddr.MarshalPB(), resp.To)
+	resp, err = amContract.GetMapping(ctx, &GetMappingRequest{
+		From: newEthAddr.MarshalPB(),
+	})
+	r.NoError(err)
+	s.Equal(s.validDAppAddr.MarshalPB(), resp.To)
+	hasResp, err := amContract.HasMapping(ctx, &HasMappingRequest{
+		From: s.validEthAddr.MarshalPB(),
+	})
+	r.NoError(err)
+	s.False(hasResp.HasMapping, "old mapping should be removed")
+
+	nonceResp, err := amContract.GetRotationNonce(ctx, &GetRotationNonceRequest{
+		From: s.validDAppAddr.MarshalPB(),
+	})
+	r.NoError(err)
+	s.Equal(uint64(1), nonceResp.Nonce)
+
+	r.Error(amContract.RotateMapping(ctx, req), "should error if the rotation is replayed")
+}
+
+func (s *AddressMapperTestSuite) TestRotateMappingByRecoveryAdmin() {
+	r := s.Require()
+	fakeCtx := plugin.CreateFakeContext(s.validDAppAddr /*caller*/, loom.RootAddress("chain") /*contract*/)
+	fakeCtx.SetFeature(features.AddressMapperVersion1_2, true)
+	ctx := contract.WrapPluginContext(fakeCtx)
+	adminCtx := contract.WrapPluginContext(fakeCtx.WithSender(addr2))
+
+	amContract := &AddressMapper{}
+	r.NoError(amContract.Init(ctx, &InitRequest{RecoveryAdmin: addr2.MarshalPB()}))
+
+	adminResp, err := amContract.GetRecoveryAdmin(ctx, &GetRecoveryAdminRequest{})
+	r.NoError(err)
+	s.Equal(addr2.MarshalPB(), adminResp.RecoveryAdmin)
+
+	sig, err := SignIdentityMapping(s.validEthAddr, s.validDAppAddr, s.validEthKey, sigType)
+	r.NoError(err)
+	r.NoError(amContract.AddIdentityMapping(ctx, &AddIdentityMappingRequest{
+		From:      s.validEthAddr.MarshalPB(),
+		To:        s.validDAppAddr.MarshalPB(),
+		Signature: sig,
+	}))
+
+	newEthKey, err := crypto.GenerateKey()
+	r.NoError(err)
+	newEthLocalAddr, err := loom.LocalAddressFromHexString(crypto.PubkeyToAddress(newEthKey.PublicKey).Hex())
+	r.NoError(err)
+	newEthAddr := loom.Address{ChainID: "eth", Local: newEthLocalAddr}
+
+	newSig, err := SignMappingRotation(s.validDAppAddr, s.validEthAddr, newEthAddr, 0, newEthKey, sigType)
+	r.NoError(err)
+	r.NoError(amContract.RotateMapping(adminCtx, &RotateMappingRequest{
+		From:         s.validDAppAddr.MarshalPB(),
+		OldTo:        s.validEthAddr.MarshalPB(),
+		NewTo:        newEthAddr.MarshalPB(),
+		NewSignature: newSig,
+	}))
+
+	resp, err := amContract.GetMapping(ctx, &GetMappingRequest{
+		From: s.validDAppAddr.MarshalPB(),
+	})
+	r.NoError(err)
+	s.Equal(newEthAddr.MarshalPB(), resp.To)
+
+	r.Equal(ErrNotAuthorized, amContract.SetRecoveryAdmin(ctx, &SetRecoveryAdminRequest{
+		RecoveryAdmin: addr3.MarshalPB(),
+	}), "only the current recovery admin should be able to change the recovery admin")
+	r.NoError(amContract.SetRecoveryAdmin(adminCtx, &SetRecoveryAdminRequest{
+		RecoveryAdmin: addr3.MarshalPB(),
+	}))
+	adminResp, err = amContract.GetRecoveryAdmin(ctx, &GetRecoveryAdminRequest{})
+	r.NoError(err)
+	s.Equal(addr3.MarshalPB(), adminResp.RecoveryAdmin)
+}
+
+func (s *AddressMapperTestSuite) TestResetRecoveryAdmin() {
+	r := s.Require()
+	addr2 := loom.MustParseAddress("chain:0xfa4c7920accfd66b86f5fd0e69682a79f762d49e")
+	fakeCtx := plugin.CreateFakeContext(s.validDAppAddr /*caller*/, loom.RootAddress("chain") /*contract*/)
+	ctx := contract.WrapPluginContext(fakeCtx)
+
+	amContract := &AddressMapper{}
+	r.NoError(amContract.Init(ctx, &InitRequest{}))
+
+	req := &SetRecoveryAdminRequest{RecoveryAdmin: addr2.MarshalPB()}
+	r.Equal(ErrFeatureNotEnabled, ResetRecoveryAdmin(ctx, req))
+	fakeCtx.SetFeature(features.AddressMapperVersion1_2, true)
+	r.Equal(ErrNotAuthorized, amContract.SetRecoveryAdmin(ctx, req),
+		"recovery admin can't be set by a contract call if there isn't one")
+	r.Equal(ErrInvalidRequest, ResetRecoveryAdmin(ctx, &SetRecoveryAdminRequest{}))
+	r.NoError(ResetRecoveryAdmin(ctx, req))
+
+	adminResp, err := amContract.GetRecoveryAdmin(ctx, &GetRecoveryAdminRequest{})
+	r.NoError(err)
+	s.Equal(addr2.MarshalPB(), adminResp.RecoveryAdmin)
+}
+
 func (s *AddressMapperTestSuite) TestGethSigRecovery() {
 	r := s.Require()
 	// hash was generated by
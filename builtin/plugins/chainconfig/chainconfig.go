@@ -1,7 +1,11 @@
 package chainconfig
 
 import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math/big"
 	"sort"
+	"strings"
 
 	"github.com/gogo/protobuf/proto"
 	loom "github.com/loomnetwork/go-loom"
@@ -47,6 +51,31 @@ type (
 	SetValidatorInfoRequest    = cctypes.SetValidatorInfoRequest
 	ListValidatorsInfoRequest  = cctypes.ListValidatorsInfoRequest
 	ListValidatorsInfoResponse = cctypes.ListValidatorsInfoResponse
+
+	PowerVoteParams            = ChainConfigPowerVoteParams
+	SetPowerVoteParamsRequest  = ChainConfigSetPowerVoteParamsRequest
+	GetPowerVoteParamsRequest  = ChainConfigGetPowerVoteParamsRequest
+	GetPowerVoteParamsResponse = ChainConfigGetPowerVoteParamsResponse
+	FeatureSupport             = ChainConfigFeatureSupport
+	ListFeatureSupportRequest  = ChainConfigListFeatureSupportRequest
+	ListFeatureSupportResponse = ChainConfigListFeatureSupportResponse
+
+	FeatureSchedule              = ChainConfigFeatureSchedule
+	AddScheduledFeatureRequest   = ChainConfigAddScheduledFeatureRequest
+	ListFeatureSchedulesRequest  = ChainConfigListFeatureSchedulesRequest
+	ListFeatureSchedulesResponse = ChainConfigListFeatureSchedulesResponse
+
+	FnRecord              = ChainConfigFnRecord
+	SetFnRecordRequest    = ChainConfigSetFnRecordRequest
+	RemoveFnRecordRequest = ChainConfigRemoveFnRecordRequest
+	ListFnRecordsRequest  = ChainConfigListFnRecordsRequest
+	ListFnRecordsResponse = ChainConfigListFnRecordsResponse
+
+	PluginUpgrade                = ChainConfigPluginUpgrade
+	SchedulePluginUpgradeRequest = ChainConfigSchedulePluginUpgradeRequest
+	CancelPluginUpgradeRequest   = ChainConfigCancelPluginUpgradeRequest
+	ListPluginUpgradesRequest    = ChainConfigListPluginUpgradesRequest
+	ListPluginUpgradesResponse   = ChainConfigListPluginUpgradesResponse
 )
 
 const (
@@ -92,27 +121,49 @@ var (
 )
 
 const (
-	featurePrefix       = "ft"
-	actionPrefix        = "act"
-	ownerRole           = "owner"
-	validatorInfoPrefix = "vi"
+	featurePrefix         = "ft"
+	featureSchedulePrefix = "fs"
+	actionPrefix          = "act"
+	ownerRole             = "owner"
+	validatorInfoPrefix   = "vi"
+	fnRecordPrefix        = "fn"
+	pluginUpgradePrefix   = "pu"
 )
 
 var (
 	setParamsPerm  = []byte("setp")
 	addFeaturePerm = []byte("addf")
 
-	paramsKey = []byte("params")
+	paramsKey          = []byte("params")
+	powerVoteParamsKey = []byte("pvparams")
 )
 
 func featureKey(featureName string) []byte {
 	return util.PrefixKey([]byte(featurePrefix), []byte(featureName))
 }
 
+func featureScheduleKey(featureName string) []byte {
+	return util.PrefixKey([]byte(featureSchedulePrefix), []byte(featureName))
+}
+
 func actionKey(actionName string) []byte {
 	return util.PrefixKey([]byte(actionPrefix), []byte(actionName))
 }
 
+func fnRecordsPrefix(fnID string) []byte {
+	return util.PrefixKey([]byte(fnRecordPrefix), []byte(fnID))
+}
+
+func fnRecordKey(fnID string, activationHeight uint64) []byte {
+	heightBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(heightBytes, activationHeight)
+	return util.PrefixKey(fnRecordsPrefix(fnID), heightBytes)
+}
+
+func pluginUpgradeKey(pluginName string) []byte {
+	return util.PrefixKey([]byte(pluginUpgradePrefix), []byte(pluginName))
+}
+
 func validatorInfoKey(addr loom.Address) []byte {
 	return util.PrefixKey([]byte(validatorInfoPrefix), addr.Bytes())
 }
@@ -218,6 +269,60 @@ func (c *ChainConfig) AddFeature(ctx contract.Context, req *AddFeatureRequest) e
 	return nil
 }
 
+// AddScheduledFeature should be called by the contract owner to add new features that should only
+// be activated at a specific block height, or a specific number of blocks after they've been
+// enabled by a sufficient number of validators. Scheduled features remain in the WAITING state
+// until the scheduled height is reached.
+func (c *ChainConfig) AddScheduledFeature(ctx contract.Context, req *AddScheduledFeatureRequest) error {
+	if len(req.Names) == 0 {
+		return ErrInvalidRequest
+	}
+	if (req.ActivationHeight == 0) == (req.ActivationDelay == 0) {
+		return ErrInvalidRequest
+	}
+	if !ctx.FeatureEnabled(features.ChainCfgVersion1_6, false) {
+		return ErrFeatureNotEnabled
+	}
+	if req.ActivationHeight != 0 && req.ActivationHeight <= uint64(ctx.Block().Height) {
+		return ErrInvalidRequest
+	}
+	for _, name := range req.Names {
+		if err := addFeature(ctx, name, req.BuildNumber, req.AutoEnable); err != nil {
+			return err
+		}
+		schedule := &FeatureSchedule{
+			Name:             name,
+			ActivationHeight: req.ActivationHeight,
+			ActivationDelay:  req.ActivationDelay,
+		}
+		if err := ctx.Set(featureScheduleKey(name), schedule); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListFeatureSchedules returns the activation schedules of all the scheduled features.
+func (c *ChainConfig) ListFeatureSchedules(
+	ctx contract.StaticContext, req *ListFeatureSchedulesRequest,
+) (*ListFeatureSchedulesResponse, error) {
+	scheduleRange := ctx.Range([]byte(featureSchedulePrefix))
+	schedules := []*FeatureSchedule{}
+	for _, m := range scheduleRange {
+		var schedule FeatureSchedule
+		if err := proto.Unmarshal(m.Value, &schedule); err != nil {
+			return nil, errors.Wrapf(err, "unmarshal feature schedule %s", string(m.Key))
+		}
+		schedules = append(schedules, &schedule)
+	}
+	sort.Slice(schedules, func(i, j int) bool {
+		return schedules[i].Name < schedules[j].Name
+	})
+	return &ListFeatureSchedulesResponse{
+		Schedules: schedules,
+	}, nil
+}
+
 // RemoveFeature should be called by the contract owner to remove features.
 // NOTE: Features can only be removed before they're activated by the chain.
 func (c *ChainConfig) RemoveFeature(ctx contract.Context, req *RemoveFeatureRequest) error {
@@ -295,10 +400,82 @@ func (c *ChainConfig) GetFeature(ctx contract.StaticContext, req *GetFeatureRequ
 	}, nil
 }
 
+// SetPowerVoteParams should be called by the contract owner to enable or disable weighting of
+// feature & config change votes by validator power. When enabled a pending feature (or action)
+// must reach both the vote threshold (percentage of validators), and the power threshold
+// (percentage of total validator power) before it's activated.
+func (c *ChainConfig) SetPowerVoteParams(ctx contract.Context, req *SetPowerVoteParamsRequest) error {
+	if req.Params == nil {
+		return ErrInvalidRequest
+	}
+	if !ctx.FeatureEnabled(features.ChainCfgVersion1_5, false) {
+		return ErrFeatureNotEnabled
+	}
+	if ok, _ := ctx.HasPermission(setParamsPerm, []string{ownerRole}); !ok {
+		return ErrNotAuthorized
+	}
+	if req.Params.PowerThreshold > 100 || (req.Params.Enabled && req.Params.PowerThreshold == 0) {
+		return ErrInvalidParams
+	}
+	return ctx.Set(powerVoteParamsKey, req.Params)
+}
+
+func (c *ChainConfig) GetPowerVoteParams(
+	ctx contract.StaticContext, req *GetPowerVoteParamsRequest,
+) (*GetPowerVoteParamsResponse, error) {
+	params, err := getPowerVoteParams(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &GetPowerVoteParamsResponse{
+		Params: params,
+	}, nil
+}
+
+// ListFeatureSupport returns the percentage of validators, and the percentage of validator power,
+// that currently support each of the known features.
+func (c *ChainConfig) ListFeatureSupport(
+	ctx contract.StaticContext, req *ListFeatureSupportRequest,
+) (*ListFeatureSupportResponse, error) {
+	curValidators, err := getCurrentValidators(ctx)
+	if err != nil {
+		return nil, err
+	}
+	powers, totalPower, err := getValidatorPowers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	featureRange := ctx.Range([]byte(featurePrefix))
+	support := []*FeatureSupport{}
+	for _, m := range featureRange {
+		var f Feature
+		if err := proto.Unmarshal(m.Value, &f); err != nil {
+			return nil, errors.Wrapf(err, "unmarshal feature %s", string(m.Key))
+		}
+		feature, err := getFeature(ctx, f.Name, curValidators)
+		if err != nil {
+			return nil, err
+		}
+		support = append(support, &FeatureSupport{
+			Name:            feature.Name,
+			CountPercentage: feature.Percentage,
+			PowerPercentage: featurePowerPercentage(feature, powers, totalPower),
+		})
+	}
+	sort.Slice(support, func(i, j int) bool {
+		return support[i].Name < support[j].Name
+	})
+	return &ListFeatureSupportResponse{
+		Features: support,
+	}, nil
+}
+
 // EnableFeatures updates the status of features that haven't been activated yet:
 // - A PENDING feature will become WAITING once the percentage of validators that have enabled the
-//   feature reaches a certain threshold.
-// - A WAITING feature will become ENABLED after a sufficient number of block confirmations.
+//   feature reaches a certain threshold (and if power voting is enabled, once the percentage of
+//   validator power that has enabled the feature reaches the power threshold).
+// - A WAITING feature will become ENABLED after a sufficient number of block confirmations, or if
+//   the feature has been scheduled, once the scheduled block height is reached.
 // Returns a list of features whose status has changed from WAITING to ENABLED at the given height.
 func EnableFeatures(ctx contract.Context, blockHeight, buildNumber uint64) ([]*Feature, error) {
 	params, err := getParams(ctx)
@@ -311,6 +488,19 @@ func EnableFeatures(ctx contract.Context, blockHeight, buildNumber uint64) ([]*F
 		return nil, err
 	}
 
+	powerVoteParams, err := loadActivePowerVoteParams(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var powers map[string]int64
+	var totalPower int64
+	if powerVoteParams != nil {
+		powers, totalPower, err = getValidatorPowers(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	featureRange := ctx.Range([]byte(featurePrefix))
 	enabledFeatures := make([]*Feature, 0)
 	for _, m := range featureRange {
@@ -326,12 +516,21 @@ func EnableFeatures(ctx contract.Context, blockHeight, buildNumber uint64) ([]*F
 
 		switch feature.Status {
 		case FeaturePending:
-			if feature.Percentage >= params.VoteThreshold {
+			supported := feature.Percentage >= params.VoteThreshold
+			powerPercentage := uint64(0)
+			if supported && powerVoteParams != nil {
+				powerPercentage = featurePowerPercentage(feature, powers, totalPower)
+				supported = powerPercentage >= powerVoteParams.PowerThreshold
+			}
+			if supported {
 				feature.Status = FeatureWaiting
 				feature.BlockHeight = blockHeight
 				if err := ctx.Set(featureKey(feature.Name), feature); err != nil {
 					return nil, err
 				}
+				if err := scheduleFeature(ctx, feature.Name, blockHeight, params.NumBlockConfirmations); err != nil {
+					return nil, err
+				}
 				ctx.Logger().Info(
 					"[Feature status changed]",
 					"name", feature.Name,
@@ -339,10 +538,19 @@ func EnableFeatures(ctx contract.Context, blockHeight, buildNumber uint64) ([]*F
 					"to", FeatureWaiting,
 					"block_height", blockHeight,
 					"percentage", feature.Percentage,
+					"power_percentage", powerPercentage,
 				)
 			}
 		case FeatureWaiting:
-			if blockHeight > (feature.BlockHeight + params.NumBlockConfirmations) {
+			schedule, err := loadFeatureSchedule(ctx, feature.Name)
+			if err != nil {
+				return nil, err
+			}
+			activate := blockHeight > (feature.BlockHeight + params.NumBlockConfirmations)
+			if schedule != nil && schedule.ScheduledHeight != 0 {
+				activate = blockHeight >= schedule.ScheduledHeight
+			}
+			if activate {
 				if buildNumber < feature.BuildNumber {
 					return nil, ErrFeatureNotSupported
 				}
@@ -381,6 +589,19 @@ func HarvestPendingActions(ctx contract.Context, buildNumber uint64) ([]*Action,
 		return nil, err
 	}
 
+	powerVoteParams, err := loadActivePowerVoteParams(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var powers map[string]int64
+	var totalPower int64
+	if powerVoteParams != nil {
+		powers, totalPower, err = getValidatorPowers(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	for _, m := range actionsRange {
 		var action Action
 		if err := proto.Unmarshal(m.Value, &action); err != nil {
@@ -388,14 +609,25 @@ func HarvestPendingActions(ctx contract.Context, buildNumber uint64) ([]*Action,
 		}
 
 		supportedValidator := 0
+		supportedPower := int64(0)
 		for _, validatorInfo := range validatorsInfo {
 			if validatorInfo.BuildNumber >= action.BuildNumber {
 				supportedValidator++
+				if powerVoteParams != nil {
+					supportedPower += powers[loom.UnmarshalAddressPB(validatorInfo.Address).Local.String()]
+				}
 			}
 		}
 		// Return this action, if the number of validators that supports this action
 		// has reached the vote threshold
-		if len(validatorsInfo) > 0 && uint64((supportedValidator*100)/len(validatorsInfo)) >= params.VoteThreshold {
+		supported := len(validatorsInfo) > 0 &&
+			uint64((supportedValidator*100)/len(validatorsInfo)) >= params.VoteThreshold
+		// If power voting is enabled the validators that support this action must also hold
+		// enough of the total validator power
+		if supported && powerVoteParams != nil {
+			supported = powerPercentage(supportedPower, totalPower) >= powerVoteParams.PowerThreshold
+		}
+		if supported {
 			if buildNumber < action.BuildNumber {
 				return nil, ErrConfigChangeNotSupported
 			}
@@ -588,6 +820,69 @@ func setParams(ctx contract.Context, voteThreshold, numBlockConfirmations uint64
 	return ctx.Set(paramsKey, params)
 }
 
+func getPowerVoteParams(ctx contract.StaticContext) (*PowerVoteParams, error) {
+	var params PowerVoteParams
+	err := ctx.Get(powerVoteParamsKey, &params)
+	if err != nil && err != contract.ErrNotFound {
+		return nil, errors.Wrap(err, "failed to load chainconfig power vote params")
+	}
+	return &params, nil
+}
+
+// loadActivePowerVoteParams returns the power vote params if votes should be weighted by validator
+// power, or nil otherwise.
+func loadActivePowerVoteParams(ctx contract.StaticContext) (*PowerVoteParams, error) {
+	if !ctx.FeatureEnabled(features.ChainCfgVersion1_5, false) {
+		return nil, nil
+	}
+	params, err := getPowerVoteParams(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !params.Enabled {
+		return nil, nil
+	}
+	return params, nil
+}
+
+// getValidatorPowers returns the power of each of the current validators keyed by the validator's
+// local address, and the total power of all the current validators.
+func getValidatorPowers(ctx contract.StaticContext) (map[string]int64, int64, error) {
+	validatorsList := ctx.Validators()
+	if len(validatorsList) == 0 {
+		return nil, 0, ErrEmptyValidatorsList
+	}
+
+	powers := make(map[string]int64, len(validatorsList))
+	totalPower := int64(0)
+	for _, v := range validatorsList {
+		if v != nil {
+			powers[loom.LocalAddressFromPublicKey(v.PubKey).String()] = v.Power
+			totalPower += v.Power
+		}
+	}
+	return powers, totalPower, nil
+}
+
+// featurePowerPercentage calculates the percentage of the total validator power held by the
+// current validators that have enabled the given feature.
+func featurePowerPercentage(feature *Feature, powers map[string]int64, totalPower int64) uint64 {
+	enabledPower := int64(0)
+	for _, v := range feature.Validators {
+		enabledPower += powers[loom.UnmarshalAddressPB(v).Local.String()]
+	}
+	return powerPercentage(enabledPower, totalPower)
+}
+
+func powerPercentage(power, totalPower int64) uint64 {
+	if totalPower <= 0 {
+		return 0
+	}
+	// total power can be large enough to overflow an int64 when multiplied by 100
+	pct := new(big.Int).Mul(big.NewInt(power), big.NewInt(100))
+	return pct.Div(pct, big.NewInt(totalPower)).Uint64()
+}
+
 func enableFeature(ctx contract.Context, name string) error {
 	if name == "" {
 		return ErrInvalidRequest
@@ -674,9 +969,48 @@ func removeFeature(ctx contract.Context, name string) error {
 		return ErrFeatureAlreadyEnabled
 	}
 	ctx.Delete(featureKey(name))
+	ctx.Delete(featureScheduleKey(name))
 	return nil
 }
 
+func loadFeatureSchedule(ctx contract.StaticContext, name string) (*FeatureSchedule, error) {
+	var schedule FeatureSchedule
+	err := ctx.Get(featureScheduleKey(name), &schedule)
+	if err == contract.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "failed to load schedule of feature %s", name)
+	}
+	return &schedule, nil
+}
+
+// scheduleFeature computes the block height at which a scheduled feature should be activated,
+// should be called when the feature reaches the vote threshold at the given block height.
+// If the requested activation height has already passed by the time the feature reaches the vote
+// threshold the feature will be activated after the usual number of block confirmations instead.
+func scheduleFeature(ctx contract.Context, name string, blockHeight, numBlockConfirmations uint64) error {
+	schedule, err := loadFeatureSchedule(ctx, name)
+	if err != nil || schedule == nil {
+		return err
+	}
+
+	if schedule.ActivationDelay != 0 {
+		schedule.ScheduledHeight = blockHeight + schedule.ActivationDelay
+	} else if schedule.ActivationHeight > blockHeight {
+		schedule.ScheduledHeight = schedule.ActivationHeight
+	} else {
+		schedule.ScheduledHeight = blockHeight + numBlockConfirmations + 1
+	}
+
+	ctx.Logger().Info(
+		"[Feature scheduled]",
+		"name", name,
+		"block_height", blockHeight,
+		"scheduled_height", schedule.ScheduledHeight,
+	)
+	return ctx.Set(featureScheduleKey(name), schedule)
+}
+
 func (c *ChainConfig) SetValidatorInfo(ctx contract.Context, req *SetValidatorInfoRequest) error {
 	if req.BuildNumber == 0 {
 		return ErrInvalidRequest
@@ -751,4 +1085,206 @@ func listValidatorsInfo(ctx contract.StaticContext) ([]*ValidatorInfo, error) {
 	return validators, nil
 }
 
+// SetFnRecord should be called by the contract owner to add or update the record of an Fn that
+// validators should register with the fnConsensus reactor. The record takes effect at its activation
+// height, which must be in the future, until then any previous record of the Fn remains in effect.
+// Setting a record replaces any records of the same Fn that were due to take effect at (or after)
+// the activation height of the new record.
+func (c *ChainConfig) SetFnRecord(ctx contract.Context, req *SetFnRecordRequest) error {
+	if req.Record == nil || req.Record.FnId == "" || req.Record.Factory == "" ||
+		req.Record.ActivationHeight <= uint64(ctx.Block().Height) {
+		return ErrInvalidRequest
+	}
+	if !ctx.FeatureEnabled(features.ChainCfgVersion1_7, false) {
+		return ErrFeatureNotEnabled
+	}
+	if ok, _ := ctx.HasPermission(setParamsPerm, []string{ownerRole}); !ok {
+		return ErrNotAuthorized
+	}
+	return saveFnRecord(ctx, &FnRecord{
+		FnId:             req.Record.FnId,
+		Factory:          req.Record.Factory,
+		Params:           req.Record.Params,
+		UpdatedHeight:    uint64(ctx.Block().Height),
+		ActivationHeight: req.Record.ActivationHeight,
+	})
+}
+
+// RemoveFnRecord should be called by the contract owner to remove an Fn, validator nodes will
+// unregister the Fn once the block at the given activation height has been committed.
+func (c *ChainConfig) RemoveFnRecord(ctx contract.Context, req *RemoveFnRecordRequest) error {
+	if req.FnId == "" || req.ActivationHeight <= uint64(ctx.Block().Height) {
+		return ErrInvalidRequest
+	}
+	if !ctx.FeatureEnabled(features.ChainCfgVersion1_7, false) {
+		return ErrFeatureNotEnabled
+	}
+	if ok, _ := ctx.HasPermission(setParamsPerm, []string{ownerRole}); !ok {
+		return ErrNotAuthorized
+	}
+	records, err := loadFnRecords(ctx, fnRecordsPrefix(req.FnId))
+	if err != nil {
+		return err
+	}
+	// The Fn must be registered by the time the removal takes effect
+	var prev *FnRecord
+	for _, r := range records {
+		if r.ActivationHeight < req.ActivationHeight {
+			prev = r
+		}
+	}
+	if prev == nil || prev.Removed {
+		return ErrInvalidRequest
+	}
+	return saveFnRecord(ctx, &FnRecord{
+		FnId:             req.FnId,
+		UpdatedHeight:    uint64(ctx.Block().Height),
+		ActivationHeight: req.ActivationHeight,
+		Removed:          true,
+	})
+}
+
+// ListFnRecords returns the records of all the Fns that should be registered with the fnConsensus
+// reactor, including the ones that will only take effect at a future height, sorted by fnID &
+// activation height.
+func (c *ChainConfig) ListFnRecords(
+	ctx contract.StaticContext, req *ListFnRecordsRequest,
+) (*ListFnRecordsResponse, error) {
+	records, err := loadFnRecords(ctx, []byte(fnRecordPrefix))
+	if err != nil {
+		return nil, err
+	}
+	return &ListFnRecordsResponse{
+		Records:     records,
+		BlockHeight: uint64(ctx.Block().Height),
+	}, nil
+}
+
+func loadFnRecords(ctx contract.StaticContext, prefix []byte) ([]*FnRecord, error) {
+	records := []*FnRecord{}
+	for _, m := range ctx.Range(prefix) {
+		var record FnRecord
+		if err := proto.Unmarshal(m.Value, &record); err != nil {
+			return nil, errors.Wrapf(err, "unmarshal fn record %s", string(m.Key))
+		}
+		records = append(records, &record)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].FnId == records[j].FnId {
+			return records[i].ActivationHeight < records[j].ActivationHeight
+		}
+		return records[i].FnId < records[j].FnId
+	})
+	return records, nil
+}
+
+// Stores the given record, and deletes the records of the same Fn that are no longer needed, i.e.
+// records that would've taken effect at (or after) the activation height of the new record, and
+// records that have been superseded by other records that are already in effect.
+func saveFnRecord(ctx contract.Context, record *FnRecord) error {
+	records, err := loadFnRecords(ctx, fnRecordsPrefix(record.FnId))
+	if err != nil {
+		return err
+	}
+	height := uint64(ctx.Block().Height)
+	for i, r := range records {
+		superseded := i+1 < len(records) && records[i+1].ActivationHeight <= height
+		if superseded || r.ActivationHeight >= record.ActivationHeight {
+			ctx.Delete(fnRecordKey(r.FnId, r.ActivationHeight))
+		}
+	}
+	return ctx.Set(fnRecordKey(record.FnId, record.ActivationHeight), record)
+}
+
+// SchedulePluginUpgrade should be called by the contract owner to switch all the contracts deployed
+// with a particular external plugin to another version of the plugin binary at a future block
+// height, scheduling an upgrade for a plugin that already has one pending replaces it.
+// All validators must have the new plugin version in their plugins dir by the time the upgrade
+// takes effect.
+func (c *ChainConfig) SchedulePluginUpgrade(ctx contract.Context, req *SchedulePluginUpgradeRequest) error {
+	if req.Upgrade == nil || req.Upgrade.Version == "" ||
+		req.Upgrade.BlockHeight <= uint64(ctx.Block().Height) {
+		return ErrInvalidRequest
+	}
+	if parts := strings.Split(req.Upgrade.PluginName, ":"); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return ErrInvalidRequest
+	}
+	if len(req.Upgrade.PluginSha256) != 0 && len(req.Upgrade.PluginSha256) != sha256.Size {
+		return ErrInvalidRequest
+	}
+	if !ctx.FeatureEnabled(features.ChainCfgVersion1_8, false) {
+		return ErrFeatureNotEnabled
+	}
+	if ok, _ := ctx.HasPermission(setParamsPerm, []string{ownerRole}); !ok {
+		return ErrNotAuthorized
+	}
+	return ctx.Set(pluginUpgradeKey(req.Upgrade.PluginName), req.Upgrade)
+}
+
+// CancelPluginUpgrade should be called by the contract owner to remove a plugin upgrade that
+// hasn't been applied yet.
+func (c *ChainConfig) CancelPluginUpgrade(ctx contract.Context, req *CancelPluginUpgradeRequest) error {
+	if req.PluginName == "" {
+		return ErrInvalidRequest
+	}
+	if !ctx.FeatureEnabled(features.ChainCfgVersion1_8, false) {
+		return ErrFeatureNotEnabled
+	}
+	if ok, _ := ctx.HasPermission(setParamsPerm, []string{ownerRole}); !ok {
+		return ErrNotAuthorized
+	}
+	if !ctx.Has(pluginUpgradeKey(req.PluginName)) {
+		return ErrInvalidRequest
+	}
+	ctx.Delete(pluginUpgradeKey(req.PluginName))
+	return nil
+}
+
+// ListPluginUpgrades returns the plugin upgrades that haven't been applied yet.
+func (c *ChainConfig) ListPluginUpgrades(
+	ctx contract.StaticContext, req *ListPluginUpgradesRequest,
+) (*ListPluginUpgradesResponse, error) {
+	upgrades, err := loadPluginUpgrades(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &ListPluginUpgradesResponse{Upgrades: upgrades}, nil
+}
+
+func loadPluginUpgrades(ctx contract.StaticContext) ([]*PluginUpgrade, error) {
+	upgrades := []*PluginUpgrade{}
+	for _, m := range ctx.Range([]byte(pluginUpgradePrefix)) {
+		var upgrade PluginUpgrade
+		if err := proto.Unmarshal(m.Value, &upgrade); err != nil {
+			return nil, errors.Wrapf(err, "unmarshal plugin upgrade %s", string(m.Key))
+		}
+		upgrades = append(upgrades, &upgrade)
+	}
+	sort.Slice(upgrades, func(i, j int) bool {
+		if upgrades[i].BlockHeight == upgrades[j].BlockHeight {
+			return upgrades[i].PluginName < upgrades[j].PluginName
+		}
+		return upgrades[i].BlockHeight < upgrades[j].BlockHeight
+	})
+	return upgrades, nil
+}
+
+// HarvestPluginUpgrades removes the plugin upgrades that should take effect at (or before) the
+// given block height from the list of pending upgrades, and returns them.
+func HarvestPluginUpgrades(ctx contract.Context, blockHeight uint64) ([]*PluginUpgrade, error) {
+	upgrades, err := loadPluginUpgrades(ctx)
+	if err != nil {
+		return nil, err
+	}
+	harvested := []*PluginUpgrade{}
+	for _, upgrade := range upgrades {
+		if upgrade.BlockHeight > blockHeight {
+			break
+		}
+		ctx.Delete(pluginUpgradeKey(upgrade.PluginName))
+		harvested = append(harvested, upgrade)
+	}
+	return harvested, nil
+}
+
 var Contract plugin.Contract = contract.MakePluginContract(&ChainConfig{})
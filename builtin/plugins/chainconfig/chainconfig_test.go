@@ -673,3 +673,405 @@ func (c *ChainConfigTestSuite) TestCfgSettingFourValidators() {
 	require.NoError(err)
 
 }
+
+func (c *ChainConfigTestSuite) TestPowerWeightedFeatureVoting() {
+	require := c.Require()
+	featureName := "hardfork"
+	chainID := "default"
+	encoder := base64.StdEncoding
+	pubKeyB64_1, _ = encoder.DecodeString(pubKey1)
+	addr1 := loom.Address{ChainID: chainID, Local: loom.LocalAddressFromPublicKey(pubKeyB64_1)}
+	pubKeyB64_2, _ = encoder.DecodeString(pubKey2)
+	addr2 := loom.Address{ChainID: chainID, Local: loom.LocalAddressFromPublicKey(pubKeyB64_2)}
+	pubKeyB64_3, _ = encoder.DecodeString(pubKey3)
+	addr3 := loom.Address{ChainID: chainID, Local: loom.LocalAddressFromPublicKey(pubKeyB64_3)}
+	pubKeyB64_4, _ = encoder.DecodeString(pubKey4)
+	addr4 := loom.Address{ChainID: chainID, Local: loom.LocalAddressFromPublicKey(pubKeyB64_4)}
+
+	// the first validator holds most of the power
+	validators := []*loom.Validator{
+		&loom.Validator{
+			PubKey: pubKeyB64_1,
+			Power:  40,
+		},
+		&loom.Validator{
+			PubKey: pubKeyB64_2,
+			Power:  10,
+		},
+		&loom.Validator{
+			PubKey: pubKeyB64_3,
+			Power:  10,
+		},
+		&loom.Validator{
+			PubKey: pubKeyB64_4,
+			Power:  10,
+		},
+	}
+	pctx := plugin.CreateFakeContext(addr1, addr1).WithBlock(loom.BlockHeader{
+		ChainID: chainID,
+		Time:    time.Now().Unix(),
+	}).WithValidators(validators)
+	pctx.SetFeature(features.ChainCfgVersion1_1, true)
+	ctx := contractpb.WrapPluginContext(pctx)
+
+	chainconfigContract := &ChainConfig{}
+	err := chainconfigContract.Init(ctx, &InitRequest{
+		Owner: addr1.MarshalPB(),
+		Params: &Params{
+			VoteThreshold:         66,
+			NumBlockConfirmations: 10,
+		},
+	})
+	require.NoError(err)
+
+	powerVoteParams := &PowerVoteParams{Enabled: true, PowerThreshold: 67}
+	err = chainconfigContract.SetPowerVoteParams(ctx, &SetPowerVoteParamsRequest{Params: powerVoteParams})
+	require.Equal(ErrFeatureNotEnabled, err)
+
+	pctx.SetFeature(features.ChainCfgVersion1_5, true)
+	err = chainconfigContract.SetPowerVoteParams(
+		contractpb.WrapPluginContext(pctx.WithSender(addr2)),
+		&SetPowerVoteParamsRequest{Params: powerVoteParams},
+	)
+	require.Equal(ErrNotAuthorized, err)
+	err = chainconfigContract.SetPowerVoteParams(ctx, &SetPowerVoteParamsRequest{
+		Params: &PowerVoteParams{Enabled: true, PowerThreshold: 0},
+	})
+	require.Equal(ErrInvalidParams, err)
+	err = chainconfigContract.SetPowerVoteParams(ctx, &SetPowerVoteParamsRequest{Params: powerVoteParams})
+	require.NoError(err)
+
+	err = chainconfigContract.AddFeature(ctx, &AddFeatureRequest{
+		Names: []string{featureName},
+	})
+	require.NoError(err)
+
+	for _, addr := range []loom.Address{addr2, addr3, addr4} {
+		err = chainconfigContract.EnableFeature(contractpb.WrapPluginContext(pctx.WithSender(addr)), &EnableFeatureRequest{
+			Names: []string{featureName},
+		})
+		require.NoError(err)
+	}
+
+	support, err := chainconfigContract.ListFeatureSupport(ctx, &ListFeatureSupportRequest{})
+	require.NoError(err)
+	require.Len(support.Features, 1)
+	require.Equal(uint64(75), support.Features[0].CountPercentage)
+	require.Equal(uint64(42), support.Features[0].PowerPercentage)
+
+	// enough validators have enabled the feature, but they don't hold enough power
+	enabledFeatures, err := EnableFeatures(ctx, 20, 1000)
+	require.NoError(err)
+	require.Equal(0, len(enabledFeatures))
+	getFeature, err := chainconfigContract.GetFeature(ctx, &GetFeatureRequest{Name: featureName})
+	require.NoError(err)
+	require.Equal(cctypes.Feature_PENDING, getFeature.Feature.Status)
+
+	err = chainconfigContract.EnableFeature(ctx, &EnableFeatureRequest{
+		Names: []string{featureName},
+	})
+	require.NoError(err)
+
+	enabledFeatures, err = EnableFeatures(ctx, 21, 1000)
+	require.NoError(err)
+	require.Equal(0, len(enabledFeatures))
+	getFeature, err = chainconfigContract.GetFeature(ctx, &GetFeatureRequest{Name: featureName})
+	require.NoError(err)
+	require.Equal(cctypes.Feature_WAITING, getFeature.Feature.Status)
+
+	enabledFeatures, err = EnableFeatures(ctx, 32, 1000)
+	require.NoError(err)
+	require.Equal(1, len(enabledFeatures))
+}
+
+func (c *ChainConfigTestSuite) TestScheduledFeatureActivation() {
+	require := c.Require()
+	chainID := "default"
+	encoder := base64.StdEncoding
+	pubKeyB64_1, _ = encoder.DecodeString(pubKey1)
+	addr1 := loom.Address{ChainID: chainID, Local: loom.LocalAddressFromPublicKey(pubKeyB64_1)}
+	validators := []*loom.Validator{
+		&loom.Validator{
+			PubKey: pubKeyB64_1,
+			Power:  10,
+		},
+	}
+	pctx := plugin.CreateFakeContext(addr1, addr1).WithBlock(loom.BlockHeader{
+		ChainID: chainID,
+		Height:  1,
+		Time:    time.Now().Unix(),
+	}).WithValidators(validators)
+	pctx.SetFeature(features.ChainCfgVersion1_1, true)
+	ctx := contractpb.WrapPluginContext(pctx)
+
+	chainconfigContract := &ChainConfig{}
+	err := chainconfigContract.Init(ctx, &InitRequest{
+		Owner: addr1.MarshalPB(),
+		Params: &Params{
+			VoteThreshold:         66,
+			NumBlockConfirmations: 10,
+		},
+	})
+	require.NoError(err)
+
+	err = chainconfigContract.AddScheduledFeature(ctx, &AddScheduledFeatureRequest{
+		Names:            []string{"fork-at-height"},
+		ActivationHeight: 100,
+	})
+	require.Equal(ErrFeatureNotEnabled, err)
+
+	pctx.SetFeature(features.ChainCfgVersion1_6, true)
+	// only one of the activation height & delay can be specified
+	err = chainconfigContract.AddScheduledFeature(ctx, &AddScheduledFeatureRequest{
+		Names:            []string{"fork-at-height"},
+		ActivationHeight: 100,
+		ActivationDelay:  5,
+	})
+	require.Equal(ErrInvalidRequest, err)
+	err = chainconfigContract.AddScheduledFeature(ctx, &AddScheduledFeatureRequest{
+		Names:            []string{"fork-at-height"},
+		ActivationHeight: 100,
+	})
+	require.NoError(err)
+	err = chainconfigContract.AddScheduledFeature(ctx, &AddScheduledFeatureRequest{
+		Names:           []string{"fork-after-delay"},
+		ActivationDelay: 5,
+	})
+	require.NoError(err)
+
+	err = chainconfigContract.EnableFeature(ctx, &EnableFeatureRequest{
+		Names: []string{"fork-at-height", "fork-after-delay"},
+	})
+	require.NoError(err)
+
+	enabledFeatures, err := EnableFeatures(ctx, 10, 1000)
+	require.NoError(err)
+	require.Equal(0, len(enabledFeatures))
+
+	schedules, err := chainconfigContract.ListFeatureSchedules(ctx, &ListFeatureSchedulesRequest{})
+	require.NoError(err)
+	require.Len(schedules.Schedules, 2)
+	require.Equal("fork-after-delay", schedules.Schedules[0].Name)
+	require.Equal(uint64(15), schedules.Schedules[0].ScheduledHeight)
+	require.Equal("fork-at-height", schedules.Schedules[1].Name)
+	require.Equal(uint64(100), schedules.Schedules[1].ScheduledHeight)
+
+	// the usual number of block confirmations doesn't apply to scheduled features
+	enabledFeatures, err = EnableFeatures(ctx, 14, 1000)
+	require.NoError(err)
+	require.Equal(0, len(enabledFeatures))
+
+	enabledFeatures, err = EnableFeatures(ctx, 15, 1000)
+	require.NoError(err)
+	require.Equal(1, len(enabledFeatures))
+	require.Equal("fork-after-delay", enabledFeatures[0].Name)
+
+	enabledFeatures, err = EnableFeatures(ctx, 99, 1000)
+	require.NoError(err)
+	require.Equal(0, len(enabledFeatures))
+
+	enabledFeatures, err = EnableFeatures(ctx, 100, 1000)
+	require.NoError(err)
+	require.Equal(1, len(enabledFeatures))
+	require.Equal("fork-at-height", enabledFeatures[0].Name)
+}
+
+func (c *ChainConfigTestSuite) TestFnRecords() {
+	require := c.Require()
+	chainID := "default"
+	encoder := base64.StdEncoding
+	pubKeyB64_1, _ = encoder.DecodeString(pubKey1)
+	addr1 := loom.Address{ChainID: chainID, Local: loom.LocalAddressFromPublicKey(pubKeyB64_1)}
+	pubKeyB64_2, _ = encoder.DecodeString(pubKey2)
+	addr2 := loom.Address{ChainID: chainID, Local: loom.LocalAddressFromPublicKey(pubKeyB64_2)}
+
+	validators := []*loom.Validator{
+		&loom.Validator{
+			PubKey: pubKeyB64_1,
+			Power:  10,
+		},
+	}
+	pctx := plugin.CreateFakeContext(addr1, addr1).WithBlock(loom.BlockHeader{
+		ChainID: chainID,
+		Height:  5,
+		Time:    time.Now().Unix(),
+	}).WithValidators(validators)
+	ctx := contractpb.WrapPluginContext(pctx)
+
+	chainconfigContract := &ChainConfig{}
+	err := chainconfigContract.Init(ctx, &InitRequest{
+		Owner: addr1.MarshalPB(),
+		Params: &Params{
+			VoteThreshold:         66,
+			NumBlockConfirmations: 10,
+		},
+	})
+	require.NoError(err)
+
+	record := &FnRecord{FnId: "tron:batch_sign_withdrawal", Factory: "tron-gateway", ActivationHeight: 10}
+	err = chainconfigContract.SetFnRecord(ctx, &SetFnRecordRequest{Record: record})
+	require.Equal(ErrFeatureNotEnabled, err)
+
+	pctx.SetFeature(features.ChainCfgVersion1_7, true)
+	err = chainconfigContract.SetFnRecord(
+		contractpb.WrapPluginContext(pctx.WithSender(addr2)), &SetFnRecordRequest{Record: record},
+	)
+	require.Equal(ErrNotAuthorized, err)
+	err = chainconfigContract.SetFnRecord(ctx, &SetFnRecordRequest{
+		Record: &FnRecord{FnId: "fn", ActivationHeight: 10},
+	})
+	require.Equal(ErrInvalidRequest, err)
+	// records can only take effect at a future height
+	err = chainconfigContract.SetFnRecord(ctx, &SetFnRecordRequest{
+		Record: &FnRecord{FnId: "fn", Factory: "gateway", ActivationHeight: 5},
+	})
+	require.Equal(ErrInvalidRequest, err)
+
+	require.NoError(chainconfigContract.SetFnRecord(ctx, &SetFnRecordRequest{Record: record}))
+	require.NoError(chainconfigContract.SetFnRecord(ctx, &SetFnRecordRequest{
+		Record: &FnRecord{FnId: "batch_sign_withdrawal", Factory: "gateway", Params: []byte("{}"), ActivationHeight: 10},
+	}))
+
+	resp, err := chainconfigContract.ListFnRecords(ctx, &ListFnRecordsRequest{})
+	require.NoError(err)
+	require.Equal(uint64(5), resp.BlockHeight)
+	require.Len(resp.Records, 2)
+	require.Equal("batch_sign_withdrawal", resp.Records[0].FnId)
+	require.Equal([]byte("{}"), resp.Records[0].Params)
+	require.Equal("tron:batch_sign_withdrawal", resp.Records[1].FnId)
+	require.Equal(uint64(5), resp.Records[1].UpdatedHeight)
+	require.Equal(uint64(10), resp.Records[1].ActivationHeight)
+
+	err = chainconfigContract.RemoveFnRecord(ctx, &RemoveFnRecordRequest{FnId: "unknown", ActivationHeight: 20})
+	require.Equal(ErrInvalidRequest, err)
+	// can't remove an Fn before it's registered
+	err = chainconfigContract.RemoveFnRecord(ctx, &RemoveFnRecordRequest{FnId: "batch_sign_withdrawal", ActivationHeight: 10})
+	require.Equal(ErrInvalidRequest, err)
+	require.NoError(chainconfigContract.RemoveFnRecord(ctx, &RemoveFnRecordRequest{
+		FnId: "batch_sign_withdrawal", ActivationHeight: 20,
+	}))
+
+	ctx = contractpb.WrapPluginContext(pctx.WithBlock(loom.BlockHeader{
+		ChainID: chainID,
+		Height:  25,
+		Time:    time.Now().Unix(),
+	}))
+	// records that have been superseded by records that are already in effect are deleted
+	require.NoError(chainconfigContract.SetFnRecord(ctx, &SetFnRecordRequest{
+		Record: &FnRecord{FnId: "batch_sign_withdrawal", Factory: "gateway", ActivationHeight: 30},
+	}))
+	// a new record replaces records that haven't taken effect yet
+	require.NoError(chainconfigContract.SetFnRecord(ctx, &SetFnRecordRequest{
+		Record: &FnRecord{FnId: "tron:batch_sign_withdrawal", Factory: "tron-gateway", ActivationHeight: 30},
+	}))
+	require.NoError(chainconfigContract.SetFnRecord(ctx, &SetFnRecordRequest{
+		Record: &FnRecord{FnId: "tron:batch_sign_withdrawal", Factory: "tron-gateway", ActivationHeight: 28},
+	}))
+
+	resp, err = chainconfigContract.ListFnRecords(ctx, &ListFnRecordsRequest{})
+	require.NoError(err)
+	require.Len(resp.Records, 4)
+	require.Equal("batch_sign_withdrawal", resp.Records[0].FnId)
+	require.True(resp.Records[0].Removed)
+	require.Equal(uint64(20), resp.Records[0].ActivationHeight)
+	require.Equal("batch_sign_withdrawal", resp.Records[1].FnId)
+	require.Equal(uint64(30), resp.Records[1].ActivationHeight)
+	require.Equal("tron:batch_sign_withdrawal", resp.Records[2].FnId)
+	require.Equal(uint64(10), resp.Records[2].ActivationHeight)
+	require.Equal("tron:batch_sign_withdrawal", resp.Records[3].FnId)
+	require.Equal(uint64(28), resp.Records[3].ActivationHeight)
+}
+
+func (c *ChainConfigTestSuite) TestPluginUpgrades() {
+	require := c.Require()
+	chainID := "default"
+	encoder := base64.StdEncoding
+	pubKeyB64_1, _ = encoder.DecodeString(pubKey1)
+	addr1 := loom.Address{ChainID: chainID, Local: loom.LocalAddressFromPublicKey(pubKeyB64_1)}
+	pubKeyB64_2, _ = encoder.DecodeString(pubKey2)
+	addr2 := loom.Address{ChainID: chainID, Local: loom.LocalAddressFromPublicKey(pubKeyB64_2)}
+
+	validators := []*loom.Validator{
+		&loom.Validator{
+			PubKey: pubKeyB64_1,
+			Power:  10,
+		},
+	}
+	pctx := plugin.CreateFakeContext(addr1, addr1).WithBlock(loom.BlockHeader{
+		ChainID: chainID,
+		Height:  5,
+		Time:    time.Now().Unix(),
+	}).WithValidators(validators)
+	ctx := contractpb.WrapPluginContext(pctx)
+
+	chainconfigContract := &ChainConfig{}
+	err := chainconfigContract.Init(ctx, &InitRequest{
+		Owner: addr1.MarshalPB(),
+		Params: &Params{
+			VoteThreshold:         66,
+			NumBlockConfirmations: 10,
+		},
+	})
+	require.NoError(err)
+
+	upgrade := &PluginUpgrade{PluginName: "mycontract:1.0.0", Version: "1.1.0", BlockHeight: 20}
+	err = chainconfigContract.SchedulePluginUpgrade(ctx, &SchedulePluginUpgradeRequest{Upgrade: upgrade})
+	require.Equal(ErrFeatureNotEnabled, err)
+
+	pctx.SetFeature(features.ChainCfgVersion1_8, true)
+	err = chainconfigContract.SchedulePluginUpgrade(
+		contractpb.WrapPluginContext(pctx.WithSender(addr2)), &SchedulePluginUpgradeRequest{Upgrade: upgrade},
+	)
+	require.Equal(ErrNotAuthorized, err)
+	// plugin name must include the version
+	err = chainconfigContract.SchedulePluginUpgrade(ctx, &SchedulePluginUpgradeRequest{
+		Upgrade: &PluginUpgrade{PluginName: "mycontract", Version: "1.1.0", BlockHeight: 20},
+	})
+	require.Equal(ErrInvalidRequest, err)
+	// upgrade must be scheduled for a future block
+	err = chainconfigContract.SchedulePluginUpgrade(ctx, &SchedulePluginUpgradeRequest{
+		Upgrade: &PluginUpgrade{PluginName: "mycontract:1.0.0", Version: "1.1.0", BlockHeight: 5},
+	})
+	require.Equal(ErrInvalidRequest, err)
+
+	// plugin hash must be a valid SHA-256 hash
+	err = chainconfigContract.SchedulePluginUpgrade(ctx, &SchedulePluginUpgradeRequest{
+		Upgrade: &PluginUpgrade{
+			PluginName: "mycontract:1.0.0", Version: "1.1.0", BlockHeight: 20, PluginSha256: []byte{1, 2, 3},
+		},
+	})
+	require.Equal(ErrInvalidRequest, err)
+
+	require.NoError(chainconfigContract.SchedulePluginUpgrade(ctx, &SchedulePluginUpgradeRequest{Upgrade: upgrade}))
+	require.NoError(chainconfigContract.SchedulePluginUpgrade(ctx, &SchedulePluginUpgradeRequest{
+		Upgrade: &PluginUpgrade{PluginName: "other:2.0.0", Version: "2.0.1", BlockHeight: 10},
+	}))
+	require.NoError(chainconfigContract.SchedulePluginUpgrade(ctx, &SchedulePluginUpgradeRequest{
+		Upgrade: &PluginUpgrade{PluginName: "third:1.0.0", Version: "1.0.1", BlockHeight: 30},
+	}))
+
+	resp, err := chainconfigContract.ListPluginUpgrades(ctx, &ListPluginUpgradesRequest{})
+	require.NoError(err)
+	require.Len(resp.Upgrades, 3)
+	require.Equal("other:2.0.0", resp.Upgrades[0].PluginName)
+	require.Equal("mycontract:1.0.0", resp.Upgrades[1].PluginName)
+	require.Equal("third:1.0.0", resp.Upgrades[2].PluginName)
+
+	err = chainconfigContract.CancelPluginUpgrade(ctx, &CancelPluginUpgradeRequest{PluginName: "unknown:1.0.0"})
+	require.Equal(ErrInvalidRequest, err)
+	require.NoError(chainconfigContract.CancelPluginUpgrade(ctx, &CancelPluginUpgradeRequest{PluginName: "third:1.0.0"}))
+
+	upgrades, err := HarvestPluginUpgrades(ctx, 9)
+	require.NoError(err)
+	require.Len(upgrades, 0)
+
+	upgrades, err = HarvestPluginUpgrades(ctx, 20)
+	require.NoError(err)
+	require.Len(upgrades, 2)
+	require.Equal("2.0.1", upgrades[0].Version)
+	require.Equal("1.1.0", upgrades[1].Version)
+
+	resp, err = chainconfigContract.ListPluginUpgrades(ctx, &ListPluginUpgradesRequest{})
+	require.NoError(err)
+	require.Len(resp.Upgrades, 0)
+}
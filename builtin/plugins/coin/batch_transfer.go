@@ -0,0 +1,123 @@
+package coin
+
+import (
+	loom "github.com/loomnetwork/go-loom"
+	contract "github.com/loomnetwork/go-loom/plugin/contractpb"
+	"github.com/loomnetwork/loomchain/features"
+	"github.com/pkg/errors"
+)
+
+type (
+	BatchTransferRecipient  = CoinBatchTransferRecipient
+	BatchTransferRequest    = CoinBatchTransferRequest
+	BatchTransferConfig     = CoinBatchTransferConfig
+	SetMaxBatchSizeRequest  = CoinSetMaxBatchSizeRequest
+	GetMaxBatchSizeRequest  = CoinGetMaxBatchSizeRequest
+	GetMaxBatchSizeResponse = CoinGetMaxBatchSizeResponse
+)
+
+// DefaultMaxBatchSize is the max number of recipients of a batch transfer, unless changed by the
+// Governance contract.
+const DefaultMaxBatchSize = 100
+
+var (
+	ErrBatchTooLarge = errors.New("[Coin Contract] too many recipients in batch")
+
+	batchTransferConfigKey = []byte("batch_transfer_config")
+)
+
+// MaxBatchSize returns the max number of recipients of a batch transfer.
+func MaxBatchSize(ctx contract.StaticContext) (uint64, error) {
+	var cfg BatchTransferConfig
+	err := ctx.Get(batchTransferConfigKey, &cfg)
+	if err == contract.ErrNotFound || (err == nil && cfg.MaxBatchSize == 0) {
+		return DefaultMaxBatchSize, nil
+	} else if err != nil {
+		return 0, errors.Wrap(err, "failed to load batch transfer config")
+	}
+	return cfg.MaxBatchSize, nil
+}
+
+// UpdateMaxBatchSize changes the max number of recipients of a batch transfer, only the Governance
+// contract is allowed to do so.
+func UpdateMaxBatchSize(ctx contract.Context, maxBatchSize uint64) error {
+	if !ctx.FeatureEnabled(features.CoinVersion1_6Feature, false) {
+		return ErrFeatureNotEnabled
+	}
+	if maxBatchSize == 0 {
+		return ErrInvalidRequest
+	}
+	govAddr, err := ctx.Resolve("governance")
+	if err != nil || ctx.Message().Sender.Compare(govAddr) != 0 {
+		return ErrNotAuthorized
+	}
+	return ctx.Set(batchTransferConfigKey, &BatchTransferConfig{MaxBatchSize: maxBatchSize})
+}
+
+// ValidateBatchTransfer checks the batch transfer request is well formed and doesn't exceed the
+// max batch size, and returns the total amount that will be transferred.
+func ValidateBatchTransfer(ctx contract.StaticContext, req *BatchTransferRequest) (*loom.BigUInt, error) {
+	if !ctx.FeatureEnabled(features.CoinVersion1_6Feature, false) {
+		return nil, ErrFeatureNotEnabled
+	}
+	if len(req.Recipients) == 0 {
+		return nil, ErrInvalidRequest
+	}
+	maxBatchSize, err := MaxBatchSize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(req.Recipients)) > maxBatchSize {
+		return nil, ErrBatchTooLarge
+	}
+
+	total := loom.NewBigUIntFromInt(0)
+	for _, r := range req.Recipients {
+		if r.To == nil || r.Amount == nil {
+			return nil, ErrInvalidRequest
+		}
+		total.Add(total, &r.Amount.Value)
+	}
+	return total, nil
+}
+
+// BatchTransfer transfers tokens from the sender to each of the recipients, a transfer event is
+// emitted for each recipient. If any of the transfers fail none of them will take effect.
+func (c *Coin) BatchTransfer(ctx contract.Context, req *BatchTransferRequest) error {
+	total, err := ValidateBatchTransfer(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	from := ctx.Message().Sender
+	balance, err := BalanceOfAddress(ctx, from)
+	if err != nil {
+		return err
+	}
+	if balance.Cmp(total) < 0 {
+		return ErrSenderBalanceTooLow
+	}
+
+	for _, r := range req.Recipients {
+		if err := transferBalance(ctx, from, loom.UnmarshalAddressPB(r.To), &r.Amount.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetMaxBatchSize changes the max number of recipients of a batch transfer, can only be called by
+// the Governance contract.
+func (c *Coin) SetMaxBatchSize(ctx contract.Context, req *SetMaxBatchSizeRequest) error {
+	return UpdateMaxBatchSize(ctx, req.MaxBatchSize)
+}
+
+func (c *Coin) GetMaxBatchSize(
+	ctx contract.StaticContext, req *GetMaxBatchSizeRequest,
+) (*GetMaxBatchSizeResponse, error) {
+	maxBatchSize, err := MaxBatchSize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &GetMaxBatchSizeResponse{MaxBatchSize: maxBatchSize}, nil
+}
@@ -0,0 +1,57 @@
+package coin
+
+import (
+	"testing"
+
+	loom "github.com/loomnetwork/go-loom"
+	"github.com/loomnetwork/go-loom/plugin"
+	"github.com/loomnetwork/go-loom/plugin/contractpb"
+	"github.com/loomnetwork/go-loom/types"
+	"github.com/loomnetwork/loomchain/features"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchTransfer(t *testing.T) {
+	pctx := plugin.CreateFakeContext(addr1, addr1)
+	pctx.SetFeature(features.CoinVersion1_1Feature, true)
+	ctx := contractpb.WrapPluginContext(pctx)
+
+	coin := &Coin{}
+	require.NoError(t, coin.Init(ctx, &InitRequest{
+		Accounts: []*InitialAccount{
+			{Owner: addr1.MarshalPB(), Balance: 1000},
+		},
+	}))
+
+	req := &BatchTransferRequest{
+		Recipients: []*BatchTransferRecipient{
+			{To: addr2.MarshalPB(), Amount: &types.BigUInt{Value: *sciNot(100, 18)}},
+			{To: addr3.MarshalPB(), Amount: &types.BigUInt{Value: *sciNot(200, 18)}},
+		},
+	}
+	require.Equal(t, ErrFeatureNotEnabled, coin.BatchTransfer(ctx, req))
+
+	pctx.SetFeature(features.CoinVersion1_6Feature, true)
+	require.NoError(t, coin.BatchTransfer(ctx, req))
+	for i, addr := range []loom.Address{addr1, addr2, addr3} {
+		balance, err := BalanceOfAddress(ctx, addr)
+		require.NoError(t, err)
+		require.Equal(t, sciNot([]int64{700, 100, 200}[i], 18).String(), balance.String())
+	}
+
+	// the whole batch is rejected if the sender can't cover all the transfers
+	require.Equal(t, ErrSenderBalanceTooLow, coin.BatchTransfer(ctx, &BatchTransferRequest{
+		Recipients: []*BatchTransferRecipient{
+			{To: addr2.MarshalPB(), Amount: &types.BigUInt{Value: *sciNot(500, 18)}},
+			{To: addr3.MarshalPB(), Amount: &types.BigUInt{Value: *sciNot(500, 18)}},
+		},
+	}))
+
+	// only the Governance contract can change the max batch size
+	require.Equal(t, ErrNotAuthorized, coin.SetMaxBatchSize(ctx, &SetMaxBatchSizeRequest{MaxBatchSize: 1}))
+	govAddr := pctx.CreateContract(contractpb.MakePluginContract(&mockGovernance{}))
+	require.NoError(t, coin.SetMaxBatchSize(
+		contractpb.WrapPluginContext(pctx.WithSender(govAddr)), &SetMaxBatchSizeRequest{MaxBatchSize: 1},
+	))
+	require.Equal(t, ErrBatchTooLarge, coin.BatchTransfer(ctx, req))
+}
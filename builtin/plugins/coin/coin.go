@@ -25,7 +25,7 @@ type (
 	TotalSupplyRequest   = ctypes.TotalSupplyRequest
 	TotalSupplyResponse  = ctypes.TotalSupplyResponse
 	BalanceOfRequest     = ctypes.BalanceOfRequest
-	BalanceOfResponse    = ctypes.BalanceOfResponse
+	BalanceOfResponse    = CoinBalanceOfResponse
 	TransferRequest      = ctypes.TransferRequest
 	TransferResponse     = ctypes.TransferResponse
 	TransferEvent        = ctypes.TransferEvent
@@ -168,6 +168,9 @@ func burn(ctx contract.Context, from loom.Address, amount *loom.BigUInt) error {
 	if bal.Cmp(amount) < 0 || supply.Cmp(amount) < 0 {
 		return fmt.Errorf("cant burn coins more than available balance: %s", bal.String())
 	}
+	if err := checkSpendableBalance(ctx, account, amount); err != nil {
+		return err
+	}
 
 	bal.Sub(&bal, amount)
 	supply.Sub(&supply, amount)
@@ -185,7 +188,7 @@ func burn(ctx contract.Context, from loom.Address, amount *loom.BigUInt) error {
 		return err
 	}
 
-	return ctx.Set(economyKey, econ)
+	return saveEconomy(ctx, econ)
 }
 
 func mint(ctx contract.Context, to loom.Address, amount *loom.BigUInt) error {
@@ -221,7 +224,94 @@ func mint(ctx contract.Context, to loom.Address, amount *loom.BigUInt) error {
 		return err
 	}
 
-	return ctx.Set(economyKey, econ)
+	return saveEconomy(ctx, econ)
+}
+
+// CollectTxFee deducts a tx fee from the payer's balance, the given percentage of the fee is
+// burned, and the rest is split evenly between the validators. Any remainder that can't be split
+// evenly is burned along with the rest of the burned amount.
+func CollectTxFee(
+	ctx contract.Context, payer loom.Address, fee *loom.BigUInt, validators []loom.Address,
+	burnPercentage uint64,
+) error {
+	if fee.Sign() == 0 {
+		return nil
+	}
+	if burnPercentage > 100 {
+		return ErrInvalidRequest
+	}
+
+	payerAccount, err := loadAccount(ctx, payer)
+	if err != nil {
+		return err
+	}
+	if payerAccount.Balance.Value.Cmp(fee) < 0 {
+		return ErrSenderBalanceTooLow
+	}
+
+	validatorShare := loom.NewBigUIntFromInt(0)
+	if len(validators) > 0 {
+		validatorShare.Mul(fee, loom.NewBigUIntFromInt(int64(100-burnPercentage)))
+		validatorShare.Div(validatorShare, loom.NewBigUIntFromInt(100))
+		validatorShare.Div(validatorShare, loom.NewBigUIntFromInt(int64(len(validators))))
+	}
+
+	burnAmount := loom.NewBigUIntFromInt(0)
+	burnAmount.Add(burnAmount, fee)
+	if validatorShare.Sign() > 0 {
+		for _, validator := range validators {
+			if err := transferBalance(ctx, payer, validator, validatorShare); err != nil {
+				return err
+			}
+			burnAmount.Sub(burnAmount, validatorShare)
+		}
+	}
+
+	if burnAmount.Sign() > 0 {
+		return burn(ctx, payer, burnAmount)
+	}
+	return nil
+}
+
+// BalanceOfAddress returns the balance of the given account.
+func BalanceOfAddress(ctx contract.StaticContext, owner loom.Address) (*loom.BigUInt, error) {
+	acct, err := loadAccount(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	return &acct.Balance.Value, nil
+}
+
+func transferBalance(ctx contract.Context, from, to loom.Address, amount *loom.BigUInt) error {
+	fromAccount, err := loadAccount(ctx, from)
+	if err != nil {
+		return err
+	}
+	fromBalance := fromAccount.Balance.Value
+	if fromBalance.Cmp(amount) < 0 {
+		return ErrSenderBalanceTooLow
+	}
+	if err := checkSpendableBalance(ctx, fromAccount, amount); err != nil {
+		return err
+	}
+	fromBalance.Sub(&fromBalance, amount)
+	fromAccount.Balance.Value = fromBalance
+	if err := saveAccount(ctx, fromAccount); err != nil {
+		return err
+	}
+
+	toAccount, err := loadAccount(ctx, to)
+	if err != nil {
+		return err
+	}
+	toBalance := toAccount.Balance.Value
+	toBalance.Add(&toBalance, amount)
+	toAccount.Balance.Value = toBalance
+	if err := saveAccount(ctx, toAccount); err != nil {
+		return err
+	}
+
+	return emitTransferEvent(ctx, from, to, amount)
 }
 
 // ERC20 methods
@@ -240,6 +330,8 @@ func (c *Coin) TotalSupply(
 	}, nil
 }
 
+// BalanceOf returns the balance of the given account, along with the amount that's locked by vesting
+// schedules, and the amount that can be transferred.
 func (c *Coin) BalanceOf(
 	ctx contract.StaticContext,
 	req *BalanceOfRequest,
@@ -252,9 +344,21 @@ func (c *Coin) BalanceOf(
 	if err != nil {
 		return nil, err
 	}
-	return &BalanceOfResponse{
-		Balance: acct.Balance,
-	}, nil
+	spendable, locked, err := spendableBalance(ctx, acct)
+	if err != nil {
+		return nil, err
+	}
+	resp := &BalanceOfResponse{
+		Balance:   acct.Balance,
+		Locked:    &types.BigUInt{Value: *locked},
+		Spendable: &types.BigUInt{Value: *spendable},
+	}
+	if ctx.FeatureEnabled(features.CoinVersion1_4Feature, false) {
+		if resp.Schedules, err = loadVestingSchedules(ctx, owner); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
 }
 
 func (c *Coin) Transfer(ctx contract.Context, req *TransferRequest) error {
@@ -280,6 +384,9 @@ func (c *Coin) transfer(ctx contract.Context, req *TransferRequest) error {
 	if fromBalance.Cmp(&amount) < 0 {
 		return ErrSenderBalanceTooLow
 	}
+	if err := checkSpendableBalance(ctx, fromAccount, &amount); err != nil {
+		return err
+	}
 
 	fromBalance.Sub(&fromBalance, &amount)
 	fromAccount.Balance.Value = fromBalance
@@ -290,6 +397,12 @@ func (c *Coin) transfer(ctx contract.Context, req *TransferRequest) error {
 	}
 
 	to := loom.UnmarshalAddressPB(req.To)
+	// Tokens returned by the DPOSv3 contract may have been delegated by a vesting account
+	if isDPOSContract(ctx, from) {
+		if err := recordVestingUndelegation(ctx, to, &amount); err != nil {
+			return err
+		}
+	}
 	toAccount, err := loadAccount(ctx, to)
 	if err != nil {
 		return err
@@ -390,6 +503,14 @@ func (c *Coin) transferFrom(ctx contract.Context, req *TransferFromRequest) erro
 	if fromBalance.Cmp(&amount) < 0 {
 		return ErrSenderBalanceTooLow
 	}
+	// Locked tokens can be delegated, so the DPOSv3 contract is allowed to transfer them.
+	if isDPOSContract(ctx, spender) {
+		if err := recordVestingDelegation(ctx, from, &amount); err != nil {
+			return err
+		}
+	} else if err := checkSpendableBalance(ctx, fromAccount, &amount); err != nil {
+		return err
+	}
 
 	fromBalance.Sub(&fromBalance, &amount)
 	fromAccount.Balance.Value = fromBalance
@@ -443,9 +564,42 @@ func loadAccount(
 
 func saveAccount(ctx contract.Context, acct *Account) error {
 	owner := loom.UnmarshalAddressPB(acct.Owner)
+	if ctx.FeatureEnabled(features.CoinVersion1_5Feature, false) {
+		prevAcct, err := loadAccount(ctx, owner)
+		if err != nil {
+			return err
+		}
+		if err := UpdateSnapshot(ctx, accountKey(owner), &prevAcct.Balance.Value); err != nil {
+			return err
+		}
+	}
 	return ctx.Set(accountKey(owner), acct)
 }
 
+func loadEconomy(ctx contract.StaticContext) (*Economy, error) {
+	econ := &Economy{
+		TotalSupply: &types.BigUInt{Value: *loom.NewBigUIntFromInt(0)},
+	}
+	err := ctx.Get(economyKey, econ)
+	if err != nil && err != contract.ErrNotFound {
+		return nil, err
+	}
+	return econ, nil
+}
+
+func saveEconomy(ctx contract.Context, econ *Economy) error {
+	if ctx.FeatureEnabled(features.CoinVersion1_5Feature, false) {
+		prevEcon, err := loadEconomy(ctx)
+		if err != nil {
+			return err
+		}
+		if err := UpdateSnapshot(ctx, economyKey, &prevEcon.TotalSupply.Value); err != nil {
+			return err
+		}
+	}
+	return ctx.Set(economyKey, econ)
+}
+
 func loadAllowance(
 	ctx contract.StaticContext,
 	owner, spender loom.Address,
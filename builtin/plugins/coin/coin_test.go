@@ -669,3 +669,44 @@ func TestNilRequest(t *testing.T) {
 	})
 	require.NoError(t, err)
 }
+
+func TestCollectTxFee(t *testing.T) {
+	pctx := plugin.CreateFakeContext(addr1, addr1)
+	ctx := contractpb.WrapPluginContext(pctx)
+	contract := &Coin{}
+	err := contract.Init(ctx, &InitRequest{
+		Accounts: []*InitialAccount{
+			&InitialAccount{
+				Owner:   addr1.MarshalPB(),
+				Balance: 1,
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	// 50% burned, the rest split between 2 validators, 1 wei can't be split evenly so is burned
+	fee := loom.NewBigUIntFromInt(1001)
+	require.NoError(t, CollectTxFee(ctx, addr1, fee, []loom.Address{addr2, addr3}, 50))
+
+	balance, err := BalanceOfAddress(ctx, addr2)
+	require.NoError(t, err)
+	require.Equal(t, int64(250), balance.Int64())
+	balance, err = BalanceOfAddress(ctx, addr3)
+	require.NoError(t, err)
+	require.Equal(t, int64(250), balance.Int64())
+
+	expected := sciNot(1, 18)
+	expected.Sub(expected, fee)
+	balance, err = BalanceOfAddress(ctx, addr1)
+	require.NoError(t, err)
+	require.Equal(t, 0, balance.Cmp(expected))
+
+	supply, err := contract.TotalSupply(ctx, &TotalSupplyRequest{})
+	require.NoError(t, err)
+	expected = sciNot(1, 18)
+	expected.Sub(expected, loom.NewBigUIntFromInt(501))
+	require.Equal(t, 0, supply.TotalSupply.Value.Cmp(expected))
+
+	// payer can't afford the fee
+	require.Equal(t, ErrSenderBalanceTooLow, CollectTxFee(ctx, addr2, fee, []loom.Address{addr3}, 50))
+}
@@ -0,0 +1,185 @@
+package coin
+
+import (
+	"encoding/binary"
+
+	"github.com/gogo/protobuf/proto"
+	loom "github.com/loomnetwork/go-loom"
+	contract "github.com/loomnetwork/go-loom/plugin/contractpb"
+	"github.com/loomnetwork/go-loom/types"
+	"github.com/loomnetwork/go-loom/util"
+	"github.com/loomnetwork/loomchain/features"
+	"github.com/pkg/errors"
+)
+
+// SnapshotEventTopic is emitted whenever a balance snapshot is taken.
+const SnapshotEventTopic = "coin:snapshot"
+
+type (
+	SnapshotState         = CoinSnapshotState
+	SnapshotValue         = CoinSnapshotValue
+	SnapshotEvent         = CoinSnapshotEvent
+	SnapshotRequest       = CoinSnapshotRequest
+	SnapshotResponse      = CoinSnapshotResponse
+	BalanceOfAtRequest    = CoinBalanceOfAtRequest
+	BalanceOfAtResponse   = CoinBalanceOfAtResponse
+	TotalSupplyAtRequest  = CoinTotalSupplyAtRequest
+	TotalSupplyAtResponse = CoinTotalSupplyAtResponse
+)
+
+var (
+	ErrNotAuthorized     = errors.New("[Coin Contract] not authorized")
+	ErrInvalidSnapshotID = errors.New("[Coin Contract] invalid snapshot ID")
+)
+
+var (
+	snapshotStateKey    = []byte("snapshot_state")
+	snapshotValuePrefix = []byte("snapshot")
+)
+
+// Snapshots work much like the OpenZeppelin ERC20Snapshot contract: taking a snapshot doesn't copy
+// any balances, instead the first time a balance (or the total supply) changes after a snapshot is
+// taken the previous value is recorded for that snapshot. The value at a particular snapshot is
+// the value recorded for the earliest snapshot taken at or after it, or the current value if
+// the value hasn't changed since the snapshot was taken.
+
+func snapshotValueKey(valueKey []byte, snapshotID uint64) []byte {
+	return util.PrefixKey(snapshotValuePrefix, valueKey, uint64ToBytes(snapshotID))
+}
+
+func uint64ToBytes(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, v)
+	return b
+}
+
+// CurrentSnapshotID returns the ID of the most recent snapshot, zero if no snapshots have been taken.
+func CurrentSnapshotID(ctx contract.StaticContext) (uint64, error) {
+	var state SnapshotState
+	if err := ctx.Get(snapshotStateKey, &state); err != nil && err != contract.ErrNotFound {
+		return 0, errors.Wrap(err, "failed to load snapshot state")
+	}
+	return state.CurrentSnapshotID, nil
+}
+
+// TakeSnapshot records a new balance snapshot and returns its ID. Only the Governance contract is
+// allowed to take snapshots. The snapshot event is emitted under the given topic.
+func TakeSnapshot(ctx contract.Context, eventTopic string) (uint64, error) {
+	if !ctx.FeatureEnabled(features.CoinVersion1_5Feature, false) {
+		return 0, ErrFeatureNotEnabled
+	}
+	govAddr, err := ctx.Resolve("governance")
+	if err != nil || ctx.Message().Sender.Compare(govAddr) != 0 {
+		return 0, ErrNotAuthorized
+	}
+
+	snapshotID, err := CurrentSnapshotID(ctx)
+	if err != nil {
+		return 0, err
+	}
+	snapshotID++
+	if err := ctx.Set(snapshotStateKey, &SnapshotState{CurrentSnapshotID: snapshotID}); err != nil {
+		return 0, err
+	}
+
+	data, err := proto.Marshal(&SnapshotEvent{
+		SnapshotId:  snapshotID,
+		BlockHeight: uint64(ctx.Block().Height),
+	})
+	if err != nil {
+		return 0, err
+	}
+	ctx.EmitTopics(data, eventTopic)
+	return snapshotID, nil
+}
+
+// UpdateSnapshot should be called before the value stored under the given key is modified,
+// the current value will be recorded for the most recent snapshot if it hasn't been already.
+func UpdateSnapshot(ctx contract.Context, valueKey []byte, currentValue *loom.BigUInt) error {
+	if !ctx.FeatureEnabled(features.CoinVersion1_5Feature, false) {
+		return nil
+	}
+	snapshotID, err := CurrentSnapshotID(ctx)
+	if err != nil || snapshotID == 0 {
+		return err
+	}
+	key := snapshotValueKey(valueKey, snapshotID)
+	if ctx.Has(key) {
+		return nil
+	}
+	return ctx.Set(key, &SnapshotValue{
+		SnapshotId: snapshotID,
+		Value:      &types.BigUInt{Value: *currentValue},
+	})
+}
+
+// ValueAt returns the value stored under the given key at the time the given snapshot was taken.
+func ValueAt(
+	ctx contract.StaticContext, valueKey []byte, snapshotID uint64, currentValue *loom.BigUInt,
+) (*loom.BigUInt, error) {
+	curSnapshotID, err := CurrentSnapshotID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if snapshotID == 0 || snapshotID > curSnapshotID {
+		return nil, ErrInvalidSnapshotID
+	}
+
+	var found *SnapshotValue
+	for _, entry := range ctx.Range(util.PrefixKey(snapshotValuePrefix, valueKey)) {
+		var v SnapshotValue
+		if err := proto.Unmarshal(entry.Value, &v); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal snapshot value")
+		}
+		if v.SnapshotId >= snapshotID && (found == nil || v.SnapshotId < found.SnapshotId) {
+			found = &v
+		}
+	}
+	if found == nil {
+		return currentValue, nil
+	}
+	return &found.Value.Value, nil
+}
+
+// Snapshot records the current balances, can only be called by the Governance contract.
+func (c *Coin) Snapshot(ctx contract.Context, req *SnapshotRequest) (*SnapshotResponse, error) {
+	snapshotID, err := TakeSnapshot(ctx, SnapshotEventTopic)
+	if err != nil {
+		return nil, err
+	}
+	return &SnapshotResponse{SnapshotId: snapshotID}, nil
+}
+
+// BalanceOfAt returns the balance of an account at the time the given snapshot was taken.
+func (c *Coin) BalanceOfAt(ctx contract.StaticContext, req *BalanceOfAtRequest) (*BalanceOfAtResponse, error) {
+	if req.Owner == nil {
+		return nil, ErrInvalidRequest
+	}
+	owner := loom.UnmarshalAddressPB(req.Owner)
+	acct, err := loadAccount(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	balance, err := ValueAt(ctx, accountKey(owner), req.SnapshotId, &acct.Balance.Value)
+	if err != nil {
+		return nil, err
+	}
+	return &BalanceOfAtResponse{
+		Balance: &types.BigUInt{Value: *balance},
+	}, nil
+}
+
+// TotalSupplyAt returns the total supply at the time the given snapshot was taken.
+func (c *Coin) TotalSupplyAt(ctx contract.StaticContext, req *TotalSupplyAtRequest) (*TotalSupplyAtResponse, error) {
+	econ, err := loadEconomy(ctx)
+	if err != nil {
+		return nil, err
+	}
+	supply, err := ValueAt(ctx, economyKey, req.SnapshotId, &econ.TotalSupply.Value)
+	if err != nil {
+		return nil, err
+	}
+	return &TotalSupplyAtResponse{
+		TotalSupply: &types.BigUInt{Value: *supply},
+	}, nil
+}
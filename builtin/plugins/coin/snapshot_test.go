@@ -0,0 +1,84 @@
+package coin
+
+import (
+	"testing"
+
+	loom "github.com/loomnetwork/go-loom"
+	"github.com/loomnetwork/go-loom/plugin"
+	"github.com/loomnetwork/go-loom/plugin/contractpb"
+	"github.com/loomnetwork/go-loom/types"
+	"github.com/loomnetwork/loomchain/features"
+	"github.com/stretchr/testify/require"
+)
+
+type mockGovernance struct {
+}
+
+func (m *mockGovernance) Meta() (plugin.Meta, error) {
+	return plugin.Meta{
+		Name:    "governance",
+		Version: "1.0.0",
+	}, nil
+}
+
+func (m *mockGovernance) DummyMethod(ctx contractpb.Context, req *MintToGatewayRequest) error {
+	return nil
+}
+
+func requireBalanceAt(t *testing.T, ctx contractpb.StaticContext, owner loom.Address, snapshotID uint64, balance int64) {
+	resp, err := (&Coin{}).BalanceOfAt(ctx, &BalanceOfAtRequest{Owner: owner.MarshalPB(), SnapshotId: snapshotID})
+	require.NoError(t, err)
+	require.Equal(t, sciNot(balance, 18).String(), resp.Balance.Value.String())
+}
+
+func TestSnapshots(t *testing.T) {
+	pctx := plugin.CreateFakeContext(addr1, addr1)
+	pctx.SetFeature(features.CoinVersion1_1Feature, true)
+	ctx := contractpb.WrapPluginContext(pctx)
+
+	coin := &Coin{}
+	require.NoError(t, coin.Init(ctx, &InitRequest{
+		Accounts: []*InitialAccount{
+			{Owner: addr1.MarshalPB(), Balance: 1000},
+		},
+	}))
+
+	govAddr := pctx.CreateContract(contractpb.MakePluginContract(&mockGovernance{}))
+	govCtx := contractpb.WrapPluginContext(pctx.WithSender(govAddr))
+	_, err := coin.Snapshot(govCtx, &SnapshotRequest{})
+	require.Equal(t, ErrFeatureNotEnabled, err)
+
+	pctx.SetFeature(features.CoinVersion1_5Feature, true)
+	// only the Governance contract can take snapshots
+	_, err = coin.Snapshot(ctx, &SnapshotRequest{})
+	require.Equal(t, ErrNotAuthorized, err)
+	resp, err := coin.Snapshot(govCtx, &SnapshotRequest{})
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), resp.SnapshotId)
+
+	transferAmount := &types.BigUInt{Value: *sciNot(100, 18)}
+	require.NoError(t, coin.Transfer(ctx, &TransferRequest{To: addr2.MarshalPB(), Amount: transferAmount}))
+
+	resp, err = coin.Snapshot(govCtx, &SnapshotRequest{})
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), resp.SnapshotId)
+
+	require.NoError(t, coin.Transfer(ctx, &TransferRequest{To: addr2.MarshalPB(), Amount: transferAmount}))
+	require.NoError(t, mint(ctx, addr3, sciNot(50, 18)))
+
+	requireBalanceAt(t, ctx, addr1, 1, 1000)
+	requireBalanceAt(t, ctx, addr1, 2, 900)
+	requireBalanceAt(t, ctx, addr2, 1, 0)
+	requireBalanceAt(t, ctx, addr2, 2, 100)
+	requireBalanceAt(t, ctx, addr3, 2, 0)
+
+	_, err = coin.BalanceOfAt(ctx, &BalanceOfAtRequest{Owner: addr1.MarshalPB(), SnapshotId: 3})
+	require.Equal(t, ErrInvalidSnapshotID, err)
+
+	supplyResp, err := coin.TotalSupplyAt(ctx, &TotalSupplyAtRequest{SnapshotId: 2})
+	require.NoError(t, err)
+	require.Equal(t, sciNot(1000, 18).String(), supplyResp.TotalSupply.Value.String())
+	totalSupply, err := coin.TotalSupply(ctx, &TotalSupplyRequest{})
+	require.NoError(t, err)
+	require.Equal(t, sciNot(1050, 18).String(), totalSupply.TotalSupply.Value.String())
+}
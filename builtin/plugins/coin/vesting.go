@@ -0,0 +1,286 @@
+package coin
+
+import (
+	"encoding/binary"
+
+	"github.com/gogo/protobuf/proto"
+	loom "github.com/loomnetwork/go-loom"
+	contract "github.com/loomnetwork/go-loom/plugin/contractpb"
+	"github.com/loomnetwork/go-loom/types"
+	"github.com/loomnetwork/go-loom/util"
+	"github.com/loomnetwork/loomchain/features"
+	"github.com/pkg/errors"
+)
+
+type (
+	VestingUnlock                = CoinVestingUnlock
+	VestingSchedule              = CoinVestingSchedule
+	CreateVestingAccountRequest  = CoinCreateVestingAccountRequest
+	ApproveVestingGrantorRequest = CoinApproveVestingGrantorRequest
+	VestingDelegation            = CoinVestingDelegation
+)
+
+// MaxVestingSchedulesPerAccount is the max number of vesting schedules an account can have.
+const MaxVestingSchedulesPerAccount = 10
+
+var (
+	ErrFeatureNotEnabled       = errors.New("[Coin Contract] feature not enabled")
+	ErrInvalidVestingSchedule  = errors.New("[Coin Contract] invalid vesting schedule")
+	ErrBalanceLocked           = errors.New("[Coin Contract] balance is locked")
+	ErrTooManyVestingSchedules = errors.New("[Coin Contract] too many vesting schedules")
+)
+
+func vestingSchedulesPrefix(addr loom.Address) []byte {
+	return util.PrefixKey([]byte("vesting"), addr.Bytes())
+}
+
+func vestingGrantorKey(beneficiary, grantor loom.Address) []byte {
+	return util.PrefixKey([]byte("vesting_grantor"), beneficiary.Bytes(), grantor.Bytes())
+}
+
+func vestingDelegationKey(addr loom.Address) []byte {
+	return util.PrefixKey([]byte("vesting_delegation"), addr.Bytes())
+}
+
+func vestingScheduleKey(addr loom.Address, id uint64) []byte {
+	idBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(idBytes, id)
+	return util.PrefixKey(vestingSchedulesPrefix(addr), idBytes)
+}
+
+// ApproveVestingGrantor allows the grantor to create a single vesting schedule for the sender,
+// the approval is consumed when the schedule is created.
+func (c *Coin) ApproveVestingGrantor(ctx contract.Context, req *ApproveVestingGrantorRequest) error {
+	if !ctx.FeatureEnabled(features.CoinVersion1_4Feature, false) {
+		return ErrFeatureNotEnabled
+	}
+	if req.Grantor == nil {
+		return ErrInvalidRequest
+	}
+	grantor := loom.UnmarshalAddressPB(req.Grantor)
+	return ctx.Set(vestingGrantorKey(ctx.Message().Sender, grantor), req)
+}
+
+// CreateVestingAccount transfers the total amount of the given vesting schedule from the sender to
+// the beneficiary, the transferred amount will be locked according to the schedule. Locked tokens
+// can be delegated to the DPOSv3 contract, but they can't be transferred until they're unlocked.
+// The beneficiary must approve the sender via ApproveVestingGrantor before each schedule is
+// created, and an account can have up to MaxVestingSchedulesPerAccount schedules, the amount
+// locked in the account is the sum of the amounts locked by each schedule.
+func (c *Coin) CreateVestingAccount(ctx contract.Context, req *CreateVestingAccountRequest) error {
+	if !ctx.FeatureEnabled(features.CoinVersion1_4Feature, false) {
+		return ErrFeatureNotEnabled
+	}
+	if err := validateVestingSchedule(req.Schedule); err != nil {
+		return err
+	}
+
+	grantor := ctx.Message().Sender
+	beneficiary := loom.UnmarshalAddressPB(req.Schedule.Beneficiary)
+	if !ctx.Has(vestingGrantorKey(beneficiary, grantor)) {
+		return ErrNotAuthorized
+	}
+	numSchedules := len(ctx.Range(vestingSchedulesPrefix(beneficiary)))
+	if numSchedules >= MaxVestingSchedulesPerAccount {
+		return ErrTooManyVestingSchedules
+	}
+
+	err := transferBalance(ctx, grantor, beneficiary, &req.Schedule.TotalAmount.Value)
+	if err != nil {
+		return err
+	}
+	ctx.Delete(vestingGrantorKey(beneficiary, grantor))
+	// Vesting schedules are never deleted, so the schedules of an account are numbered sequentially.
+	return ctx.Set(vestingScheduleKey(beneficiary, uint64(numSchedules)+1), req.Schedule)
+}
+
+func validateVestingSchedule(schedule *VestingSchedule) error {
+	if schedule == nil || schedule.Beneficiary == nil || schedule.TotalAmount == nil ||
+		schedule.TotalAmount.Value.Sign() <= 0 {
+		return ErrInvalidRequest
+	}
+
+	if len(schedule.Unlocks) > 0 {
+		total := loom.NewBigUIntFromInt(0)
+		for _, unlock := range schedule.Unlocks {
+			if unlock.Amount == nil || unlock.Amount.Value.Sign() <= 0 {
+				return ErrInvalidVestingSchedule
+			}
+			total.Add(total, &unlock.Amount.Value)
+		}
+		if total.Cmp(&schedule.TotalAmount.Value) != 0 {
+			return ErrInvalidVestingSchedule
+		}
+		return nil
+	}
+
+	if schedule.StartTime >= schedule.EndTime ||
+		schedule.CliffTime < schedule.StartTime || schedule.CliffTime > schedule.EndTime {
+		return ErrInvalidVestingSchedule
+	}
+	return nil
+}
+
+func loadVestingSchedules(ctx contract.StaticContext, owner loom.Address) ([]*VestingSchedule, error) {
+	var schedules []*VestingSchedule
+	for _, entry := range ctx.Range(vestingSchedulesPrefix(owner)) {
+		var schedule VestingSchedule
+		if err := proto.Unmarshal(entry.Value, &schedule); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal vesting schedule")
+		}
+		schedules = append(schedules, &schedule)
+	}
+	return schedules, nil
+}
+
+// lockedAmount returns the amount that's still locked by the vesting schedule at the given time.
+func lockedAmount(schedule *VestingSchedule, now uint64) *loom.BigUInt {
+	locked := loom.NewBigUIntFromInt(0)
+	if len(schedule.Unlocks) > 0 {
+		for _, unlock := range schedule.Unlocks {
+			if unlock.Time > now {
+				locked.Add(locked, &unlock.Amount.Value)
+			}
+		}
+		return locked
+	}
+
+	total := &schedule.TotalAmount.Value
+	if now < schedule.CliffTime {
+		locked.Add(locked, total)
+		return locked
+	}
+	if now >= schedule.EndTime {
+		return locked
+	}
+	vested := loom.NewBigUIntFromInt(0)
+	vested.Mul(total, loom.NewBigUIntFromInt(int64(now-schedule.StartTime)))
+	vested.Div(vested, loom.NewBigUIntFromInt(int64(schedule.EndTime-schedule.StartTime)))
+	locked.Sub(total, vested)
+	return locked
+}
+
+// scheduleLockedAmount returns the sum of the amounts locked by the vesting schedules of the given
+// account at the current block time.
+func scheduleLockedAmount(ctx contract.StaticContext, owner loom.Address) (*loom.BigUInt, error) {
+	schedules, err := loadVestingSchedules(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	locked := loom.NewBigUIntFromInt(0)
+	now := uint64(ctx.Now().Unix())
+	for _, schedule := range schedules {
+		locked.Add(locked, lockedAmount(schedule, now))
+	}
+	return locked, nil
+}
+
+func loadVestingDelegation(ctx contract.StaticContext, owner loom.Address) (*VestingDelegation, error) {
+	var d VestingDelegation
+	if err := ctx.Get(vestingDelegationKey(owner), &d); err != nil && err != contract.ErrNotFound {
+		return nil, errors.Wrap(err, "failed to load vesting delegation")
+	}
+	if d.DelegatedLocked == nil {
+		d.DelegatedLocked = &types.BigUInt{Value: *loom.NewBigUIntFromInt(0)}
+	}
+	if d.DelegatedUnlocked == nil {
+		d.DelegatedUnlocked = &types.BigUInt{Value: *loom.NewBigUIntFromInt(0)}
+	}
+	return &d, nil
+}
+
+// subOrZero returns a - b, or zero if b is greater than a.
+func subOrZero(a, b *loom.BigUInt) *loom.BigUInt {
+	diff := loom.NewBigUIntFromInt(0)
+	if a.Cmp(b) > 0 {
+		diff.Sub(a, b)
+	}
+	return diff
+}
+
+func minBigUInt(a, b *loom.BigUInt) *loom.BigUInt {
+	if a.Cmp(b) <= 0 {
+		return a
+	}
+	return b
+}
+
+// recordVestingDelegation tracks the tokens a vesting account delegates to the DPOSv3 contract,
+// locked tokens are considered to be delegated before unlocked ones.
+func recordVestingDelegation(ctx contract.Context, owner loom.Address, amount *loom.BigUInt) error {
+	if !ctx.FeatureEnabled(features.CoinVersion1_4Feature, false) {
+		return nil
+	}
+	if len(ctx.Range(vestingSchedulesPrefix(owner))) == 0 {
+		return nil
+	}
+	scheduleLocked, err := scheduleLockedAmount(ctx, owner)
+	if err != nil {
+		return err
+	}
+	d, err := loadVestingDelegation(ctx, owner)
+	if err != nil {
+		return err
+	}
+	delegatedLocked := minBigUInt(amount, subOrZero(scheduleLocked, &d.DelegatedLocked.Value))
+	d.DelegatedLocked.Value.Add(&d.DelegatedLocked.Value, delegatedLocked)
+	d.DelegatedUnlocked.Value.Add(&d.DelegatedUnlocked.Value, subOrZero(amount, delegatedLocked))
+	return ctx.Set(vestingDelegationKey(owner), d)
+}
+
+// recordVestingUndelegation tracks the tokens the DPOSv3 contract returns to a vesting account,
+// unlocked tokens are considered to be returned before locked ones. Any tokens returned in excess
+// of the delegated amount (e.g. rewards) aren't locked.
+func recordVestingUndelegation(ctx contract.Context, owner loom.Address, amount *loom.BigUInt) error {
+	if !ctx.FeatureEnabled(features.CoinVersion1_4Feature, false) || !ctx.Has(vestingDelegationKey(owner)) {
+		return nil
+	}
+	d, err := loadVestingDelegation(ctx, owner)
+	if err != nil {
+		return err
+	}
+	returnedUnlocked := minBigUInt(amount, &d.DelegatedUnlocked.Value)
+	returnedLocked := minBigUInt(subOrZero(amount, returnedUnlocked), &d.DelegatedLocked.Value)
+	d.DelegatedUnlocked.Value = *subOrZero(&d.DelegatedUnlocked.Value, returnedUnlocked)
+	d.DelegatedLocked.Value = *subOrZero(&d.DelegatedLocked.Value, returnedLocked)
+	return ctx.Set(vestingDelegationKey(owner), d)
+}
+
+// spendableBalance returns the portion of the account balance that isn't locked by vesting
+// schedules, and the portion that's locked. Locked tokens that have been delegated to the DPOSv3
+// contract aren't part of the account balance, so they're excluded from the locked portion.
+func spendableBalance(ctx contract.StaticContext, account *Account) (*loom.BigUInt, *loom.BigUInt, error) {
+	balance := &account.Balance.Value
+	locked := loom.NewBigUIntFromInt(0)
+	if ctx.FeatureEnabled(features.CoinVersion1_4Feature, false) {
+		owner := loom.UnmarshalAddressPB(account.Owner)
+		scheduleLocked, err := scheduleLockedAmount(ctx, owner)
+		if err != nil {
+			return nil, nil, err
+		}
+		d, err := loadVestingDelegation(ctx, owner)
+		if err != nil {
+			return nil, nil, err
+		}
+		locked.Add(locked, minBigUInt(subOrZero(scheduleLocked, &d.DelegatedLocked.Value), balance))
+	}
+	return subOrZero(balance, locked), locked, nil
+}
+
+// checkSpendableBalance returns ErrBalanceLocked if the given amount exceeds the portion of the
+// account balance that isn't locked by vesting schedules.
+func checkSpendableBalance(ctx contract.StaticContext, account *Account, amount *loom.BigUInt) error {
+	spendable, _, err := spendableBalance(ctx, account)
+	if err != nil {
+		return err
+	}
+	if spendable.Cmp(amount) < 0 {
+		return ErrBalanceLocked
+	}
+	return nil
+}
+
+func isDPOSContract(ctx contract.StaticContext, addr loom.Address) bool {
+	dposAddr, err := ctx.Resolve("dposV3")
+	return err == nil && addr.Compare(dposAddr) == 0
+}
@@ -0,0 +1,237 @@
+package coin
+
+import (
+	"testing"
+
+	loom "github.com/loomnetwork/go-loom"
+	"github.com/loomnetwork/go-loom/plugin"
+	"github.com/loomnetwork/go-loom/plugin/contractpb"
+	"github.com/loomnetwork/go-loom/types"
+	"github.com/loomnetwork/loomchain/features"
+	"github.com/stretchr/testify/require"
+)
+
+type mockDPOS struct {
+}
+
+func (m *mockDPOS) Meta() (plugin.Meta, error) {
+	return plugin.Meta{
+		Name:    "dposV3",
+		Version: "3.0.0",
+	}, nil
+}
+
+func (m *mockDPOS) DummyMethod(ctx contractpb.Context, req *MintToGatewayRequest) error {
+	return nil
+}
+
+func setupVestingTest(t *testing.T) (*plugin.FakeContext, *Coin) {
+	pctx := plugin.CreateFakeContext(addr1, addr1).WithBlock(loom.BlockHeader{
+		ChainID: "chain",
+		Time:    1000,
+	})
+	pctx.SetFeature(features.CoinVersion1_1Feature, true)
+	pctx.SetFeature(features.CoinVersion1_4Feature, true)
+
+	coin := &Coin{}
+	require.NoError(t, coin.Init(contractpb.WrapPluginContext(pctx), &InitRequest{
+		Accounts: []*InitialAccount{
+			{Owner: addr1.MarshalPB(), Balance: 1000},
+		},
+	}))
+	return pctx, coin
+}
+
+func coinCtxAt(pctx *plugin.FakeContext, sender loom.Address, now int64) contractpb.Context {
+	return contractpb.WrapPluginContext(pctx.WithSender(sender).WithBlock(loom.BlockHeader{
+		ChainID: "chain",
+		Time:    now,
+	}))
+}
+
+func approveVestingGrantor(t *testing.T, pctx *plugin.FakeContext, beneficiary, grantor loom.Address) {
+	require.NoError(t, (&Coin{}).ApproveVestingGrantor(coinCtxAt(pctx, beneficiary, 1000), &ApproveVestingGrantorRequest{
+		Grantor: grantor.MarshalPB(),
+	}))
+}
+
+func requireVestingBalance(t *testing.T, ctx contractpb.StaticContext, owner loom.Address, locked, spendable int64) {
+	resp, err := (&Coin{}).BalanceOf(ctx, &BalanceOfRequest{Owner: owner.MarshalPB()})
+	require.NoError(t, err)
+	require.Equal(t, sciNot(locked, 18).String(), resp.Locked.Value.String())
+	require.Equal(t, sciNot(spendable, 18).String(), resp.Spendable.Value.String())
+}
+
+func TestLinearVesting(t *testing.T) {
+	pctx, coin := setupVestingTest(t)
+	dposAddr := pctx.CreateContract(contractpb.MakePluginContract(&mockDPOS{}))
+
+	schedule := &VestingSchedule{
+		Beneficiary: addr2.MarshalPB(),
+		TotalAmount: &types.BigUInt{Value: *sciNot(400, 18)},
+		StartTime:   1000,
+		CliffTime:   1100,
+		EndTime:     2000,
+	}
+	approveVestingGrantor(t, pctx, addr2, addr1)
+	require.NoError(t, coin.CreateVestingAccount(coinCtxAt(pctx, addr1, 1000), &CreateVestingAccountRequest{
+		Schedule: schedule,
+	}))
+
+	// nothing is unlocked before the cliff
+	ctx := coinCtxAt(pctx, addr2, 1050)
+	requireVestingBalance(t, ctx, addr2, 400, 0)
+	require.Equal(t, ErrBalanceLocked, coin.Transfer(ctx, &TransferRequest{
+		To:     addr3.MarshalPB(),
+		Amount: &types.BigUInt{Value: *sciNot(1, 18)},
+	}))
+
+	// tokens received by the vesting account aren't locked
+	require.NoError(t, coin.Transfer(coinCtxAt(pctx, addr1, 1050), &TransferRequest{
+		To:     addr2.MarshalPB(),
+		Amount: &types.BigUInt{Value: *sciNot(100, 18)},
+	}))
+	requireVestingBalance(t, ctx, addr2, 400, 100)
+	require.NoError(t, coin.Transfer(ctx, &TransferRequest{
+		To:     addr3.MarshalPB(),
+		Amount: &types.BigUInt{Value: *sciNot(100, 18)},
+	}))
+
+	// locked tokens can be delegated, but can't be transferred by anyone else
+	for _, spender := range []loom.Address{dposAddr, addr3} {
+		require.NoError(t, coin.Approve(ctx, &ApproveRequest{
+			Spender: spender.MarshalPB(),
+			Amount:  &types.BigUInt{Value: *sciNot(200, 18)},
+		}))
+	}
+	require.Equal(t, ErrBalanceLocked, coin.TransferFrom(coinCtxAt(pctx, addr3, 1050), &TransferFromRequest{
+		From:   addr2.MarshalPB(),
+		To:     addr3.MarshalPB(),
+		Amount: &types.BigUInt{Value: *sciNot(200, 18)},
+	}))
+	require.NoError(t, coin.TransferFrom(coinCtxAt(pctx, dposAddr, 1050), &TransferFromRequest{
+		From:   addr2.MarshalPB(),
+		To:     dposAddr.MarshalPB(),
+		Amount: &types.BigUInt{Value: *sciNot(200, 18)},
+	}))
+	requireVestingBalance(t, ctx, addr2, 200, 0)
+
+	// delegated locked tokens don't lock tokens received by the vesting account
+	require.NoError(t, coin.Transfer(coinCtxAt(pctx, addr1, 1050), &TransferRequest{
+		To:     addr2.MarshalPB(),
+		Amount: &types.BigUInt{Value: *sciNot(50, 18)},
+	}))
+	requireVestingBalance(t, ctx, addr2, 200, 50)
+	require.NoError(t, coin.Transfer(ctx, &TransferRequest{
+		To:     addr3.MarshalPB(),
+		Amount: &types.BigUInt{Value: *sciNot(50, 18)},
+	}))
+
+	// half the tokens are unlocked half way through the schedule, the locked half is delegated
+	ctx = coinCtxAt(pctx, addr2, 1500)
+	requireVestingBalance(t, ctx, addr2, 0, 200)
+	require.NoError(t, coin.Transfer(coinCtxAt(pctx, dposAddr, 1500), &TransferRequest{
+		To:     addr2.MarshalPB(),
+		Amount: &types.BigUInt{Value: *sciNot(200, 18)},
+	}))
+	requireVestingBalance(t, ctx, addr2, 200, 200)
+
+	requireVestingBalance(t, coinCtxAt(pctx, addr2, 2000), addr2, 0, 400)
+}
+
+func TestDiscreteVesting(t *testing.T) {
+	pctx, coin := setupVestingTest(t)
+
+	approveVestingGrantor(t, pctx, addr2, addr1)
+	ctx := coinCtxAt(pctx, addr1, 1000)
+	require.Equal(t, ErrInvalidVestingSchedule, coin.CreateVestingAccount(ctx, &CreateVestingAccountRequest{
+		Schedule: &VestingSchedule{
+			Beneficiary: addr2.MarshalPB(),
+			TotalAmount: &types.BigUInt{Value: *sciNot(300, 18)},
+			Unlocks: []*VestingUnlock{
+				{Time: 1200, Amount: &types.BigUInt{Value: *sciNot(100, 18)}},
+			},
+		},
+	}))
+	require.NoError(t, coin.CreateVestingAccount(ctx, &CreateVestingAccountRequest{
+		Schedule: &VestingSchedule{
+			Beneficiary: addr2.MarshalPB(),
+			TotalAmount: &types.BigUInt{Value: *sciNot(300, 18)},
+			Unlocks: []*VestingUnlock{
+				{Time: 1200, Amount: &types.BigUInt{Value: *sciNot(100, 18)}},
+				{Time: 1300, Amount: &types.BigUInt{Value: *sciNot(200, 18)}},
+			},
+		},
+	}))
+
+	requireVestingBalance(t, coinCtxAt(pctx, addr2, 1199), addr2, 300, 0)
+	requireVestingBalance(t, coinCtxAt(pctx, addr2, 1200), addr2, 200, 100)
+	requireVestingBalance(t, coinCtxAt(pctx, addr2, 1300), addr2, 0, 300)
+}
+
+func TestMultipleVestingSchedules(t *testing.T) {
+	pctx, coin := setupVestingTest(t)
+
+	// schedules can only be created by grantors the beneficiary has approved
+	require.NoError(t, coin.Transfer(coinCtxAt(pctx, addr1, 1000), &TransferRequest{
+		To:     addr3.MarshalPB(),
+		Amount: &types.BigUInt{Value: *sciNot(1, 18)},
+	}))
+	req := &CreateVestingAccountRequest{
+		Schedule: &VestingSchedule{
+			Beneficiary: addr2.MarshalPB(),
+			TotalAmount: &types.BigUInt{Value: *sciNot(1, 18)},
+			Unlocks: []*VestingUnlock{
+				{Time: 5000, Amount: &types.BigUInt{Value: *sciNot(1, 18)}},
+			},
+		},
+	}
+	require.Equal(t, ErrNotAuthorized, coin.CreateVestingAccount(coinCtxAt(pctx, addr3, 1000), req))
+	approveVestingGrantor(t, pctx, addr2, addr3)
+	require.NoError(t, coin.CreateVestingAccount(coinCtxAt(pctx, addr3, 1000), req))
+	approveVestingGrantor(t, pctx, addr2, addr1)
+	require.NoError(t, coin.CreateVestingAccount(coinCtxAt(pctx, addr1, 1000), &CreateVestingAccountRequest{
+		Schedule: &VestingSchedule{
+			Beneficiary: addr2.MarshalPB(),
+			TotalAmount: &types.BigUInt{Value: *sciNot(300, 18)},
+			Unlocks: []*VestingUnlock{
+				{Time: 1200, Amount: &types.BigUInt{Value: *sciNot(300, 18)}},
+			},
+		},
+	}))
+
+	// the amount locked in the account is the sum of the amounts locked by each schedule
+	requireVestingBalance(t, coinCtxAt(pctx, addr2, 1199), addr2, 301, 0)
+	requireVestingBalance(t, coinCtxAt(pctx, addr2, 1200), addr2, 1, 300)
+	requireVestingBalance(t, coinCtxAt(pctx, addr2, 5000), addr2, 0, 301)
+
+	resp, err := coin.BalanceOf(coinCtxAt(pctx, addr2, 1200), &BalanceOfRequest{Owner: addr2.MarshalPB()})
+	require.NoError(t, err)
+	require.Len(t, resp.Schedules, 2)
+}
+
+func TestVestingScheduleLimits(t *testing.T) {
+	pctx, coin := setupVestingTest(t)
+	ctx := coinCtxAt(pctx, addr1, 1000)
+	req := &CreateVestingAccountRequest{
+		Schedule: &VestingSchedule{
+			Beneficiary: addr2.MarshalPB(),
+			TotalAmount: &types.BigUInt{Value: *sciNot(1, 18)},
+			Unlocks: []*VestingUnlock{
+				{Time: 5000, Amount: &types.BigUInt{Value: *sciNot(1, 18)}},
+			},
+		},
+	}
+
+	// each approval can only be used to create a single schedule
+	approveVestingGrantor(t, pctx, addr2, addr1)
+	require.NoError(t, coin.CreateVestingAccount(ctx, req))
+	require.Equal(t, ErrNotAuthorized, coin.CreateVestingAccount(ctx, req))
+
+	for i := 1; i < MaxVestingSchedulesPerAccount; i++ {
+		approveVestingGrantor(t, pctx, addr2, addr1)
+		require.NoError(t, coin.CreateVestingAccount(ctx, req))
+	}
+	approveVestingGrantor(t, pctx, addr2, addr1)
+	require.Equal(t, ErrTooManyVestingSchedules, coin.CreateVestingAccount(ctx, req))
+}
@@ -0,0 +1,471 @@
+package dataoracle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gogo/protobuf/proto"
+	loom "github.com/loomnetwork/go-loom"
+	"github.com/loomnetwork/go-loom/plugin"
+	contract "github.com/loomnetwork/go-loom/plugin/contractpb"
+	"github.com/loomnetwork/go-loom/util"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ed25519"
+)
+
+type (
+	State                  = DataOracleState
+	Feed                   = DataOracleFeed
+	FeedEpoch              = DataOracleFeedEpoch
+	DataPoint              = DataOracleDataPoint
+	Round                  = DataOracleRound
+	Observation            = DataOracleObservation
+	InitRequest            = DataOracleInitRequest
+	SetFeedRequest         = DataOracleSetFeedRequest
+	RemoveFeedRequest      = DataOracleRemoveFeedRequest
+	GetFeedRequest         = DataOracleGetFeedRequest
+	GetFeedResponse        = DataOracleGetFeedResponse
+	ListFeedsRequest       = DataOracleListFeedsRequest
+	ListFeedsResponse      = DataOracleListFeedsResponse
+	SubmitDataRequest      = DataOracleSubmitDataRequest
+	GetLatestRequest       = DataOracleGetLatestRequest
+	GetLatestResponse      = DataOracleGetLatestResponse
+	ListDataPointsRequest  = DataOracleListDataPointsRequest
+	ListDataPointsResponse = DataOracleListDataPointsResponse
+	DataPointEvent         = DataOracleDataPointEvent
+)
+
+var (
+	// ErrNotAuthorized indicates that a contract method failed because the caller didn't have
+	// the permission to execute that method.
+	ErrNotAuthorized = errors.New("[DataOracle] not authorized")
+	// ErrInvalidRequest is a generic error that's returned when something is wrong with the
+	// request message, e.g. missing or invalid fields.
+	ErrInvalidRequest = errors.New("[DataOracle] invalid request")
+	// ErrOwnerNotSpecified returned if init request does not have owner address
+	ErrOwnerNotSpecified = errors.New("[DataOracle] owner not specified")
+	// ErrFeedNotFound indicates that a feed does not exist
+	ErrFeedNotFound = errors.New("[DataOracle] feed not found")
+	// ErrUnexpectedRound is returned when data is submitted for any round other than the one
+	// following the last round of the feed.
+	ErrUnexpectedRound = errors.New("[DataOracle] unexpected round")
+	// ErrInvalidObservation is returned when an observation wasn't signed by a current validator,
+	// or doesn't match the round it was submitted for.
+	ErrInvalidObservation = errors.New("[DataOracle] invalid observation")
+	// ErrNotEnoughObservations is returned when the validators that submitted observations for a
+	// round don't hold more than 2/3 of the total validator power.
+	ErrNotEnoughObservations = errors.New("[DataOracle] not enough observations")
+)
+
+const (
+	ownerRole = "owner"
+
+	// DataPointEventTopic is the topic of the event emitted when a new data point is stored.
+	DataPointEventTopic = "dataoracle:datapoint"
+
+	// Max number of data points that can be returned by a single query.
+	maxDataPointsQueryLimit = 1000
+)
+
+var (
+	modifyPerm = []byte("modp")
+
+	stateKey        = []byte("state")
+	feedPrefix      = []byte("feed")
+	epochPrefix     = []byte("epoch")
+	dataPointPrefix = []byte("data")
+
+	// Selector of updateData(uint256,int256,uint256), which is called on the EVM consumer of a feed.
+	evmUpdateDataSelector = crypto.Keccak256([]byte("updateData(uint256,int256,uint256)"))[:4]
+)
+
+func feedKey(feedID string) []byte {
+	return util.PrefixKey(feedPrefix, []byte(feedID))
+}
+
+func feedEpochKey(feedID string) []byte {
+	return util.PrefixKey(epochPrefix, []byte(feedID))
+}
+
+func dataPointKey(feedID string, round uint64) []byte {
+	return util.PrefixKey(dataPointPrefix, []byte(feedID), uint64ToBytes(round))
+}
+
+func uint64ToBytes(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, v)
+	return b
+}
+
+// ObservationHash returns the hash a validator must sign to attest that it observed the given
+// value for a feed round. The hash commits to the chain ID & the address of the DataOracle
+// contract, so observations can't be replayed on another chain or to another DataOracle contract,
+// and to the feed epoch, so observations can't be replayed to a feed that has been re-created.
+func ObservationHash(
+	contractAddr loom.Address, feedID string, epoch uint64, round uint64, value int64,
+) []byte {
+	h := sha256.New()
+	h.Write([]byte(contractAddr.ChainID))
+	h.Write([]byte{0})
+	h.Write(contractAddr.Local)
+	h.Write([]byte(feedID))
+	h.Write([]byte{0})
+	h.Write(uint64ToBytes(epoch))
+	h.Write(uint64ToBytes(round))
+	h.Write(uint64ToBytes(uint64(value)))
+	return h.Sum(nil)
+}
+
+// DataOracle contract stores time-series data submitted by the validators. Each data point is the
+// median of the values observed by the validators for a round of a feed, the observations are
+// collected off-chain by the fnConsensus reactor, and each one must be signed by the validator
+// that made it.
+//
+// Go contracts can read the data via static calls to GetLatest & ListDataPoints, while EVM
+// contracts can be registered as the consumer of a feed, in which case they'll be notified of
+// each new data point.
+type DataOracle struct {
+}
+
+func (o *DataOracle) Meta() (plugin.Meta, error) {
+	return plugin.Meta{
+		Name:    "dataoracle",
+		Version: "1.0.0",
+	}, nil
+}
+
+func (o *DataOracle) Init(ctx contract.Context, req *InitRequest) error {
+	if req.Owner == nil {
+		return ErrOwnerNotSpecified
+	}
+	ownerAddr := loom.UnmarshalAddressPB(req.Owner)
+	ctx.GrantPermissionTo(ownerAddr, modifyPerm, ownerRole)
+	return ctx.Set(stateKey, &State{Owner: req.Owner})
+}
+
+// SetFeed creates a new feed, or updates the settings of an existing one, only the contract owner
+// can call this method.
+func (o *DataOracle) SetFeed(ctx contract.Context, req *SetFeedRequest) error {
+	if ok, _ := ctx.HasPermission(modifyPerm, []string{ownerRole}); !ok {
+		return ErrNotAuthorized
+	}
+	if req.Feed == nil || req.Feed.Id == "" {
+		return ErrInvalidRequest
+	}
+
+	feed, err := loadFeed(ctx, req.Feed.Id)
+	if err == ErrFeedNotFound {
+		// Round numbers start from scratch when a feed is re-created, so the epoch is bumped to
+		// invalidate observations made for any previous feed with the same ID.
+		var lastEpoch FeedEpoch
+		if err := ctx.Get(feedEpochKey(req.Feed.Id), &lastEpoch); err != nil && err != contract.ErrNotFound {
+			return err
+		}
+		feed = &Feed{Id: req.Feed.Id, Epoch: lastEpoch.Epoch + 1}
+		if err := ctx.Set(feedEpochKey(feed.Id), &FeedEpoch{Epoch: feed.Epoch}); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	} else if feed.Decimals != req.Feed.Decimals && feed.LastRound > 0 {
+		// Changing the scale of a feed would make the existing data points meaningless
+		return errors.Wrap(ErrInvalidRequest, "decimals can't be changed once a feed has data")
+	}
+
+	feed.Description = req.Feed.Description
+	feed.Decimals = req.Feed.Decimals
+	feed.MaxDataPoints = req.Feed.MaxDataPoints
+	feed.EvmConsumer = req.Feed.EvmConsumer
+	pruneDataPoints(ctx, feed)
+	return ctx.Set(feedKey(feed.Id), feed)
+}
+
+// RemoveFeed removes a feed along with all its data points, only the contract owner can call this
+// method.
+func (o *DataOracle) RemoveFeed(ctx contract.Context, req *RemoveFeedRequest) error {
+	if ok, _ := ctx.HasPermission(modifyPerm, []string{ownerRole}); !ok {
+		return ErrNotAuthorized
+	}
+
+	feed, err := loadFeed(ctx, req.FeedId)
+	if err != nil {
+		return err
+	}
+	if feed.LastRound > 0 {
+		for round := feed.FirstRound; round <= feed.LastRound; round++ {
+			ctx.Delete(dataPointKey(feed.Id, round))
+		}
+	}
+	ctx.Delete(feedKey(feed.Id))
+	return nil
+}
+
+func (o *DataOracle) GetFeed(ctx contract.StaticContext, req *GetFeedRequest) (*GetFeedResponse, error) {
+	feed, err := loadFeed(ctx, req.FeedId)
+	if err != nil {
+		return nil, err
+	}
+	return &GetFeedResponse{Feed: feed}, nil
+}
+
+// ListFeeds returns all the feeds, sorted by ID.
+func (o *DataOracle) ListFeeds(ctx contract.StaticContext, req *ListFeedsRequest) (*ListFeedsResponse, error) {
+	feeds := []*Feed{}
+	for _, m := range ctx.Range(feedPrefix) {
+		var feed Feed
+		if err := proto.Unmarshal(m.Value, &feed); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal feed")
+		}
+		feeds = append(feeds, &feed)
+	}
+	sort.Slice(feeds, func(i, j int) bool {
+		return feeds[i].Id < feeds[j].Id
+	})
+	return &ListFeedsResponse{Feeds: feeds}, nil
+}
+
+// SubmitData stores the median of the observations made by the validators for the next round of
+// a feed. Anyone can call this method, but the submitted observations must have been signed by
+// validators holding more than 2/3 of the total validator power.
+func (o *DataOracle) SubmitData(ctx contract.Context, req *SubmitDataRequest) error {
+	feed, err := loadFeed(ctx, req.FeedId)
+	if err != nil {
+		return err
+	}
+	if req.Round != feed.LastRound+1 {
+		return ErrUnexpectedRound
+	}
+
+	values, err := verifyObservations(ctx, feed, req)
+	if err != nil {
+		return err
+	}
+
+	dataPoint := &DataPoint{
+		FeedId:          feed.Id,
+		Round:           req.Round,
+		Value:           median(values),
+		Timestamp:       ctx.Now().Unix(),
+		BlockHeight:     uint64(ctx.Block().Height),
+		NumObservations: uint32(len(values)),
+	}
+	if err := ctx.Set(dataPointKey(feed.Id, dataPoint.Round), dataPoint); err != nil {
+		return err
+	}
+
+	feed.LastRound = dataPoint.Round
+	if feed.FirstRound == 0 {
+		feed.FirstRound = dataPoint.Round
+	}
+	pruneDataPoints(ctx, feed)
+	if err := ctx.Set(feedKey(feed.Id), feed); err != nil {
+		return err
+	}
+
+	if feed.EvmConsumer != nil {
+		if err := notifyEVMConsumer(ctx, loom.UnmarshalAddressPB(feed.EvmConsumer), dataPoint); err != nil {
+			return errors.Wrap(err, "failed to notify EVM consumer")
+		}
+	}
+	return emitDataPointEvent(ctx, dataPoint)
+}
+
+// GetLatest returns the most recent data point of a feed.
+func (o *DataOracle) GetLatest(ctx contract.StaticContext, req *GetLatestRequest) (*GetLatestResponse, error) {
+	feed, err := loadFeed(ctx, req.FeedId)
+	if err != nil {
+		return nil, err
+	}
+	if feed.LastRound == 0 {
+		return &GetLatestResponse{}, nil
+	}
+
+	var dataPoint DataPoint
+	if err := ctx.Get(dataPointKey(feed.Id, feed.LastRound), &dataPoint); err != nil {
+		return nil, err
+	}
+	return &GetLatestResponse{DataPoint: &dataPoint}, nil
+}
+
+// ListDataPoints returns the data points of a feed within the requested range of rounds, ordered
+// by round.
+func (o *DataOracle) ListDataPoints(
+	ctx contract.StaticContext, req *ListDataPointsRequest,
+) (*ListDataPointsResponse, error) {
+	feed, err := loadFeed(ctx, req.FeedId)
+	if err != nil {
+		return nil, err
+	}
+
+	dataPoints := []*DataPoint{}
+	if feed.LastRound > 0 {
+		fromRound, toRound := dataPointsRange(feed, req.FromRound, req.ToRound, req.Limit)
+		for round := fromRound; round <= toRound; round++ {
+			var dataPoint DataPoint
+			if err := ctx.Get(dataPointKey(feed.Id, round), &dataPoint); err != nil {
+				if err == contract.ErrNotFound {
+					continue
+				}
+				return nil, err
+			}
+			dataPoints = append(dataPoints, &dataPoint)
+		}
+	}
+
+	return &ListDataPointsResponse{
+		DataPoints: dataPoints,
+		LastRound:  feed.LastRound,
+	}, nil
+}
+
+// dataPointsRange returns the (inclusive) range of rounds that should be returned by a data points
+// query, clamped to the rounds that haven't been pruned yet.
+func dataPointsRange(feed *Feed, fromRound, toRound, limit uint64) (uint64, uint64) {
+	if limit == 0 || limit > maxDataPointsQueryLimit {
+		limit = maxDataPointsQueryLimit
+	}
+	if toRound == 0 || toRound > feed.LastRound {
+		toRound = feed.LastRound
+	}
+	if fromRound == 0 {
+		fromRound = 1
+		if toRound > limit {
+			fromRound = toRound - limit + 1
+		}
+	} else if toRound >= fromRound && toRound-fromRound >= limit {
+		toRound = fromRound + limit - 1
+	}
+	if fromRound < feed.FirstRound {
+		fromRound = feed.FirstRound
+	}
+	return fromRound, toRound
+}
+
+// verifyObservations checks that the observations in the request were signed by distinct current
+// validators holding more than 2/3 of the total validator power, and returns the observed values.
+func verifyObservations(ctx contract.StaticContext, feed *Feed, req *SubmitDataRequest) ([]int64, error) {
+	validators := ctx.Validators()
+	totalPower := big.NewInt(0)
+	for _, v := range validators {
+		if v != nil {
+			totalPower.Add(totalPower, big.NewInt(v.Power))
+		}
+	}
+
+	signedPower := big.NewInt(0)
+	values := make([]int64, 0, len(req.Observations))
+	seen := map[string]bool{}
+	contractAddr := ctx.ContractAddress()
+	for _, obs := range req.Observations {
+		if obs == nil || obs.FeedId != req.FeedId || obs.Round != req.Round {
+			return nil, ErrInvalidObservation
+		}
+		if seen[string(obs.PublicKey)] {
+			return nil, errors.Wrap(ErrInvalidObservation, "duplicate observation")
+		}
+
+		var power int64
+		found := false
+		for _, v := range validators {
+			if v != nil && bytes.Equal(v.PubKey, obs.PublicKey) {
+				power = v.Power
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, errors.Wrap(ErrInvalidObservation, "observation not made by a validator")
+		}
+		if len(obs.PublicKey) != ed25519.PublicKeySize || len(obs.Signature) != ed25519.SignatureSize ||
+			!ed25519.Verify(
+				obs.PublicKey, ObservationHash(contractAddr, obs.FeedId, feed.Epoch, obs.Round, obs.Value), obs.Signature,
+			) {
+			return nil, errors.Wrap(ErrInvalidObservation, "invalid signature")
+		}
+
+		seen[string(obs.PublicKey)] = true
+		signedPower.Add(signedPower, big.NewInt(power))
+		values = append(values, obs.Value)
+	}
+
+	// signedPower must be > 2/3 of totalPower
+	threshold := new(big.Int).Mul(totalPower, big.NewInt(2))
+	if len(values) == 0 || new(big.Int).Mul(signedPower, big.NewInt(3)).Cmp(threshold) <= 0 {
+		return nil, ErrNotEnoughObservations
+	}
+	return values, nil
+}
+
+// median returns the median of the given values, if there's an even number of values the mean of
+// the middle two is returned (rounded towards zero).
+func median(values []int64) int64 {
+	sorted := make([]int64, len(values))
+	copy(sorted, values)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return sorted[mid]
+	}
+	sum := new(big.Int).Add(big.NewInt(sorted[mid-1]), big.NewInt(sorted[mid]))
+	return sum.Quo(sum, big.NewInt(2)).Int64()
+}
+
+// pruneDataPoints deletes the oldest data points of a feed until the number of data points doesn't
+// exceed the max allowed for the feed. The caller is responsible for persisting the updated feed.
+func pruneDataPoints(ctx contract.Context, feed *Feed) {
+	if feed.MaxDataPoints == 0 || feed.LastRound == 0 {
+		return
+	}
+	for feed.LastRound-feed.FirstRound+1 > feed.MaxDataPoints {
+		ctx.Delete(dataPointKey(feed.Id, feed.FirstRound))
+		feed.FirstRound++
+	}
+}
+
+// notifyEVMConsumer calls updateData(uint256 round, int256 value, uint256 timestamp) on the given
+// EVM contract.
+func notifyEVMConsumer(ctx contract.Context, consumer loom.Address, dataPoint *DataPoint) error {
+	input := make([]byte, 0, 4+32*3)
+	input = append(input, evmUpdateDataSelector...)
+	input = append(input, math.PaddedBigBytes(new(big.Int).SetUint64(dataPoint.Round), 32)...)
+	input = append(input, math.PaddedBigBytes(math.U256(big.NewInt(dataPoint.Value)), 32)...)
+	input = append(input, math.PaddedBigBytes(big.NewInt(dataPoint.Timestamp), 32)...)
+	var output []byte
+	return contract.CallEVM(ctx, consumer, input, &output)
+}
+
+func loadFeed(ctx contract.StaticContext, feedID string) (*Feed, error) {
+	if feedID == "" {
+		return nil, ErrInvalidRequest
+	}
+	var feed Feed
+	if err := ctx.Get(feedKey(feedID), &feed); err != nil {
+		if err == contract.ErrNotFound {
+			return nil, ErrFeedNotFound
+		}
+		return nil, err
+	}
+	return &feed, nil
+}
+
+func emitDataPointEvent(ctx contract.Context, dataPoint *DataPoint) error {
+	marshalled, err := proto.Marshal(&DataPointEvent{
+		DataPoint: dataPoint,
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx.EmitTopics(marshalled, DataPointEventTopic)
+	return nil
+}
+
+var Contract plugin.Contract = contract.MakePluginContract(&DataOracle{})
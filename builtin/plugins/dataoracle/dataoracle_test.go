@@ -0,0 +1,251 @@
+package dataoracle
+
+import (
+	"crypto/rand"
+	"testing"
+
+	loom "github.com/loomnetwork/go-loom"
+	"github.com/loomnetwork/go-loom/plugin"
+	"github.com/loomnetwork/go-loom/plugin/contractpb"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ed25519"
+)
+
+var (
+	owner  = loom.MustParseAddress("default:0xb16a379ec18d4093666f8f38b11a3071c920207d")
+	nobody = loom.MustParseAddress("default:0x5cecd1f7261e1f4c684e297be3edf03b825e01c4")
+)
+
+type testValidator struct {
+	pubKey       ed25519.PublicKey
+	privKey      ed25519.PrivateKey
+	contractAddr loom.Address
+}
+
+func (v *testValidator) observe(feedID string, round uint64, value int64) *Observation {
+	return v.observeEpoch(feedID, 1, round, value)
+}
+
+func (v *testValidator) observeEpoch(feedID string, epoch uint64, round uint64, value int64) *Observation {
+	return &Observation{
+		FeedId:    feedID,
+		Round:     round,
+		Value:     value,
+		PublicKey: v.pubKey,
+		Signature: ed25519.Sign(v.privKey, ObservationHash(v.contractAddr, feedID, epoch, round, value)),
+	}
+}
+
+// Deploys the DataOracle contract with four equally weighted validators, and creates a feed.
+func setupTestEnv(t *testing.T) (*plugin.FakeContext, []*testValidator) {
+	validators := make([]*testValidator, 4)
+	loomValidators := make([]*loom.Validator, 4)
+	for i := range validators {
+		pubKey, privKey, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		validators[i] = &testValidator{pubKey: pubKey, privKey: privKey}
+		loomValidators[i] = &loom.Validator{PubKey: pubKey, Power: 10}
+	}
+
+	pctx := plugin.CreateFakeContext(owner, owner).WithBlock(loom.BlockHeader{
+		ChainID: "default",
+		Height:  1,
+		Time:    100000,
+	}).WithValidators(loomValidators)
+	contractAddr := pctx.CreateContract(Contract)
+	pctx = pctx.WithAddress(contractAddr)
+	for _, v := range validators {
+		v.contractAddr = contractAddr
+	}
+
+	o := &DataOracle{}
+	ctx := contractpb.WrapPluginContext(pctx)
+	require.NoError(t, o.Init(ctx, &InitRequest{Owner: owner.MarshalPB()}))
+	require.NoError(t, o.SetFeed(ctx, &SetFeedRequest{
+		Feed: &Feed{Id: "ETH/USD", Decimals: 2, MaxDataPoints: 3},
+	}))
+	return pctx, validators
+}
+
+func TestSetFeed(t *testing.T) {
+	pctx, _ := setupTestEnv(t)
+	o := &DataOracle{}
+
+	err := o.SetFeed(contractpb.WrapPluginContext(pctx.WithSender(nobody)), &SetFeedRequest{
+		Feed: &Feed{Id: "BTC/USD"},
+	})
+	require.Equal(t, ErrNotAuthorized, err)
+
+	ctx := contractpb.WrapPluginContext(pctx)
+	require.NoError(t, o.SetFeed(ctx, &SetFeedRequest{
+		Feed: &Feed{Id: "BTC/USD", Description: "Bitcoin", Decimals: 8},
+	}))
+	resp, err := o.ListFeeds(ctx, &ListFeedsRequest{})
+	require.NoError(t, err)
+	require.Len(t, resp.Feeds, 2)
+	require.Equal(t, "BTC/USD", resp.Feeds[0].Id)
+	require.Equal(t, uint32(8), resp.Feeds[0].Decimals)
+	require.Equal(t, "ETH/USD", resp.Feeds[1].Id)
+
+	require.NoError(t, o.RemoveFeed(ctx, &RemoveFeedRequest{FeedId: "BTC/USD"}))
+	_, err = o.GetFeed(ctx, &GetFeedRequest{FeedId: "BTC/USD"})
+	require.Equal(t, ErrFeedNotFound, err)
+}
+
+func TestSubmitData(t *testing.T) {
+	pctx, validators := setupTestEnv(t)
+	o := &DataOracle{}
+	ctx := contractpb.WrapPluginContext(pctx.WithSender(nobody))
+
+	// Observations from validators holding half the power aren't enough
+	err := o.SubmitData(ctx, &SubmitDataRequest{
+		FeedId: "ETH/USD",
+		Round:  1,
+		Observations: []*Observation{
+			validators[0].observe("ETH/USD", 1, 100),
+			validators[1].observe("ETH/USD", 1, 102),
+		},
+	})
+	require.Equal(t, ErrNotEnoughObservations, err)
+
+	// Duplicate observations don't count twice
+	err = o.SubmitData(ctx, &SubmitDataRequest{
+		FeedId: "ETH/USD",
+		Round:  1,
+		Observations: []*Observation{
+			validators[0].observe("ETH/USD", 1, 100),
+			validators[1].observe("ETH/USD", 1, 102),
+			validators[1].observe("ETH/USD", 1, 102),
+		},
+	})
+	require.Equal(t, ErrInvalidObservation, errors.Cause(err))
+
+	// Tampered values are rejected
+	tampered := validators[2].observe("ETH/USD", 1, 104)
+	tampered.Value = 500
+	err = o.SubmitData(ctx, &SubmitDataRequest{
+		FeedId: "ETH/USD",
+		Round:  1,
+		Observations: []*Observation{
+			validators[0].observe("ETH/USD", 1, 100),
+			validators[1].observe("ETH/USD", 1, 102),
+			tampered,
+		},
+	})
+	require.Equal(t, ErrInvalidObservation, errors.Cause(err))
+
+	// Observations made for another DataOracle contract are rejected
+	otherContract := *validators[2]
+	otherContract.contractAddr = loom.MustParseAddress("default:0xfa4c7920accfd66b86f5fd0e69682a79f762d49e")
+	err = o.SubmitData(ctx, &SubmitDataRequest{
+		FeedId: "ETH/USD",
+		Round:  1,
+		Observations: []*Observation{
+			validators[0].observe("ETH/USD", 1, 100),
+			validators[1].observe("ETH/USD", 1, 102),
+			otherContract.observe("ETH/USD", 1, 104),
+		},
+	})
+	require.Equal(t, ErrInvalidObservation, errors.Cause(err))
+
+	// Rounds must be submitted in order
+	err = o.SubmitData(ctx, &SubmitDataRequest{
+		FeedId: "ETH/USD",
+		Round:  2,
+		Observations: []*Observation{
+			validators[0].observe("ETH/USD", 2, 100),
+			validators[1].observe("ETH/USD", 2, 102),
+			validators[2].observe("ETH/USD", 2, 104),
+		},
+	})
+	require.Equal(t, ErrUnexpectedRound, err)
+
+	require.NoError(t, o.SubmitData(ctx, &SubmitDataRequest{
+		FeedId: "ETH/USD",
+		Round:  1,
+		Observations: []*Observation{
+			validators[0].observe("ETH/USD", 1, 100),
+			validators[1].observe("ETH/USD", 1, 102),
+			validators[2].observe("ETH/USD", 1, 500),
+		},
+	}))
+	latest, err := o.GetLatest(ctx, &GetLatestRequest{FeedId: "ETH/USD"})
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), latest.DataPoint.Round)
+	require.Equal(t, int64(102), latest.DataPoint.Value)
+	require.Equal(t, uint32(3), latest.DataPoint.NumObservations)
+	require.Equal(t, int64(100000), latest.DataPoint.Timestamp)
+
+	// Only the last 3 data points should be retained
+	for round := uint64(2); round <= 5; round++ {
+		observations := []*Observation{}
+		for i, v := range validators {
+			observations = append(observations, v.observe("ETH/USD", round, int64(round*100)+int64(i)))
+		}
+		require.NoError(t, o.SubmitData(ctx, &SubmitDataRequest{
+			FeedId:       "ETH/USD",
+			Round:        round,
+			Observations: observations,
+		}))
+	}
+
+	resp, err := o.ListDataPoints(ctx, &ListDataPointsRequest{FeedId: "ETH/USD"})
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), resp.LastRound)
+	require.Len(t, resp.DataPoints, 3)
+	require.Equal(t, uint64(3), resp.DataPoints[0].Round)
+	require.Equal(t, uint64(5), resp.DataPoints[2].Round)
+	// Median of 500, 501, 502, 503
+	require.Equal(t, int64(501), resp.DataPoints[2].Value)
+
+	resp, err = o.ListDataPoints(ctx, &ListDataPointsRequest{FeedId: "ETH/USD", FromRound: 4, Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, resp.DataPoints, 1)
+	require.Equal(t, uint64(4), resp.DataPoints[0].Round)
+}
+
+func TestRecreatedFeed(t *testing.T) {
+	pctx, validators := setupTestEnv(t)
+	o := &DataOracle{}
+	ctx := contractpb.WrapPluginContext(pctx)
+
+	observations := []*Observation{
+		validators[0].observe("ETH/USD", 1, 100),
+		validators[1].observe("ETH/USD", 1, 102),
+		validators[2].observe("ETH/USD", 1, 104),
+	}
+	require.NoError(t, o.SubmitData(ctx, &SubmitDataRequest{
+		FeedId: "ETH/USD", Round: 1, Observations: observations,
+	}))
+
+	// Round numbers start from scratch when a feed is re-created, but observations made for the
+	// previous feed can't be replayed because the feed epoch has changed.
+	require.NoError(t, o.RemoveFeed(ctx, &RemoveFeedRequest{FeedId: "ETH/USD"}))
+	require.NoError(t, o.SetFeed(ctx, &SetFeedRequest{Feed: &Feed{Id: "ETH/USD", Decimals: 2}}))
+	resp, err := o.GetFeed(ctx, &GetFeedRequest{FeedId: "ETH/USD"})
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), resp.Feed.Epoch)
+	require.Equal(t, uint64(0), resp.Feed.LastRound)
+
+	err = o.SubmitData(ctx, &SubmitDataRequest{
+		FeedId: "ETH/USD", Round: 1, Observations: observations,
+	})
+	require.Equal(t, ErrInvalidObservation, errors.Cause(err))
+	require.NoError(t, o.SubmitData(ctx, &SubmitDataRequest{
+		FeedId: "ETH/USD",
+		Round:  1,
+		Observations: []*Observation{
+			validators[0].observeEpoch("ETH/USD", 2, 1, 200),
+			validators[1].observeEpoch("ETH/USD", 2, 1, 202),
+			validators[2].observeEpoch("ETH/USD", 2, 1, 204),
+		},
+	}))
+}
+
+func TestMedian(t *testing.T) {
+	require.Equal(t, int64(5), median([]int64{5}))
+	require.Equal(t, int64(3), median([]int64{9, 1, 3}))
+	require.Equal(t, int64(-2), median([]int64{-1, -4, 0, -3}))
+	require.Equal(t, int64(9223372036854775806), median([]int64{9223372036854775807, 9223372036854775805}))
+}
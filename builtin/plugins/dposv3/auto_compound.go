@@ -0,0 +1,160 @@
+package dposv3
+
+import (
+	"github.com/gogo/protobuf/proto"
+	loom "github.com/loomnetwork/go-loom"
+	"github.com/loomnetwork/go-loom/common"
+	contract "github.com/loomnetwork/go-loom/plugin/contractpb"
+	types "github.com/loomnetwork/go-loom/types"
+	"github.com/loomnetwork/loomchain/features"
+	"github.com/pkg/errors"
+)
+
+// SetAutoCompound enables or disables auto-compounding of the rewards the sender earns from a
+// validator. While enabled the rewards accrued by the sender are added to the delegation with the
+// given index at each election, the delegation keeps its existing locktime tier & lock time.
+// Only one delegation per validator can be auto-compounded into, enabling auto-compounding for
+// another delegation replaces the previous setting.
+func (c *DPOS) SetAutoCompound(ctx contract.Context, req *SetAutoCompoundRequest) error {
+	if !ctx.FeatureEnabled(features.DPOSVersion3_10, false) {
+		return errors.New("DPOS v3.10 is not enabled")
+	}
+
+	delegator := ctx.Message().Sender
+	ctx.Logger().Info("DPOSv3 SetAutoCompound", "delegator", delegator, "request", req)
+
+	if req.ValidatorAddress == nil {
+		return logDposError(ctx, errors.New("SetAutoCompound called with req.ValidatorAddress == nil"), req.String())
+	}
+	validator := loom.UnmarshalAddressPB(req.ValidatorAddress)
+	key := autoCompoundKey(validator, delegator)
+
+	if !req.Enabled {
+		ctx.Delete(key)
+		return nil
+	}
+
+	if validator.Compare(LimboValidatorAddress(ctx)) == 0 {
+		return logDposError(ctx, errors.New("Can't auto-compound rewards into the limbo validator"), req.String())
+	}
+	if req.Index == REWARD_DELEGATION_INDEX {
+		return logDposError(ctx, errors.New("Can't auto-compound rewards into the rewards delegation"), req.String())
+	}
+
+	_, err := GetDelegation(ctx, req.Index, *req.ValidatorAddress, *delegator.MarshalPB())
+	if err == contract.ErrNotFound {
+		return logDposError(ctx, errors.New("Delegation not found"), req.String())
+	} else if err != nil {
+		return err
+	}
+
+	return ctx.Set(key, &AutoCompoundSetting{
+		Validator: req.ValidatorAddress,
+		Delegator: delegator.MarshalPB(),
+		Index:     req.Index,
+	})
+}
+
+// GetAutoCompound returns the auto-compounding setting of the given validator/delegator pair.
+func (c *DPOS) GetAutoCompound(ctx contract.StaticContext, req *GetAutoCompoundRequest) (*GetAutoCompoundResponse, error) {
+	if req.ValidatorAddress == nil || req.DelegatorAddress == nil {
+		return nil, errors.New("validator & delegator addresses must be specified")
+	}
+
+	var setting AutoCompoundSetting
+	key := autoCompoundKey(loom.UnmarshalAddressPB(req.ValidatorAddress), loom.UnmarshalAddressPB(req.DelegatorAddress))
+	if err := ctx.Get(key, &setting); err != nil {
+		if err == contract.ErrNotFound {
+			return &GetAutoCompoundResponse{}, nil
+		}
+		return nil, err
+	}
+	return &GetAutoCompoundResponse{Setting: &setting}, nil
+}
+
+// compoundRewards moves the accrued rewards of every delegator that enabled auto-compounding into
+// the delegation they selected, and updates the delegation totals of the affected validators to
+// account for the compounded amount now earning the locktime bonus of that delegation.
+// This must be called after all the delegations have been updated for the current election.
+func compoundRewards(
+	ctx contract.Context, cachedDelegations *CachedDposStorage, delegationTotals map[string]*loom.BigUInt,
+) error {
+	for _, entry := range ctx.Range(autoCompoundPrefix) {
+		var setting AutoCompoundSetting
+		if err := proto.Unmarshal(entry.Value, &setting); err != nil {
+			return errors.Wrap(err, "failed to unmarshal auto-compound setting")
+		}
+
+		rewardDelegation, err := GetDelegation(ctx, REWARD_DELEGATION_INDEX, *setting.Validator, *setting.Delegator)
+		if err == contract.ErrNotFound {
+			continue
+		} else if err != nil {
+			return err
+		}
+		// Rewards that are in the process of being claimed are left alone
+		if rewardDelegation.State != BONDED || !common.IsPositive(rewardDelegation.Amount.Value) {
+			continue
+		}
+
+		delegation, err := GetDelegation(ctx, setting.Index, *setting.Validator, *setting.Delegator)
+		if err == contract.ErrNotFound {
+			// The delegation has been fully unbonded or redelegated, so there's nothing to compound
+			// into anymore.
+			ctx.Delete(autoCompoundKey(
+				loom.UnmarshalAddressPB(setting.Validator), loom.UnmarshalAddressPB(setting.Delegator),
+			))
+			continue
+		} else if err != nil {
+			return err
+		}
+		if delegation.State != BONDED {
+			continue
+		}
+
+		compounded := rewardDelegation.Amount.Value
+		weightedReward := calculateWeightedDelegationAmount(*rewardDelegation)
+		weightedBefore := calculateWeightedDelegationAmount(*delegation)
+
+		updatedAmount := common.BigZero()
+		updatedAmount.Add(&delegation.Amount.Value, &compounded)
+		delegation.Amount = &types.BigUInt{Value: *updatedAmount}
+		rewardDelegation.Amount = loom.BigZeroPB()
+
+		if err := cachedDelegations.SetDelegation(ctx, delegation); err != nil {
+			return err
+		}
+		if err := cachedDelegations.SetDelegation(ctx, rewardDelegation); err != nil {
+			return err
+		}
+
+		validatorKey := loom.UnmarshalAddressPB(setting.Validator).String()
+		if total := delegationTotals[validatorKey]; total != nil {
+			weightedAfter := calculateWeightedDelegationAmount(*delegation)
+			newTotal := common.BigZero()
+			newTotal.Add(total, &weightedAfter)
+			newTotal.Sub(newTotal, &weightedBefore)
+			newTotal.Sub(newTotal, &weightedReward)
+			delegationTotals[validatorKey] = newTotal
+		}
+
+		if err := emitDelegatorAutoCompoundsEvent(ctx, delegation, &compounded); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func emitDelegatorAutoCompoundsEvent(ctx contract.Context, delegation *Delegation, amount *loom.BigUInt) error {
+	marshalled, err := proto.Marshal(&DposDelegatorAutoCompoundsEvent{
+		Validator: delegation.Validator,
+		Delegator: delegation.Delegator,
+		Index:     delegation.Index,
+		Amount:    &types.BigUInt{Value: *amount},
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx.EmitTopics(marshalled, DelegatorAutoCompoundsEventTopic)
+	return nil
+}
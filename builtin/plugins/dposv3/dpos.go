@@ -19,6 +19,8 @@ import (
 
 const (
 	defaultDowntimePeriod          = 4096
+	defaultMaxEvidenceAge          = 100
+	defaultDoubleSignJailPeriod    = 86400
 	defaultRegistrationRequirement = 1250000
 	defaultMaxYearlyReward         = 60000000
 	tokenDecimals                  = 18
@@ -54,6 +56,9 @@ const (
 	DelegatorUnbondsEventTopic       = "dposv3:delegatorunbonds"
 	ReferrerRegistersEventTopic      = "dposv3:referrerregisters"
 	DelegatorClaimsRewardsEventTopic = "dposv3:delegatorclaimsrewards"
+	DelegatorAutoCompoundsEventTopic = "dposv3:delegatorautocompounds"
+	UnbondingReleasedEventTopic      = "dposv3:unbondingreleased"
+	SlashUnbondingEventTopic         = "dposv3:slashunbonding"
 )
 
 var (
@@ -1094,8 +1099,25 @@ func (c *DPOS) Unjail(ctx contract.Context, req *UnjailRequest) error {
 		return fmt.Errorf("%s is not jailed", candidateAddress.String())
 	}
 
+	// A validator that was jailed for double signing must remain jailed for the jail period, even if
+	// the oracle attempts to unjail it.
+	var jail DoubleSignJail
+	if err := ctx.Get(doubleSignJailKey(candidateAddress), &jail); err == nil {
+		if ctx.Block().Height < jail.ReleaseHeight {
+			return fmt.Errorf(
+				"%s was jailed for double signing and can't be unjailed until block %d",
+				candidateAddress.String(), jail.ReleaseHeight,
+			)
+		}
+	} else if err != contract.ErrNotFound {
+		return err
+	}
+
 	ctx.Logger().Info("DPOSv3 Unjail", "request", req)
 	statistic.Jailed = false
+	// Any pending double sign slash will be applied in the next election like any other slash
+	ctx.Delete(doubleSignJailedKey(candidateAddress))
+	ctx.Delete(doubleSignJailKey(candidateAddress))
 
 	if err = SetStatistic(ctx, statistic); err != nil {
 		return err
@@ -1289,12 +1311,25 @@ func Elect(ctx contract.Context) error {
 		return nil
 	}
 
-	delegationResults, err := rewardAndSlash(ctx, cachedDelegations, state)
+	summaries := make(map[string]*ElectionValidatorSummary)
+	delegationResults, err := rewardAndSlash(ctx, cachedDelegations, state, summaries)
 	if err != nil {
 		return err
 	}
 	ctx.Logger().Debug("DPOSv3 Elect", "delegationResults", len(delegationResults))
 
+	if ctx.FeatureEnabled(features.DPOSVersion3_12, false) {
+		if err := releaseUnbondings(ctx); err != nil {
+			return err
+		}
+	}
+
+	for _, res := range delegationResults {
+		if summary := summaries[res.ValidatorAddress.String()]; summary != nil {
+			summary.DelegationTotal = &types.BigUInt{Value: res.DelegationTotal}
+		}
+	}
+
 	validatorCount := int(state.Params.ValidatorCount)
 	if len(delegationResults) < validatorCount {
 		validatorCount = len(delegationResults)
@@ -1353,6 +1388,19 @@ func Elect(ctx contract.Context) error {
 				Power:  validatorPower,
 			})
 
+			validatorKey := res.ValidatorAddress.String()
+			if summaries[validatorKey] == nil {
+				summaries[validatorKey] = &ElectionValidatorSummary{
+					Validator:       candidate.Address,
+					Rewards:         loom.BigZeroPB(),
+					SlashPercentage: loom.BigZeroPB(),
+					Jailed:          statistic.Jailed,
+					MissedBlocks:    statistic.RecentlyMissedBlocks & 0xFFFF,
+					DelegationTotal: delegationTotal,
+				}
+			}
+			summaries[validatorKey].Elected = true
+
 			if err = SetStatistic(ctx, statistic); err != nil {
 				return err
 			}
@@ -1370,6 +1418,12 @@ func Elect(ctx contract.Context) error {
 		}
 	}
 
+	if ctx.FeatureEnabled(features.DPOSVersion3_11, false) {
+		if err := recordElectionSummary(ctx, state, summaries); err != nil {
+			return err
+		}
+	}
+
 	if err = updateCandidateList(ctx); err != nil {
 		return err
 	}
@@ -1612,7 +1666,12 @@ func ShiftDowntimeWindow(ctx contract.Context, currentHeight int64, candidates [
 				}
 
 				if shouldSlash {
-					if err := slash(ctx, statistic, inactivitySlashPercentage); err != nil {
+					// the validator has been offline since the start of the downtime window
+					infractionHeight := currentHeight - int64(4*state.Params.DowntimePeriod)
+					if infractionHeight < 0 {
+						infractionHeight = 0
+					}
+					if err := slash(ctx, statistic, inactivitySlashPercentage, infractionHeight); err != nil {
 						return err
 					}
 				}
@@ -1715,16 +1774,92 @@ func getDowntimeRecord(ctx contract.StaticContext, statistic *ValidatorStatistic
 	}
 }
 
-func SlashDoubleSign(ctx contract.Context, statistic *ValidatorStatistic) error {
+// SlashDoubleSign slashes a validator for double signing at the given height.
+func SlashDoubleSign(ctx contract.Context, statistic *ValidatorStatistic, evidenceHeight int64) error {
 	state, err := LoadState(ctx)
 	if err != nil {
 		return err
 	}
 
-	return slash(ctx, statistic, state.Params.ByzantineSlashingPercentage.Value)
+	return slash(ctx, statistic, state.Params.ByzantineSlashingPercentage.Value, evidenceHeight)
 }
 
-func slash(ctx contract.Context, statistic *ValidatorStatistic, slashPercentage loom.BigUInt) error {
+// SlashDoubleSignEvidence jails & slashes the validator with the given Tendermint address for
+// double signing a block at the given height. Evidence that's older than the max evidence age, or
+// that has already been acted upon, is ignored.
+func SlashDoubleSignEvidence(
+	ctx contract.Context, tendermintAddress []byte, evidenceHeight int64, currentHeight int64,
+	candidates []*Candidate,
+) error {
+	cfg, err := loadDoubleSignSlashingConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	if evidenceHeight <= currentHeight-int64(cfg.MaxEvidenceAge) {
+		ctx.Logger().Info(
+			"DPOSv3 ignoring stale double sign evidence",
+			"evidenceHeight", evidenceHeight, "currentHeight", currentHeight,
+		)
+		return nil
+	}
+
+	evidenceKey := doubleSignEvidenceKey(tendermintAddress, evidenceHeight)
+	if ctx.Has(evidenceKey) {
+		return nil
+	}
+
+	validatorAddr, err := GetLocalCandidateAddressFromTendermintAddress(ctx, tendermintAddress, candidates)
+	if err == contract.ErrNotFound {
+		// the validator has unregistered since, so there's nothing left to slash
+		ctx.Logger().Info("DPOSv3 double sign evidence for unknown candidate", "evidenceHeight", evidenceHeight)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	statistic, err := GetStatistic(ctx, validatorAddr)
+	if err == contract.ErrNotFound {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	ctx.Logger().Info("DPOSv3 slashing double signing validator", "validator", validatorAddr, "evidenceHeight", evidenceHeight)
+
+	if err := SlashDoubleSign(ctx, statistic, evidenceHeight); err != nil {
+		return err
+	}
+	if !statistic.Jailed {
+		statistic.Jailed = true
+		if err := emitJailEvent(ctx, validatorAddr.MarshalPB()); err != nil {
+			return err
+		}
+	}
+	if err := SetStatistic(ctx, statistic); err != nil {
+		return err
+	}
+
+	evidence := &DoubleSignEvidence{
+		Validator: validatorAddr.MarshalPB(),
+		Height:    evidenceHeight,
+	}
+	if err := ctx.Set(doubleSignJailedKey(validatorAddr), evidence); err != nil {
+		return err
+	}
+	jail := &DoubleSignJail{
+		Validator:     validatorAddr.MarshalPB(),
+		ReleaseHeight: currentHeight + int64(cfg.JailPeriod),
+	}
+	if err := ctx.Set(doubleSignJailKey(validatorAddr), jail); err != nil {
+		return err
+	}
+	return ctx.Set(evidenceKey, evidence)
+}
+
+func slash(
+	ctx contract.Context, statistic *ValidatorStatistic, slashPercentage loom.BigUInt, infractionHeight int64,
+) error {
 	updatedAmount := common.BigZero()
 	updatedAmount.Add(&statistic.SlashPercentage.Value, &slashPercentage)
 	// this check ensures that the slash percentage never exceeds 100%
@@ -1733,9 +1868,31 @@ func slash(ctx contract.Context, statistic *ValidatorStatistic, slashPercentage
 	}
 	statistic.SlashPercentage = &types.BigUInt{Value: *updatedAmount}
 
+	if ctx.FeatureEnabled(features.DPOSVersion3_12, false) {
+		if err := recordPendingSlash(ctx, loom.UnmarshalAddressPB(statistic.Address), infractionHeight); err != nil {
+			return err
+		}
+	}
+
 	return emitSlashEvent(ctx, statistic.Address, slashPercentage)
 }
 
+// recordPendingSlash records the infraction height of a slash that will be applied at the next
+// election, if the validator has multiple pending slashes only the earliest infraction is kept.
+func recordPendingSlash(ctx contract.Context, validatorAddr loom.Address, infractionHeight int64) error {
+	var pending PendingSlash
+	err := ctx.Get(pendingSlashKey(validatorAddr), &pending)
+	if err == nil && pending.InfractionHeight <= infractionHeight {
+		return nil
+	} else if err != nil && err != contract.ErrNotFound {
+		return err
+	}
+	return ctx.Set(pendingSlashKey(validatorAddr), &PendingSlash{
+		Validator:        validatorAddr.MarshalPB(),
+		InfractionHeight: infractionHeight,
+	})
+}
+
 // Returns the total amount of tokens which have been distributed to delegators
 // and validators as rewards
 func (c *DPOS) CheckRewards(ctx contract.StaticContext, req *CheckRewardsRequest) (*CheckRewardsResponse, error) {
@@ -1769,7 +1926,10 @@ func loadCoin(ctx contract.Context) (*ERC20, error) {
 // rewards & slashes are calculated along with former delegation totals
 // rewards are distributed to validators based on fee
 // rewards distribution amounts are prepared for delegators
-func rewardAndSlash(ctx contract.Context, cachedDelegations *CachedDposStorage, state *State) ([]*DelegationResult, error) {
+func rewardAndSlash(
+	ctx contract.Context, cachedDelegations *CachedDposStorage, state *State,
+	summaries map[string]*ElectionValidatorSummary,
+) ([]*DelegationResult, error) {
 	formerValidatorTotals := make(map[string]loom.BigUInt)
 	delegatorRewards := make(map[string]*loom.BigUInt)
 	distributedRewards := common.BigZero()
@@ -1791,13 +1951,36 @@ func rewardAndSlash(ctx contract.Context, cachedDelegations *CachedDposStorage,
 		validatorKey := candidateAddress.String()
 		statistic, _ := GetStatistic(ctx, candidateAddress)
 
+		summary := &ElectionValidatorSummary{
+			Validator:       candidate.Address,
+			Rewards:         loom.BigZeroPB(),
+			SlashPercentage: loom.BigZeroPB(),
+		}
+		summaries[validatorKey] = summary
+
 		if statistic == nil {
 			delegatorRewards[validatorKey] = common.BigZero()
 			formerValidatorTotals[validatorKey] = *common.BigZero()
 		} else {
+			summary.Jailed = statistic.Jailed
+			summary.MissedBlocks = statistic.RecentlyMissedBlocks & 0xFFFF
+
 			// If a validator is jailed, don't calculate and distribute rewards
 			if ctx.FeatureEnabled(features.DPOSVersion3_3, false) {
 				if statistic.Jailed {
+					// Being jailed shouldn't allow a double signing validator to avoid slashing, validators
+					// jailed for any other reason are only slashed once they're unjailed, as before.
+					if ctx.FeatureEnabled(features.DPOSVersion3_9, false) &&
+						ctx.Has(doubleSignJailedKey(candidateAddress)) {
+						summary.SlashPercentage = statistic.SlashPercentage
+						if err := slashValidatorDelegations(ctx, cachedDelegations, statistic, candidateAddress); err != nil {
+							return nil, err
+						}
+						if err := SetStatistic(ctx, statistic); err != nil {
+							return nil, err
+						}
+						ctx.Delete(doubleSignJailedKey(candidateAddress))
+					}
 					delegatorRewards[validatorKey] = common.BigZero()
 					formerValidatorTotals[validatorKey] = *common.BigZero()
 					continue
@@ -1807,6 +1990,7 @@ func rewardAndSlash(ctx contract.Context, cachedDelegations *CachedDposStorage,
 			// rewarded for avoiding faults during the last slashing period
 			if common.IsZero(statistic.SlashPercentage.Value) {
 				distributionTotal := calculateRewards(statistic.DelegationTotal.Value, state.Params, state.TotalValidatorDelegations.Value)
+				summary.Rewards = &types.BigUInt{Value: distributionTotal}
 
 				// The validator share, equal to validator_fee * total_validotor_reward
 				// is to be split between the referrers and the validator
@@ -1879,6 +2063,7 @@ func rewardAndSlash(ctx contract.Context, cachedDelegations *CachedDposStorage,
 					state.TotalRewardDistribution.Value.Add(&state.TotalRewardDistribution.Value, &distributionTotal)
 				}
 			} else {
+				summary.SlashPercentage = statistic.SlashPercentage
 				if err := slashValidatorDelegations(ctx, cachedDelegations, statistic, candidateAddress); err != nil {
 					return nil, err
 				}
@@ -1896,6 +2081,12 @@ func rewardAndSlash(ctx contract.Context, cachedDelegations *CachedDposStorage,
 		return nil, err
 	}
 
+	if ctx.FeatureEnabled(features.DPOSVersion3_10, false) {
+		if err := compoundRewards(ctx, cachedDelegations, newDelegationTotals); err != nil {
+			return nil, err
+		}
+	}
+
 	if ctx.FeatureEnabled(features.DPOSVersion3_1, false) {
 		state.TotalRewardDistribution.Value.Add(&state.TotalRewardDistribution.Value, distributedRewards)
 	}
@@ -1995,6 +2186,19 @@ func slashValidatorDelegations(
 		}
 	}
 
+	if ctx.FeatureEnabled(features.DPOSVersion3_12, false) {
+		// Slashes accumulated before the unbonding queue was enabled have no recorded infraction
+		// height, so they apply to the whole queue.
+		var pending PendingSlash
+		if err := ctx.Get(pendingSlashKey(validatorAddress), &pending); err != nil && err != contract.ErrNotFound {
+			return err
+		}
+		if err := slashUnbondings(ctx, validatorAddress, statistic.SlashPercentage, pending.InfractionHeight); err != nil {
+			return err
+		}
+		ctx.Delete(pendingSlashKey(validatorAddress))
+	}
+
 	// reset slash total
 	statistic.SlashPercentage = loom.BigZeroPB()
 
@@ -2082,14 +2286,22 @@ func distributeDelegatorRewards(ctx contract.Context, cachedDelegations *CachedD
 		} else if delegation.State == UNBONDING {
 			updatedAmount.Sub(&delegation.Amount.Value, &delegation.UpdateAmount.Value)
 			delegation.Amount = &types.BigUInt{Value: *updatedAmount}
-			coin, err := loadCoin(ctx)
-			if err != nil {
-				return nil, err
-			}
-			err = coin.Transfer(loom.UnmarshalAddressPB(delegation.Delegator), &delegation.UpdateAmount.Value)
-			if err != nil {
-				transferFromErr := fmt.Sprintf("Failed coin Transfer - distributeDelegatorRewards, %v, %s", delegation.Delegator.String(), delegation.UpdateAmount.Value.String())
-				return nil, logDposError(ctx, err, transferFromErr)
+			// Unbonded tokens (other than claimed rewards) remain slashable in the unbonding queue
+			// until the unbonding period ends.
+			if ctx.FeatureEnabled(features.DPOSVersion3_12, false) && delegation.Index != REWARD_DELEGATION_INDEX {
+				if err := queueUnbonding(ctx, delegation, delegation.UpdateAmount); err != nil {
+					return nil, err
+				}
+			} else {
+				coin, err := loadCoin(ctx)
+				if err != nil {
+					return nil, err
+				}
+				err = coin.Transfer(loom.UnmarshalAddressPB(delegation.Delegator), &delegation.UpdateAmount.Value)
+				if err != nil {
+					transferFromErr := fmt.Sprintf("Failed coin Transfer - distributeDelegatorRewards, %v, %s", delegation.Delegator.String(), delegation.UpdateAmount.Value.String())
+					return nil, logDposError(ctx, err, transferFromErr)
+				}
 			}
 		} else if delegation.State == REDELEGATING {
 			if err = cachedDelegations.DeleteDelegation(ctx, delegation); err != nil {
@@ -2475,6 +2687,66 @@ func (c *DPOS) SetSlashingPercentages(ctx contract.Context, req *SetSlashingPerc
 	return saveState(ctx, state)
 }
 
+// SetMaxEvidenceAge sets the number of blocks after which double sign evidence is no longer acted
+// upon.
+func (c *DPOS) SetMaxEvidenceAge(ctx contract.Context, req *SetMaxEvidenceAgeRequest) error {
+	if !ctx.FeatureEnabled(features.DPOSVersion3_9, false) {
+		return errors.New("DPOS v3.9 is not enabled")
+	}
+	if req.MaxEvidenceAge == 0 {
+		return logDposError(ctx, errors.New("Max evidence age must be greater than zero."), req.String())
+	}
+
+	sender := ctx.Message().Sender
+	ctx.Logger().Info("DPOSv3 SetMaxEvidenceAge", "sender", sender, "request", req)
+
+	state, err := LoadState(ctx)
+	if err != nil {
+		return err
+	}
+
+	if state.Params.OracleAddress == nil || sender.Compare(loom.UnmarshalAddressPB(state.Params.OracleAddress)) != 0 {
+		return logDposError(ctx, errOnlyOracle, req.String())
+	}
+
+	cfg, err := loadDoubleSignSlashingConfig(ctx)
+	if err != nil {
+		return err
+	}
+	cfg.MaxEvidenceAge = req.MaxEvidenceAge
+	return ctx.Set(doubleSignSlashingConfigKey, cfg)
+}
+
+// SetDoubleSignJailPeriod sets the min number of blocks a validator jailed for double signing
+// remains jailed.
+func (c *DPOS) SetDoubleSignJailPeriod(ctx contract.Context, req *SetDoubleSignJailPeriodRequest) error {
+	if !ctx.FeatureEnabled(features.DPOSVersion3_9, false) {
+		return errors.New("DPOS v3.9 is not enabled")
+	}
+	if req.JailPeriod == 0 {
+		return logDposError(ctx, errors.New("Jail period must be greater than zero."), req.String())
+	}
+
+	sender := ctx.Message().Sender
+	ctx.Logger().Info("DPOSv3 SetDoubleSignJailPeriod", "sender", sender, "request", req)
+
+	state, err := LoadState(ctx)
+	if err != nil {
+		return err
+	}
+
+	if state.Params.OracleAddress == nil || sender.Compare(loom.UnmarshalAddressPB(state.Params.OracleAddress)) != 0 {
+		return logDposError(ctx, errOnlyOracle, req.String())
+	}
+
+	cfg, err := loadDoubleSignSlashingConfig(ctx)
+	if err != nil {
+		return err
+	}
+	cfg.JailPeriod = req.JailPeriod
+	return ctx.Set(doubleSignSlashingConfigKey, cfg)
+}
+
 func (c *DPOS) SetMaxDowntimePercentage(ctx contract.Context, req *SetMaxDowntimePercentageRequest) error {
 	if !ctx.FeatureEnabled(features.DPOSVersion3_4, false) {
 		return errors.New("DPOS v3.4 is not enabled")
@@ -3728,6 +3728,506 @@ func TestEnableValidatorJailing(t *testing.T) {
 	require.False(t, statistic.Jailed)
 }
 
+func TestDoubleSignSlashing(t *testing.T) {
+	pctx := createCtx()
+	oraclePubKey, _ := hex.DecodeString(validatorPubKeyHex2)
+	oracleAddr := loom.Address{
+		Local: loom.LocalAddressFromPublicKey(oraclePubKey),
+	}
+
+	coinContract := &coin.Coin{}
+	coinAddr := pctx.CreateContract(coin.Contract)
+	coinCtx := pctx.WithAddress(coinAddr)
+	coinContract.Init(contractpb.WrapPluginContext(coinCtx), &coin.InitRequest{
+		Accounts: []*coin.InitialAccount{
+			makeAccount(addr1, 1000000000000000000),
+		},
+	})
+
+	registrationFee := &types.BigUInt{Value: *loom.NewBigUIntFromInt(100)}
+	dpos, err := deployDPOSContract(pctx, &Params{
+		ValidatorCount:          1,
+		RegistrationRequirement: registrationFee,
+		OracleAddress:           oracleAddr.MarshalPB(),
+	})
+	require.Nil(t, err)
+	dposCtx := pctx.WithAddress(dpos.Address)
+
+	err = coinContract.Approve(contractpb.WrapPluginContext(coinCtx.WithSender(addr1)), &coin.ApproveRequest{
+		Spender: dpos.Address.MarshalPB(),
+		Amount:  registrationFee,
+	})
+	require.Nil(t, err)
+	require.NoError(t, dpos.RegisterCandidate(pctx.WithSender(addr1), pubKey1, nil, nil, nil, nil, nil, nil))
+	require.NoError(t, elect(pctx, dpos.Address))
+
+	candidates, err := LoadCandidateList(contractpb.WrapPluginContext(dposCtx))
+	require.Nil(t, err)
+
+	// setting the max evidence age requires the feature flag
+	err = dpos.Contract.SetMaxEvidenceAge(
+		contractpb.WrapPluginContext(dposCtx.WithSender(oracleAddr)),
+		&SetMaxEvidenceAgeRequest{MaxEvidenceAge: 50},
+	)
+	require.Error(t, err)
+	dposCtx.SetFeature(features.DPOSVersion3_3, true)
+	dposCtx.SetFeature(features.DPOSVersion3_9, true)
+	err = dpos.Contract.SetMaxEvidenceAge(
+		contractpb.WrapPluginContext(dposCtx.WithSender(addr1)),
+		&SetMaxEvidenceAgeRequest{MaxEvidenceAge: 50},
+	)
+	require.Equal(t, errOnlyOracle, err)
+	require.NoError(t, dpos.Contract.SetMaxEvidenceAge(
+		contractpb.WrapPluginContext(dposCtx.WithSender(oracleAddr)),
+		&SetMaxEvidenceAgeRequest{MaxEvidenceAge: 50},
+	))
+
+	tendermintAddr := []byte(loom.LocalAddressFromPublicKeyV2(pubKey1))
+
+	// stale evidence is ignored
+	require.NoError(t, SlashDoubleSignEvidence(
+		contractpb.WrapPluginContext(dposCtx), tendermintAddr, 10, 60, candidates,
+	))
+	statistic, err := GetStatistic(contractpb.WrapPluginContext(dposCtx), addr1)
+	require.Nil(t, err)
+	require.False(t, statistic.Jailed)
+	require.True(t, common.IsZero(statistic.SlashPercentage.Value))
+
+	require.NoError(t, SlashDoubleSignEvidence(
+		contractpb.WrapPluginContext(dposCtx), tendermintAddr, 20, 60, candidates,
+	))
+	statistic, err = GetStatistic(contractpb.WrapPluginContext(dposCtx), addr1)
+	require.Nil(t, err)
+	require.True(t, statistic.Jailed)
+	require.True(t, statistic.SlashPercentage.Value.Cmp(&doubleSignSlashPercentage) == 0)
+
+	// the same evidence shouldn't result in the validator being slashed again
+	require.NoError(t, SlashDoubleSignEvidence(
+		contractpb.WrapPluginContext(dposCtx), tendermintAddr, 20, 61, candidates,
+	))
+	statistic, err = GetStatistic(contractpb.WrapPluginContext(dposCtx), addr1)
+	require.Nil(t, err)
+	require.True(t, statistic.SlashPercentage.Value.Cmp(&doubleSignSlashPercentage) == 0)
+
+	// the jailed validator's delegations should be slashed in the next election
+	require.NoError(t, elect(pctx, dpos.Address))
+
+	statistic, err = GetStatistic(contractpb.WrapPluginContext(dposCtx), addr1)
+	require.Nil(t, err)
+	require.True(t, common.IsZero(statistic.SlashPercentage.Value))
+
+	// verify that 5% of self-delegation was removed via slashing
+	_, delegatedAmount, _, err := dpos.CheckDelegation(pctx, &addr1, &addr1)
+	require.Nil(t, err)
+	expectedSlashedDelegation := CalculateFraction(*loom.NewBigUIntFromInt(9500), registrationFee.Value)
+	assert.True(t, delegatedAmount.Cmp(expectedSlashedDelegation.Int) == 0)
+
+	// the pending slash of a validator that's still jailed, but hasn't double signed since the last
+	// election (e.g. it was slashed for downtime), should only be applied once it's unjailed
+	statistic.SlashPercentage = &types.BigUInt{Value: defaultInactivitySlashPercentage}
+	require.NoError(t, SetStatistic(contractpb.WrapPluginContext(dposCtx), statistic))
+	require.NoError(t, elect(pctx, dpos.Address))
+
+	statistic, err = GetStatistic(contractpb.WrapPluginContext(dposCtx), addr1)
+	require.Nil(t, err)
+	require.True(t, statistic.Jailed)
+	require.True(t, statistic.SlashPercentage.Value.Cmp(&defaultInactivitySlashPercentage) == 0)
+	_, delegatedAmount, _, err = dpos.CheckDelegation(pctx, &addr1, &addr1)
+	require.Nil(t, err)
+	assert.True(t, delegatedAmount.Cmp(expectedSlashedDelegation.Int) == 0)
+
+	// the jail period can only be changed by the oracle, and only applies to validators that are
+	// jailed after it's changed
+	require.Equal(t, errOnlyOracle, dpos.Contract.SetDoubleSignJailPeriod(
+		contractpb.WrapPluginContext(dposCtx.WithSender(addr1)),
+		&SetDoubleSignJailPeriodRequest{JailPeriod: 10},
+	))
+	require.NoError(t, dpos.Contract.SetDoubleSignJailPeriod(
+		contractpb.WrapPluginContext(dposCtx.WithSender(oracleAddr)),
+		&SetDoubleSignJailPeriodRequest{JailPeriod: 10},
+	))
+	// a validator jailed for double signing can't be unjailed, even by the oracle, until the jail
+	// period has elapsed, the evidence was acted upon at height 60
+	jailedCtx := dposCtx.WithBlock(loom.BlockHeader{ChainID: chainID, Height: 61, Time: startTime})
+	require.Error(t, dpos.Unjail(jailedCtx.WithSender(addr1), nil))
+	require.Error(t, dpos.Unjail(jailedCtx.WithSender(oracleAddr), &addr1))
+
+	releasedCtx := dposCtx.WithBlock(loom.BlockHeader{
+		ChainID: chainID, Height: 60 + defaultDoubleSignJailPeriod, Time: startTime,
+	})
+	require.NoError(t, dpos.Unjail(releasedCtx.WithSender(addr1), nil))
+	statistic, err = GetStatistic(contractpb.WrapPluginContext(dposCtx), addr1)
+	require.Nil(t, err)
+	require.False(t, statistic.Jailed)
+}
+
+func TestAutoCompoundRewards(t *testing.T) {
+	pctx := createCtx()
+	coinAddr := pctx.CreateContract(coin.Contract)
+
+	coinContract := &coin.Coin{}
+	coinCtx := pctx.WithAddress(coinAddr)
+	coinContract.Init(contractpb.WrapPluginContext(coinCtx), &coin.InitRequest{
+		Accounts: []*coin.InitialAccount{
+			makeAccount(delegatorAddress1, 100000000),
+			makeAccount(addr1, 100000000),
+		},
+	})
+
+	cycleLengthSeconds := int64(100)
+	dpos, err := deployDPOSContract(pctx, &Params{
+		ValidatorCount:      10,
+		ElectionCycleLength: cycleLengthSeconds,
+		CoinContractAddress: coinAddr.MarshalPB(),
+	})
+	require.Nil(t, err)
+
+	// transfer coins to reward fund
+	amount := big.NewInt(10)
+	amount.Exp(amount, big.NewInt(19), nil)
+	coinContract.Transfer(contractpb.WrapPluginContext(coinCtx), &coin.TransferRequest{
+		To:     dpos.Address.MarshalPB(),
+		Amount: &types.BigUInt{Value: common.BigUInt{amount}},
+	})
+
+	registrationFee := &types.BigUInt{Value: *scientificNotation(defaultRegistrationRequirement, tokenDecimals)}
+	err = coinContract.Approve(contractpb.WrapPluginContext(coinCtx.WithSender(addr1)), &coin.ApproveRequest{
+		Spender: dpos.Address.MarshalPB(),
+		Amount:  registrationFee,
+	})
+	require.Nil(t, err)
+	require.NoError(t, dpos.RegisterCandidate(pctx.WithSender(addr1), pubKey1, nil, nil, nil, nil, nil, nil))
+	require.NoError(t, elect(pctx, dpos.Address))
+
+	delegationAmount := loom.BigUInt{big.NewInt(1e18)}
+	tier := uint64(2)
+	err = coinContract.Approve(contractpb.WrapPluginContext(coinCtx.WithSender(delegatorAddress1)), &coin.ApproveRequest{
+		Spender: dpos.Address.MarshalPB(),
+		Amount:  &types.BigUInt{Value: delegationAmount},
+	})
+	require.Nil(t, err)
+	require.NoError(t, dpos.Delegate(pctx.WithSender(delegatorAddress1), &addr1, delegationAmount.Int, &tier, nil))
+	pctx.SetTime(pctx.Now().Add(time.Duration(cycleLengthSeconds) * time.Second))
+	require.NoError(t, elect(pctx, dpos.Address))
+
+	// auto-compounding requires the feature flag
+	require.Error(t, dpos.SetAutoCompound(pctx.WithSender(delegatorAddress1), &addr1, 1, true))
+	pctx.WithAddress(dpos.Address).SetFeature(features.DPOSVersion3_10, true)
+
+	// rewards can't be compounded into the rewards delegation, or a delegation that doesn't exist
+	require.Error(t, dpos.SetAutoCompound(pctx.WithSender(delegatorAddress1), &addr1, REWARD_DELEGATION_INDEX, true))
+	require.Error(t, dpos.SetAutoCompound(pctx.WithSender(delegatorAddress1), &addr1, 5, true))
+	require.NoError(t, dpos.SetAutoCompound(pctx.WithSender(delegatorAddress1), &addr1, 1, true))
+
+	getDelegation := func() *Delegation {
+		delegations, _, _, err := dpos.CheckDelegation(pctx, &addr1, &delegatorAddress1)
+		require.NoError(t, err)
+		for _, d := range delegations {
+			if d.Index == 1 {
+				return d
+			}
+		}
+		require.FailNow(t, "delegation not found")
+		return nil
+	}
+	lockTime := getDelegation().LockTime
+
+	// rewards should be added to the delegation at each election instead of accruing in the
+	// rewards delegation
+	prevAmount := delegationAmount
+	for i := 0; i < 3; i++ {
+		pctx.SetTime(pctx.Now().Add(time.Duration(cycleLengthSeconds) * time.Second))
+		require.NoError(t, elect(pctx, dpos.Address))
+
+		delegation := getDelegation()
+		assert.True(t, delegation.Amount.Value.Cmp(&prevAmount) > 0)
+		assert.Equal(t, TierMap[tier], delegation.LocktimeTier)
+		assert.Equal(t, lockTime, delegation.LockTime)
+		prevAmount = delegation.Amount.Value
+
+		rewardDelegation, err := dpos.CheckRewardDelegation(pctx.WithSender(delegatorAddress1), &addr1)
+		require.NoError(t, err)
+		assert.True(t, common.IsZero(rewardDelegation.Amount.Value))
+	}
+
+	// once disabled rewards should accrue in the rewards delegation again
+	require.NoError(t, dpos.SetAutoCompound(pctx.WithSender(delegatorAddress1), &addr1, 0, false))
+	pctx.SetTime(pctx.Now().Add(time.Duration(cycleLengthSeconds) * time.Second))
+	require.NoError(t, elect(pctx, dpos.Address))
+
+	assert.True(t, getDelegation().Amount.Value.Cmp(&prevAmount) == 0)
+	rewardDelegation, err := dpos.CheckRewardDelegation(pctx.WithSender(delegatorAddress1), &addr1)
+	require.NoError(t, err)
+	assert.True(t, common.IsPositive(rewardDelegation.Amount.Value))
+}
+
+func TestElectionHistory(t *testing.T) {
+	pctx := createCtx()
+	coinAddr := pctx.CreateContract(coin.Contract)
+
+	coinContract := &coin.Coin{}
+	coinCtx := pctx.WithAddress(coinAddr)
+	coinContract.Init(contractpb.WrapPluginContext(coinCtx), &coin.InitRequest{
+		Accounts: []*coin.InitialAccount{
+			makeAccount(addr1, 100000000),
+			makeAccount(addr2, 100000000),
+		},
+	})
+
+	cycleLengthSeconds := int64(100)
+	dpos, err := deployDPOSContract(pctx, &Params{
+		ValidatorCount:      10,
+		ElectionCycleLength: cycleLengthSeconds,
+		CoinContractAddress: coinAddr.MarshalPB(),
+		OracleAddress:       addr3.MarshalPB(),
+	})
+	require.Nil(t, err)
+	dposCtx := pctx.WithAddress(dpos.Address)
+
+	// transfer coins to reward fund
+	amount := big.NewInt(10)
+	amount.Exp(amount, big.NewInt(19), nil)
+	coinContract.Transfer(contractpb.WrapPluginContext(coinCtx), &coin.TransferRequest{
+		To:     dpos.Address.MarshalPB(),
+		Amount: &types.BigUInt{Value: common.BigUInt{amount}},
+	})
+
+	registrationFee := &types.BigUInt{Value: *scientificNotation(defaultRegistrationRequirement, tokenDecimals)}
+	addrs := []loom.Address{addr1, addr2}
+	pubKeys := [][]byte{pubKey1, pubKey2}
+	for i, addr := range addrs {
+		err = coinContract.Approve(contractpb.WrapPluginContext(coinCtx.WithSender(addr)), &coin.ApproveRequest{
+			Spender: dpos.Address.MarshalPB(),
+			Amount:  registrationFee,
+		})
+		require.Nil(t, err)
+		require.NoError(t, dpos.RegisterCandidate(pctx.WithSender(addr), pubKeys[i], nil, nil, nil, nil, nil, nil))
+	}
+
+	// elections aren't recorded until the feature flag is enabled
+	require.NoError(t, elect(pctx, dpos.Address))
+	resp, err := dpos.Contract.ListElectionSummaries(
+		contractpb.WrapPluginContext(dposCtx), &ListElectionSummariesRequest{},
+	)
+	require.NoError(t, err)
+	require.Len(t, resp.Summaries, 0)
+	require.Equal(t, uint64(0), resp.LastIndex)
+
+	dposCtx.SetFeature(features.DPOSVersion3_11, true)
+	for i := 0; i < 5; i++ {
+		pctx.SetTime(pctx.Now().Add(time.Duration(cycleLengthSeconds) * time.Second))
+		require.NoError(t, elect(pctx, dpos.Address))
+	}
+
+	resp, err = dpos.Contract.ListElectionSummaries(
+		contractpb.WrapPluginContext(dposCtx), &ListElectionSummariesRequest{},
+	)
+	require.NoError(t, err)
+	require.Len(t, resp.Summaries, 5)
+	require.Equal(t, uint64(5), resp.LastIndex)
+	for i, summary := range resp.Summaries {
+		require.Equal(t, uint64(i+1), summary.Index)
+		require.Len(t, summary.Validators, 2)
+		require.True(t, common.IsPositive(summary.TotalRewards.Value))
+		for _, v := range summary.Validators {
+			require.True(t, v.Elected)
+			require.True(t, common.IsPositive(v.Rewards.Value))
+			require.True(t, common.IsPositive(v.DelegationTotal.Value))
+		}
+	}
+
+	// the most recent elections are returned when no start index is specified
+	resp, err = dpos.Contract.ListElectionSummaries(
+		contractpb.WrapPluginContext(dposCtx), &ListElectionSummariesRequest{Limit: 2},
+	)
+	require.NoError(t, err)
+	require.Len(t, resp.Summaries, 2)
+	require.Equal(t, uint64(4), resp.Summaries[0].Index)
+	require.Equal(t, uint64(5), resp.Summaries[1].Index)
+
+	resp, err = dpos.Contract.ListElectionSummaries(
+		contractpb.WrapPluginContext(dposCtx), &ListElectionSummariesRequest{FromIndex: 2, ToIndex: 3},
+	)
+	require.NoError(t, err)
+	require.Len(t, resp.Summaries, 2)
+	require.Equal(t, uint64(2), resp.Summaries[0].Index)
+
+	resp, err = dpos.Contract.ListElectionSummaries(
+		contractpb.WrapPluginContext(dposCtx), &ListElectionSummariesRequest{FromIndex: 6},
+	)
+	require.NoError(t, err)
+	require.Len(t, resp.Summaries, 0)
+
+	historyResp, err := dpos.Contract.ValidatorHistory(
+		contractpb.WrapPluginContext(dposCtx), &ValidatorHistoryRequest{
+			Validator: addr2.MarshalPB(),
+			FromIndex: 3,
+		},
+	)
+	require.NoError(t, err)
+	require.Len(t, historyResp.Records, 3)
+	for i, record := range historyResp.Records {
+		require.Equal(t, uint64(i+3), record.ElectionIndex)
+		require.Equal(t, 0, loom.UnmarshalAddressPB(record.Summary.Validator).Compare(addr2))
+	}
+
+	// only the oracle can change the number of summaries retained, the oldest summaries are pruned
+	// at the next election
+	setHistoryLimit := func(sender loom.Address, maxSummaries uint64) error {
+		return dpos.Contract.SetElectionHistoryLimit(
+			contractpb.WrapPluginContext(dposCtx.WithSender(sender)),
+			&SetElectionHistoryLimitRequest{MaxSummaries: maxSummaries},
+		)
+	}
+	require.Equal(t, errOnlyOracle, setHistoryLimit(addr1, 3))
+	require.Error(t, setHistoryLimit(addr3, 0))
+	require.NoError(t, setHistoryLimit(addr3, 3))
+	pctx.SetTime(pctx.Now().Add(time.Duration(cycleLengthSeconds) * time.Second))
+	require.NoError(t, elect(pctx, dpos.Address))
+
+	resp, err = dpos.Contract.ListElectionSummaries(
+		contractpb.WrapPluginContext(dposCtx), &ListElectionSummariesRequest{FromIndex: 1},
+	)
+	require.NoError(t, err)
+	require.Len(t, resp.Summaries, 3)
+	require.Equal(t, uint64(4), resp.FirstIndex)
+	require.Equal(t, uint64(6), resp.LastIndex)
+	require.Equal(t, uint64(4), resp.Summaries[0].Index)
+}
+
+func TestUnbondingQueue(t *testing.T) {
+	pctx := createCtx()
+	oraclePubKey, _ := hex.DecodeString(validatorPubKeyHex2)
+	oracleAddr := loom.Address{
+		Local: loom.LocalAddressFromPublicKey(oraclePubKey),
+	}
+
+	coinContract := &coin.Coin{}
+	coinAddr := pctx.CreateContract(coin.Contract)
+	coinCtx := pctx.WithAddress(coinAddr)
+	coinContract.Init(contractpb.WrapPluginContext(coinCtx), &coin.InitRequest{
+		Accounts: []*coin.InitialAccount{
+			makeAccount(addr1, 1000000000000000000),
+		},
+	})
+	require.NoError(t, coinContract.Transfer(contractpb.WrapPluginContext(coinCtx.WithSender(addr1)), &coin.TransferRequest{
+		To:     delegatorAddress1.MarshalPB(),
+		Amount: &types.BigUInt{Value: *loom.NewBigUIntFromInt(1000)},
+	}))
+
+	registrationFee := &types.BigUInt{Value: *loom.NewBigUIntFromInt(100)}
+	dpos, err := deployDPOSContract(pctx, &Params{
+		ValidatorCount:          1,
+		RegistrationRequirement: registrationFee,
+		OracleAddress:           oracleAddr.MarshalPB(),
+	})
+	require.Nil(t, err)
+	dposCtx := pctx.WithAddress(dpos.Address)
+
+	err = coinContract.Approve(contractpb.WrapPluginContext(coinCtx.WithSender(addr1)), &coin.ApproveRequest{
+		Spender: dpos.Address.MarshalPB(),
+		Amount:  registrationFee,
+	})
+	require.Nil(t, err)
+	require.NoError(t, dpos.RegisterCandidate(pctx.WithSender(addr1), pubKey1, nil, nil, nil, nil, nil, nil))
+	require.NoError(t, elect(pctx, dpos.Address))
+
+	delegationAmount := big.NewInt(1000)
+	err = coinContract.Approve(contractpb.WrapPluginContext(coinCtx.WithSender(delegatorAddress1)), &coin.ApproveRequest{
+		Spender: dpos.Address.MarshalPB(),
+		Amount:  &types.BigUInt{Value: *loom.NewBigUInt(delegationAmount)},
+	})
+	require.Nil(t, err)
+	require.NoError(t, dpos.Delegate(pctx.WithSender(delegatorAddress1), &addr1, delegationAmount, nil, nil))
+	require.NoError(t, elect(pctx, dpos.Address))
+
+	balanceOf := func(addr loom.Address) *big.Int {
+		resp, err := coinContract.BalanceOf(contractpb.WrapPluginContext(coinCtx), &coin.BalanceOfRequest{
+			Owner: addr.MarshalPB(),
+		})
+		require.NoError(t, err)
+		return resp.Balance.Value.Int
+	}
+	listUnbondings := func(req *ListUnbondingsRequest) []*UnbondingEntry {
+		resp, err := dpos.Contract.ListUnbondings(contractpb.WrapPluginContext(dposCtx), req)
+		require.NoError(t, err)
+		return resp.Unbondings
+	}
+	require.Equal(t, int64(0), balanceOf(delegatorAddress1).Int64())
+
+	// setting the unbonding period requires the feature flag
+	setUnbondingPeriod := func(sender loom.Address, period int64) error {
+		return dpos.Contract.SetUnbondingPeriod(
+			contractpb.WrapPluginContext(dposCtx.WithSender(sender)),
+			&SetUnbondingPeriodRequest{UnbondingPeriod: period},
+		)
+	}
+	require.Error(t, setUnbondingPeriod(oracleAddr, 1000))
+	dposCtx.SetFeature(features.DPOSVersion3_12, true)
+	require.Equal(t, errOnlyOracle, setUnbondingPeriod(addr1, 1000))
+	require.NoError(t, setUnbondingPeriod(oracleAddr, 1000))
+
+	// unbonded tokens should be queued instead of being returned immediately
+	require.NoError(t, dpos.Unbond(pctx.WithSender(delegatorAddress1), &addr1, big.NewInt(200), 1))
+	require.NoError(t, elect(pctx, dpos.Address))
+	require.Equal(t, int64(0), balanceOf(delegatorAddress1).Int64())
+
+	unbondings := listUnbondings(&ListUnbondingsRequest{})
+	require.Len(t, unbondings, 1)
+	require.Equal(t, int64(200), unbondings[0].Amount.Value.Int64())
+	require.Equal(t, uint64(1), unbondings[0].Index)
+	require.Equal(t, pctx.Now().Unix()+1000, unbondings[0].ReleaseTime)
+	require.Len(t, listUnbondings(&ListUnbondingsRequest{Delegator: delegatorAddress1.MarshalPB()}), 1)
+	require.Len(t, listUnbondings(&ListUnbondingsRequest{Delegator: delegatorAddress2.MarshalPB()}), 0)
+
+	// queued tokens remain slashable for infractions at or before the height they were unbonded at
+	slashDoubleSign := func(height int64) {
+		statistic, err := GetStatistic(contractpb.WrapPluginContext(dposCtx), addr1)
+		require.NoError(t, err)
+		require.NoError(t, SlashDoubleSign(contractpb.WrapPluginContext(dposCtx), statistic, height))
+		require.NoError(t, SetStatistic(contractpb.WrapPluginContext(dposCtx), statistic))
+		require.NoError(t, elect(pctx, dpos.Address))
+	}
+	slashDoubleSign(unbondings[0].UnbondingHeight)
+	unbondings = listUnbondings(&ListUnbondingsRequest{})
+	require.Len(t, unbondings, 1)
+	require.Equal(t, int64(190), unbondings[0].Amount.Value.Int64())
+
+	// but not for infractions that occurred after they were unbonded
+	slashDoubleSign(unbondings[0].UnbondingHeight + 1)
+	unbondings = listUnbondings(&ListUnbondingsRequest{})
+	require.Len(t, unbondings, 1)
+	require.Equal(t, int64(190), unbondings[0].Amount.Value.Int64())
+
+	// the queue can be listed a page at a time
+	for i := 0; i < 2; i++ {
+		require.NoError(t, dpos.Unbond(pctx.WithSender(delegatorAddress1), &addr1, big.NewInt(10), 1))
+		require.NoError(t, elect(pctx, dpos.Address))
+	}
+	resp, err := dpos.Contract.ListUnbondings(contractpb.WrapPluginContext(dposCtx), &ListUnbondingsRequest{Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, resp.Unbondings, 2)
+	require.Equal(t, uint64(3), resp.NextId)
+	resp, err = dpos.Contract.ListUnbondings(contractpb.WrapPluginContext(dposCtx), &ListUnbondingsRequest{
+		FromId: resp.NextId,
+		Limit:  2,
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Unbondings, 1)
+	require.Equal(t, uint64(3), resp.Unbondings[0].Id)
+	require.Equal(t, uint64(0), resp.NextId)
+
+	// and are released at the first election after the unbonding period ends
+	pctx.SetTime(pctx.Now().Add(999 * time.Second))
+	require.NoError(t, elect(pctx, dpos.Address))
+	require.Len(t, listUnbondings(&ListUnbondingsRequest{}), 3)
+
+	pctx.SetTime(pctx.Now().Add(1 * time.Second))
+	require.NoError(t, elect(pctx, dpos.Address))
+	require.Len(t, listUnbondings(&ListUnbondingsRequest{}), 0)
+	require.Equal(t, int64(210), balanceOf(delegatorAddress1).Int64())
+}
+
 // UTILITIES
 
 func makeAccount(owner loom.Address, bal uint64) *coin.InitialAccount {
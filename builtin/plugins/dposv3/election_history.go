@@ -0,0 +1,218 @@
+package dposv3
+
+import (
+	"sort"
+
+	loom "github.com/loomnetwork/go-loom"
+	"github.com/loomnetwork/go-loom/common"
+	contract "github.com/loomnetwork/go-loom/plugin/contractpb"
+	types "github.com/loomnetwork/go-loom/types"
+	"github.com/loomnetwork/loomchain/features"
+	"github.com/pkg/errors"
+)
+
+const (
+	// Max number of elections that can be returned by a single history query.
+	maxElectionHistoryQueryLimit = 100
+	// Number of election summaries retained by default, at one election every 10 minutes that's
+	// roughly 70 days worth of history.
+	defaultMaxElectionSummaries = 10000
+	// Max number of summaries pruned after each election, so lowering the retention limit doesn't
+	// stall the election in which it takes effect.
+	maxElectionSummariesPrunedPerElection = 100
+)
+
+// recordElectionSummary persists a summary of the election that was just held, the summaries are
+// assigned sequential indices starting from one. Summaries older than the retention limit are
+// pruned.
+func recordElectionSummary(
+	ctx contract.Context, state *State, summaries map[string]*ElectionValidatorSummary,
+) error {
+	historyState, err := loadElectionHistoryState(ctx)
+	if err != nil {
+		return err
+	}
+
+	validators := make([]*ElectionValidatorSummary, 0, len(summaries))
+	totalRewards := common.BigZero()
+	for _, summary := range summaries {
+		if summary.DelegationTotal == nil {
+			summary.DelegationTotal = loom.BigZeroPB()
+		}
+		totalRewards.Add(totalRewards, &summary.Rewards.Value)
+		validators = append(validators, summary)
+	}
+	// Map iteration order is random, so the summaries must be sorted to keep the stored value
+	// deterministic.
+	sort.Slice(validators, func(i, j int) bool {
+		return loom.UnmarshalAddressPB(validators[i].Validator).Compare(
+			loom.UnmarshalAddressPB(validators[j].Validator),
+		) < 0
+	})
+
+	historyState.LastIndex++
+	summary := &ElectionSummary{
+		Index:                     historyState.LastIndex,
+		Time:                      ctx.Now().Unix(),
+		BlockHeight:               ctx.Block().Height,
+		TotalValidatorDelegations: state.TotalValidatorDelegations,
+		TotalRewards:              &types.BigUInt{Value: *totalRewards},
+		Validators:                validators,
+	}
+	if err := ctx.Set(electionSummaryKey(summary.Index), summary); err != nil {
+		return err
+	}
+	pruneElectionSummaries(ctx, historyState)
+	return ctx.Set(electionHistoryStateKey, historyState)
+}
+
+// pruneElectionSummaries deletes the oldest summaries until no more than the max number of
+// summaries remain, or the per election pruning limit is reached.
+func pruneElectionSummaries(ctx contract.Context, historyState *ElectionHistoryState) {
+	maxSummaries := historyState.MaxSummaries
+	if maxSummaries == 0 {
+		maxSummaries = defaultMaxElectionSummaries
+	}
+	if historyState.FirstIndex == 0 {
+		historyState.FirstIndex = 1
+	}
+	for i := 0; i < maxElectionSummariesPrunedPerElection; i++ {
+		if historyState.LastIndex-historyState.FirstIndex < maxSummaries {
+			return
+		}
+		ctx.Delete(electionSummaryKey(historyState.FirstIndex))
+		historyState.FirstIndex++
+	}
+}
+
+// SetElectionHistoryLimit sets the max number of election summaries to retain, when the limit is
+// lowered the excess summaries are pruned gradually over the following elections.
+func (c *DPOS) SetElectionHistoryLimit(ctx contract.Context, req *SetElectionHistoryLimitRequest) error {
+	if !ctx.FeatureEnabled(features.DPOSVersion3_11, false) {
+		return errors.New("DPOS v3.11 is not enabled")
+	}
+	if req.MaxSummaries == 0 {
+		return logDposError(ctx, errors.New("Election history limit must be greater than zero."), req.String())
+	}
+
+	sender := ctx.Message().Sender
+	ctx.Logger().Info("DPOSv3 SetElectionHistoryLimit", "sender", sender, "request", req)
+
+	state, err := LoadState(ctx)
+	if err != nil {
+		return err
+	}
+
+	if state.Params.OracleAddress == nil || sender.Compare(loom.UnmarshalAddressPB(state.Params.OracleAddress)) != 0 {
+		return logDposError(ctx, errOnlyOracle, req.String())
+	}
+
+	historyState, err := loadElectionHistoryState(ctx)
+	if err != nil {
+		return err
+	}
+	historyState.MaxSummaries = req.MaxSummaries
+	return ctx.Set(electionHistoryStateKey, historyState)
+}
+
+func loadElectionHistoryState(ctx contract.StaticContext) (*ElectionHistoryState, error) {
+	var historyState ElectionHistoryState
+	err := ctx.Get(electionHistoryStateKey, &historyState)
+	if err != nil && err != contract.ErrNotFound {
+		return nil, err
+	}
+	return &historyState, nil
+}
+
+// electionHistoryRange returns the (inclusive) range of election indices that should be returned
+// by a history query. If fromIndex is zero the range ends at the most recent election.
+func electionHistoryRange(lastIndex, fromIndex, toIndex, limit uint64) (uint64, uint64) {
+	if limit == 0 || limit > maxElectionHistoryQueryLimit {
+		limit = maxElectionHistoryQueryLimit
+	}
+	if toIndex == 0 || toIndex > lastIndex {
+		toIndex = lastIndex
+	}
+	if fromIndex == 0 {
+		fromIndex = 1
+		if toIndex > limit {
+			fromIndex = toIndex - limit + 1
+		}
+	} else if toIndex >= fromIndex && toIndex-fromIndex >= limit {
+		toIndex = fromIndex + limit - 1
+	}
+	return fromIndex, toIndex
+}
+
+// ListElectionSummaries returns the summaries of the elections within the requested index range.
+func (c *DPOS) ListElectionSummaries(
+	ctx contract.StaticContext, req *ListElectionSummariesRequest,
+) (*ListElectionSummariesResponse, error) {
+	historyState, err := loadElectionHistoryState(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	fromIndex, toIndex := electionHistoryRange(historyState.LastIndex, req.FromIndex, req.ToIndex, req.Limit)
+	summaries := []*ElectionSummary{}
+	for i := fromIndex; i <= toIndex; i++ {
+		var summary ElectionSummary
+		if err := ctx.Get(electionSummaryKey(i), &summary); err != nil {
+			if err == contract.ErrNotFound {
+				continue
+			}
+			return nil, err
+		}
+		summaries = append(summaries, &summary)
+	}
+
+	return &ListElectionSummariesResponse{
+		Summaries:  summaries,
+		LastIndex:  historyState.LastIndex,
+		FirstIndex: historyState.FirstIndex,
+	}, nil
+}
+
+// ValidatorHistory returns the per-election summaries of a single validator within the requested
+// index range, elections the validator didn't take part in are omitted.
+func (c *DPOS) ValidatorHistory(
+	ctx contract.StaticContext, req *ValidatorHistoryRequest,
+) (*ValidatorHistoryResponse, error) {
+	if req.Validator == nil {
+		return nil, errors.New("validator address must be specified")
+	}
+	validator := loom.UnmarshalAddressPB(req.Validator)
+
+	historyState, err := loadElectionHistoryState(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	fromIndex, toIndex := electionHistoryRange(historyState.LastIndex, req.FromIndex, req.ToIndex, req.Limit)
+	records := []*ValidatorElectionRecord{}
+	for i := fromIndex; i <= toIndex; i++ {
+		var summary ElectionSummary
+		if err := ctx.Get(electionSummaryKey(i), &summary); err != nil {
+			if err == contract.ErrNotFound {
+				continue
+			}
+			return nil, err
+		}
+		for _, v := range summary.Validators {
+			if loom.UnmarshalAddressPB(v.Validator).Compare(validator) == 0 {
+				records = append(records, &ValidatorElectionRecord{
+					ElectionIndex: summary.Index,
+					Time:          summary.Time,
+					Summary:       v,
+				})
+				break
+			}
+		}
+	}
+
+	return &ValidatorHistoryResponse{
+		Records:    records,
+		LastIndex:  historyState.LastIndex,
+		FirstIndex: historyState.FirstIndex,
+	}, nil
+}
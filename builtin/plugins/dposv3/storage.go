@@ -2,6 +2,7 @@ package dposv3
 
 import (
 	"bytes"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"sort"
@@ -30,12 +31,67 @@ var (
 	requestBatchTallyKey   = []byte("request_batch_tally")
 	deprecatedReferrersKey = []byte("referrers")
 	referrerPrefix         = []byte("rf")
+
+	doubleSignSlashingConfigKey = []byte("double_sign_slashing_config")
+	doubleSignEvidencePrefix    = []byte("dse")
+	doubleSignJailedPrefix      = []byte("dsj")
+	doubleSignJailPrefix        = []byte("dsjr")
+	pendingSlashPrefix          = []byte("pslash")
+
+	autoCompoundPrefix = []byte("ac")
+
+	electionHistoryStateKey = []byte("election_history_state")
+	electionSummaryPrefix   = []byte("es")
+
+	unbondingConfigKey     = []byte("unbonding_config")
+	unbondingQueueStateKey = []byte("unbonding_queue_state")
+	unbondingPrefix        = []byte("ub")
 )
 
 func referrerKey(referrerName string) []byte {
 	return util.PrefixKey([]byte(referrerPrefix), []byte(referrerName))
 }
 
+func doubleSignEvidenceKey(tendermintAddress []byte, height int64) []byte {
+	heightBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(heightBytes, uint64(height))
+	return util.PrefixKey(doubleSignEvidencePrefix, tendermintAddress, heightBytes)
+}
+
+// doubleSignJailedKey is the key of the evidence a validator was last jailed for double signing,
+// it's removed once the resulting slash has been applied, or the validator is unjailed.
+func doubleSignJailedKey(validator loom.Address) []byte {
+	return util.PrefixKey(doubleSignJailedPrefix, validator.Bytes())
+}
+
+// doubleSignJailKey is the key of the height at which a validator that was jailed for double
+// signing can be unjailed, it's removed once the validator is unjailed.
+func doubleSignJailKey(validator loom.Address) []byte {
+	return util.PrefixKey(doubleSignJailPrefix, validator.Bytes())
+}
+
+// pendingSlashKey is the key of the earliest infraction a validator has been slashed for since
+// the validator's slashes were last applied.
+func pendingSlashKey(validator loom.Address) []byte {
+	return util.PrefixKey(pendingSlashPrefix, validator.Bytes())
+}
+
+func autoCompoundKey(validator, delegator loom.Address) []byte {
+	return util.PrefixKey(autoCompoundPrefix, validator.Bytes(), delegator.Bytes())
+}
+
+func electionSummaryKey(index uint64) []byte {
+	indexBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(indexBytes, index)
+	return util.PrefixKey(electionSummaryPrefix, indexBytes)
+}
+
+func unbondingKey(id uint64) []byte {
+	idBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(idBytes, id)
+	return util.PrefixKey(unbondingPrefix, idBytes)
+}
+
 func sortValidators(validators []*Validator) []*Validator {
 	sort.Sort(byPubkey(validators))
 	return validators
@@ -516,6 +572,21 @@ func saveState(ctx contract.Context, state *State) error {
 	return ctx.Set(stateKey, state)
 }
 
+func loadDoubleSignSlashingConfig(ctx contract.StaticContext) (*DoubleSignSlashingConfig, error) {
+	cfg := DoubleSignSlashingConfig{
+		MaxEvidenceAge: defaultMaxEvidenceAge,
+		JailPeriod:     defaultDoubleSignJailPeriod,
+	}
+	if err := ctx.Get(doubleSignSlashingConfigKey, &cfg); err != nil && err != contract.ErrNotFound {
+		return nil, err
+	}
+	// The jail period wasn't stored by earlier versions of the contract
+	if cfg.JailPeriod == 0 {
+		cfg.JailPeriod = defaultDoubleSignJailPeriod
+	}
+	return &cfg, nil
+}
+
 func LoadState(ctx contract.StaticContext) (*State, error) {
 	var state State
 	err := ctx.Get(stateKey, &state)
@@ -273,6 +273,18 @@ func (dpos *testDPOSContract) Unjail(ctx *plugin.FakeContext, candidate *loom.Ad
 	return err
 }
 
+func (dpos *testDPOSContract) SetAutoCompound(ctx *plugin.FakeContext, validator *loom.Address, index uint64, enabled bool) error {
+	err := dpos.Contract.SetAutoCompound(
+		contract.WrapPluginContext(ctx.WithAddress(dpos.Address)),
+		&SetAutoCompoundRequest{
+			ValidatorAddress: validator.MarshalPB(),
+			Index:            index,
+			Enabled:          enabled,
+		},
+	)
+	return err
+}
+
 func (dpos *testDPOSContract) SetSlashingPercentage(ctx *plugin.FakeContext, crashSlashingPercentage, byzantizeFaultSlashingPercentage int64) error {
 	err := dpos.Contract.SetSlashingPercentages(
 		contract.WrapPluginContext(ctx.WithAddress(dpos.Address)),
@@ -0,0 +1,241 @@
+package dposv3
+
+import (
+	"fmt"
+
+	"github.com/gogo/protobuf/proto"
+	loom "github.com/loomnetwork/go-loom"
+	"github.com/loomnetwork/go-loom/common"
+	contract "github.com/loomnetwork/go-loom/plugin/contractpb"
+	types "github.com/loomnetwork/go-loom/types"
+	"github.com/loomnetwork/loomchain/features"
+	"github.com/pkg/errors"
+)
+
+const (
+	// Number of seconds unbonded tokens remain slashable before they're returned to the delegator.
+	defaultUnbondingPeriod = int64(60 * 60 * 24 * 7)
+	// Max number of entries returned by ListUnbondings.
+	maxUnbondingsQueryLimit = 100
+)
+
+func loadUnbondingConfig(ctx contract.StaticContext) (*UnbondingConfig, error) {
+	cfg := UnbondingConfig{
+		UnbondingPeriod: defaultUnbondingPeriod,
+	}
+	if err := ctx.Get(unbondingConfigKey, &cfg); err != nil && err != contract.ErrNotFound {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// SetUnbondingPeriod sets the number of seconds unbonded tokens are held in the unbonding queue.
+// The new period only applies to tokens unbonded after it has been changed.
+func (c *DPOS) SetUnbondingPeriod(ctx contract.Context, req *SetUnbondingPeriodRequest) error {
+	if !ctx.FeatureEnabled(features.DPOSVersion3_12, false) {
+		return errors.New("DPOS v3.12 is not enabled")
+	}
+	if req.UnbondingPeriod < 0 {
+		return logDposError(ctx, errors.New("Unbonding period must not be negative."), req.String())
+	}
+
+	sender := ctx.Message().Sender
+	ctx.Logger().Info("DPOSv3 SetUnbondingPeriod", "sender", sender, "request", req)
+
+	state, err := LoadState(ctx)
+	if err != nil {
+		return err
+	}
+
+	if state.Params.OracleAddress == nil || sender.Compare(loom.UnmarshalAddressPB(state.Params.OracleAddress)) != 0 {
+		return logDposError(ctx, errOnlyOracle, req.String())
+	}
+
+	cfg, err := loadUnbondingConfig(ctx)
+	if err != nil {
+		return err
+	}
+	cfg.UnbondingPeriod = req.UnbondingPeriod
+	return ctx.Set(unbondingConfigKey, cfg)
+}
+
+// ListUnbondings returns the tokens that are currently in the unbonding queue, optionally filtered
+// by validator and/or delegator. The entries are returned in pages of at most 100 entries, ordered
+// by ID.
+func (c *DPOS) ListUnbondings(ctx contract.StaticContext, req *ListUnbondingsRequest) (*ListUnbondingsResponse, error) {
+	cfg, err := loadUnbondingConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := loadUnbondingEntries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := int(req.Limit)
+	if limit == 0 || limit > maxUnbondingsQueryLimit {
+		limit = maxUnbondingsQueryLimit
+	}
+
+	unbondings := []*UnbondingEntry{}
+	var nextID uint64
+	for _, entry := range entries {
+		if entry.Id < req.FromId {
+			continue
+		}
+		if req.Validator != nil && loom.UnmarshalAddressPB(entry.Validator).Compare(loom.UnmarshalAddressPB(req.Validator)) != 0 {
+			continue
+		}
+		if req.Delegator != nil && loom.UnmarshalAddressPB(entry.Delegator).Compare(loom.UnmarshalAddressPB(req.Delegator)) != 0 {
+			continue
+		}
+		if len(unbondings) == limit {
+			nextID = entry.Id
+			break
+		}
+		unbondings = append(unbondings, entry)
+	}
+
+	return &ListUnbondingsResponse{
+		Unbondings:      unbondings,
+		UnbondingPeriod: cfg.UnbondingPeriod,
+		NextId:          nextID,
+	}, nil
+}
+
+// Loads all the entries in the unbonding queue, ordered by ID (which is also the order in which
+// the entries were queued).
+func loadUnbondingEntries(ctx contract.StaticContext) ([]*UnbondingEntry, error) {
+	entries := []*UnbondingEntry{}
+	for _, m := range ctx.Range(unbondingPrefix) {
+		var entry UnbondingEntry
+		if err := proto.Unmarshal(m.Value, &entry); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal unbonding entry")
+		}
+		entries = append(entries, &entry)
+	}
+	return entries, nil
+}
+
+// queueUnbonding adds the amount unbonded from the given delegation to the unbonding queue.
+func queueUnbonding(ctx contract.Context, delegation *Delegation, amount *types.BigUInt) error {
+	cfg, err := loadUnbondingConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	var queueState UnbondingQueueState
+	if err := ctx.Get(unbondingQueueStateKey, &queueState); err != nil && err != contract.ErrNotFound {
+		return err
+	}
+	queueState.LastId++
+
+	entry := &UnbondingEntry{
+		Id:              queueState.LastId,
+		Validator:       delegation.Validator,
+		Delegator:       delegation.Delegator,
+		Index:           delegation.Index,
+		Amount:          amount,
+		UnbondingHeight: ctx.Block().Height,
+		ReleaseTime:     ctx.Now().Unix() + cfg.UnbondingPeriod,
+	}
+	if err := ctx.Set(unbondingKey(entry.Id), entry); err != nil {
+		return err
+	}
+	return ctx.Set(unbondingQueueStateKey, &queueState)
+}
+
+// releaseUnbondings returns the tokens of all the unbonding queue entries whose unbonding period
+// has ended to their delegators.
+func releaseUnbondings(ctx contract.Context) error {
+	entries, err := loadUnbondingEntries(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := ctx.Now().Unix()
+	var coin *ERC20
+	for _, entry := range entries {
+		if entry.ReleaseTime > now {
+			continue
+		}
+
+		if common.IsPositive(entry.Amount.Value) {
+			if coin == nil {
+				if coin, err = loadCoin(ctx); err != nil {
+					return err
+				}
+			}
+			if err := coin.Transfer(loom.UnmarshalAddressPB(entry.Delegator), &entry.Amount.Value); err != nil {
+				transferErr := fmt.Sprintf("Failed coin Transfer - releaseUnbondings, %v, %s", entry.Delegator.String(), entry.Amount.Value.String())
+				return logDposError(ctx, err, transferErr)
+			}
+		}
+
+		ctx.Delete(unbondingKey(entry.Id))
+		if err := emitUnbondingReleasedEvent(ctx, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// slashUnbondings slashes the tokens that are still in the unbonding queue after being unbonded
+// from the given validator at or after the infraction height, tokens unbonded before the infraction
+// weren't backing the validator when it occurred so they're left alone.
+func slashUnbondings(
+	ctx contract.Context, validatorAddress loom.Address, slashPercentage *types.BigUInt, infractionHeight int64,
+) error {
+	entries, err := loadUnbondingEntries(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if loom.UnmarshalAddressPB(entry.Validator).Compare(validatorAddress) != 0 ||
+			entry.UnbondingHeight < infractionHeight {
+			continue
+		}
+
+		toSlash := CalculateFraction(slashPercentage.Value, entry.Amount.Value)
+		updatedAmount := common.BigZero()
+		updatedAmount.Sub(&entry.Amount.Value, &toSlash)
+		entry.Amount = &types.BigUInt{Value: *updatedAmount}
+		if err := ctx.Set(unbondingKey(entry.Id), entry); err != nil {
+			return err
+		}
+		if err := emitSlashUnbondingEvent(ctx, entry, &types.BigUInt{Value: toSlash}, slashPercentage); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func emitUnbondingReleasedEvent(ctx contract.Context, entry *UnbondingEntry) error {
+	marshalled, err := proto.Marshal(&DposUnbondingReleasedEvent{
+		Unbonding: entry,
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx.EmitTopics(marshalled, UnbondingReleasedEventTopic)
+	return nil
+}
+
+func emitSlashUnbondingEvent(
+	ctx contract.Context, entry *UnbondingEntry, slashAmount, slashPercentage *types.BigUInt,
+) error {
+	marshalled, err := proto.Marshal(&DposSlashUnbondingEvent{
+		Unbonding:       entry,
+		SlashAmount:     slashAmount,
+		SlashPercentage: slashPercentage,
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx.EmitTopics(marshalled, SlashUnbondingEventTopic)
+	return nil
+}
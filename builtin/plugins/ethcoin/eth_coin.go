@@ -9,6 +9,7 @@ import (
 	contract "github.com/loomnetwork/go-loom/plugin/contractpb"
 	"github.com/loomnetwork/go-loom/types"
 	"github.com/loomnetwork/go-loom/util"
+	"github.com/loomnetwork/loomchain/builtin/plugins/coin"
 	"github.com/loomnetwork/loomchain/features"
 	"github.com/pkg/errors"
 )
@@ -16,6 +17,7 @@ import (
 const (
 	TransferEventTopic = "ethcoin:transfer"
 	ApprovalEventTopic = "ethcoin:approval"
+	SnapshotEventTopic = "ethcoin:snapshot"
 )
 
 type (
@@ -38,6 +40,18 @@ type (
 	Allowance            = ctypes.Allowance
 	Account              = ctypes.Account
 	Economy              = ctypes.Economy
+
+	SnapshotRequest       = coin.SnapshotRequest
+	SnapshotResponse      = coin.SnapshotResponse
+	BalanceOfAtRequest    = coin.BalanceOfAtRequest
+	BalanceOfAtResponse   = coin.BalanceOfAtResponse
+	TotalSupplyAtRequest  = coin.TotalSupplyAtRequest
+	TotalSupplyAtResponse = coin.TotalSupplyAtResponse
+
+	BatchTransferRequest    = coin.BatchTransferRequest
+	SetMaxBatchSizeRequest  = coin.SetMaxBatchSizeRequest
+	GetMaxBatchSizeRequest  = coin.GetMaxBatchSizeRequest
+	GetMaxBatchSizeResponse = coin.GetMaxBatchSizeResponse
 )
 
 var (
@@ -156,7 +170,7 @@ func Mint(ctx contract.Context, to loom.Address, amount *loom.BigUInt) error {
 		return err
 	}
 
-	return ctx.Set(economyKey, econ)
+	return saveEconomy(ctx, econ)
 }
 
 // ERC20 methods
@@ -296,6 +310,92 @@ func transfer(ctx contract.Context, from, to loom.Address, amount *loom.BigUInt)
 	return emitTransferEvent(ctx, from, to, amount)
 }
 
+// Snapshot records the current balances, can only be called by the Governance contract.
+func (c *ETHCoin) Snapshot(ctx contract.Context, req *SnapshotRequest) (*SnapshotResponse, error) {
+	snapshotID, err := coin.TakeSnapshot(ctx, SnapshotEventTopic)
+	if err != nil {
+		return nil, err
+	}
+	return &SnapshotResponse{SnapshotId: snapshotID}, nil
+}
+
+// BalanceOfAt returns the balance of an account at the time the given snapshot was taken.
+func (c *ETHCoin) BalanceOfAt(ctx contract.StaticContext, req *BalanceOfAtRequest) (*BalanceOfAtResponse, error) {
+	if req.Owner == nil {
+		return nil, ErrInvalidRequest
+	}
+	owner := loom.UnmarshalAddressPB(req.Owner)
+	balance, err := BalanceOf(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	balance, err = coin.ValueAt(ctx, accountKey(owner), req.SnapshotId, balance)
+	if err != nil {
+		return nil, err
+	}
+	return &BalanceOfAtResponse{
+		Balance: &types.BigUInt{Value: *balance},
+	}, nil
+}
+
+// TotalSupplyAt returns the total supply at the time the given snapshot was taken.
+func (c *ETHCoin) TotalSupplyAt(
+	ctx contract.StaticContext, req *TotalSupplyAtRequest,
+) (*TotalSupplyAtResponse, error) {
+	econ, err := loadEconomy(ctx)
+	if err != nil {
+		return nil, err
+	}
+	supply, err := coin.ValueAt(ctx, economyKey, req.SnapshotId, &econ.TotalSupply.Value)
+	if err != nil {
+		return nil, err
+	}
+	return &TotalSupplyAtResponse{
+		TotalSupply: &types.BigUInt{Value: *supply},
+	}, nil
+}
+
+// BatchTransfer transfers ETH from the sender to each of the recipients, a transfer event is
+// emitted for each recipient. If any of the transfers fail none of them will take effect.
+func (c *ETHCoin) BatchTransfer(ctx contract.Context, req *BatchTransferRequest) error {
+	total, err := coin.ValidateBatchTransfer(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	from := ctx.Message().Sender
+	balance, err := BalanceOf(ctx, from)
+	if err != nil {
+		return err
+	}
+	if balance.Cmp(total) < 0 {
+		return ErrSenderBalanceTooLow
+	}
+
+	for _, r := range req.Recipients {
+		if err := Transfer(ctx, from, loom.UnmarshalAddressPB(r.To), &r.Amount.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetMaxBatchSize changes the max number of recipients of a batch transfer, can only be called by
+// the Governance contract.
+func (c *ETHCoin) SetMaxBatchSize(ctx contract.Context, req *SetMaxBatchSizeRequest) error {
+	return coin.UpdateMaxBatchSize(ctx, req.MaxBatchSize)
+}
+
+func (c *ETHCoin) GetMaxBatchSize(
+	ctx contract.StaticContext, req *GetMaxBatchSizeRequest,
+) (*GetMaxBatchSizeResponse, error) {
+	maxBatchSize, err := coin.MaxBatchSize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &GetMaxBatchSizeResponse{MaxBatchSize: maxBatchSize}, nil
+}
+
 func (c *ETHCoin) Approve(ctx contract.Context, req *ApproveRequest) error {
 	if ctx.FeatureEnabled(features.CoinVersion1_2Feature, false) && (req.Spender == nil || req.Amount == nil) {
 		return ErrInvalidRequest
@@ -427,9 +527,42 @@ func loadAccount(ctx contract.StaticContext, owner loom.Address) (*Account, erro
 
 func saveAccount(ctx contract.Context, acct *Account) error {
 	owner := loom.UnmarshalAddressPB(acct.Owner)
+	if ctx.FeatureEnabled(features.CoinVersion1_5Feature, false) {
+		prevAcct, err := loadAccount(ctx, owner)
+		if err != nil {
+			return err
+		}
+		if err := coin.UpdateSnapshot(ctx, accountKey(owner), &prevAcct.Balance.Value); err != nil {
+			return err
+		}
+	}
 	return ctx.Set(accountKey(owner), acct)
 }
 
+func loadEconomy(ctx contract.StaticContext) (*Economy, error) {
+	econ := &Economy{
+		TotalSupply: &types.BigUInt{Value: *loom.NewBigUIntFromInt(0)},
+	}
+	err := ctx.Get(economyKey, econ)
+	if err != nil && err != contract.ErrNotFound {
+		return nil, err
+	}
+	return econ, nil
+}
+
+func saveEconomy(ctx contract.Context, econ *Economy) error {
+	if ctx.FeatureEnabled(features.CoinVersion1_5Feature, false) {
+		prevEcon, err := loadEconomy(ctx)
+		if err != nil {
+			return err
+		}
+		if err := coin.UpdateSnapshot(ctx, economyKey, &prevEcon.TotalSupply.Value); err != nil {
+			return err
+		}
+	}
+	return ctx.Set(economyKey, econ)
+}
+
 func loadAllowance(ctx contract.StaticContext, owner, spender loom.Address) (*Allowance, error) {
 	allow := &Allowance{
 		Owner:   owner.MarshalPB(),
@@ -0,0 +1,643 @@
+package governance
+
+import (
+	"encoding/binary"
+	"sort"
+
+	"github.com/gogo/protobuf/proto"
+	loom "github.com/loomnetwork/go-loom"
+	ctypes "github.com/loomnetwork/go-loom/builtin/types/coin"
+	dtypes "github.com/loomnetwork/go-loom/builtin/types/dposv3"
+	"github.com/loomnetwork/go-loom/common"
+	"github.com/loomnetwork/go-loom/plugin"
+	contract "github.com/loomnetwork/go-loom/plugin/contractpb"
+	"github.com/loomnetwork/go-loom/types"
+	"github.com/loomnetwork/go-loom/util"
+	"github.com/pkg/errors"
+)
+
+type (
+	Params                 = GovernanceParams
+	State                  = GovernanceState
+	ParamChange            = GovernanceParamChange
+	CommunityPoolSpend     = GovernanceCommunityPoolSpend
+	Proposal               = GovernanceProposal
+	ActiveProposal         = GovernanceActiveProposal
+	ProposalKind           = GovernanceProposal_Kind
+	ProposalStatus         = GovernanceProposal_Status
+	Vote                   = GovernanceVote
+	VoteChoice             = GovernanceVote_Choice
+	InitRequest            = GovernanceInitRequest
+	SetParamsRequest       = GovernanceSetParamsRequest
+	GetParamsRequest       = GovernanceGetParamsRequest
+	GetParamsResponse      = GovernanceGetParamsResponse
+	SubmitProposalRequest  = GovernanceSubmitProposalRequest
+	SubmitProposalResponse = GovernanceSubmitProposalResponse
+	VoteRequest            = GovernanceVoteRequest
+	GetProposalRequest     = GovernanceGetProposalRequest
+	GetProposalResponse    = GovernanceGetProposalResponse
+	ListProposalsRequest   = GovernanceListProposalsRequest
+	ListProposalsResponse  = GovernanceListProposalsResponse
+)
+
+const (
+	// ParamChangeProposal calls a contract method with the Governance contract as the sender.
+	ParamChangeProposal = GovernanceProposal_PARAM_CHANGE
+	// EnableFeatureProposal activates a feature flag on the chain.
+	EnableFeatureProposal = GovernanceProposal_ENABLE_FEATURE
+	// CommunityPoolSpendProposal transfers coins held by the Governance contract.
+	CommunityPoolSpendProposal = GovernanceProposal_COMMUNITY_POOL_SPEND
+
+	// ProposalVoting status indicates a proposal is still open for voting.
+	ProposalVoting = GovernanceProposal_VOTING
+	// ProposalExecuted status indicates a proposal passed and was executed.
+	ProposalExecuted = GovernanceProposal_EXECUTED
+	// ProposalRejected status indicates a proposal didn't reach quorum, or was voted down.
+	ProposalRejected = GovernanceProposal_REJECTED
+	// ProposalFailed status indicates a proposal passed but its execution failed.
+	ProposalFailed = GovernanceProposal_FAILED
+
+	VoteYes     = GovernanceVote_YES
+	VoteNo      = GovernanceVote_NO
+	VoteAbstain = GovernanceVote_ABSTAIN
+)
+
+var (
+	// ErrNotAuthorized indicates that a contract method failed because the caller didn't have
+	// the permission to execute that method.
+	ErrNotAuthorized = errors.New("[Governance] not authorized")
+	// ErrInvalidRequest is a generic error that's returned when something is wrong with the
+	// request message, e.g. missing or invalid fields.
+	ErrInvalidRequest = errors.New("[Governance] invalid request")
+	// ErrOwnerNotSpecified returned if init request does not have owner address
+	ErrOwnerNotSpecified = errors.New("[Governance] owner not specified")
+	// ErrInvalidParams returned if parameters are invalid
+	ErrInvalidParams = errors.New("[Governance] invalid params")
+	// ErrProposalNotFound indicates that a proposal does not exist
+	ErrProposalNotFound = errors.New("[Governance] proposal not found")
+	// ErrVotingClosed is returned when voting on a proposal whose voting period has ended
+	ErrVotingClosed = errors.New("[Governance] voting period has ended")
+	// ErrNotEnoughStake is returned when the sender doesn't have enough DPOSv3 delegations to
+	// submit a proposal or vote
+	ErrNotEnoughStake = errors.New("[Governance] not enough stake")
+)
+
+const (
+	ownerRole = "owner"
+
+	// Roughly a day at 5 second blocks
+	defaultVotingPeriod            = 17280
+	defaultMinProposerStake        = 10000
+	tokenDecimals                  = 18
+	defaultQuorumPercentage        = 3340
+	defaultPassThresholdPercentage = 5000
+	hundredPercentInBasisPoints    = 10000
+)
+
+var (
+	modifyPerm = []byte("modp")
+
+	stateKey             = []byte("state")
+	proposalPrefix       = []byte("prop")
+	activeProposalPrefix = []byte("active")
+	votePrefix           = []byte("vote")
+)
+
+func proposalKey(id uint64) []byte {
+	return util.PrefixKey(proposalPrefix, uint64ToBytes(id))
+}
+
+func activeProposalKey(id uint64) []byte {
+	return util.PrefixKey(activeProposalPrefix, uint64ToBytes(id))
+}
+
+func proposalVotesPrefix(id uint64) []byte {
+	return util.PrefixKey(votePrefix, uint64ToBytes(id))
+}
+
+func voteKey(id uint64, voter loom.Address) []byte {
+	return util.PrefixKey(votePrefix, uint64ToBytes(id), voter.Bytes())
+}
+
+func uint64ToBytes(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, v)
+	return b
+}
+
+// Governance contract allows anyone with stake in DPOSv3 to submit proposals, which are voted on by
+// delegators & validators, with each vote weighted by the voter's delegations. Proposals that pass
+// are executed automatically at the end of the block in which their voting period ends.
+//
+// Param change proposals call contract methods with the Governance contract as the sender, so in
+// order to change DPOSv3 params via governance the DPOSv3 oracle must be set to the address of the
+// Governance contract.
+type Governance struct {
+}
+
+func (g *Governance) Meta() (plugin.Meta, error) {
+	return plugin.Meta{
+		Name:    "governance",
+		Version: "1.0.0",
+	}, nil
+}
+
+func (g *Governance) Init(ctx contract.Context, req *InitRequest) error {
+	if req.Owner == nil {
+		return ErrOwnerNotSpecified
+	}
+	ownerAddr := loom.UnmarshalAddressPB(req.Owner)
+	ctx.GrantPermissionTo(ownerAddr, modifyPerm, ownerRole)
+
+	params := req.Params
+	if params == nil {
+		params = &Params{}
+	}
+	if params.VotingPeriod == 0 {
+		params.VotingPeriod = defaultVotingPeriod
+	}
+	if params.MinProposerStake == nil {
+		params.MinProposerStake = defaultMinProposerStakeAmount()
+	}
+	if params.QuorumPercentage == 0 {
+		params.QuorumPercentage = defaultQuorumPercentage
+	}
+	if params.PassThresholdPercentage == 0 {
+		params.PassThresholdPercentage = defaultPassThresholdPercentage
+	}
+	if err := validateParams(params); err != nil {
+		return err
+	}
+
+	return saveState(ctx, &State{
+		Owner:  req.Owner,
+		Params: params,
+	})
+}
+
+// SetParams can be called by the contract owner, or by the contract itself when executing a
+// param change proposal.
+func (g *Governance) SetParams(ctx contract.Context, req *SetParamsRequest) error {
+	if ok, _ := ctx.HasPermission(modifyPerm, []string{ownerRole}); !ok &&
+		ctx.Message().Sender.Compare(ctx.ContractAddress()) != 0 {
+		return ErrNotAuthorized
+	}
+	if req.Params == nil {
+		return ErrInvalidRequest
+	}
+	if req.Params.MinProposerStake == nil {
+		req.Params.MinProposerStake = defaultMinProposerStakeAmount()
+	}
+	if err := validateParams(req.Params); err != nil {
+		return err
+	}
+
+	state, err := loadState(ctx)
+	if err != nil {
+		return err
+	}
+	state.Params = req.Params
+	return saveState(ctx, state)
+}
+
+func (g *Governance) GetParams(ctx contract.StaticContext, req *GetParamsRequest) (*GetParamsResponse, error) {
+	state, err := loadState(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &GetParamsResponse{Params: state.Params}, nil
+}
+
+// SubmitProposal creates a new proposal, which remains open for voting for the number of blocks
+// specified by the VotingPeriod param.
+func (g *Governance) SubmitProposal(
+	ctx contract.Context, req *SubmitProposalRequest,
+) (*SubmitProposalResponse, error) {
+	if err := validateProposal(ctx, req); err != nil {
+		return nil, err
+	}
+
+	state, err := loadState(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	proposer := ctx.Message().Sender
+	stake, err := delegatedAmount(ctx, proposer)
+	if err != nil {
+		return nil, err
+	}
+	if common.IsZero(*stake) || stake.Cmp(&state.Params.MinProposerStake.Value) < 0 {
+		return nil, ErrNotEnoughStake
+	}
+
+	state.LastProposalId++
+	height := uint64(ctx.Block().Height)
+	proposal := &Proposal{
+		Id:              state.LastProposalId,
+		Proposer:        proposer.MarshalPB(),
+		Kind:            req.Kind,
+		Description:     req.Description,
+		ParamChange:     req.ParamChange,
+		FeatureName:     req.FeatureName,
+		PoolSpend:       req.PoolSpend,
+		Status:          ProposalVoting,
+		SubmitHeight:    height,
+		VotingEndHeight: height + state.Params.VotingPeriod,
+	}
+	if err := ctx.Set(proposalKey(proposal.Id), proposal); err != nil {
+		return nil, err
+	}
+	err = ctx.Set(activeProposalKey(proposal.Id), &ActiveProposal{
+		ProposalId:      proposal.Id,
+		VotingEndHeight: proposal.VotingEndHeight,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := saveState(ctx, state); err != nil {
+		return nil, err
+	}
+	return &SubmitProposalResponse{ProposalId: proposal.Id}, nil
+}
+
+// Vote records the sender's vote on a proposal, replacing any previous vote by the sender.
+// The voting power of each vote is computed from the voter's delegations at the end of the voting
+// period.
+func (g *Governance) Vote(ctx contract.Context, req *VoteRequest) error {
+	switch req.Choice {
+	case VoteYes, VoteNo, VoteAbstain:
+	default:
+		return ErrInvalidRequest
+	}
+
+	proposal, err := loadProposal(ctx, req.ProposalId)
+	if err != nil {
+		return err
+	}
+	if proposal.Status != ProposalVoting || uint64(ctx.Block().Height) >= proposal.VotingEndHeight {
+		return ErrVotingClosed
+	}
+
+	voter := ctx.Message().Sender
+	stake, err := delegatedAmount(ctx, voter)
+	if err != nil {
+		return err
+	}
+	if common.IsZero(*stake) {
+		return ErrNotEnoughStake
+	}
+
+	return ctx.Set(voteKey(proposal.Id, voter), &Vote{
+		Voter:  voter.MarshalPB(),
+		Choice: req.Choice,
+	})
+}
+
+func (g *Governance) GetProposal(
+	ctx contract.StaticContext, req *GetProposalRequest,
+) (*GetProposalResponse, error) {
+	proposal, err := loadProposal(ctx, req.ProposalId)
+	if err != nil {
+		return nil, err
+	}
+	votes, err := loadVotes(ctx, proposal.Id)
+	if err != nil {
+		return nil, err
+	}
+	return &GetProposalResponse{
+		Proposal: proposal,
+		Votes:    votes,
+	}, nil
+}
+
+func (g *Governance) ListProposals(
+	ctx contract.StaticContext, req *ListProposalsRequest,
+) (*ListProposalsResponse, error) {
+	proposals := []*Proposal{}
+	for _, entry := range ctx.Range(proposalPrefix) {
+		var proposal Proposal
+		if err := proto.Unmarshal(entry.Value, &proposal); err != nil {
+			return nil, errors.Wrapf(err, "unmarshal proposal %x", entry.Key)
+		}
+		proposals = append(proposals, &proposal)
+	}
+	sort.Slice(proposals, func(i, j int) bool {
+		return proposals[i].Id < proposals[j].Id
+	})
+	return &ListProposalsResponse{Proposals: proposals}, nil
+}
+
+// ProposalExecutor executes a proposal that passed. If it returns an error the proposal is marked as
+// failed, so the executor must ensure none of the changes made by the failed proposal persist.
+type ProposalExecutor func(proposal *Proposal) error
+
+// ProcessProposals is called at the end of each block to tally the votes of proposals whose voting
+// period has ended, and execute the ones that passed. Returns the proposals that were processed,
+// enabling the features of executed EnableFeatureProposal proposals is left up to the caller.
+// Proposals whose votes can't be tallied, or whose execution fails, are marked as failed.
+func ProcessProposals(ctx contract.Context, blockHeight uint64, execute ProposalExecutor) ([]*Proposal, error) {
+	state, err := loadState(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	endedProposalIDs, err := loadEndedProposalIDs(ctx, blockHeight)
+	if err != nil {
+		return nil, err
+	}
+
+	var processed []*Proposal
+	var stakes *delegatedStakes
+	var stakesErr error
+	for _, id := range endedProposalIDs {
+		proposal, err := loadProposal(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		if stakes == nil && stakesErr == nil {
+			stakes, stakesErr = loadDelegatedStakes(ctx)
+		}
+		if stakesErr != nil {
+			markProposalFailed(ctx, proposal, errors.Wrap(stakesErr, "failed to load delegated stakes"))
+		} else if err := tallyVotes(ctx, proposal, stakes); err != nil {
+			markProposalFailed(ctx, proposal, errors.Wrap(err, "failed to tally votes"))
+		} else if proposalPassed(state.Params, proposal, stakes.total) {
+			if err := execute(proposal); err != nil {
+				markProposalFailed(ctx, proposal, err)
+			} else {
+				proposal.Status = ProposalExecuted
+			}
+		} else {
+			proposal.Status = ProposalRejected
+		}
+
+		if err := ctx.Set(proposalKey(proposal.Id), proposal); err != nil {
+			return nil, err
+		}
+		ctx.Delete(activeProposalKey(proposal.Id))
+		processed = append(processed, proposal)
+	}
+	return processed, nil
+}
+
+// loadEndedProposalIDs returns the IDs of the active proposals whose voting period has ended by
+// the given block height, in the order they were submitted.
+func loadEndedProposalIDs(ctx contract.StaticContext, blockHeight uint64) ([]uint64, error) {
+	var ids []uint64
+	for _, entry := range ctx.Range(activeProposalPrefix) {
+		var active ActiveProposal
+		if err := proto.Unmarshal(entry.Value, &active); err != nil {
+			return nil, errors.Wrapf(err, "unmarshal active proposal %x", entry.Key)
+		}
+		if blockHeight >= active.VotingEndHeight {
+			ids = append(ids, active.ProposalId)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+	return ids, nil
+}
+
+// defaultMinProposerStakeAmount returns the default MinProposerStake param, 10,000 tokens.
+func defaultMinProposerStakeAmount() *types.BigUInt {
+	amount := loom.NewBigUIntFromInt(10)
+	amount.Exp(amount, loom.NewBigUIntFromInt(tokenDecimals), nil)
+	amount.Mul(amount, loom.NewBigUIntFromInt(defaultMinProposerStake))
+	return &types.BigUInt{Value: *amount}
+}
+
+func markProposalFailed(ctx contract.StaticContext, proposal *Proposal, err error) {
+	ctx.Logger().Error("Governance proposal failed", "proposal", proposal.Id, "err", err)
+	proposal.Status = ProposalFailed
+	proposal.ExecutionError = err.Error()
+}
+
+// tallyVotes weights each vote by the amount the voter has delegated, the stakes used to weight the
+// votes are the same ones the quorum is computed from.
+func tallyVotes(ctx contract.StaticContext, proposal *Proposal, stakes *delegatedStakes) error {
+	votes, err := loadVotes(ctx, proposal.Id)
+	if err != nil {
+		return err
+	}
+
+	yes := common.BigZero()
+	no := common.BigZero()
+	abstain := common.BigZero()
+	for _, vote := range votes {
+		power := stakes.of(loom.UnmarshalAddressPB(vote.Voter))
+		switch vote.Choice {
+		case VoteYes:
+			yes.Add(yes, power)
+		case VoteNo:
+			no.Add(no, power)
+		case VoteAbstain:
+			abstain.Add(abstain, power)
+		}
+	}
+
+	proposal.YesPower = &types.BigUInt{Value: *yes}
+	proposal.NoPower = &types.BigUInt{Value: *no}
+	proposal.AbstainPower = &types.BigUInt{Value: *abstain}
+	return nil
+}
+
+// A proposal passes if the voting power of all the votes reaches the quorum percentage of the total
+// delegated stake, and the voting power in favour of the proposal exceeds the pass threshold
+// percentage of the non-abstaining voting power.
+func proposalPassed(params *Params, proposal *Proposal, totalStake *loom.BigUInt) bool {
+	if common.IsZero(*totalStake) {
+		return false
+	}
+
+	voted := common.BigZero()
+	voted.Add(&proposal.YesPower.Value, &proposal.NoPower.Value)
+	nonAbstaining := common.BigZero()
+	nonAbstaining.Set(voted.Int)
+	voted.Add(voted, &proposal.AbstainPower.Value)
+
+	quorum := common.BigZero()
+	quorum.Mul(totalStake, loom.NewBigUIntFromInt(int64(params.QuorumPercentage)))
+	voted.Mul(voted, loom.NewBigUIntFromInt(hundredPercentInBasisPoints))
+	if voted.Cmp(quorum) < 0 || common.IsZero(*nonAbstaining) {
+		return false
+	}
+
+	threshold := common.BigZero()
+	threshold.Mul(nonAbstaining, loom.NewBigUIntFromInt(int64(params.PassThresholdPercentage)))
+	yes := common.BigZero()
+	yes.Mul(&proposal.YesPower.Value, loom.NewBigUIntFromInt(hundredPercentInBasisPoints))
+	return yes.Cmp(threshold) > 0
+}
+
+// ExecuteProposal executes a proposal that passed, changes made by a proposal whose execution fails
+// part way through aren't rolled back, that's left up to the caller.
+func ExecuteProposal(ctx contract.Context, proposal *Proposal) error {
+	switch proposal.Kind {
+	case ParamChangeProposal:
+		contractAddr, err := ctx.Resolve(proposal.ParamChange.ContractName)
+		if err != nil {
+			return errors.Wrapf(err, "failed to resolve contract %s", proposal.ParamChange.ContractName)
+		}
+		methodCall := &plugin.ContractMethodCall{
+			Method: proposal.ParamChange.Method,
+			Args:   proposal.ParamChange.Args,
+		}
+		return contract.Call(ctx, contractAddr, methodCall, nil)
+
+	case EnableFeatureProposal:
+		// Features are enabled by the caller of ProcessProposals since contracts can't modify the
+		// chain feature flags.
+		return nil
+
+	case CommunityPoolSpendProposal:
+		coinAddr, err := ctx.Resolve("coin")
+		if err != nil {
+			return errors.Wrap(err, "address of coin contract")
+		}
+		req := &ctypes.TransferRequest{
+			To:     proposal.PoolSpend.Recipient,
+			Amount: proposal.PoolSpend.Amount,
+		}
+		return contract.CallMethod(ctx, coinAddr, "Transfer", req, nil)
+	}
+	return ErrInvalidRequest
+}
+
+func validateProposal(ctx contract.StaticContext, req *SubmitProposalRequest) error {
+	switch req.Kind {
+	case ParamChangeProposal:
+		if req.ParamChange == nil || req.ParamChange.ContractName == "" || req.ParamChange.Method == "" {
+			return ErrInvalidRequest
+		}
+		if _, err := ctx.Resolve(req.ParamChange.ContractName); err != nil {
+			return errors.Wrapf(ErrInvalidRequest, "unknown contract %s", req.ParamChange.ContractName)
+		}
+	case EnableFeatureProposal:
+		if req.FeatureName == "" {
+			return ErrInvalidRequest
+		}
+	case CommunityPoolSpendProposal:
+		spend := req.PoolSpend
+		if spend == nil || spend.Recipient == nil || spend.Amount == nil || spend.Amount.Value.Int == nil ||
+			spend.Amount.Value.Sign() <= 0 {
+			return ErrInvalidRequest
+		}
+	default:
+		return ErrInvalidRequest
+	}
+	return nil
+}
+
+func validateParams(params *Params) error {
+	if params.VotingPeriod == 0 ||
+		params.QuorumPercentage > hundredPercentInBasisPoints ||
+		params.PassThresholdPercentage > hundredPercentInBasisPoints {
+		return ErrInvalidParams
+	}
+	return nil
+}
+
+// delegatedAmount returns the total amount the given account has delegated in DPOSv3.
+func delegatedAmount(ctx contract.StaticContext, addr loom.Address) (*loom.BigUInt, error) {
+	dposAddr, err := ctx.Resolve("dposV3")
+	if err != nil {
+		return nil, errors.Wrap(err, "address of dposV3 contract")
+	}
+	req := &dtypes.CheckAllDelegationsRequest{DelegatorAddress: addr.MarshalPB()}
+	var resp dtypes.CheckAllDelegationsResponse
+	if err := contract.StaticCallMethod(ctx, dposAddr, "CheckAllDelegations", req, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Amount == nil {
+		return common.BigZero(), nil
+	}
+	return &resp.Amount.Value, nil
+}
+
+// delegatedStakes is a snapshot of the amounts delegated to the DPOSv3 candidates.
+type delegatedStakes struct {
+	// amount delegated by each account, keyed by account address
+	delegators map[string]*loom.BigUInt
+	total      *loom.BigUInt
+}
+
+// of returns the amount the given account has delegated.
+func (s *delegatedStakes) of(addr loom.Address) *loom.BigUInt {
+	if amount, ok := s.delegators[addr.String()]; ok {
+		return amount
+	}
+	return common.BigZero()
+}
+
+// loadDelegatedStakes returns the amount each account has delegated to the current DPOSv3
+// candidates, and the total amount delegated to them.
+func loadDelegatedStakes(ctx contract.StaticContext) (*delegatedStakes, error) {
+	dposAddr, err := ctx.Resolve("dposV3")
+	if err != nil {
+		return nil, errors.Wrap(err, "address of dposV3 contract")
+	}
+	var resp dtypes.ListAllDelegationsResponse
+	req := &dtypes.ListAllDelegationsRequest{}
+	if err := contract.StaticCallMethod(ctx, dposAddr, "ListAllDelegations", req, &resp); err != nil {
+		return nil, err
+	}
+	stakes := &delegatedStakes{
+		delegators: map[string]*loom.BigUInt{},
+		total:      common.BigZero(),
+	}
+	for _, candidateDelegations := range resp.ListResponses {
+		for _, delegation := range candidateDelegations.Delegations {
+			if delegation.Delegator == nil || delegation.Amount == nil {
+				continue
+			}
+			delegator := loom.UnmarshalAddressPB(delegation.Delegator).String()
+			if _, ok := stakes.delegators[delegator]; !ok {
+				stakes.delegators[delegator] = common.BigZero()
+			}
+			stakes.delegators[delegator].Add(stakes.delegators[delegator], &delegation.Amount.Value)
+			stakes.total.Add(stakes.total, &delegation.Amount.Value)
+		}
+	}
+	return stakes, nil
+}
+
+func loadState(ctx contract.StaticContext) (*State, error) {
+	var state State
+	if err := ctx.Get(stateKey, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+func saveState(ctx contract.Context, state *State) error {
+	return ctx.Set(stateKey, state)
+}
+
+func loadProposal(ctx contract.StaticContext, id uint64) (*Proposal, error) {
+	var proposal Proposal
+	if err := ctx.Get(proposalKey(id), &proposal); err != nil {
+		if err == contract.ErrNotFound {
+			return nil, ErrProposalNotFound
+		}
+		return nil, err
+	}
+	return &proposal, nil
+}
+
+func loadVotes(ctx contract.StaticContext, proposalID uint64) ([]*Vote, error) {
+	votes := []*Vote{}
+	for _, entry := range ctx.Range(proposalVotesPrefix(proposalID)) {
+		var vote Vote
+		if err := proto.Unmarshal(entry.Value, &vote); err != nil {
+			return nil, errors.Wrapf(err, "unmarshal vote %x", entry.Key)
+		}
+		votes = append(votes, &vote)
+	}
+	return votes, nil
+}
+
+var Contract plugin.Contract = contract.MakePluginContract(&Governance{})
@@ -0,0 +1,283 @@
+package governance
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	loom "github.com/loomnetwork/go-loom"
+	dtypes "github.com/loomnetwork/go-loom/builtin/types/dposv3"
+	"github.com/loomnetwork/go-loom/plugin"
+	"github.com/loomnetwork/go-loom/plugin/contractpb"
+	"github.com/loomnetwork/go-loom/types"
+	"github.com/loomnetwork/loomchain/builtin/plugins/coin"
+	"github.com/loomnetwork/loomchain/builtin/plugins/dposv3"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	chainID = "default"
+
+	validatorPubKey, _ = hex.DecodeString("3866f776276246e4f9998aa90632931d89b0d3a5930e804e02299533f55b39e1")
+	validatorAddr      = loom.Address{
+		ChainID: chainID,
+		Local:   loom.LocalAddressFromPublicKey(validatorPubKey),
+	}
+	owner     = loom.MustParseAddress("default:0xb16a379ec18d4093666f8f38b11a3071c920207d")
+	delegator = loom.MustParseAddress("default:0xfa4c7920accfd66b86f5fd0e69682a79f762d49e")
+	nobody    = loom.MustParseAddress("default:0x5cecd1f7261e1f4c684e297be3edf03b825e01c4")
+)
+
+type testEnv struct {
+	pctx     *plugin.FakeContext
+	govAddr  loom.Address
+	dposAddr loom.Address
+	coinAddr loom.Address
+}
+
+// Deploys the Coin, DPOSv3 & Governance contracts, the DPOS oracle is set to the Governance
+// contract, and the validator & delegator have delegated 100 & 50 tokens respectively.
+func setupTestEnv(t *testing.T) *testEnv {
+	pctx := plugin.CreateFakeContext(owner, owner).WithBlock(loom.BlockHeader{
+		ChainID: chainID,
+		Height:  1,
+		Time:    100000,
+	})
+
+	govAddr := pctx.CreateContract(Contract)
+	require.NoError(t, (&Governance{}).Init(
+		contractpb.WrapPluginContext(pctx.WithAddress(govAddr)),
+		&InitRequest{
+			Owner: owner.MarshalPB(),
+			Params: &Params{
+				VotingPeriod:     10,
+				MinProposerStake: &types.BigUInt{Value: *loom.NewBigUIntFromInt(1)},
+			},
+		},
+	))
+
+	coinAddr := pctx.CreateContract(coin.Contract)
+	coinCtx := pctx.WithAddress(coinAddr)
+	require.NoError(t, (&coin.Coin{}).Init(contractpb.WrapPluginContext(coinCtx), &coin.InitRequest{
+		Accounts: []*coin.InitialAccount{
+			{Owner: validatorAddr.MarshalPB(), Balance: 1000},
+			{Owner: delegator.MarshalPB(), Balance: 1000},
+			{Owner: govAddr.MarshalPB(), Balance: 1000},
+		},
+	}))
+
+	dposAddr := pctx.CreateContract(dposv3.Contract)
+	dposContract := &dposv3.DPOS{}
+	registrationRequirement := &types.BigUInt{Value: *loom.NewBigUIntFromInt(100)}
+	require.NoError(t, dposContract.Init(contractpb.WrapPluginContext(pctx.WithAddress(dposAddr)), &dposv3.InitRequest{
+		Params: &dposv3.Params{
+			ValidatorCount:          21,
+			RegistrationRequirement: registrationRequirement,
+			OracleAddress:           govAddr.MarshalPB(),
+		},
+	}))
+
+	require.NoError(t, (&coin.Coin{}).Approve(contractpb.WrapPluginContext(coinCtx.WithSender(validatorAddr)), &coin.ApproveRequest{
+		Spender: dposAddr.MarshalPB(),
+		Amount:  registrationRequirement,
+	}))
+	require.NoError(t, dposContract.RegisterCandidate(
+		contractpb.WrapPluginContext(pctx.WithAddress(dposAddr).WithSender(validatorAddr)),
+		&dposv3.RegisterCandidateRequest{PubKey: validatorPubKey},
+	))
+
+	delegationAmount := &types.BigUInt{Value: *loom.NewBigUIntFromInt(50)}
+	require.NoError(t, (&coin.Coin{}).Approve(contractpb.WrapPluginContext(coinCtx.WithSender(delegator)), &coin.ApproveRequest{
+		Spender: dposAddr.MarshalPB(),
+		Amount:  delegationAmount,
+	}))
+	require.NoError(t, dposContract.Delegate(
+		contractpb.WrapPluginContext(pctx.WithAddress(dposAddr).WithSender(delegator)),
+		&dposv3.DelegateRequest{ValidatorAddress: validatorAddr.MarshalPB(), Amount: delegationAmount},
+	))
+	require.NoError(t, dposv3.Elect(contractpb.WrapPluginContext(pctx.WithAddress(dposAddr))))
+
+	return &testEnv{
+		pctx:     pctx,
+		govAddr:  govAddr,
+		dposAddr: dposAddr,
+		coinAddr: coinAddr,
+	}
+}
+
+func (e *testEnv) govCtx(sender loom.Address, height int64) contractpb.Context {
+	return contractpb.WrapPluginContext(e.pctx.WithAddress(e.govAddr).WithSender(sender).WithBlock(loom.BlockHeader{
+		ChainID: chainID,
+		Height:  height,
+		Time:    100000,
+	}))
+}
+
+func (e *testEnv) processProposals(height int64) ([]*Proposal, error) {
+	ctx := e.govCtx(owner, height)
+	return ProcessProposals(ctx, uint64(height), func(proposal *Proposal) error {
+		return ExecuteProposal(ctx, proposal)
+	})
+}
+
+func TestParamChangeProposal(t *testing.T) {
+	env := setupTestEnv(t)
+	gov := &Governance{}
+
+	args, err := proto.Marshal(&dtypes.SetValidatorCountRequest{ValidatorCount: 5})
+	require.NoError(t, err)
+	req := &SubmitProposalRequest{
+		Kind: ParamChangeProposal,
+		ParamChange: &ParamChange{
+			ContractName: "dposV3",
+			Method:       "SetValidatorCount",
+			Args:         args,
+		},
+	}
+
+	// only accounts with stake can submit proposals & vote
+	_, err = gov.SubmitProposal(env.govCtx(nobody, 1), req)
+	require.Equal(t, ErrNotEnoughStake, err)
+
+	resp, err := gov.SubmitProposal(env.govCtx(validatorAddr, 1), req)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), resp.ProposalId)
+
+	require.NoError(t, gov.Vote(env.govCtx(validatorAddr, 2), &VoteRequest{ProposalId: 1, Choice: VoteYes}))
+	require.NoError(t, gov.Vote(env.govCtx(delegator, 2), &VoteRequest{ProposalId: 1, Choice: VoteNo}))
+	require.Equal(t, ErrNotEnoughStake, gov.Vote(env.govCtx(nobody, 2), &VoteRequest{ProposalId: 1, Choice: VoteYes}))
+
+	// voting period hasn't ended yet
+	processed, err := env.processProposals(10)
+	require.NoError(t, err)
+	require.Len(t, processed, 0)
+
+	require.Equal(t, ErrVotingClosed, gov.Vote(env.govCtx(delegator, 11), &VoteRequest{ProposalId: 1, Choice: VoteYes}))
+
+	processed, err = env.processProposals(11)
+	require.NoError(t, err)
+	require.Len(t, processed, 1)
+	require.Equal(t, ProposalExecuted, processed[0].Status)
+	require.Equal(t, int64(100), processed[0].YesPower.Value.Int64())
+	require.Equal(t, int64(50), processed[0].NoPower.Value.Int64())
+
+	state, err := dposv3.LoadState(contractpb.WrapPluginContext(env.pctx.WithAddress(env.dposAddr)))
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), state.Params.ValidatorCount)
+
+	// processed proposals are no longer active
+	processed, err = env.processProposals(12)
+	require.NoError(t, err)
+	require.Len(t, processed, 0)
+}
+
+func TestRejectedProposals(t *testing.T) {
+	env := setupTestEnv(t)
+	gov := &Governance{}
+
+	_, err := gov.SubmitProposal(env.govCtx(delegator, 1), &SubmitProposalRequest{
+		Kind:        EnableFeatureProposal,
+		FeatureName: "test:feature",
+	})
+	require.NoError(t, err)
+	_, err = gov.SubmitProposal(env.govCtx(delegator, 1), &SubmitProposalRequest{
+		Kind: CommunityPoolSpendProposal,
+		PoolSpend: &CommunityPoolSpend{
+			Recipient: nobody.MarshalPB(),
+			Amount:    &types.BigUInt{Value: *loom.NewBigUIntFromInt(500)},
+		},
+	})
+	require.NoError(t, err)
+
+	// 50 out of 150 delegated tokens doesn't reach the default quorum of 33.4%
+	require.NoError(t, gov.Vote(env.govCtx(delegator, 2), &VoteRequest{ProposalId: 1, Choice: VoteYes}))
+	// the pool spend is voted down
+	require.NoError(t, gov.Vote(env.govCtx(delegator, 2), &VoteRequest{ProposalId: 2, Choice: VoteYes}))
+	require.NoError(t, gov.Vote(env.govCtx(validatorAddr, 2), &VoteRequest{ProposalId: 2, Choice: VoteNo}))
+
+	processed, err := env.processProposals(11)
+	require.NoError(t, err)
+	require.Len(t, processed, 2)
+	require.Equal(t, ProposalRejected, processed[0].Status)
+	require.Equal(t, ProposalRejected, processed[1].Status)
+
+	balance, err := coin.BalanceOfAddress(contractpb.WrapPluginContext(env.pctx.WithAddress(env.coinAddr)), nobody)
+	require.NoError(t, err)
+	require.True(t, balance.Int.Sign() == 0)
+
+	resp, err := gov.ListProposals(env.govCtx(owner, 12), &ListProposalsRequest{})
+	require.NoError(t, err)
+	require.Len(t, resp.Proposals, 2)
+}
+
+func TestFailedProposals(t *testing.T) {
+	env := setupTestEnv(t)
+	gov := &Governance{}
+
+	_, err := gov.SubmitProposal(env.govCtx(validatorAddr, 1), &SubmitProposalRequest{
+		Kind:        EnableFeatureProposal,
+		FeatureName: "test:feature",
+	})
+	require.NoError(t, err)
+	require.NoError(t, gov.Vote(env.govCtx(validatorAddr, 2), &VoteRequest{ProposalId: 1, Choice: VoteYes}))
+
+	// a proposal that can't be executed is marked as failed instead of aborting the processing
+	processed, err := ProcessProposals(env.govCtx(owner, 11), 11, func(proposal *Proposal) error {
+		return errors.New("execution failed")
+	})
+	require.NoError(t, err)
+	require.Len(t, processed, 1)
+	require.Equal(t, ProposalFailed, processed[0].Status)
+	require.Equal(t, "execution failed", processed[0].ExecutionError)
+
+	// failed proposals are no longer active
+	processed, err = env.processProposals(12)
+	require.NoError(t, err)
+	require.Len(t, processed, 0)
+}
+
+func TestDefaultMinProposerStake(t *testing.T) {
+	env := setupTestEnv(t)
+	gov := &Governance{}
+
+	require.NoError(t, gov.SetParams(env.govCtx(owner, 1), &SetParamsRequest{Params: &Params{VotingPeriod: 10}}))
+	resp, err := gov.GetParams(env.govCtx(owner, 1), &GetParamsRequest{})
+	require.NoError(t, err)
+	require.Equal(t, "10000000000000000000000", resp.Params.MinProposerStake.Value.String())
+
+	// neither the validator nor the delegator has staked enough to submit a proposal
+	_, err = gov.SubmitProposal(env.govCtx(validatorAddr, 1), &SubmitProposalRequest{
+		Kind:        EnableFeatureProposal,
+		FeatureName: "test:feature",
+	})
+	require.Equal(t, ErrNotEnoughStake, err)
+}
+
+func TestProposalsProcessedWhenVotingEnds(t *testing.T) {
+	env := setupTestEnv(t)
+	gov := &Governance{}
+
+	for _, height := range []int64{5, 1, 3} {
+		_, err := gov.SubmitProposal(env.govCtx(delegator, height), &SubmitProposalRequest{
+			Kind:        EnableFeatureProposal,
+			FeatureName: "test:feature",
+		})
+		require.NoError(t, err)
+	}
+
+	processed, err := env.processProposals(11)
+	require.NoError(t, err)
+	require.Len(t, processed, 1)
+	require.Equal(t, uint64(2), processed[0].Id)
+
+	processed, err = env.processProposals(15)
+	require.NoError(t, err)
+	require.Len(t, processed, 2)
+	require.Equal(t, uint64(1), processed[0].Id)
+	require.Equal(t, uint64(3), processed[1].Id)
+
+	processed, err = env.processProposals(16)
+	require.NoError(t, err)
+	require.Len(t, processed, 0)
+}
@@ -0,0 +1,274 @@
+package ratelimit
+
+import (
+	"encoding/binary"
+
+	"github.com/gogo/protobuf/proto"
+	loom "github.com/loomnetwork/go-loom"
+	"github.com/loomnetwork/go-loom/plugin"
+	contract "github.com/loomnetwork/go-loom/plugin/contractpb"
+	"github.com/loomnetwork/go-loom/util"
+	"github.com/pkg/errors"
+)
+
+type (
+	Policy               = RateLimitPolicy
+	InitRequest          = RateLimitInitRequest
+	SetPolicyRequest     = RateLimitSetPolicyRequest
+	SetPolicyResponse    = RateLimitSetPolicyResponse
+	RemovePolicyRequest  = RateLimitRemovePolicyRequest
+	ListPoliciesRequest  = RateLimitListPoliciesRequest
+	ListPoliciesResponse = RateLimitListPoliciesResponse
+	Counter              = RateLimitCounter
+	LastPolicyID         = RateLimitLastPolicyID
+)
+
+var (
+	// ErrNotAuthorized indicates that a contract method failed because the caller didn't have
+	// the permission to execute that method.
+	ErrNotAuthorized = errors.New("[RateLimit] not authorized")
+	// ErrInvalidRequest is a generic error that's returned when something is wrong with the
+	// request message, e.g. missing or invalid fields.
+	ErrInvalidRequest = errors.New("[RateLimit] invalid request")
+	// ErrOwnerNotSpecified returned if init request does not have owner address
+	ErrOwnerNotSpecified = errors.New("[RateLimit] owner not specified")
+	// ErrPolicyNotFound is returned when a policy with the given ID doesn't exist
+	ErrPolicyNotFound = errors.New("[RateLimit] policy not found")
+	// ErrRateLimitExceeded is returned when a tx would exceed the limit set by a policy
+	ErrRateLimitExceeded = errors.New("[RateLimit] rate limit exceeded, try again later")
+)
+
+const (
+	ownerRole = "owner"
+)
+
+var (
+	modifyPerm = []byte("modp")
+
+	policyPrefix  = []byte("pol")
+	counterPrefix = []byte("ctr")
+	windowPrefix  = []byte("wnd")
+	lastIDKey     = []byte("lastid")
+)
+
+func policyKey(id uint64) []byte {
+	return util.PrefixKey(policyPrefix, uint64ToBytes(id))
+}
+
+func counterKey(policy *Policy, sender loom.Address) []byte {
+	if policy.PerAccount {
+		return util.PrefixKey(counterPrefix, uint64ToBytes(policy.Id), sender.Bytes())
+	}
+	return util.PrefixKey(counterPrefix, uint64ToBytes(policy.Id))
+}
+
+// windowKey is the key of the window the counters of a policy currently belong to.
+func windowKey(policyID uint64) []byte {
+	return util.PrefixKey(windowPrefix, uint64ToBytes(policyID))
+}
+
+func uint64ToBytes(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, v)
+	return b
+}
+
+type RateLimit struct {
+}
+
+func (rl *RateLimit) Meta() (plugin.Meta, error) {
+	return plugin.Meta{
+		Name:    "ratelimit",
+		Version: "1.0.0",
+	}, nil
+}
+
+func (rl *RateLimit) Init(ctx contract.Context, req *InitRequest) error {
+	if req.Owner == nil {
+		return ErrOwnerNotSpecified
+	}
+	ownerAddr := loom.UnmarshalAddressPB(req.Owner)
+	ctx.GrantPermissionTo(ownerAddr, modifyPerm, ownerRole)
+
+	for _, policy := range req.Policies {
+		if _, err := setPolicy(ctx, policy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetPolicy creates a new policy, or replaces an existing one, only the contract owner can
+// call this method.
+func (rl *RateLimit) SetPolicy(ctx contract.Context, req *SetPolicyRequest) (*SetPolicyResponse, error) {
+	if ok, _ := ctx.HasPermission(modifyPerm, []string{ownerRole}); !ok {
+		return nil, ErrNotAuthorized
+	}
+	if req.Policy == nil {
+		return nil, ErrInvalidRequest
+	}
+	if req.Policy.Id != 0 && !ctx.Has(policyKey(req.Policy.Id)) {
+		return nil, ErrPolicyNotFound
+	}
+
+	id, err := setPolicy(ctx, req.Policy)
+	if err != nil {
+		return nil, err
+	}
+	return &SetPolicyResponse{Id: id}, nil
+}
+
+// RemovePolicy removes an existing policy, only the contract owner can call this method.
+func (rl *RateLimit) RemovePolicy(ctx contract.Context, req *RemovePolicyRequest) error {
+	if ok, _ := ctx.HasPermission(modifyPerm, []string{ownerRole}); !ok {
+		return ErrNotAuthorized
+	}
+	if !ctx.Has(policyKey(req.Id)) {
+		return ErrPolicyNotFound
+	}
+	ctx.Delete(policyKey(req.Id))
+	deleteCounters(ctx, req.Id)
+	return nil
+}
+
+func (rl *RateLimit) ListPolicies(
+	ctx contract.StaticContext, req *ListPoliciesRequest,
+) (*ListPoliciesResponse, error) {
+	policies, err := ListPolicies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &ListPoliciesResponse{Policies: policies}, nil
+}
+
+func setPolicy(ctx contract.Context, policy *Policy) (uint64, error) {
+	if policy.MaxTxs == 0 || policy.BlockWindow == 0 {
+		return 0, ErrInvalidRequest
+	}
+
+	if policy.Id == 0 {
+		var lastID LastPolicyID
+		if err := ctx.Get(lastIDKey, &lastID); err != nil && err != contract.ErrNotFound {
+			return 0, err
+		}
+		lastID.Id++
+		if err := ctx.Set(lastIDKey, &lastID); err != nil {
+			return 0, err
+		}
+		policy.Id = lastID.Id
+	} else {
+		// Changing the policy invalidates any counters associated with it
+		deleteCounters(ctx, policy.Id)
+	}
+
+	if err := ctx.Set(policyKey(policy.Id), policy); err != nil {
+		return 0, err
+	}
+	return policy.Id, nil
+}
+
+func deleteCounters(ctx contract.Context, policyID uint64) {
+	sharedCounterKey := util.PrefixKey(counterPrefix, uint64ToBytes(policyID))
+	for _, entry := range ctx.Range(sharedCounterKey) {
+		ctx.Delete(util.PrefixKey(sharedCounterKey, entry.Key))
+	}
+	ctx.Delete(sharedCounterKey)
+	ctx.Delete(windowKey(policyID))
+}
+
+// startWindow deletes the counters of the given policy if they belong to a window that precedes
+// the given one, otherwise per-account counters would accumulate for every account that ever sent
+// a tx matching the policy.
+func startWindow(ctx contract.Context, policy *Policy, window uint64) error {
+	var cur Counter
+	err := ctx.Get(windowKey(policy.Id), &cur)
+	if err == nil && cur.Window == window {
+		return nil
+	}
+	if err != nil && err != contract.ErrNotFound {
+		return err
+	}
+	deleteCounters(ctx, policy.Id)
+	return ctx.Set(windowKey(policy.Id), &Counter{Window: window})
+}
+
+// ListPolicies returns all the rate-limit policies currently in effect.
+func ListPolicies(ctx contract.StaticContext) ([]*Policy, error) {
+	policies := []*Policy{}
+	for _, entry := range ctx.Range(policyPrefix) {
+		var policy Policy
+		if err := proto.Unmarshal(entry.Value, &policy); err != nil {
+			return nil, errors.Wrapf(err, "unmarshal policy %x", entry.Key)
+		}
+		policies = append(policies, &policy)
+	}
+	return policies, nil
+}
+
+// PolicyMatches checks if the given policy applies to a tx from the sender to the given contract
+// & method. The contract address should be nil for txs that don't target an existing contract.
+func PolicyMatches(policy *Policy, sender loom.Address, contractAddr *loom.Address, method string) bool {
+	if policy.Account != nil && loom.UnmarshalAddressPB(policy.Account).Compare(sender) != 0 {
+		return false
+	}
+	if policy.Contract != nil {
+		if contractAddr == nil || loom.UnmarshalAddressPB(policy.Contract).Compare(*contractAddr) != 0 {
+			return false
+		}
+	}
+	if policy.Method != "" && policy.Method != method {
+		return false
+	}
+	return true
+}
+
+// RecordTx counts a tx against every policy that applies to it, and returns ErrRateLimitExceeded
+// if the tx would exceed the limit of any of those policies, in which case none of the counters
+// are modified. Windows are based on block height so every node reaches the same decision. The
+// counters of a policy are deleted when the first tx of a new window is counted against it.
+func RecordTx(
+	ctx contract.Context, sender loom.Address, contractAddr *loom.Address, method string, height int64,
+) error {
+	policies, err := ListPolicies(ctx)
+	if err != nil {
+		return err
+	}
+
+	var matched []*Policy
+	var keys [][]byte
+	var counters []*Counter
+	for _, policy := range policies {
+		if !PolicyMatches(policy, sender, contractAddr, method) {
+			continue
+		}
+
+		window := uint64(height) / policy.BlockWindow
+		key := counterKey(policy, sender)
+		var counter Counter
+		if err := ctx.Get(key, &counter); err != nil && err != contract.ErrNotFound {
+			return err
+		}
+		if counter.Window != window {
+			counter = Counter{Window: window}
+		}
+		if counter.Count >= policy.MaxTxs {
+			return errors.Wrapf(ErrRateLimitExceeded, "policy %d", policy.Id)
+		}
+		counter.Count++
+		matched = append(matched, policy)
+		keys = append(keys, key)
+		counters = append(counters, &counter)
+	}
+
+	for i := range keys {
+		if err := startWindow(ctx, matched[i], counters[i].Window); err != nil {
+			return err
+		}
+		if err := ctx.Set(keys[i], counters[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var Contract plugin.Contract = contract.MakePluginContract(&RateLimit{})
@@ -0,0 +1,95 @@
+package ratelimit
+
+import (
+	"testing"
+
+	loom "github.com/loomnetwork/go-loom"
+	"github.com/loomnetwork/go-loom/plugin"
+	"github.com/loomnetwork/go-loom/plugin/contractpb"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	owner     = loom.MustParseAddress("default:0xb16a379ec18d4093666f8f38b11a3071c920207d")
+	addr1     = loom.MustParseAddress("default:0xfa4c7920accfd66b86f5fd0e69682a79f762d49e")
+	addr2     = loom.MustParseAddress("default:0x5cecd1f7261e1f4c684e297be3edf03b825e01c4")
+	contract1 = loom.MustParseAddress("default:0x46ecd1f7261e1f4c684e297be3edf03b825e01c4")
+)
+
+func TestPolicyManagement(t *testing.T) {
+	pctx := plugin.CreateFakeContext(owner, owner)
+	ctx := contractpb.WrapPluginContext(pctx)
+
+	rl := &RateLimit{}
+	require.NoError(t, rl.Init(ctx, &InitRequest{
+		Owner: owner.MarshalPB(),
+		Policies: []*Policy{
+			{MaxTxs: 5, BlockWindow: 10},
+		},
+	}))
+
+	resp, err := rl.SetPolicy(ctx, &SetPolicyRequest{
+		Policy: &Policy{Contract: contract1.MarshalPB(), MaxTxs: 1, BlockWindow: 10},
+	})
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), resp.Id)
+
+	_, err = rl.SetPolicy(ctx, &SetPolicyRequest{Policy: &Policy{MaxTxs: 0, BlockWindow: 10}})
+	require.Equal(t, ErrInvalidRequest, err)
+	_, err = rl.SetPolicy(ctx, &SetPolicyRequest{Policy: &Policy{Id: 5, MaxTxs: 1, BlockWindow: 10}})
+	require.Equal(t, ErrPolicyNotFound, err)
+
+	// only the owner can change policies
+	ctx = contractpb.WrapPluginContext(pctx.WithSender(addr1))
+	_, err = rl.SetPolicy(ctx, &SetPolicyRequest{Policy: &Policy{MaxTxs: 1, BlockWindow: 10}})
+	require.Equal(t, ErrNotAuthorized, err)
+	require.Equal(t, ErrNotAuthorized, rl.RemovePolicy(ctx, &RemovePolicyRequest{Id: 1}))
+
+	ctx = contractpb.WrapPluginContext(pctx.WithSender(owner))
+	require.NoError(t, rl.RemovePolicy(ctx, &RemovePolicyRequest{Id: 1}))
+	require.Equal(t, ErrPolicyNotFound, rl.RemovePolicy(ctx, &RemovePolicyRequest{Id: 1}))
+
+	list, err := rl.ListPolicies(ctx, &ListPoliciesRequest{})
+	require.NoError(t, err)
+	require.Len(t, list.Policies, 1)
+	require.Equal(t, uint64(2), list.Policies[0].Id)
+}
+
+func TestRecordTx(t *testing.T) {
+	pctx := plugin.CreateFakeContext(owner, owner)
+	ctx := contractpb.WrapPluginContext(pctx)
+
+	rl := &RateLimit{}
+	require.NoError(t, rl.Init(ctx, &InitRequest{
+		Owner: owner.MarshalPB(),
+		Policies: []*Policy{
+			// 2 transfers per account every 10 blocks
+			{Contract: contract1.MarshalPB(), Method: "Transfer", MaxTxs: 2, BlockWindow: 10, PerAccount: true},
+			// 3 txs to the contract every 10 blocks
+			{Contract: contract1.MarshalPB(), MaxTxs: 3, BlockWindow: 10},
+		},
+	}))
+
+	require.NoError(t, RecordTx(ctx, addr1, &contract1, "Transfer", 10))
+	require.NoError(t, RecordTx(ctx, addr1, &contract1, "Transfer", 11))
+	err := RecordTx(ctx, addr1, &contract1, "Transfer", 12)
+	require.Equal(t, ErrRateLimitExceeded, errors.Cause(err))
+	require.NoError(t, RecordTx(ctx, addr2, &contract1, "Transfer", 12))
+	// shared limit has been reached
+	err = RecordTx(ctx, addr2, &contract1, "Approve", 13)
+	require.Equal(t, ErrRateLimitExceeded, errors.Cause(err))
+	// txs that don't match any policies aren't limited
+	require.NoError(t, RecordTx(ctx, addr2, nil, "", 13))
+
+	transferPolicy := &Policy{Id: 1, PerAccount: true}
+	require.True(t, ctx.Has(counterKey(transferPolicy, addr2)))
+
+	// limits are reset in the next window, and the counters of the previous window are deleted
+	require.NoError(t, RecordTx(ctx, addr1, &contract1, "Transfer", 20))
+	require.False(t, ctx.Has(counterKey(transferPolicy, addr2)))
+	require.NoError(t, RecordTx(ctx, addr1, &contract1, "Transfer", 21))
+	require.NoError(t, RecordTx(ctx, addr2, &contract1, "Approve", 22))
+	err = RecordTx(ctx, addr2, &contract1, "Approve", 23)
+	require.Equal(t, ErrRateLimitExceeded, errors.Cause(err))
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/loomnetwork/go-loom/auth"
 	cctypes "github.com/loomnetwork/go-loom/builtin/types/chainconfig"
 	"github.com/loomnetwork/go-loom/client"
+	ccplugin "github.com/loomnetwork/loomchain/builtin/plugins/chainconfig"
 	"github.com/pkg/errors"
 )
 
@@ -20,6 +21,8 @@ type (
 	SetValidatorInfo         = cctypes.SetValidatorInfoRequest
 	GetValidatorInfoRequest  = cctypes.GetValidatorInfoRequest
 	GetValidatorInfoResponse = cctypes.GetValidatorInfoResponse
+	ListFnRecordsRequest     = ccplugin.ListFnRecordsRequest
+	ListFnRecordsResponse    = ccplugin.ListFnRecordsResponse
 )
 
 const (
@@ -145,3 +148,18 @@ func (cc *ChainConfigClient) GetValidatorInfo() (*GetValidatorInfoResponse, erro
 	}
 	return &resp, nil
 }
+
+// ListFnRecords returns the records of the Fns that should be registered with the fnConsensus reactor.
+func (cc *ChainConfigClient) ListFnRecords() (*ListFnRecordsResponse, error) {
+	var resp ListFnRecordsResponse
+	if _, err := cc.contract.StaticCall(
+		"ListFnRecords",
+		&ListFnRecordsRequest{},
+		cc.caller,
+		&resp,
+	); err != nil {
+		cc.logger.Error("Failed to retrieve Fn records from ChainConfig contract", "err", err)
+		return nil, err
+	}
+	return &resp, nil
+}
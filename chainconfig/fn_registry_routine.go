@@ -0,0 +1,105 @@
+package chainconfig
+
+import (
+	"runtime"
+	"time"
+
+	goloom "github.com/loomnetwork/go-loom"
+	"github.com/loomnetwork/go-loom/auth"
+	"github.com/loomnetwork/go-loom/client"
+	"github.com/loomnetwork/loomchain/config"
+	"github.com/loomnetwork/loomchain/fnConsensus"
+)
+
+// FnRegistryRoutine loads the Fn records from the ChainConfig contract every time a block is
+// committed, and syncs the dynamic fnConsensus registry with them, so that Fns are registered &
+// unregistered at the block height at which their records take effect, without having to restart
+// the node.
+type FnRegistryRoutine struct {
+	cfg          *config.ChainConfigConfig
+	chainID      string
+	address      goloom.Address
+	signer       auth.Signer
+	logger       *goloom.Logger
+	registry     *fnConsensus.DynamicFnRegistry
+	blockCommits <-chan int64
+}
+
+// NewFnRegistryRoutine returns a new instance of FnRegistryRoutine
+func NewFnRegistryRoutine(
+	cfg *config.ChainConfigConfig,
+	chainID string,
+	nodeSigner auth.Signer,
+	registry *fnConsensus.DynamicFnRegistry,
+	blockCommits <-chan int64,
+	logger *goloom.Logger,
+) *FnRegistryRoutine {
+	return &FnRegistryRoutine{
+		cfg:     cfg,
+		chainID: chainID,
+		address: goloom.Address{
+			ChainID: chainID,
+			Local:   goloom.LocalAddressFromPublicKey(nodeSigner.PublicKey()),
+		},
+		signer:       nodeSigner,
+		logger:       logger,
+		registry:     registry,
+		blockCommits: blockCommits,
+	}
+}
+
+// RunWithRecovery should be run as a go-routine, it will auto-restart on panic unless it hits
+// a runtime error.
+func (r *FnRegistryRoutine) RunWithRecovery() {
+	defer func() {
+		if rec := recover(); rec != nil {
+			r.logger.Error("recovered from panic in FnRegistryRoutine", "r", rec)
+			// Unless it's a runtime error restart the goroutine
+			if _, ok := rec.(runtime.Error); !ok {
+				time.Sleep(30 * time.Second)
+				r.logger.Info("Restarting FnRegistryRoutine.")
+				go r.RunWithRecovery()
+			}
+		}
+	}()
+
+	r.run()
+}
+
+// Syncs the registry whenever the height of a newly committed block is received, the height itself
+// isn't used since the records are loaded from the last committed block, which may be more recent.
+func (r *FnRegistryRoutine) run() {
+	for range r.blockCommits {
+		if err := r.sync(); err != nil {
+			r.logger.Error("Failed to sync Fn registry", "err", err)
+		}
+	}
+}
+
+// Loads the Fn records from the ChainConfig contract and syncs the registry with them, the records
+// are queried from the last committed block so the registry only changes at committed heights.
+func (r *FnRegistryRoutine) sync() error {
+	dappClient := client.NewDAppChainRPCClient(r.chainID, r.cfg.DAppChainWriteURI, r.cfg.DAppChainReadURI)
+	chainConfigClient, err := NewChainConfigClient(dappClient, r.address, r.signer, r.logger)
+	if err != nil {
+		return err
+	}
+
+	resp, err := chainConfigClient.ListFnRecords()
+	if err != nil {
+		return err
+	}
+
+	records := make([]*fnConsensus.FnRecord, 0, len(resp.Records))
+	for _, record := range resp.Records {
+		records = append(records, &fnConsensus.FnRecord{
+			FnID:             record.FnId,
+			Factory:          record.Factory,
+			Params:           record.Params,
+			ActivationHeight: record.ActivationHeight,
+			Removed:          record.Removed,
+		})
+	}
+
+	return r.registry.Sync(int64(resp.BlockHeight), records)
+}
@@ -0,0 +1,303 @@
+package accesscontrol
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/loomnetwork/go-loom"
+	"github.com/loomnetwork/go-loom/cli"
+	"github.com/loomnetwork/go-loom/types"
+	ac "github.com/loomnetwork/loomchain/builtin/plugins/access_control"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	acContractName = "accesscontrol"
+)
+
+type deniedAccountInfo struct {
+	Account   string
+	Contracts []string `json:",omitempty"`
+}
+
+type accessListInfo struct {
+	AllowlistOnly   bool
+	AllowedAccounts []string
+	DeniedAccounts  []*deniedAccountInfo
+}
+
+type accountAccessInfo struct {
+	Allowed bool
+	Denied  *deniedAccountInfo `json:",omitempty"`
+}
+
+func NewAccessControlCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "access-control <command>",
+		Short: "Account Access Control CLI",
+	}
+
+	cmd.AddCommand(
+		allowAccountCmd(),
+		disallowAccountCmd(),
+		denyAccountCmd(),
+		undenyAccountCmd(),
+		setAllowlistOnlyCmd(),
+		getAccountAccessCmd(),
+		listAccessCmd(),
+	)
+	return cmd
+}
+
+const allowAccountCmdExample = `
+loom access-control allow 0x7262d4c97c7B93937E4810D289b7320e9dA82857
+`
+
+func allowAccountCmd() *cobra.Command {
+	var flags cli.ContractCallFlags
+	cmd := &cobra.Command{
+		Use:     "allow <account address>",
+		Short:   "Add an account to the allow list",
+		Example: allowAccountCmdExample,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			addr, err := cli.ParseAddress(args[0], flags.ChainID)
+			if err != nil {
+				return err
+			}
+
+			cmd.SilenceUsage = true
+
+			req := &ac.AllowAccountRequest{Account: addr.MarshalPB()}
+			return cli.CallContractWithFlags(&flags, acContractName, "AllowAccount", req, nil)
+		},
+	}
+	cli.AddContractCallFlags(cmd.Flags(), &flags)
+	return cmd
+}
+
+const disallowAccountCmdExample = `
+loom access-control disallow 0x7262d4c97c7B93937E4810D289b7320e9dA82857
+`
+
+func disallowAccountCmd() *cobra.Command {
+	var flags cli.ContractCallFlags
+	cmd := &cobra.Command{
+		Use:     "disallow <account address>",
+		Short:   "Remove an account from the allow list",
+		Example: disallowAccountCmdExample,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			addr, err := cli.ParseAddress(args[0], flags.ChainID)
+			if err != nil {
+				return err
+			}
+
+			cmd.SilenceUsage = true
+
+			req := &ac.DisallowAccountRequest{Account: addr.MarshalPB()}
+			return cli.CallContractWithFlags(&flags, acContractName, "DisallowAccount", req, nil)
+		},
+	}
+	cli.AddContractCallFlags(cmd.Flags(), &flags)
+	return cmd
+}
+
+const denyAccountCmdExample = `
+# Deny all txs from an account
+loom access-control deny 0x7262d4c97c7B93937E4810D289b7320e9dA82857
+
+# Only deny calls to specific contracts
+loom access-control deny 0x7262d4c97c7B93937E4810D289b7320e9dA82857 \
+  --contracts 0x2Ff9aB3aBDbC79efD22e3AC79787d8f9B5F99B40,0x40352F3aeD8E7281795dEf0CA7581F41DbE41F5B
+`
+
+func denyAccountCmd() *cobra.Command {
+	var flags cli.ContractCallFlags
+	var contracts []string
+	cmd := &cobra.Command{
+		Use:     "deny <account address>",
+		Short:   "Add an account to the deny list",
+		Example: denyAccountCmdExample,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			addr, err := cli.ParseAddress(args[0], flags.ChainID)
+			if err != nil {
+				return err
+			}
+
+			req := &ac.DenyAccountRequest{Account: addr.MarshalPB()}
+			for _, c := range contracts {
+				contractAddr, err := cli.ParseAddress(c, flags.ChainID)
+				if err != nil {
+					return errors.Wrapf(err, "invalid contract address %s", c)
+				}
+				req.Contracts = append(req.Contracts, contractAddr.MarshalPB())
+			}
+
+			cmd.SilenceUsage = true
+
+			return cli.CallContractWithFlags(&flags, acContractName, "DenyAccount", req, nil)
+		},
+	}
+	cmdFlags := cmd.Flags()
+	cmdFlags.StringSliceVar(
+		&contracts, "contracts", nil, "Only deny calls to these contracts (comma separated list)",
+	)
+	cli.AddContractCallFlags(cmdFlags, &flags)
+	return cmd
+}
+
+const undenyAccountCmdExample = `
+loom access-control undeny 0x7262d4c97c7B93937E4810D289b7320e9dA82857
+`
+
+func undenyAccountCmd() *cobra.Command {
+	var flags cli.ContractCallFlags
+	cmd := &cobra.Command{
+		Use:     "undeny <account address>",
+		Short:   "Remove an account from the deny list",
+		Example: undenyAccountCmdExample,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			addr, err := cli.ParseAddress(args[0], flags.ChainID)
+			if err != nil {
+				return err
+			}
+
+			cmd.SilenceUsage = true
+
+			req := &ac.UndenyAccountRequest{Account: addr.MarshalPB()}
+			return cli.CallContractWithFlags(&flags, acContractName, "UndenyAccount", req, nil)
+		},
+	}
+	cli.AddContractCallFlags(cmd.Flags(), &flags)
+	return cmd
+}
+
+const setAllowlistOnlyCmdExample = `
+loom access-control set-allowlist-only true
+`
+
+func setAllowlistOnlyCmd() *cobra.Command {
+	var flags cli.ContractCallFlags
+	cmd := &cobra.Command{
+		Use:     "set-allowlist-only <true|false>",
+		Short:   "Only allow accounts in the allow list to send txs",
+		Example: setAllowlistOnlyCmdExample,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			enabled, err := strconv.ParseBool(args[0])
+			if err != nil {
+				return errors.Wrap(err, "expected true or false")
+			}
+
+			cmd.SilenceUsage = true
+
+			req := &ac.SetAllowlistOnlyRequest{Enabled: enabled}
+			return cli.CallContractWithFlags(&flags, acContractName, "SetAllowlistOnly", req, nil)
+		},
+	}
+	cli.AddContractCallFlags(cmd.Flags(), &flags)
+	return cmd
+}
+
+const getAccountAccessCmdExample = `
+loom access-control get 0x7262d4c97c7B93937E4810D289b7320e9dA82857
+`
+
+func getAccountAccessCmd() *cobra.Command {
+	var flags cli.ContractCallFlags
+	cmd := &cobra.Command{
+		Use:     "get <account address>",
+		Short:   "Show the access list entries of an account",
+		Example: getAccountAccessCmdExample,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			addr, err := cli.ResolveAccountAddress(args[0], &flags)
+			if err != nil {
+				return err
+			}
+
+			cmd.SilenceUsage = true
+
+			req := &ac.GetAccountAccessRequest{Account: addr.MarshalPB()}
+			var resp ac.GetAccountAccessResponse
+			if err := cli.StaticCallContractWithFlags(&flags, acContractName, "GetAccountAccess", req, &resp); err != nil {
+				return err
+			}
+
+			info := accountAccessInfo{Allowed: resp.Allowed}
+			if resp.Denied != nil {
+				info.Denied = getDeniedAccountInfo(resp.Denied)
+			}
+			output, err := json.MarshalIndent(info, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(output))
+			return nil
+		},
+	}
+	cli.AddContractStaticCallFlags(cmd.Flags(), &flags)
+	return cmd
+}
+
+const listAccessCmdExample = `
+loom access-control list
+`
+
+func listAccessCmd() *cobra.Command {
+	var flags cli.ContractCallFlags
+	cmd := &cobra.Command{
+		Use:     "list",
+		Short:   "Display the allow & deny lists",
+		Example: listAccessCmdExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			req := &ac.ListAccessRequest{}
+			var resp ac.ListAccessResponse
+			if err := cli.StaticCallContractWithFlags(&flags, acContractName, "ListAccess", req, &resp); err != nil {
+				return err
+			}
+
+			info := accessListInfo{
+				AllowlistOnly:   resp.AllowlistOnly,
+				AllowedAccounts: []string{},
+				DeniedAccounts:  []*deniedAccountInfo{},
+			}
+			for _, acct := range resp.AllowedAccounts {
+				info.AllowedAccounts = append(info.AllowedAccounts, addressString(acct.Account))
+			}
+			for _, acct := range resp.DeniedAccounts {
+				info.DeniedAccounts = append(info.DeniedAccounts, getDeniedAccountInfo(acct))
+			}
+
+			output, err := json.MarshalIndent(info, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(output))
+			return nil
+		},
+	}
+	cli.AddContractStaticCallFlags(cmd.Flags(), &flags)
+	return cmd
+}
+
+func getDeniedAccountInfo(acct *ac.DeniedAccount) *deniedAccountInfo {
+	info := &deniedAccountInfo{
+		Account: addressString(acct.Account),
+	}
+	for _, c := range acct.Contracts {
+		info.Contracts = append(info.Contracts, addressString(c))
+	}
+	return info
+}
+
+func addressString(addr *types.Address) string {
+	return loom.UnmarshalAddressPB(addr).String()
+}
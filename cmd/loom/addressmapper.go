@@ -38,41 +38,9 @@ func AddIdentityMappingCmd() *cobra.Command {
 			}
 			mapping.From = user.MarshalPB()
 
-			var privkey *ecdsa.PrivateKey
-			var foreignLocalAddr loom.LocalAddress
-			var sigType = evmcompat.SignatureType_EIP712
-
-			switch strings.TrimSpace(chainId) {
-			case "eth":
-				privkey, err = crypto.LoadECDSA(args[1])
-				if err != nil {
-					return errors.Wrapf(err, "read ethereum private key from file %v", args[1])
-				}
-				foreignLocalAddr, err = loom.LocalAddressFromHexString(crypto.PubkeyToAddress(privkey.PublicKey).Hex())
-				if err != nil {
-					return errors.Wrapf(err, "bad ethereum private key from file %v", args[1])
-				}
-			case "tron":
-				privkey, err = lcrypto.LoadBtecSecp256k1PrivKey(args[1])
-				if err != nil {
-					return errors.Wrapf(err, "read tron private key from file %v", args[1])
-				}
-				foreignLocalAddr, err = loom.LocalAddressFromHexString(crypto.PubkeyToAddress(privkey.PublicKey).Hex())
-				if err != nil {
-					return errors.Wrapf(err, "bad tron private key from file% v", args[1])
-				}
-				sigType = evmcompat.SignatureType_TRON
-			case "binance":
-				privkey, err = crypto.LoadECDSA(args[1])
-				if err != nil {
-					return errors.Wrapf(err, "read binance private key from file %v", args[1])
-				}
-				signer := auth.NewBinanceSigner(crypto.FromECDSA(privkey))
-				foreignLocalAddr, err = loom.LocalAddressFromHexString(evmcompat.BitcoinAddress(signer.PublicKey()).Hex())
-				if err != nil {
-					return errors.Wrapf(err, "bad binance private key from file %v", args[1])
-				}
-				sigType = evmcompat.SignatureType_BINANCE
+			privkey, foreignLocalAddr, sigType, err := loadForeignKey(chainId, args[1])
+			if err != nil {
+				return err
 			}
 
 			foreignAddr := loom.Address{ChainID: chainId, Local: foreignLocalAddr}
@@ -101,6 +69,134 @@ func AddIdentityMappingCmd() *cobra.Command {
 	return cmd
 }
 
+func RotateMappingCmd() *cobra.Command {
+	var chainId, oldKeyFile string
+	var callFlags cli.ContractCallFlags
+	cmd := &cobra.Command{
+		Use:   "rotate-mapping <loom-addr> <old-mapped-addr> <new-key-file>",
+		Short: "Re-points the mapping of a DAppChain account to a new Mainnet account.",
+		Long: `Re-points the mapping of a DAppChain account to a new Mainnet account.
+The request must be signed by both the old & new Mainnet keys, unless it's sent by the recovery
+admin, in which case the --old-key flag can be omitted.`,
+		Args: cobra.ExactArgs(3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			user, err := cli.ParseAddress(args[0], callFlags.ChainID)
+			if err != nil {
+				return errors.Wrapf(err, "failed to parse address %v", args[0])
+			}
+			oldLocalAddr, err := loom.LocalAddressFromHexString(args[1])
+			if err != nil {
+				return errors.Wrapf(err, "failed to parse address %v", args[1])
+			}
+			oldForeignAddr := loom.Address{ChainID: chainId, Local: oldLocalAddr}
+
+			newPrivKey, newLocalAddr, sigType, err := loadForeignKey(chainId, args[2])
+			if err != nil {
+				return err
+			}
+			newForeignAddr := loom.Address{ChainID: chainId, Local: newLocalAddr}
+
+			var nonceResp address_mapper.GetRotationNonceResponse
+			err = cli.StaticCallContractWithFlags(&callFlags, AddressMapperName, "GetRotationNonce",
+				&address_mapper.GetRotationNonceRequest{
+					From: user.MarshalPB(),
+				}, &nonceResp)
+			if err != nil {
+				return errors.Wrap(err, "failed to get rotation nonce")
+			}
+
+			req := &address_mapper.RotateMappingRequest{
+				From:  user.MarshalPB(),
+				OldTo: oldForeignAddr.MarshalPB(),
+				NewTo: newForeignAddr.MarshalPB(),
+			}
+			req.NewSignature, err = address_mapper.SignMappingRotation(
+				user, oldForeignAddr, newForeignAddr, nonceResp.Nonce, newPrivKey, sigType,
+			)
+			if err != nil {
+				return errors.Wrapf(err, "sigining rotation with new %s key", chainId)
+			}
+
+			if oldKeyFile != "" {
+				oldPrivKey, oldKeyAddr, oldSigType, err := loadForeignKey(chainId, oldKeyFile)
+				if err != nil {
+					return err
+				}
+				if oldKeyAddr.Compare(oldLocalAddr) != 0 {
+					return fmt.Errorf("old key doesn't match mapped address %v", args[1])
+				}
+				req.OldSignature, err = address_mapper.SignMappingRotation(
+					user, oldForeignAddr, newForeignAddr, nonceResp.Nonce, oldPrivKey, oldSigType,
+				)
+				if err != nil {
+					return errors.Wrapf(err, "sigining rotation with old %s key", chainId)
+				}
+			}
+
+			err = cli.CallContractWithFlags(&callFlags, AddressMapperName, "RotateMapping", req, nil)
+			if err != nil {
+				return errors.Wrap(err, "call contract")
+			}
+			fmt.Println("mapping rotated successfully")
+			return nil
+		},
+	}
+	cli.AddContractCallFlags(cmd.Flags(), &callFlags)
+	cmd.Flags().StringVarP(&chainId, "mapped-chain-id", "c", "eth", "ethereum chain id")
+	cmd.Flags().StringVar(&oldKeyFile, "old-key", "", "file containing the key of the old mapped account")
+	return cmd
+}
+
+func SetRecoveryAdminCmd() *cobra.Command {
+	var callFlags cli.ContractCallFlags
+	cmd := &cobra.Command{
+		Use:   "set-recovery-admin <admin-addr>",
+		Short: "Changes the account that can rotate mappings without the old mapped key.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			admin, err := cli.ParseAddress(args[0], callFlags.ChainID)
+			if err != nil {
+				return errors.Wrapf(err, "failed to parse address %v", args[0])
+			}
+			err = cli.CallContractWithFlags(&callFlags, AddressMapperName, "SetRecoveryAdmin",
+				&address_mapper.SetRecoveryAdminRequest{
+					RecoveryAdmin: admin.MarshalPB(),
+				}, nil)
+			if err != nil {
+				return errors.Wrap(err, "call contract")
+			}
+			return nil
+		},
+	}
+	cli.AddContractCallFlags(cmd.Flags(), &callFlags)
+	return cmd
+}
+
+func GetRecoveryAdminCmd() *cobra.Command {
+	var flags cli.ContractCallFlags
+	cmd := &cobra.Command{
+		Use:   "get-recovery-admin",
+		Short: "Show the account that can rotate mappings without the old mapped key.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var resp address_mapper.GetRecoveryAdminResponse
+			err := cli.StaticCallContractWithFlags(&flags, AddressMapperName, "GetRecoveryAdmin",
+				&address_mapper.GetRecoveryAdminRequest{}, &resp)
+			if err != nil {
+				return errors.Wrap(err, "static call contract")
+			}
+			if resp.RecoveryAdmin == nil {
+				fmt.Println("recovery admin not set")
+				return nil
+			}
+			fmt.Println(loom.UnmarshalAddressPB(resp.RecoveryAdmin).String())
+			return nil
+		},
+	}
+	cli.AddContractStaticCallFlags(cmd.Flags(), &flags)
+	return cmd
+}
+
 func GetMapping() *cobra.Command {
 	var flags cli.ContractCallFlags
 	cmd := &cobra.Command{
@@ -172,6 +268,54 @@ func NewAddressMapperCommand() *cobra.Command {
 		AddIdentityMappingCmd(),
 		GetMapping(),
 		ListMappingCmd(),
+		RotateMappingCmd(),
+		SetRecoveryAdminCmd(),
+		GetRecoveryAdminCmd(),
 	)
 	return cmd
 }
+
+// loadForeignKey loads the private key of a foreign account from the given file, and returns it
+// along with the local address of the account & the signature type that should be used with it.
+func loadForeignKey(
+	chainId, keyFile string,
+) (*ecdsa.PrivateKey, loom.LocalAddress, evmcompat.SignatureType, error) {
+	var privkey *ecdsa.PrivateKey
+	var foreignLocalAddr loom.LocalAddress
+	var sigType = evmcompat.SignatureType_EIP712
+	var err error
+
+	switch strings.TrimSpace(chainId) {
+	case "eth":
+		privkey, err = crypto.LoadECDSA(keyFile)
+		if err != nil {
+			return nil, nil, sigType, errors.Wrapf(err, "read ethereum private key from file %v", keyFile)
+		}
+		foreignLocalAddr, err = loom.LocalAddressFromHexString(crypto.PubkeyToAddress(privkey.PublicKey).Hex())
+		if err != nil {
+			return nil, nil, sigType, errors.Wrapf(err, "bad ethereum private key from file %v", keyFile)
+		}
+	case "tron":
+		privkey, err = lcrypto.LoadBtecSecp256k1PrivKey(keyFile)
+		if err != nil {
+			return nil, nil, sigType, errors.Wrapf(err, "read tron private key from file %v", keyFile)
+		}
+		foreignLocalAddr, err = loom.LocalAddressFromHexString(crypto.PubkeyToAddress(privkey.PublicKey).Hex())
+		if err != nil {
+			return nil, nil, sigType, errors.Wrapf(err, "bad tron private key from file% v", keyFile)
+		}
+		sigType = evmcompat.SignatureType_TRON
+	case "binance":
+		privkey, err = crypto.LoadECDSA(keyFile)
+		if err != nil {
+			return nil, nil, sigType, errors.Wrapf(err, "read binance private key from file %v", keyFile)
+		}
+		signer := auth.NewBinanceSigner(crypto.FromECDSA(privkey))
+		foreignLocalAddr, err = loom.LocalAddressFromHexString(evmcompat.BitcoinAddress(signer.PublicKey()).Hex())
+		if err != nil {
+			return nil, nil, sigType, errors.Wrapf(err, "bad binance private key from file %v", keyFile)
+		}
+		sigType = evmcompat.SignatureType_BINANCE
+	}
+	return privkey, foreignLocalAddr, sigType, nil
+}
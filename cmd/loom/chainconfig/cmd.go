@@ -1,6 +1,8 @@
 package chainconfig
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"sort"
@@ -16,7 +18,11 @@ import (
 	"github.com/loomnetwork/go-loom/client"
 	"github.com/loomnetwork/go-loom/config"
 	plugintypes "github.com/loomnetwork/go-loom/plugin/types"
+	"github.com/loomnetwork/loomchain/blockwindow"
+	ccplugin "github.com/loomnetwork/loomchain/builtin/plugins/chainconfig"
 	"github.com/loomnetwork/loomchain/builtin/plugins/dposv3"
+	"github.com/loomnetwork/loomchain/feemarket"
+	"github.com/loomnetwork/loomchain/gasmeter"
 	"github.com/spf13/cobra"
 	"github.com/tendermint/go-amino"
 	"github.com/tendermint/tendermint/crypto/ed25519"
@@ -38,6 +44,8 @@ func NewChainCfgCommand() *cobra.Command {
 		GetFeatureCmd(),
 		SetParamsCmd(),
 		GetParamsCmd(),
+		SetPowerVoteParamsCmd(),
+		GetPowerVoteParamsCmd(),
 		ListFeaturesCmd(),
 		FeatureEnabledCmd(),
 		RemoveFeatureCmd(),
@@ -47,6 +55,12 @@ func NewChainCfgCommand() *cobra.Command {
 		SetValidatorInfoCmd(),
 		GetValidatorInfoCmd(),
 		ListValidatorsInfoCmd(),
+		SetFnRecordCmd(),
+		RemoveFnRecordCmd(),
+		ListFnRecordsCmd(),
+		SchedulePluginUpgradeCmd(),
+		CancelPluginUpgradeCmd(),
+		ListPluginUpgradesCmd(),
 	)
 	return cmd
 }
@@ -81,11 +95,17 @@ func EnableFeatureCmd() *cobra.Command {
 
 const addFeatureCmdExample = `
 loom chain-cfg add-feature hardfork multichain --build 866 --no-auto-enable
+
+# Activate the feature at block 5000000 (if it's been enabled by enough validators by then)
+loom chain-cfg add-feature hardfork --build 866 --activation-height 5000000
+
+# Activate the feature 10000 blocks after it's been enabled by enough validators
+loom chain-cfg add-feature hardfork --build 866 --activation-delay 10000
 `
 
 func AddFeatureCmd() *cobra.Command {
 	var flags cli.ContractCallFlags
-	var buildNumber uint64
+	var buildNumber, activationHeight, activationDelay uint64
 	var noAutoEnable bool
 	cmd := &cobra.Command{
 		Use:     "add-feature <feature name 1> ... <feature name N>",
@@ -97,6 +117,19 @@ func AddFeatureCmd() *cobra.Command {
 					return fmt.Errorf("Invalid feature name")
 				}
 			}
+			if activationHeight != 0 && activationDelay != 0 {
+				return fmt.Errorf("only one of --activation-height and --activation-delay can be specified")
+			}
+			if activationHeight != 0 || activationDelay != 0 {
+				req := &ccplugin.AddScheduledFeatureRequest{
+					Names:            args,
+					BuildNumber:      buildNumber,
+					AutoEnable:       !noAutoEnable,
+					ActivationHeight: activationHeight,
+					ActivationDelay:  activationDelay,
+				}
+				return cli.CallContractWithFlags(&flags, chainConfigContractName, "AddScheduledFeature", req, nil)
+			}
 			req := &cctype.AddFeatureRequest{
 				Names:       args,
 				BuildNumber: buildNumber,
@@ -118,6 +151,15 @@ func AddFeatureCmd() *cobra.Command {
 		false,
 		"Don't allow validator nodes to auto-enable this feature (operator will have to do so manually)",
 	)
+	cmdFlags.Uint64Var(
+		&activationHeight, "activation-height", 0, "Block height at which the feature should be activated",
+	)
+	cmdFlags.Uint64Var(
+		&activationDelay,
+		"activation-delay",
+		0,
+		"Number of blocks to wait after the feature is enabled by enough validators before activating it",
+	)
 	cmd.MarkFlagRequired("build")
 	return cmd
 }
@@ -185,6 +227,71 @@ func GetParamsCmd() *cobra.Command {
 	return cmd
 }
 
+const setPowerVoteParamsCmdExample = `
+# Only activate features that are supported by validators holding at least 67% of the total power
+loom chain-cfg set-power-vote-params true 67
+
+loom chain-cfg set-power-vote-params false 0
+`
+
+func SetPowerVoteParamsCmd() *cobra.Command {
+	var flags cli.ContractCallFlags
+	cmd := &cobra.Command{
+		Use:     "set-power-vote-params <enabled> <power threshold>",
+		Short:   "Enable or disable weighting of feature votes by validator power",
+		Example: setPowerVoteParamsCmdExample,
+		Args:    cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			enabled, err := strconv.ParseBool(args[0])
+			if err != nil {
+				return fmt.Errorf("expected true or false: %v", err)
+			}
+			powerThreshold, err := strconv.ParseUint(args[1], 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid power threshold: %v", err)
+			}
+			request := &ccplugin.SetPowerVoteParamsRequest{
+				Params: &ccplugin.PowerVoteParams{
+					Enabled:        enabled,
+					PowerThreshold: powerThreshold,
+				},
+			}
+			return cli.CallContractWithFlags(&flags, chainConfigContractName, "SetPowerVoteParams", request, nil)
+		},
+	}
+	cli.AddContractCallFlags(cmd.Flags(), &flags)
+	return cmd
+}
+
+const getPowerVoteParamsCmdExample = `
+loom chain-cfg get-power-vote-params
+`
+
+func GetPowerVoteParamsCmd() *cobra.Command {
+	var flags cli.ContractCallFlags
+	cmd := &cobra.Command{
+		Use:     "get-power-vote-params",
+		Short:   "Get the power voting parameters from chainconfig",
+		Example: getPowerVoteParamsCmdExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var resp ccplugin.GetPowerVoteParamsResponse
+			err := cli.StaticCallContractWithFlags(&flags, chainConfigContractName, "GetPowerVoteParams",
+				&ccplugin.GetPowerVoteParamsRequest{}, &resp)
+			if err != nil {
+				return err
+			}
+			out, err := formatJSON(&resp)
+			if err != nil {
+				return err
+			}
+			fmt.Println(out)
+			return nil
+		},
+	}
+	cli.AddContractStaticCallFlags(cmd.Flags(), &flags)
+	return cmd
+}
+
 const getFeatureCmdExample = `
 loom chain-cfg get-feature hardfork
 `
@@ -233,16 +340,44 @@ func ListFeaturesCmd() *cobra.Command {
 				return err
 			}
 
+			// Nodes running older builds don't report the percentage of validator power that
+			// supports each feature, in which case that column is left blank.
+			var supportResp ccplugin.ListFeatureSupportResponse
+			powerPercentages := map[string]string{}
+			err = cli.StaticCallContractWithFlags(&flags, chainConfigContractName, "ListFeatureSupport",
+				&ccplugin.ListFeatureSupportRequest{}, &supportResp)
+			if err == nil {
+				for _, fs := range supportResp.Features {
+					powerPercentages[fs.Name] = strconv.FormatUint(fs.PowerPercentage, 10)
+				}
+			}
+			// Waiting features that have been scheduled are displayed as SCHEDULED along with the
+			// block height at which they'll be activated.
+			var schedulesResp ccplugin.ListFeatureSchedulesResponse
+			scheduledHeights := map[string]uint64{}
+			err = cli.StaticCallContractWithFlags(&flags, chainConfigContractName, "ListFeatureSchedules",
+				&ccplugin.ListFeatureSchedulesRequest{}, &schedulesResp)
+			if err == nil {
+				for _, schedule := range schedulesResp.Schedules {
+					scheduledHeights[schedule.Name] = schedule.ScheduledHeight
+				}
+			}
+
 			type maxLength struct {
-				Name        int
-				Status      int
-				Validators  int
-				Height      int
-				Percentage  int
-				BuildNumber int
+				Name            int
+				Status          int
+				Validators      int
+				Height          int
+				Percentage      int
+				PowerPercentage int
+				BuildNumber     int
+				Activation      int
 			}
 
-			ml := maxLength{Name: 4, Status: 7, Validators: 10, Height: 6, Percentage: 6, BuildNumber: 5}
+			ml := maxLength{
+				Name: 4, Status: 9, Validators: 10, Height: 6, Percentage: 6, PowerPercentage: 7, BuildNumber: 5,
+				Activation: 10,
+			}
 			for _, value := range resp.Features {
 				if len(value.Name) > ml.Name {
 					ml.Name = len(value.Name)
@@ -252,18 +387,28 @@ func ListFeaturesCmd() *cobra.Command {
 				}
 			}
 			fmt.Printf(
-				"%-*s | %-*s | %-*s | %-*s | %-*s | %-*s\n", ml.Name,
+				"%-*s | %-*s | %-*s | %-*s | %-*s | %-*s | %-*s | %-*s\n", ml.Name,
 				"name", ml.Status, "status", ml.Validators, "validators",
-				ml.Height, "height", ml.Percentage, "vote %", ml.BuildNumber, "build")
+				ml.Height, "height", ml.Percentage, "vote %", ml.PowerPercentage, "power %",
+				ml.BuildNumber, "build", ml.Activation, "activation")
 			fmt.Printf(
 				strings.Repeat("-", ml.Name+ml.Status+ml.Validators+
-					ml.Height+ml.Percentage+ml.BuildNumber+15) + "\n")
+					ml.Height+ml.Percentage+ml.PowerPercentage+ml.BuildNumber+ml.Activation+21) + "\n")
 			for _, value := range resp.Features {
-				fmt.Printf("%-*s | %-*s | %-*d | %-*d | %-*d | %-*d\n",
-					ml.Name, value.Name, ml.Status, value.Status,
+				status := value.Status.String()
+				activation := ""
+				if scheduledHeight := scheduledHeights[value.Name]; scheduledHeight != 0 {
+					activation = strconv.FormatUint(scheduledHeight, 10)
+					if value.Status == cctype.Feature_WAITING {
+						status = "SCHEDULED"
+					}
+				}
+				fmt.Printf("%-*s | %-*s | %-*d | %-*d | %-*d | %-*s | %-*d | %-*s\n",
+					ml.Name, value.Name, ml.Status, status,
 					ml.Validators, len(value.Validators), ml.Height,
 					value.BlockHeight, ml.Percentage, value.Percentage,
-					ml.BuildNumber, value.BuildNumber)
+					ml.PowerPercentage, powerPercentages[value.Name],
+					ml.BuildNumber, value.BuildNumber, ml.Activation, activation)
 			}
 			return nil
 		},
@@ -485,9 +630,23 @@ func SetSettingCmd() *cobra.Command {
 			}
 
 			// validate config setting
-			defaultConfig := config.DefaultConfig()
-			if err := config.SetConfigSetting(defaultConfig, args[0], value); err != nil {
-				return err
+			if feemarket.IsSetting(args[0]) {
+				if err := feemarket.SetConfigSetting(feemarket.DefaultConfig(), args[0], value); err != nil {
+					return err
+				}
+			} else if gasmeter.IsSetting(args[0]) {
+				if err := gasmeter.SetConfigSetting(gasmeter.DefaultConfig(), args[0], value); err != nil {
+					return err
+				}
+			} else if blockwindow.IsSetting(args[0]) {
+				if err := blockwindow.SetConfigSetting(blockwindow.DefaultConfig(), args[0], value); err != nil {
+					return err
+				}
+			} else {
+				defaultConfig := config.DefaultConfig()
+				if err := config.SetConfigSetting(defaultConfig, args[0], value); err != nil {
+					return err
+				}
 			}
 
 			req := &cctype.SetSettingRequest{
@@ -628,6 +787,180 @@ func ListValidatorsInfoCmd() *cobra.Command {
 
 // Utils
 
+const setFnRecordCmdExample = `
+loom chain-cfg set-fn-record mydata:price_feed dataoracle --height 150000 --params '{"key":"value"}'
+`
+
+func SetFnRecordCmd() *cobra.Command {
+	var flags cli.ContractCallFlags
+	var params string
+	var height uint64
+	cmd := &cobra.Command{
+		Use:     "set-fn-record <fn id> <factory name>",
+		Short:   "Add or update the record of an Fn validators should register with the fnConsensus reactor",
+		Example: setFnRecordCmdExample,
+		Args:    cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			request := &ccplugin.SetFnRecordRequest{
+				Record: &ccplugin.FnRecord{
+					FnId:             args[0],
+					Factory:          args[1],
+					Params:           []byte(params),
+					ActivationHeight: height,
+				},
+			}
+			return cli.CallContractWithFlags(&flags, chainConfigContractName, "SetFnRecord", request, nil)
+		},
+	}
+	cmd.Flags().StringVar(&params, "params", "", "Factory specific params")
+	cmd.Flags().Uint64Var(&height, "height", 0, "Block height at which the record should take effect")
+	cmd.MarkFlagRequired("height")
+	cli.AddContractCallFlags(cmd.Flags(), &flags)
+	return cmd
+}
+
+const removeFnRecordCmdExample = `
+loom chain-cfg remove-fn-record mydata:price_feed --height 150000
+`
+
+func RemoveFnRecordCmd() *cobra.Command {
+	var flags cli.ContractCallFlags
+	var height uint64
+	cmd := &cobra.Command{
+		Use:     "remove-fn-record <fn id>",
+		Short:   "Remove an Fn, validators will unregister the Fn from the fnConsensus reactor at the given block height",
+		Example: removeFnRecordCmdExample,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			request := &ccplugin.RemoveFnRecordRequest{FnId: args[0], ActivationHeight: height}
+			return cli.CallContractWithFlags(&flags, chainConfigContractName, "RemoveFnRecord", request, nil)
+		},
+	}
+	cmd.Flags().Uint64Var(&height, "height", 0, "Block height at which the Fn should be unregistered")
+	cmd.MarkFlagRequired("height")
+	cli.AddContractCallFlags(cmd.Flags(), &flags)
+	return cmd
+}
+
+const listFnRecordsCmdExample = `
+loom chain-cfg list-fn-records
+`
+
+func ListFnRecordsCmd() *cobra.Command {
+	var flags cli.ContractCallFlags
+	cmd := &cobra.Command{
+		Use:     "list-fn-records",
+		Short:   "List the records of the Fns validators should register with the fnConsensus reactor",
+		Example: listFnRecordsCmdExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var resp ccplugin.ListFnRecordsResponse
+			err := cli.StaticCallContractWithFlags(&flags, chainConfigContractName, "ListFnRecords",
+				&ccplugin.ListFnRecordsRequest{}, &resp)
+			if err != nil {
+				return err
+			}
+			out, err := formatJSON(&resp)
+			if err != nil {
+				return err
+			}
+			fmt.Println(out)
+			return nil
+		},
+	}
+	cli.AddContractStaticCallFlags(cmd.Flags(), &flags)
+	return cmd
+}
+
+const schedulePluginUpgradeCmdExample = `
+loom chain-cfg schedule-plugin-upgrade mycontract:1.0.0 1.1.0 --height 150000 \
+  --sha256 6b86b273ff34fce19d6b804eff5a3f5747ada4eaa22f1d49c01e52ddb7875b4b
+`
+
+func SchedulePluginUpgradeCmd() *cobra.Command {
+	var flags cli.ContractCallFlags
+	var height uint64
+	var pluginHash string
+	cmd := &cobra.Command{
+		Use:     "schedule-plugin-upgrade <plugin name:version> <new version>",
+		Short:   "Switch all contracts that use a plugin to a new version of the plugin at the given block height",
+		Example: schedulePluginUpgradeCmdExample,
+		Args:    cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var hash []byte
+			if pluginHash != "" {
+				var err error
+				hash, err = hex.DecodeString(strings.TrimPrefix(pluginHash, "0x"))
+				if err != nil || len(hash) != sha256.Size {
+					return fmt.Errorf("invalid SHA-256 hash")
+				}
+			}
+			request := &ccplugin.SchedulePluginUpgradeRequest{
+				Upgrade: &ccplugin.PluginUpgrade{
+					PluginName:   args[0],
+					Version:      args[1],
+					BlockHeight:  height,
+					PluginSha256: hash,
+				},
+			}
+			return cli.CallContractWithFlags(&flags, chainConfigContractName, "SchedulePluginUpgrade", request, nil)
+		},
+	}
+	cmd.Flags().Uint64Var(&height, "height", 0, "Block height at which the new plugin version should be loaded")
+	cmd.Flags().StringVar(&pluginHash, "sha256", "", "Hex encoded SHA-256 hash of the new plugin binary")
+	cmd.MarkFlagRequired("height")
+	cli.AddContractCallFlags(cmd.Flags(), &flags)
+	return cmd
+}
+
+const cancelPluginUpgradeCmdExample = `
+loom chain-cfg cancel-plugin-upgrade mycontract:1.0.0
+`
+
+func CancelPluginUpgradeCmd() *cobra.Command {
+	var flags cli.ContractCallFlags
+	cmd := &cobra.Command{
+		Use:     "cancel-plugin-upgrade <plugin name:version>",
+		Short:   "Cancel a plugin upgrade that hasn't been applied yet",
+		Example: cancelPluginUpgradeCmdExample,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			request := &ccplugin.CancelPluginUpgradeRequest{PluginName: args[0]}
+			return cli.CallContractWithFlags(&flags, chainConfigContractName, "CancelPluginUpgrade", request, nil)
+		},
+	}
+	cli.AddContractCallFlags(cmd.Flags(), &flags)
+	return cmd
+}
+
+const listPluginUpgradesCmdExample = `
+loom chain-cfg list-plugin-upgrades
+`
+
+func ListPluginUpgradesCmd() *cobra.Command {
+	var flags cli.ContractCallFlags
+	cmd := &cobra.Command{
+		Use:     "list-plugin-upgrades",
+		Short:   "List the plugin upgrades that haven't been applied yet",
+		Example: listPluginUpgradesCmdExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var resp ccplugin.ListPluginUpgradesResponse
+			err := cli.StaticCallContractWithFlags(&flags, chainConfigContractName, "ListPluginUpgrades",
+				&ccplugin.ListPluginUpgradesRequest{}, &resp)
+			if err != nil {
+				return err
+			}
+			out, err := formatJSON(&resp)
+			if err != nil {
+				return err
+			}
+			fmt.Println(out)
+			return nil
+		},
+	}
+	cli.AddContractStaticCallFlags(cmd.Flags(), &flags)
+	return cmd
+}
+
 func formatJSON(pb proto.Message) (string, error) {
 	marshaler := jsonpb.Marshaler{
 		Indent:       "  ",
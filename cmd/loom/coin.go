@@ -1,13 +1,19 @@
 package main
 
 import (
+	"encoding/csv"
 	"fmt"
+	"os"
+	"strconv"
+	"strings"
 
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
 	"github.com/loomnetwork/go-loom/builtin/types/coin"
 	"github.com/loomnetwork/go-loom/cli"
 	"github.com/loomnetwork/go-loom/types"
+	coinplugin "github.com/loomnetwork/loomchain/builtin/plugins/coin"
 )
 
 const CoinContractName = "coin"
@@ -116,7 +122,7 @@ func BalanceCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
-			var resp coin.BalanceOfResponse
+			var resp coinplugin.BalanceOfResponse
 			err = cli.StaticCallContractWithFlags(&staticflags, CoinContractName, "BalanceOf", &coin.BalanceOfRequest{
 				Owner: addr.MarshalPB(),
 			}, &resp)
@@ -135,6 +141,207 @@ func BalanceCmd() *cobra.Command {
 	return cmd
 }
 
+func ApproveVestingGrantorCmd() *cobra.Command {
+	var flags cli.ContractCallFlags
+	cmd := &cobra.Command{
+		Use:   "approve-vesting-grantor [grantor]",
+		Short: "Allow the grantor to create a single vesting schedule for the caller",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			addr, err := cli.ResolveAddress(args[0], flags.ChainID, flags.URI)
+			if err != nil {
+				return err
+			}
+			return cli.CallContractWithFlags(
+				&flags, CoinContractName, "ApproveVestingGrantor",
+				&coinplugin.ApproveVestingGrantorRequest{Grantor: addr.MarshalPB()}, nil,
+			)
+		},
+	}
+	cli.AddContractCallFlags(cmd.Flags(), &flags)
+	return cmd
+}
+
+const createVestingAccountCmdExample = `
+# The beneficiary must first allow the caller to create a vesting schedule for it
+loom coin approve-vesting-grantor 0x2a6b071aD396cEFdd16c731454af0d8c95ECD4B2 --key path/to/beneficiary_private_key
+
+# Lock 1000 LOOM for a year with a 3 month cliff
+loom coin create-vesting-account 0x7262d4c97c7B93937E4810D289b7320e9dA82857 1000 \
+  --start 1577836800 --cliff 1585699200 --end 1609459200
+
+# Unlock 400 LOOM on Jan 1 2020, and 600 LOOM on Jan 1 2021
+loom coin create-vesting-account 0x7262d4c97c7B93937E4810D289b7320e9dA82857 1000 \
+  --unlocks 1577836800:400,1609459200:600
+`
+
+func CreateVestingAccountCmd() *cobra.Command {
+	var flags cli.ContractCallFlags
+	var startTime, cliffTime, endTime uint64
+	var unlocks []string
+	cmd := &cobra.Command{
+		Use:     "create-vesting-account [beneficiary] [amount]",
+		Short:   "Transfer coins to an account that will be locked according to a vesting schedule",
+		Example: createVestingAccountCmdExample,
+		Args:    cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			addr, err := cli.ResolveAddress(args[0], flags.ChainID, flags.URI)
+			if err != nil {
+				return err
+			}
+			amount, err := cli.ParseAmount(args[1])
+			if err != nil {
+				return err
+			}
+
+			schedule := &coinplugin.VestingSchedule{
+				Beneficiary: addr.MarshalPB(),
+				TotalAmount: &types.BigUInt{Value: *amount},
+				StartTime:   startTime,
+				CliffTime:   cliffTime,
+				EndTime:     endTime,
+			}
+			for _, unlock := range unlocks {
+				parts := strings.Split(unlock, ":")
+				if len(parts) != 2 {
+					return fmt.Errorf("invalid unlock %s, expected <unix time>:<amount>", unlock)
+				}
+				unlockTime, err := strconv.ParseUint(parts[0], 10, 64)
+				if err != nil {
+					return errors.Wrapf(err, "invalid unlock time %s", parts[0])
+				}
+				unlockAmount, err := cli.ParseAmount(parts[1])
+				if err != nil {
+					return err
+				}
+				schedule.Unlocks = append(schedule.Unlocks, &coinplugin.VestingUnlock{
+					Time:   unlockTime,
+					Amount: &types.BigUInt{Value: *unlockAmount},
+				})
+			}
+
+			return cli.CallContractWithFlags(
+				&flags, CoinContractName, "CreateVestingAccount",
+				&coinplugin.CreateVestingAccountRequest{Schedule: schedule}, nil,
+			)
+		},
+	}
+	cmdFlags := cmd.Flags()
+	cmdFlags.Uint64Var(&startTime, "start", 0, "Unix timestamp at which tokens start unlocking linearly")
+	cmdFlags.Uint64Var(&cliffTime, "cliff", 0, "Unix timestamp before which no tokens are unlocked")
+	cmdFlags.Uint64Var(&endTime, "end", 0, "Unix timestamp at which all the tokens are unlocked")
+	cmdFlags.StringSliceVar(
+		&unlocks, "unlocks", nil, "Discrete unlocks in the form <unix time>:<amount> (comma separated list)",
+	)
+	cli.AddContractCallFlags(cmdFlags, &flags)
+	return cmd
+}
+
+func BalanceAtCmd() *cobra.Command {
+	var staticflags cli.ContractCallFlags
+	cmd := &cobra.Command{
+		Use:   "balance-at [address] [snapshot id]",
+		Short: "Fetch the balance of a coin account at the time a snapshot was taken",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			addr, err := cli.ResolveAddress(args[0], staticflags.ChainID, staticflags.URI)
+			if err != nil {
+				return err
+			}
+			snapshotID, err := strconv.ParseUint(args[1], 10, 64)
+			if err != nil {
+				return errors.Wrapf(err, "invalid snapshot ID %s", args[1])
+			}
+			var resp coinplugin.BalanceOfAtResponse
+			err = cli.StaticCallContractWithFlags(
+				&staticflags, CoinContractName, "BalanceOfAt",
+				&coinplugin.BalanceOfAtRequest{Owner: addr.MarshalPB(), SnapshotId: snapshotID}, &resp,
+			)
+			if err != nil {
+				return err
+			}
+			out, err := formatJSON(&resp)
+			if err != nil {
+				return err
+			}
+			fmt.Println(out)
+			return nil
+		},
+	}
+	cli.AddContractStaticCallFlags(cmd.Flags(), &staticflags)
+	return cmd
+}
+
+const batchTransferCmdExample = `
+# Each line of the CSV file should contain the recipient address and the amount, e.g.
+# 0x7262d4c97c7B93937E4810D289b7320e9dA82857,100
+loom coin batch-transfer payouts.csv --batch-size 50
+`
+
+func BatchTransferCmd() *cobra.Command {
+	var flags cli.ContractCallFlags
+	var batchSize int
+	cmd := &cobra.Command{
+		Use:     "batch-transfer [csv file]",
+		Short:   "Transfer coins to many accounts, each batch of recipients is sent in a single tx",
+		Example: batchTransferCmdExample,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if batchSize <= 0 {
+				return fmt.Errorf("batch size must be greater than zero")
+			}
+			file, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+
+			reader := csv.NewReader(file)
+			reader.Comment = '#'
+			reader.FieldsPerRecord = 2
+			records, err := reader.ReadAll()
+			if err != nil {
+				return errors.Wrapf(err, "failed to read %s", args[0])
+			}
+
+			recipients := make([]*coinplugin.BatchTransferRecipient, 0, len(records))
+			for i, record := range records {
+				addr, err := cli.ResolveAddress(strings.TrimSpace(record[0]), flags.ChainID, flags.URI)
+				if err != nil {
+					return errors.Wrapf(err, "invalid address on line %d", i+1)
+				}
+				amount, err := cli.ParseAmount(strings.TrimSpace(record[1]))
+				if err != nil {
+					return errors.Wrapf(err, "invalid amount on line %d", i+1)
+				}
+				recipients = append(recipients, &coinplugin.BatchTransferRecipient{
+					To:     addr.MarshalPB(),
+					Amount: &types.BigUInt{Value: *amount},
+				})
+			}
+
+			for start := 0; start < len(recipients); start += batchSize {
+				end := start + batchSize
+				if end > len(recipients) {
+					end = len(recipients)
+				}
+				req := &coinplugin.BatchTransferRequest{Recipients: recipients[start:end]}
+				if err := cli.CallContractWithFlags(&flags, CoinContractName, "BatchTransfer", req, nil); err != nil {
+					return errors.Wrapf(err, "failed to transfer to recipients %d-%d", start+1, end)
+				}
+				fmt.Printf("Transferred to recipients %d-%d\n", start+1, end)
+			}
+			return nil
+		},
+	}
+	cmdFlags := cmd.Flags()
+	cmdFlags.IntVar(
+		&batchSize, "batch-size", coinplugin.DefaultMaxBatchSize, "Max number of recipients per tx",
+	)
+	cli.AddContractCallFlags(cmdFlags, &flags)
+	return cmd
+}
+
 func NewCoinCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "coin <command>",
@@ -146,6 +353,10 @@ func NewCoinCommand() *cobra.Command {
 		BalanceCmd(),
 		TransferCmd(),
 		TransferFromCmd(),
+		ApproveVestingGrantorCmd(),
+		CreateVestingAccountCmd(),
+		BalanceAtCmd(),
+		BatchTransferCmd(),
 	)
 	return cmd
 }
@@ -2,14 +2,18 @@ package common
 
 import (
 	goloomplugin "github.com/loomnetwork/go-loom/plugin"
+	"github.com/loomnetwork/loomchain/builtin/plugins/access_control"
 	"github.com/loomnetwork/loomchain/builtin/plugins/address_mapper"
 	"github.com/loomnetwork/loomchain/builtin/plugins/chainconfig"
+	"github.com/loomnetwork/loomchain/builtin/plugins/dataoracle"
 	"github.com/loomnetwork/loomchain/builtin/plugins/deployer_whitelist"
 	"github.com/loomnetwork/loomchain/builtin/plugins/dposv2"
 	"github.com/loomnetwork/loomchain/builtin/plugins/dposv3"
 	"github.com/loomnetwork/loomchain/builtin/plugins/ethcoin"
+	"github.com/loomnetwork/loomchain/builtin/plugins/governance"
 	"github.com/loomnetwork/loomchain/builtin/plugins/karma"
 	"github.com/loomnetwork/loomchain/builtin/plugins/plasma_cash"
+	"github.com/loomnetwork/loomchain/builtin/plugins/ratelimit"
 	"github.com/loomnetwork/loomchain/builtin/plugins/sample_go_contract"
 	"github.com/loomnetwork/loomchain/builtin/plugins/user_deployer_whitelist"
 	"github.com/loomnetwork/loomchain/cmd/loom/replay"
@@ -50,6 +54,18 @@ func NewDefaultContractsLoader(cfg *config.Config) plugin.Loader {
 	if cfg.UserDeployerWhitelist.ContractEnabled {
 		contracts = append(contracts, user_deployer_whitelist.Contract)
 	}
+	if cfg.RateLimit.ContractEnabled {
+		contracts = append(contracts, ratelimit.Contract)
+	}
+	if cfg.AccessControl.ContractEnabled {
+		contracts = append(contracts, access_control.Contract)
+	}
+	if cfg.Governance.ContractEnabled {
+		contracts = append(contracts, governance.Contract)
+	}
+	if cfg.DataOracle.ContractEnabled {
+		contracts = append(contracts, dataoracle.Contract)
+	}
 
 	if cfg.AddressMapperContractEnabled() {
 		contracts = append(contracts, address_mapper.Contract)
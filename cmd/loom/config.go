@@ -13,10 +13,14 @@ import (
 	cconfig "github.com/loomnetwork/go-loom/config"
 	"github.com/loomnetwork/go-loom/plugin/contractpb"
 	"github.com/loomnetwork/go-loom/types"
+	"github.com/loomnetwork/loomchain/builtin/plugins/access_control"
 	"github.com/loomnetwork/loomchain/builtin/plugins/chainconfig"
+	"github.com/loomnetwork/loomchain/builtin/plugins/dataoracle"
 	"github.com/loomnetwork/loomchain/builtin/plugins/dposv2"
 	"github.com/loomnetwork/loomchain/builtin/plugins/dposv3"
+	"github.com/loomnetwork/loomchain/builtin/plugins/governance"
 	"github.com/loomnetwork/loomchain/builtin/plugins/karma"
+	"github.com/loomnetwork/loomchain/builtin/plugins/ratelimit"
 	"github.com/loomnetwork/loomchain/config"
 	"github.com/loomnetwork/loomchain/features"
 	"github.com/loomnetwork/loomchain/plugin"
@@ -288,6 +292,74 @@ func defaultGenesis(cfg *config.Config, validator *loom.Validator) (*config.Gene
 		})
 	}
 
+	if cfg.RateLimit.ContractEnabled {
+		rlInit, err := marshalInit(&ratelimit.InitRequest{
+			Owner: contractOwner,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		contracts = append(contracts, config.ContractConfig{
+			VMTypeName: "plugin",
+			Format:     "plugin",
+			Name:       "ratelimit",
+			Location:   "ratelimit:1.0.0",
+			Init:       rlInit,
+		})
+	}
+
+	if cfg.AccessControl.ContractEnabled {
+		acInit, err := marshalInit(&access_control.InitRequest{
+			Owner: contractOwner,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		contracts = append(contracts, config.ContractConfig{
+			VMTypeName: "plugin",
+			Format:     "plugin",
+			Name:       "accesscontrol",
+			Location:   "accesscontrol:1.0.0",
+			Init:       acInit,
+		})
+	}
+
+	if cfg.Governance.ContractEnabled {
+		govInit, err := marshalInit(&governance.InitRequest{
+			Owner: contractOwner,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		contracts = append(contracts, config.ContractConfig{
+			VMTypeName: "plugin",
+			Format:     "plugin",
+			Name:       "governance",
+			Location:   "governance:1.0.0",
+			Init:       govInit,
+		})
+	}
+
+	if cfg.DataOracle.ContractEnabled {
+		oracleInit, err := marshalInit(&dataoracle.InitRequest{
+			Owner: contractOwner,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		contracts = append(contracts, config.ContractConfig{
+			VMTypeName: "plugin",
+			Format:     "plugin",
+			Name:       "dataoracle",
+			Location:   "dataoracle:1.0.0",
+			Init:       oracleInit,
+		})
+	}
+
 	if cfg.Karma.Enabled {
 		karmaInitRequest := ktypes.KarmaInitRequest{
 			Sources: []*ktypes.KarmaSourceReward{
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/loomnetwork/go-loom/cli"
+	"github.com/loomnetwork/go-loom/client"
+	"github.com/loomnetwork/loomchain/registry"
+	"github.com/loomnetwork/loomchain/tx_handler"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/tendermint/go-amino"
+)
+
+const transferOwnershipCommandExample = `
+loom contract transfer-ownership default:0x81ee596ba88eF371a51d4B535E07cB243A8C692d \
+  default:0x5cecd1f7261e1f4c684e297be3edf03b825e01c4 -k owner_priv_key
+`
+
+func transferOwnershipCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "transfer-ownership [contract] [new owner]",
+		Short:   "Transfer the ownership of a contract to another account, can only be done by the contract owner",
+		Args:    cobra.ExactArgs(2),
+		Example: transferOwnershipCommandExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			contractAddr, err := cli.ResolveAddress(args[0], cli.TxFlags.ChainID, cli.TxFlags.URI)
+			if err != nil {
+				return err
+			}
+			newOwner, err := cli.ParseAddress(args[1], cli.TxFlags.ChainID)
+			if err != nil {
+				return err
+			}
+			err = commitRegistryTx(tx_handler.TransferOwnershipTxID, &registry.TransferOwnershipTx{
+				Contract: contractAddr.MarshalPB(),
+				NewOwner: newOwner.MarshalPB(),
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Ownership of contract %s transferred to %s\n", contractAddr.String(), newOwner.String())
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&cli.TxFlags.PrivFile, "key", "k", "", "private key file")
+	setChainFlags(cmd.Flags())
+	return cmd
+}
+
+const setContractMetadataCommandExample = `
+loom contract set-metadata default:0x81ee596ba88eF371a51d4B535E07cB243A8C692d --abi MyContract.abi \
+  --source-url https://github.com/loomnetwork/mycontract --description "My contract" -k owner_priv_key
+`
+
+func setContractMetadataCommand() *cobra.Command {
+	var abiFile, protoDescriptorFile, sourceHash string
+	var metadata registry.ContractMetadata
+	cmd := &cobra.Command{
+		Use:     "set-metadata [contract]",
+		Short:   "Replace the metadata of a contract, can only be done by the contract owner",
+		Args:    cobra.ExactArgs(1),
+		Example: setContractMetadataCommandExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			contractAddr, err := cli.ResolveAddress(args[0], cli.TxFlags.ChainID, cli.TxFlags.URI)
+			if err != nil {
+				return err
+			}
+			if abiFile != "" {
+				abi, err := ioutil.ReadFile(abiFile)
+				if err != nil {
+					return errors.Wrap(err, "failed to read ABI file")
+				}
+				metadata.Abi = string(abi)
+			}
+			if protoDescriptorFile != "" {
+				metadata.ProtoDescriptor, err = ioutil.ReadFile(protoDescriptorFile)
+				if err != nil {
+					return errors.Wrap(err, "failed to read proto descriptor file")
+				}
+			}
+			if sourceHash != "" {
+				metadata.SourceHash, err = hex.DecodeString(sourceHash)
+				if err != nil {
+					return errors.Wrap(err, "invalid source hash")
+				}
+			}
+			err = commitRegistryTx(tx_handler.SetContractMetadataTxID, &registry.SetContractMetadataTx{
+				Contract: contractAddr.MarshalPB(),
+				Metadata: &metadata,
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Metadata of contract %s updated\n", contractAddr.String())
+			return nil
+		},
+	}
+	cmdFlags := cmd.Flags()
+	cmdFlags.StringVar(&abiFile, "abi", "", "file containing the JSON ABI of an EVM contract")
+	cmdFlags.StringVar(
+		&protoDescriptorFile, "proto-descriptor", "",
+		"file containing the serialized FileDescriptorSet of a Go contract",
+	)
+	cmdFlags.StringVar(&metadata.SourceUrl, "source-url", "", "URL of the contract source")
+	cmdFlags.StringVar(&sourceHash, "source-hash", "", "hex encoded SHA-256 hash of the verified contract source")
+	cmdFlags.StringVar(&metadata.Description, "description", "", "description of the contract")
+	cmdFlags.StringVarP(&cli.TxFlags.PrivFile, "key", "k", "", "private key file")
+	setChainFlags(cmdFlags)
+	return cmd
+}
+
+type contractMetadataInfo struct {
+	ABI             string `json:",omitempty"`
+	ProtoDescriptor []byte `json:",omitempty"`
+	SourceURL       string `json:",omitempty"`
+	SourceHash      string `json:",omitempty"`
+	Description     string `json:",omitempty"`
+}
+
+func contractMetadataCommand() *cobra.Command {
+	var flags cli.ContractCallFlags
+	cmd := &cobra.Command{
+		Use:   "metadata [contract]",
+		Short: "Get the metadata of a contract",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			addr, err := cli.ResolveAddress(args[0], flags.ChainID, flags.URI)
+			if err != nil {
+				return err
+			}
+			var rm json.RawMessage
+			params := map[string]interface{}{"contract": addr.String()}
+			if err := client.NewJSONRPCClient(flags.URI+"/query").Call("contractmetadata", params, "1", &rm); err != nil {
+				return err
+			}
+			var metadata registry.ContractMetadata
+			if len(rm) != 0 && string(rm) != "null" {
+				if err := amino.NewCodec().UnmarshalJSON(rm, &metadata); err != nil {
+					return err
+				}
+			}
+			out, err := json.MarshalIndent(&contractMetadataInfo{
+				ABI:             metadata.Abi,
+				ProtoDescriptor: metadata.ProtoDescriptor,
+				SourceURL:       metadata.SourceUrl,
+				SourceHash:      hex.EncodeToString(metadata.SourceHash),
+				Description:     metadata.Description,
+			}, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Print(string(out))
+			return nil
+		},
+	}
+	cli.AddContractStaticCallFlags(cmd.Flags(), &flags)
+	return cmd
+}
@@ -0,0 +1,273 @@
+package dataoracle
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/loomnetwork/go-loom"
+	"github.com/loomnetwork/go-loom/cli"
+	oracle "github.com/loomnetwork/loomchain/builtin/plugins/dataoracle"
+	"github.com/loomnetwork/loomchain/dataoracle"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	oracleContractName = "dataoracle"
+)
+
+type feedInfo struct {
+	ID            string
+	Description   string `json:",omitempty"`
+	Decimals      uint32
+	MaxDataPoints uint64
+	EVMConsumer   string `json:",omitempty"`
+	FirstRound    uint64
+	LastRound     uint64
+}
+
+func NewDataOracleCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "data-oracle <command>",
+		Short: "Data Oracle CLI",
+	}
+
+	cmd.AddCommand(
+		setFeedCmd(),
+		removeFeedCmd(),
+		listFeedsCmd(),
+		getLatestCmd(),
+		listDataPointsCmd(),
+		fetchCmd(),
+	)
+	return cmd
+}
+
+const setFeedCmdExample = `
+loom data-oracle set-feed ETH/USD --decimals 8 --max-data-points 10000 --description "Ether price in USD"
+`
+
+func setFeedCmd() *cobra.Command {
+	var flags cli.ContractCallFlags
+	var description, evmConsumer string
+	var decimals uint32
+	var maxDataPoints uint64
+	cmd := &cobra.Command{
+		Use:     "set-feed <feed id>",
+		Short:   "Create a new data feed, or update the settings of an existing one",
+		Example: setFeedCmdExample,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			feed := &oracle.Feed{
+				Id:            args[0],
+				Description:   description,
+				Decimals:      decimals,
+				MaxDataPoints: maxDataPoints,
+			}
+			if evmConsumer != "" {
+				addr, err := cli.ParseAddress(evmConsumer, flags.ChainID)
+				if err != nil {
+					return err
+				}
+				feed.EvmConsumer = addr.MarshalPB()
+			}
+
+			cmd.SilenceUsage = true
+
+			req := &oracle.SetFeedRequest{Feed: feed}
+			return cli.CallContractWithFlags(&flags, oracleContractName, "SetFeed", req, nil)
+		},
+	}
+
+	cmdFlags := cmd.Flags()
+	cmdFlags.StringVar(&description, "description", "", "Description of the feed")
+	cmdFlags.Uint32Var(&decimals, "decimals", 0, "Number of decimal places the feed values are scaled by")
+	cmdFlags.Uint64Var(
+		&maxDataPoints, "max-data-points", 0,
+		"Max number of data points to retain, all data points are retained if not specified",
+	)
+	cmdFlags.StringVar(&evmConsumer, "evm-consumer", "", "EVM contract to notify of new data points")
+	cli.AddContractCallFlags(cmdFlags, &flags)
+	return cmd
+}
+
+const removeFeedCmdExample = `
+loom data-oracle remove-feed ETH/USD
+`
+
+func removeFeedCmd() *cobra.Command {
+	var flags cli.ContractCallFlags
+	cmd := &cobra.Command{
+		Use:     "remove-feed <feed id>",
+		Short:   "Remove a data feed along with all its data points",
+		Example: removeFeedCmdExample,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			req := &oracle.RemoveFeedRequest{FeedId: args[0]}
+			return cli.CallContractWithFlags(&flags, oracleContractName, "RemoveFeed", req, nil)
+		},
+	}
+	cli.AddContractCallFlags(cmd.Flags(), &flags)
+	return cmd
+}
+
+const listFeedsCmdExample = `
+loom data-oracle list-feeds
+`
+
+func listFeedsCmd() *cobra.Command {
+	var flags cli.ContractCallFlags
+	cmd := &cobra.Command{
+		Use:     "list-feeds",
+		Short:   "Display all data feeds",
+		Example: listFeedsCmdExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			req := &oracle.ListFeedsRequest{}
+			var resp oracle.ListFeedsResponse
+			if err := cli.StaticCallContractWithFlags(&flags, oracleContractName, "ListFeeds", req, &resp); err != nil {
+				return err
+			}
+
+			feeds := []*feedInfo{}
+			for _, feed := range resp.Feeds {
+				info := &feedInfo{
+					ID:            feed.Id,
+					Description:   feed.Description,
+					Decimals:      feed.Decimals,
+					MaxDataPoints: feed.MaxDataPoints,
+					FirstRound:    feed.FirstRound,
+					LastRound:     feed.LastRound,
+				}
+				if feed.EvmConsumer != nil {
+					info.EVMConsumer = loom.UnmarshalAddressPB(feed.EvmConsumer).String()
+				}
+				feeds = append(feeds, info)
+			}
+			return printJSON(feeds)
+		},
+	}
+	cli.AddContractStaticCallFlags(cmd.Flags(), &flags)
+	return cmd
+}
+
+const getLatestCmdExample = `
+loom data-oracle latest ETH/USD
+`
+
+func getLatestCmd() *cobra.Command {
+	var flags cli.ContractCallFlags
+	cmd := &cobra.Command{
+		Use:     "latest <feed id>",
+		Short:   "Display the most recent data point of a feed",
+		Example: getLatestCmdExample,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			req := &oracle.GetLatestRequest{FeedId: args[0]}
+			var resp oracle.GetLatestResponse
+			if err := cli.StaticCallContractWithFlags(&flags, oracleContractName, "GetLatest", req, &resp); err != nil {
+				return err
+			}
+			if resp.DataPoint == nil {
+				fmt.Println("no data has been submitted to the feed yet")
+				return nil
+			}
+			return printJSON(resp.DataPoint)
+		},
+	}
+	cli.AddContractStaticCallFlags(cmd.Flags(), &flags)
+	return cmd
+}
+
+const listDataPointsCmdExample = `
+# Display the 10 most recent data points
+loom data-oracle list-data-points ETH/USD --limit 10
+
+# Display the data points from rounds 100 to 200
+loom data-oracle list-data-points ETH/USD --from 100 --to 200
+`
+
+func listDataPointsCmd() *cobra.Command {
+	var flags cli.ContractCallFlags
+	var fromRound, toRound, limit uint64
+	cmd := &cobra.Command{
+		Use:     "list-data-points <feed id>",
+		Short:   "Display the data points of a feed",
+		Example: listDataPointsCmdExample,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			req := &oracle.ListDataPointsRequest{
+				FeedId:    args[0],
+				FromRound: fromRound,
+				ToRound:   toRound,
+				Limit:     limit,
+			}
+			var resp oracle.ListDataPointsResponse
+			if err := cli.StaticCallContractWithFlags(&flags, oracleContractName, "ListDataPoints", req, &resp); err != nil {
+				return err
+			}
+			return printJSON(resp.DataPoints)
+		},
+	}
+	cmdFlags := cmd.Flags()
+	cmdFlags.Uint64Var(&fromRound, "from", 0, "First round to display")
+	cmdFlags.Uint64Var(&toRound, "to", 0, "Last round to display, defaults to the most recent round")
+	cmdFlags.Uint64Var(&limit, "limit", 100, "Max number of data points to display")
+	cli.AddContractStaticCallFlags(cmdFlags, &flags)
+	return cmd
+}
+
+const fetchCmdExample = `
+loom data-oracle fetch '{"type":"http","url":"https://example.com/prices","path":"ethereum.usd"}' --decimals 8
+`
+
+func fetchCmd() *cobra.Command {
+	var decimals uint32
+	cmd := &cobra.Command{
+		Use:     "fetch <source config>",
+		Short:   "Fetch a value from a data source, can be used to test the source of a data oracle Fn",
+		Example: fetchCmdExample,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var sourceCfg dataoracle.SourceConfig
+			if err := json.Unmarshal([]byte(args[0]), &sourceCfg); err != nil {
+				return errors.Wrap(err, "invalid source config")
+			}
+			source, err := dataoracle.NewSource(&sourceCfg)
+			if err != nil {
+				return err
+			}
+
+			cmd.SilenceUsage = true
+
+			rawValue, err := source.Fetch()
+			if err != nil {
+				return err
+			}
+			value, err := dataoracle.ParseValue(rawValue, decimals)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("raw value: %s\nscaled value: %d\n", rawValue, value)
+			return nil
+		},
+	}
+	cmd.Flags().Uint32Var(&decimals, "decimals", 0, "Number of decimal places to scale the value by")
+	return cmd
+}
+
+func printJSON(v interface{}) error {
+	output, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(output))
+	return nil
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/loomnetwork/go-loom/plugin"
 	"github.com/loomnetwork/go-loom/types"
 	"github.com/loomnetwork/loomchain/auth"
+	"github.com/loomnetwork/loomchain/fnConsensus"
 	"github.com/loomnetwork/loomchain/log"
 	"github.com/loomnetwork/loomchain/vm"
 	"github.com/pkg/errors"
@@ -259,6 +260,44 @@ func newDeleteAppHeightCommand() *cobra.Command {
 	return cmd
 }
 
+func newFnVotesCommand() *cobra.Command {
+	var nodeURI, fnID string
+	var limit int
+	cmd := &cobra.Command{
+		Use:   "fn-votes",
+		Short: "Displays the fnConsensus vote sets tracked by a validator node",
+		Long: "Displays the fnConsensus vote sets that are currently in progress, followed by the most " +
+			"recently completed & expired vote sets. Requires the node's unsafe RPC interface to be enabled.",
+		Example: "loom debug fn-votes --uri http://localhost:26680 --fn-id gateway --limit 20",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c := client.NewJSONRPCClient(nodeURI)
+			var rm json.RawMessage
+			params := map[string]interface{}{
+				"fnID":  fnID,
+				"limit": strconv.Itoa(limit),
+			}
+			if err := c.Call("fn_votes", params, "1", &rm); err != nil {
+				return errors.Wrap(err, "failed to call fn_votes")
+			}
+			var result fnConsensus.ResultFnVotes
+			if err := amino.NewCodec().UnmarshalJSON(rm, &result); err != nil {
+				return errors.Wrap(err, "failed to unmarshal rpc response result")
+			}
+			output, err := json.MarshalIndent(result.Votes, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(output))
+			return nil
+		},
+	}
+	cmdFlags := cmd.Flags()
+	cmdFlags.StringVarP(&nodeURI, "uri", "u", "http://localhost:26680", "Unsafe RPC URI of the node")
+	cmdFlags.StringVar(&fnID, "fn-id", "", "Only display the vote sets of the Fn with this ID")
+	cmdFlags.IntVarP(&limit, "limit", "l", 100, "Max number of completed & expired vote sets to display")
+	return cmd
+}
+
 // NewDebugCommand creates a new instance of the top-level debug command
 func NewDebugCommand() *cobra.Command {
 	cmd := &cobra.Command{
@@ -272,6 +311,7 @@ func NewDebugCommand() *cobra.Command {
 		newSetAppHeightCommand(),
 		newGetAppHeightCommand(),
 		newDeleteAppHeightCommand(),
+		newFnVotesCommand(),
 	)
 	return cmd
 }
@@ -329,10 +369,15 @@ func decodeMessageTx(tx tmtypes.Tx) (string, error) {
 	}
 
 	var vmName string
-	if vmType == vm.VMType_PLUGIN {
+	switch vmType {
+	case vm.VMType_PLUGIN:
 		vmName = "go"
-	} else if vmType == vm.VMType_EVM {
+	case vm.VMType_EVM:
 		vmName = "evm"
+	case vm.VMType_WASM:
+		vmName = "wasm"
+	default:
+		vmName = fmt.Sprintf("unknown (%d)", vmType)
 	}
 
 	return fmt.Sprintf(
@@ -12,6 +12,7 @@ import (
 	"github.com/loomnetwork/go-loom/builtin/types/dposv3"
 	"github.com/loomnetwork/go-loom/cli"
 	"github.com/loomnetwork/go-loom/types"
+	dposv3plugin "github.com/loomnetwork/loomchain/builtin/plugins/dposv3"
 	"github.com/spf13/cobra"
 )
 
@@ -717,6 +718,94 @@ func DowntimeRecordCmdV3() *cobra.Command {
 	return cmd
 }
 
+const electionHistoryCmdExample = `
+loom dpos3 election-history --limit 10
+loom dpos3 election-history --from 100 --to 200
+`
+
+func ElectionHistoryCmdV3() *cobra.Command {
+	var flags cli.ContractCallFlags
+	var fromIndex, toIndex, limit uint64
+	cmd := &cobra.Command{
+		Use:     "election-history",
+		Short:   "Display summaries of past elections",
+		Example: electionHistoryCmdExample,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var resp dposv3plugin.ListElectionSummariesResponse
+			err := cli.StaticCallContractWithFlags(
+				&flags, DPOSV3ContractName, "ListElectionSummaries",
+				&dposv3plugin.ListElectionSummariesRequest{
+					FromIndex: fromIndex,
+					ToIndex:   toIndex,
+					Limit:     limit,
+				},
+				&resp,
+			)
+			if err != nil {
+				return err
+			}
+			out, err := formatJSON(&resp)
+			if err != nil {
+				return err
+			}
+			fmt.Println(out)
+			return nil
+		},
+	}
+	cmd.Flags().Uint64Var(&fromIndex, "from", 0, "Index of the first election to display")
+	cmd.Flags().Uint64Var(&toIndex, "to", 0, "Index of the last election to display")
+	cmd.Flags().Uint64Var(&limit, "limit", 0, "Max number of elections to display")
+	cli.AddContractStaticCallFlags(cmd.Flags(), &flags)
+	return cmd
+}
+
+const validatorHistoryCmdExample = `
+loom dpos3 validator-history 0x7262d4c97c7B93937E4810D289b7320e9dA82857 --limit 10
+`
+
+func ValidatorHistoryCmdV3() *cobra.Command {
+	var flags cli.ContractCallFlags
+	var fromIndex, toIndex, limit uint64
+	cmd := &cobra.Command{
+		Use:     "validator-history [validator address]",
+		Short:   "Display the rewards, slashes & uptime of a validator in past elections",
+		Example: validatorHistoryCmdExample,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			validatorAddress, err := cli.ParseAddress(args[0], flags.ChainID)
+			if err != nil {
+				return err
+			}
+			var resp dposv3plugin.ValidatorHistoryResponse
+			err = cli.StaticCallContractWithFlags(
+				&flags, DPOSV3ContractName, "ValidatorHistory",
+				&dposv3plugin.ValidatorHistoryRequest{
+					Validator: validatorAddress.MarshalPB(),
+					FromIndex: fromIndex,
+					ToIndex:   toIndex,
+					Limit:     limit,
+				},
+				&resp,
+			)
+			if err != nil {
+				return err
+			}
+			out, err := formatJSON(&resp)
+			if err != nil {
+				return err
+			}
+			fmt.Println(out)
+			return nil
+		},
+	}
+	cmd.Flags().Uint64Var(&fromIndex, "from", 0, "Index of the first election to display")
+	cmd.Flags().Uint64Var(&toIndex, "to", 0, "Index of the last election to display")
+	cmd.Flags().Uint64Var(&limit, "limit", 0, "Max number of elections to display")
+	cli.AddContractStaticCallFlags(cmd.Flags(), &flags)
+	return cmd
+}
+
 const unbondCmdExample = `
 loom dpos3 unbond 0x7262d4c97c7B93937E4810D289b7320e9dA82857 10 0 --key path/to/private_key
 `
@@ -783,6 +872,104 @@ func UnbondAllDelegationsCmdV3() *cobra.Command {
 	return cmd
 }
 
+const setAutoCompoundCmdExample = `
+loom dpos3 set-auto-compound 0x7262d4c97c7B93937E4810D289b7320e9dA82857 1 --key path/to/private_key
+loom dpos3 set-auto-compound 0x7262d4c97c7B93937E4810D289b7320e9dA82857 --disable --key path/to/private_key
+`
+
+func SetAutoCompoundCmdV3() *cobra.Command {
+	var flags cli.ContractCallFlags
+	var disable bool
+	cmd := &cobra.Command{
+		Use:     "set-auto-compound [validator address] [index]",
+		Short:   "Automatically add rewards from a validator to a delegation at each election",
+		Example: setAutoCompoundCmdExample,
+		Args:    cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			addr, err := cli.ParseAddress(args[0], flags.ChainID)
+			if err != nil {
+				return err
+			}
+
+			var index uint64
+			if !disable {
+				if len(args) < 2 {
+					return errors.New("delegation index must be specified")
+				}
+				index, err = strconv.ParseUint(args[1], 10, 64)
+				if err != nil {
+					return err
+				}
+			}
+
+			return cli.CallContractWithFlags(
+				&flags, DPOSV3ContractName, "SetAutoCompound", &dposv3plugin.SetAutoCompoundRequest{
+					ValidatorAddress: addr.MarshalPB(),
+					Index:            index,
+					Enabled:          !disable,
+				}, nil)
+		},
+	}
+	cmd.Flags().BoolVar(&disable, "disable", false, "Stop auto-compounding rewards from the validator")
+	cli.AddContractCallFlags(cmd.Flags(), &flags)
+	return cmd
+}
+
+const listUnbondingsCmdExample = `
+loom dpos3 list-unbondings
+loom dpos3 list-unbondings --delegator 0x62666100f8988238d81831dc543D098572F283A1
+`
+
+func ListUnbondingsCmdV3() *cobra.Command {
+	var flags cli.ContractCallFlags
+	var validator, delegator string
+	var fromID, limit uint64
+	cmd := &cobra.Command{
+		Use:     "list-unbondings",
+		Short:   "Display the tokens waiting in the unbonding queue",
+		Example: listUnbondingsCmdExample,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			req := &dposv3plugin.ListUnbondingsRequest{
+				FromId: fromID,
+				Limit:  limit,
+			}
+			if validator != "" {
+				validatorAddress, err := cli.ParseAddress(validator, flags.ChainID)
+				if err != nil {
+					return err
+				}
+				req.Validator = validatorAddress.MarshalPB()
+			}
+			if delegator != "" {
+				delegatorAddress, err := cli.ResolveAccountAddress(delegator, &flags)
+				if err != nil {
+					return err
+				}
+				req.Delegator = delegatorAddress.MarshalPB()
+			}
+
+			var resp dposv3plugin.ListUnbondingsResponse
+			err := cli.StaticCallContractWithFlags(&flags, DPOSV3ContractName, "ListUnbondings", req, &resp)
+			if err != nil {
+				return err
+			}
+			out, err := formatJSON(&resp)
+			if err != nil {
+				return err
+			}
+			fmt.Println(out)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&validator, "validator", "", "Only display tokens unbonded from this validator")
+	cmd.Flags().StringVar(&delegator, "delegator", "", "Only display tokens unbonded by this delegator")
+	cmd.Flags().Uint64Var(&fromID, "from", 0, "ID of the first unbonding to display")
+	cmd.Flags().Uint64Var(&limit, "limit", 0, "Max number of unbondings to display")
+	cli.AddContractStaticCallFlags(cmd.Flags(), &flags)
+	return cmd
+}
+
 const claimDelegatorRewardsCmdExample = `
 loom dpos3 claim-delegator-rewards --key path/to/private_key
 `
@@ -1342,6 +1529,114 @@ func SetMaxDowntimePercentageCmdV3() *cobra.Command {
 	return cmd
 }
 
+const setMaxEvidenceAgeCmdExample = `
+loom dpos3 set-max-evidence-age 100 --key path/to/private_key
+`
+
+func SetMaxEvidenceAgeCmdV3() *cobra.Command {
+	var flags cli.ContractCallFlags
+	cmd := &cobra.Command{
+		Use:     "set-max-evidence-age [number of blocks]",
+		Short:   "Set the max age (in blocks) of double sign evidence that results in slashing",
+		Example: setMaxEvidenceAgeCmdExample,
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			maxEvidenceAge, err := strconv.ParseUint(args[0], 10, 64)
+			if err != nil {
+				return err
+			}
+
+			return cli.CallContractWithFlags(
+				&flags, DPOSV3ContractName, "SetMaxEvidenceAge", &dposv3plugin.SetMaxEvidenceAgeRequest{
+					MaxEvidenceAge: maxEvidenceAge,
+				}, nil)
+		},
+	}
+	cli.AddContractCallFlags(cmd.Flags(), &flags)
+	return cmd
+}
+
+const setDoubleSignJailPeriodCmdExample = `
+loom dpos3 set-double-sign-jail-period 86400 --key path/to/private_key
+`
+
+func SetDoubleSignJailPeriodCmdV3() *cobra.Command {
+	var flags cli.ContractCallFlags
+	cmd := &cobra.Command{
+		Use:     "set-double-sign-jail-period [number of blocks]",
+		Short:   "Set the min number of blocks a validator jailed for double signing remains jailed",
+		Example: setDoubleSignJailPeriodCmdExample,
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			jailPeriod, err := strconv.ParseUint(args[0], 10, 64)
+			if err != nil {
+				return err
+			}
+
+			return cli.CallContractWithFlags(
+				&flags, DPOSV3ContractName, "SetDoubleSignJailPeriod", &dposv3plugin.SetDoubleSignJailPeriodRequest{
+					JailPeriod: jailPeriod,
+				}, nil)
+		},
+	}
+	cli.AddContractCallFlags(cmd.Flags(), &flags)
+	return cmd
+}
+
+const setElectionHistoryLimitCmdExample = `
+loom dpos3 set-election-history-limit 10000 --key path/to/private_key
+`
+
+func SetElectionHistoryLimitCmdV3() *cobra.Command {
+	var flags cli.ContractCallFlags
+	cmd := &cobra.Command{
+		Use:     "set-election-history-limit [number of elections]",
+		Short:   "Set the max number of election summaries retained by the DPOS contract",
+		Example: setElectionHistoryLimitCmdExample,
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			maxSummaries, err := strconv.ParseUint(args[0], 10, 64)
+			if err != nil {
+				return err
+			}
+
+			return cli.CallContractWithFlags(
+				&flags, DPOSV3ContractName, "SetElectionHistoryLimit", &dposv3plugin.SetElectionHistoryLimitRequest{
+					MaxSummaries: maxSummaries,
+				}, nil)
+		},
+	}
+	cli.AddContractCallFlags(cmd.Flags(), &flags)
+	return cmd
+}
+
+const setUnbondingPeriodCmdExample = `
+loom dpos3 set-unbonding-period 604800 --key path/to/private_key
+`
+
+func SetUnbondingPeriodCmdV3() *cobra.Command {
+	var flags cli.ContractCallFlags
+	cmd := &cobra.Command{
+		Use:     "set-unbonding-period [seconds]",
+		Short:   "Set the number of seconds unbonded tokens remain slashable before they're released",
+		Example: setUnbondingPeriodCmdExample,
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			unbondingPeriod, err := strconv.ParseInt(args[0], 10, 64)
+			if err != nil {
+				return err
+			}
+
+			return cli.CallContractWithFlags(
+				&flags, DPOSV3ContractName, "SetUnbondingPeriod", &dposv3plugin.SetUnbondingPeriodRequest{
+					UnbondingPeriod: unbondingPeriod,
+				}, nil)
+		},
+	}
+	cli.AddContractCallFlags(cmd.Flags(), &flags)
+	return cmd
+}
+
 const setMinCandidateFeeCmdExample = `
 loom dpos3 set-min-candidate-fee 900 --key path/to/private_key
 `
@@ -1407,8 +1702,12 @@ func NewDPOSV3Command() *cobra.Command {
 		CheckAllDelegationsCmdV3(),
 		CheckRewardsCmdV3(),
 		DowntimeRecordCmdV3(),
+		ElectionHistoryCmdV3(),
+		ValidatorHistoryCmdV3(),
 		UnbondCmdV3(),
 		UnbondAllDelegationsCmdV3(),
+		SetAutoCompoundCmdV3(),
+		ListUnbondingsCmdV3(),
 		RegisterReferrerCmdV3(),
 		SetDowntimePeriodCmdV3(),
 		SetElectionCycleCmdV3(),
@@ -1418,6 +1717,10 @@ func NewDPOSV3Command() *cobra.Command {
 		SetOracleAddressCmdV3(),
 		SetSlashingPercentagesCmdV3(),
 		SetMaxDowntimePercentageCmdV3(),
+		SetMaxEvidenceAgeCmdV3(),
+		SetDoubleSignJailPeriodCmdV3(),
+		SetElectionHistoryLimitCmdV3(),
+		SetUnbondingPeriodCmdV3(),
 		ChangeFeeCmdV3(),
 		TimeUntilElectionCmdV3(),
 		GetStateCmdV3(),
@@ -42,6 +42,49 @@ func startGatewayReactors(
 	return nil
 }
 
+// Registers the factories that can be used to create the batch sign withdrawal Fns from the Fn
+// records in the ChainConfig contract. The Fns are configured using the node's own gateway config,
+// so these factories don't accept any params, records that specify params are rejected.
+func registerGatewayFnFactories(
+	chainID string,
+	registry *fnConsensus.DynamicFnRegistry,
+	cfg *config.Config,
+	nodeSigner glAuth.Signer,
+) error {
+	factories := map[string]fnConsensus.FnFactory{
+		GatewayName:     batchSignWithdrawalFnFactory(false, chainID, cfg.TransferGateway, nodeSigner),
+		LoomGatewayName: batchSignWithdrawalFnFactory(true, chainID, cfg.LoomCoinTransferGateway, nodeSigner),
+		TronGatewayName: batchSignWithdrawalFnFactory(true, chainID, cfg.TronTransferGateway, nodeSigner),
+	}
+	for name, factory := range factories {
+		if err := registry.RegisterFactory(name, factory); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func batchSignWithdrawalFnFactory(
+	isLoomCoinOrTron bool,
+	chainID string,
+	cfg *tgateway.TransferGatewayConfig,
+	nodeSigner glAuth.Signer,
+) fnConsensus.FnFactory {
+	return func(fnID string, params []byte) (fnConsensus.Fn, error) {
+		if len(params) > 0 {
+			return nil, fmt.Errorf("batch sign withdrawal Fn %s doesn't accept any params", fnID)
+		}
+		if !cfg.BatchSignFnConfig.Enabled {
+			return nil, fmt.Errorf("batch sign withdrawal Fn %s is disabled in the node config", fnID)
+		}
+		batchSignWithdrawalFn, err := tgateway.CreateBatchSignWithdrawalFn(isLoomCoinOrTron, chainID, cfg, nodeSigner)
+		if err != nil {
+			return nil, err
+		}
+		return batchSignWithdrawalFn, nil
+	}
+}
+
 // Checks if the query server at the given DAppChainReadURI is responding.
 func checkQueryService(name string, chainID string, DAppChainReadURI string, DAppChainWriteURI string) {
 	ticker := time.NewTicker(5 * time.Second)
@@ -0,0 +1,460 @@
+package governance
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/loomnetwork/go-loom"
+	dtypes "github.com/loomnetwork/go-loom/builtin/types/dposv3"
+	"github.com/loomnetwork/go-loom/cli"
+	"github.com/loomnetwork/go-loom/types"
+	gov "github.com/loomnetwork/loomchain/builtin/plugins/governance"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	govContractName  = "governance"
+	dposContractName = "dposV3"
+)
+
+type paramsInfo struct {
+	VotingPeriod            uint64
+	MinProposerStake        string
+	QuorumPercentage        uint64
+	PassThresholdPercentage uint64
+}
+
+type voteInfo struct {
+	Voter  string
+	Choice string
+}
+
+type proposalInfo struct {
+	ID              uint64
+	Proposer        string
+	Kind            string
+	Description     string
+	Contract        string `json:",omitempty"`
+	Method          string `json:",omitempty"`
+	Args            string `json:",omitempty"`
+	Feature         string `json:",omitempty"`
+	Recipient       string `json:",omitempty"`
+	Amount          string `json:",omitempty"`
+	Status          string
+	SubmitHeight    uint64
+	VotingEndHeight uint64
+	YesPower        string      `json:",omitempty"`
+	NoPower         string      `json:",omitempty"`
+	AbstainPower    string      `json:",omitempty"`
+	ExecutionError  string      `json:",omitempty"`
+	Votes           []*voteInfo `json:",omitempty"`
+}
+
+func NewGovernanceCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "governance <command>",
+		Short: "On-chain Governance CLI",
+	}
+
+	cmd.AddCommand(
+		proposeDPOSParamCmd(),
+		proposeCallCmd(),
+		proposeFeatureCmd(),
+		proposeSpendCmd(),
+		voteCmd(),
+		getProposalCmd(),
+		listProposalsCmd(),
+		getParamsCmd(),
+	)
+	return cmd
+}
+
+const proposeDPOSParamCmdExample = `
+loom governance propose-dpos-param validator-count 21 --description "Increase validator count"
+loom governance propose-dpos-param slashing-percentages 100 500
+`
+
+func proposeDPOSParamCmd() *cobra.Command {
+	var flags cli.ContractCallFlags
+	var description string
+	cmd := &cobra.Command{
+		Use: "propose-dpos-param <param> <value>...",
+		Short: "Propose a change to a DPOS param, one of: validator-count, max-yearly-reward, " +
+			"registration-requirement, min-candidate-fee, downtime-period, slashing-percentages",
+		Long: "Propose a change to a DPOS param. The proposal can only be executed if the DPOS oracle " +
+			"has been set to the address of the Governance contract.",
+		Example: proposeDPOSParamCmdExample,
+		Args:    cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			method, req, err := dposParamRequest(args[0], args[1:])
+			if err != nil {
+				return err
+			}
+			reqBytes, err := proto.Marshal(req)
+			if err != nil {
+				return err
+			}
+
+			cmd.SilenceUsage = true
+
+			return submitProposal(&flags, &gov.SubmitProposalRequest{
+				Kind:        gov.ParamChangeProposal,
+				Description: description,
+				ParamChange: &gov.ParamChange{
+					ContractName: dposContractName,
+					Method:       method,
+					Args:         reqBytes,
+				},
+			})
+		},
+	}
+	cmdFlags := cmd.Flags()
+	cmdFlags.StringVar(&description, "description", "", "Description of the proposal")
+	cli.AddContractCallFlags(cmdFlags, &flags)
+	return cmd
+}
+
+func dposParamRequest(param string, values []string) (string, proto.Message, error) {
+	switch param {
+	case "validator-count":
+		count, err := strconv.ParseInt(values[0], 10, 64)
+		if err != nil {
+			return "", nil, err
+		}
+		return "SetValidatorCount", &dtypes.SetValidatorCountRequest{ValidatorCount: count}, nil
+	case "max-yearly-reward":
+		amount, err := cli.ParseAmount(values[0])
+		if err != nil {
+			return "", nil, err
+		}
+		return "SetMaxYearlyReward", &dtypes.SetMaxYearlyRewardRequest{
+			MaxYearlyReward: &types.BigUInt{Value: *amount},
+		}, nil
+	case "registration-requirement":
+		amount, err := cli.ParseAmount(values[0])
+		if err != nil {
+			return "", nil, err
+		}
+		return "SetRegistrationRequirement", &dtypes.SetRegistrationRequirementRequest{
+			RegistrationRequirement: &types.BigUInt{Value: *amount},
+		}, nil
+	case "min-candidate-fee":
+		fee, err := strconv.ParseUint(values[0], 10, 64)
+		if err != nil {
+			return "", nil, err
+		}
+		return "SetMinCandidateFee", &dtypes.SetMinCandidateFeeRequest{MinCandidateFee: fee}, nil
+	case "downtime-period":
+		period, err := strconv.ParseUint(values[0], 10, 64)
+		if err != nil {
+			return "", nil, err
+		}
+		return "SetDowntimePeriod", &dtypes.SetDowntimePeriodRequest{DowntimePeriod: period}, nil
+	case "slashing-percentages":
+		if len(values) != 2 {
+			return "", nil, errors.New("expected crash & byzantine fault slashing percentages")
+		}
+		crash, err := strconv.ParseInt(values[0], 10, 64)
+		if err != nil {
+			return "", nil, err
+		}
+		byzantine, err := strconv.ParseInt(values[1], 10, 64)
+		if err != nil {
+			return "", nil, err
+		}
+		return "SetSlashingPercentages", &dtypes.SetSlashingPercentagesRequest{
+			CrashSlashingPercentage:     &types.BigUInt{Value: *loom.NewBigUIntFromInt(crash)},
+			ByzantineSlashingPercentage: &types.BigUInt{Value: *loom.NewBigUIntFromInt(byzantine)},
+		}, nil
+	}
+	return "", nil, fmt.Errorf("unsupported DPOS param %s", param)
+}
+
+const proposeCallCmdExample = `
+loom governance propose-call dposV3 SetElectionCycle 08b054 --description "Change election cycle"
+`
+
+func proposeCallCmd() *cobra.Command {
+	var flags cli.ContractCallFlags
+	var description string
+	cmd := &cobra.Command{
+		Use:     "propose-call <contract name> <method> <hex-encoded protobuf args>",
+		Short:   "Propose a call to a Go contract method, made by the Governance contract",
+		Example: proposeCallCmdExample,
+		Args:    cobra.ExactArgs(3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			methodArgs, err := hex.DecodeString(strings.TrimPrefix(args[2], "0x"))
+			if err != nil {
+				return errors.Wrap(err, "invalid args")
+			}
+
+			cmd.SilenceUsage = true
+
+			return submitProposal(&flags, &gov.SubmitProposalRequest{
+				Kind:        gov.ParamChangeProposal,
+				Description: description,
+				ParamChange: &gov.ParamChange{
+					ContractName: args[0],
+					Method:       args[1],
+					Args:         methodArgs,
+				},
+			})
+		},
+	}
+	cmdFlags := cmd.Flags()
+	cmdFlags.StringVar(&description, "description", "", "Description of the proposal")
+	cli.AddContractCallFlags(cmdFlags, &flags)
+	return cmd
+}
+
+const proposeFeatureCmdExample = `
+loom governance propose-feature tx:fee-market --description "Enable the fee market"
+`
+
+func proposeFeatureCmd() *cobra.Command {
+	var flags cli.ContractCallFlags
+	var description string
+	cmd := &cobra.Command{
+		Use:     "propose-feature <feature name>",
+		Short:   "Propose the activation of a feature flag",
+		Example: proposeFeatureCmdExample,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			return submitProposal(&flags, &gov.SubmitProposalRequest{
+				Kind:        gov.EnableFeatureProposal,
+				Description: description,
+				FeatureName: args[0],
+			})
+		},
+	}
+	cmdFlags := cmd.Flags()
+	cmdFlags.StringVar(&description, "description", "", "Description of the proposal")
+	cli.AddContractCallFlags(cmdFlags, &flags)
+	return cmd
+}
+
+const proposeSpendCmdExample = `
+loom governance propose-spend 0x7262d4c97c7B93937E4810D289b7320e9dA82857 1000 --description "Grant"
+`
+
+func proposeSpendCmd() *cobra.Command {
+	var flags cli.ContractCallFlags
+	var description string
+	cmd := &cobra.Command{
+		Use:     "propose-spend <recipient address> <amount>",
+		Short:   "Propose a transfer of coins from the community pool",
+		Example: proposeSpendCmdExample,
+		Args:    cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			recipient, err := cli.ParseAddress(args[0], flags.ChainID)
+			if err != nil {
+				return err
+			}
+			amount, err := cli.ParseAmount(args[1])
+			if err != nil {
+				return err
+			}
+
+			cmd.SilenceUsage = true
+
+			return submitProposal(&flags, &gov.SubmitProposalRequest{
+				Kind:        gov.CommunityPoolSpendProposal,
+				Description: description,
+				PoolSpend: &gov.CommunityPoolSpend{
+					Recipient: recipient.MarshalPB(),
+					Amount:    &types.BigUInt{Value: *amount},
+				},
+			})
+		},
+	}
+	cmdFlags := cmd.Flags()
+	cmdFlags.StringVar(&description, "description", "", "Description of the proposal")
+	cli.AddContractCallFlags(cmdFlags, &flags)
+	return cmd
+}
+
+func submitProposal(flags *cli.ContractCallFlags, req *gov.SubmitProposalRequest) error {
+	var resp gov.SubmitProposalResponse
+	if err := cli.CallContractWithFlags(flags, govContractName, "SubmitProposal", req, &resp); err != nil {
+		return err
+	}
+	fmt.Printf("Submitted proposal %d\n", resp.ProposalId)
+	return nil
+}
+
+const voteCmdExample = `
+loom governance vote 1 yes
+`
+
+func voteCmd() *cobra.Command {
+	var flags cli.ContractCallFlags
+	cmd := &cobra.Command{
+		Use:     "vote <proposal id> <yes|no|abstain>",
+		Short:   "Vote on a proposal, votes are weighted by the voter's DPOS delegations",
+		Example: voteCmdExample,
+		Args:    cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			proposalID, err := strconv.ParseUint(args[0], 10, 64)
+			if err != nil {
+				return errors.Wrap(err, "invalid proposal ID")
+			}
+			choice, ok := gov.GovernanceVote_Choice_value[strings.ToUpper(args[1])]
+			if !ok {
+				return fmt.Errorf("invalid vote %s, expected yes, no, or abstain", args[1])
+			}
+
+			cmd.SilenceUsage = true
+
+			req := &gov.VoteRequest{
+				ProposalId: proposalID,
+				Choice:     gov.VoteChoice(choice),
+			}
+			return cli.CallContractWithFlags(&flags, govContractName, "Vote", req, nil)
+		},
+	}
+	cli.AddContractCallFlags(cmd.Flags(), &flags)
+	return cmd
+}
+
+const getProposalCmdExample = `
+loom governance get-proposal 1
+`
+
+func getProposalCmd() *cobra.Command {
+	var flags cli.ContractCallFlags
+	cmd := &cobra.Command{
+		Use:     "get-proposal <proposal id>",
+		Short:   "Display a proposal & the votes cast on it",
+		Example: getProposalCmdExample,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			proposalID, err := strconv.ParseUint(args[0], 10, 64)
+			if err != nil {
+				return errors.Wrap(err, "invalid proposal ID")
+			}
+
+			cmd.SilenceUsage = true
+
+			req := &gov.GetProposalRequest{ProposalId: proposalID}
+			var resp gov.GetProposalResponse
+			if err := cli.StaticCallContractWithFlags(&flags, govContractName, "GetProposal", req, &resp); err != nil {
+				return err
+			}
+
+			info := getProposalInfo(resp.Proposal)
+			for _, vote := range resp.Votes {
+				info.Votes = append(info.Votes, &voteInfo{
+					Voter:  loom.UnmarshalAddressPB(vote.Voter).String(),
+					Choice: vote.Choice.String(),
+				})
+			}
+			return printJSON(info)
+		},
+	}
+	cli.AddContractStaticCallFlags(cmd.Flags(), &flags)
+	return cmd
+}
+
+func listProposalsCmd() *cobra.Command {
+	var flags cli.ContractCallFlags
+	cmd := &cobra.Command{
+		Use:   "list-proposals",
+		Short: "Display all proposals",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			var resp gov.ListProposalsResponse
+			err := cli.StaticCallContractWithFlags(
+				&flags, govContractName, "ListProposals", &gov.ListProposalsRequest{}, &resp,
+			)
+			if err != nil {
+				return err
+			}
+
+			proposals := []*proposalInfo{}
+			for _, proposal := range resp.Proposals {
+				proposals = append(proposals, getProposalInfo(proposal))
+			}
+			return printJSON(proposals)
+		},
+	}
+	cli.AddContractStaticCallFlags(cmd.Flags(), &flags)
+	return cmd
+}
+
+func getParamsCmd() *cobra.Command {
+	var flags cli.ContractCallFlags
+	cmd := &cobra.Command{
+		Use:   "get-params",
+		Short: "Display the Governance contract params",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			var resp gov.GetParamsResponse
+			err := cli.StaticCallContractWithFlags(
+				&flags, govContractName, "GetParams", &gov.GetParamsRequest{}, &resp,
+			)
+			if err != nil {
+				return err
+			}
+			return printJSON(&paramsInfo{
+				VotingPeriod:            resp.Params.VotingPeriod,
+				MinProposerStake:        bigUIntString(resp.Params.MinProposerStake),
+				QuorumPercentage:        resp.Params.QuorumPercentage,
+				PassThresholdPercentage: resp.Params.PassThresholdPercentage,
+			})
+		},
+	}
+	cli.AddContractStaticCallFlags(cmd.Flags(), &flags)
+	return cmd
+}
+
+func getProposalInfo(proposal *gov.Proposal) *proposalInfo {
+	info := &proposalInfo{
+		ID:              proposal.Id,
+		Proposer:        loom.UnmarshalAddressPB(proposal.Proposer).String(),
+		Kind:            proposal.Kind.String(),
+		Description:     proposal.Description,
+		Feature:         proposal.FeatureName,
+		Status:          proposal.Status.String(),
+		SubmitHeight:    proposal.SubmitHeight,
+		VotingEndHeight: proposal.VotingEndHeight,
+		YesPower:        bigUIntString(proposal.YesPower),
+		NoPower:         bigUIntString(proposal.NoPower),
+		AbstainPower:    bigUIntString(proposal.AbstainPower),
+		ExecutionError:  proposal.ExecutionError,
+	}
+	if proposal.ParamChange != nil {
+		info.Contract = proposal.ParamChange.ContractName
+		info.Method = proposal.ParamChange.Method
+		info.Args = hex.EncodeToString(proposal.ParamChange.Args)
+	}
+	if proposal.PoolSpend != nil {
+		info.Recipient = loom.UnmarshalAddressPB(proposal.PoolSpend.Recipient).String()
+		info.Amount = bigUIntString(proposal.PoolSpend.Amount)
+	}
+	return info
+}
+
+func bigUIntString(v *types.BigUInt) string {
+	if v == nil || v.Value.Int == nil {
+		return ""
+	}
+	return v.Value.String()
+}
+
+func printJSON(v interface{}) error {
+	output, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(output))
+	return nil
+}
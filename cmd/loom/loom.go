@@ -17,6 +17,7 @@ import (
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus/push"
+	"github.com/tendermint/go-amino"
 	"github.com/tendermint/tendermint/libs/db"
 
 	kitprometheus "github.com/go-kit/kit/metrics/prometheus"
@@ -41,15 +42,20 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/loomnetwork/loomchain/chainconfig"
+	accesscontrolcmd "github.com/loomnetwork/loomchain/cmd/loom/accesscontrol"
 	chaincfgcmd "github.com/loomnetwork/loomchain/cmd/loom/chainconfig"
 	"github.com/loomnetwork/loomchain/cmd/loom/common"
+	oraclecmd "github.com/loomnetwork/loomchain/cmd/loom/dataoracle"
 	dbcmd "github.com/loomnetwork/loomchain/cmd/loom/db"
 	"github.com/loomnetwork/loomchain/cmd/loom/dbg"
 	deployer "github.com/loomnetwork/loomchain/cmd/loom/deployerwhitelist"
 	gatewaycmd "github.com/loomnetwork/loomchain/cmd/loom/gateway"
+	govcmd "github.com/loomnetwork/loomchain/cmd/loom/governance"
+	ratelimitcmd "github.com/loomnetwork/loomchain/cmd/loom/ratelimit"
 	userdeployer "github.com/loomnetwork/loomchain/cmd/loom/userdeployerwhitelist"
 	"github.com/loomnetwork/loomchain/config"
 	"github.com/loomnetwork/loomchain/core"
+	"github.com/loomnetwork/loomchain/dataoracle"
 	cdb "github.com/loomnetwork/loomchain/db"
 	"github.com/loomnetwork/loomchain/eth/polls"
 	"github.com/loomnetwork/loomchain/events"
@@ -70,6 +76,7 @@ import (
 	"github.com/loomnetwork/loomchain/throttle"
 	"github.com/loomnetwork/loomchain/tx_handler"
 	"github.com/loomnetwork/loomchain/vm"
+	"github.com/loomnetwork/loomchain/wasm"
 	"github.com/pkg/errors"
 	stdprometheus "github.com/prometheus/client_golang/prometheus"
 	"github.com/spf13/cobra"
@@ -81,6 +88,9 @@ var (
 	configKey    = []byte("config")
 )
 
+// How often the node checks for external plugin processes that need to be restarted.
+const externalPluginSuperviseInterval = 5 * time.Second
+
 var RootCmd = &cobra.Command{
 	Use:   "loom",
 	Short: "Loom DAppChain",
@@ -139,12 +149,45 @@ func newEnvCommand() *cobra.Command {
 				"go-btcd":          loomchain.BtcdGitSHA,
 				"plugin path":      cfg.PluginsPath(),
 				"peers":            cfg.Peers,
+				"plugin health":    pluginHealthSummary(cfg),
 			})
 			return nil
 		},
 	}
 }
 
+// Returns a summary of the state of the external plugin processes spawned by the node, the state is
+// fetched from the node's unsafe RPC interface, so it's only available if the node is running.
+func pluginHealthSummary(cfg *config.Config) string {
+	if !cfg.UnsafeRPCEnabled {
+		return "unavailable, unsafe RPC is disabled"
+	}
+	uri := strings.Replace(cfg.UnsafeRPCBindAddress, "tcp://", "http://", 1)
+	var rm json.RawMessage
+	if err := client.NewJSONRPCClient(uri).Call("plugin_health", map[string]interface{}{}, "1", &rm); err != nil {
+		return fmt.Sprintf("unavailable, %v", err)
+	}
+	var result plugin.ResultPluginHealth
+	if err := amino.NewCodec().UnmarshalJSON(rm, &result); err != nil {
+		return fmt.Sprintf("unavailable, %v", err)
+	}
+	if len(result.Plugins) == 0 {
+		return "no external plugins loaded"
+	}
+	var summary []string
+	for _, p := range result.Plugins {
+		state := "running"
+		if !p.Running {
+			state = "exited"
+			if p.NextRestartAt != 0 {
+				state += ", restarting at " + time.Unix(p.NextRestartAt, 0).Format(time.RFC3339)
+			}
+		}
+		summary = append(summary, fmt.Sprintf("%s (%s, restarts: %d)", p.Name, state, p.Restarts))
+	}
+	return strings.Join(summary, "; ")
+}
+
 type genKeyFlags struct {
 	PublicFile string `json:"publicfile"`
 	PrivFile   string `json:"privfile"`
@@ -354,9 +397,14 @@ func newRunCommand() *cobra.Command {
 			}
 			var fnRegistry fnConsensus.FnRegistry
 			if cfg.FnConsensus.Enabled {
-				fnRegistry = fnConsensus.NewInMemoryFnRegistry()
+				if cfg.FnConsensus.DynamicFnsEnabled {
+					fnRegistry = fnConsensus.NewDynamicFnRegistry()
+				} else {
+					fnRegistry = fnConsensus.NewInMemoryFnRegistry()
+				}
 			}
 			var loaders []plugin.Loader
+			var externalLoader *plugin.ExternalLoader
 			for _, loader := range cfg.ContractLoaders {
 				if strings.EqualFold("static", loader) {
 					loaders = append(loaders, common.NewDefaultContractsLoader(cfg))
@@ -365,7 +413,8 @@ func newRunCommand() *cobra.Command {
 					loaders = append(loaders, plugin.NewManager(cfg.PluginsPath()))
 				}
 				if strings.EqualFold("external", loader) {
-					loaders = append(loaders, plugin.NewExternalLoader(cfg.PluginsPath()))
+					externalLoader = plugin.NewExternalLoader(cfg.PluginsPath())
+					loaders = append(loaders, externalLoader)
 				}
 			}
 			backend := initBackend(cfg, abciServerAddr, fnRegistry)
@@ -406,6 +455,17 @@ func newRunCommand() *cobra.Command {
 			if err != nil {
 				return err
 			}
+			// Notifies the Fn registry sync routine of each committed block, if the routine is busy
+			// syncing it'll sync again once it's done, so any notifications in between are dropped.
+			fnRecordsBlockCommits := make(chan int64, 1)
+			if _, ok := fnRegistry.(*fnConsensus.DynamicFnRegistry); ok && cfg.FnConsensus.Reactor.IsValidator {
+				app.OnBlockCommitted = func(height int64) {
+					select {
+					case fnRecordsBlockCommits <- height:
+					default:
+					}
+				}
+			}
 			if err := backend.Start(app); err != nil {
 				return err
 			}
@@ -415,7 +475,20 @@ func newRunCommand() *cobra.Command {
 				return err
 			}
 
-			if err := initQueryService(app, chainID, cfg, loader, app.ReceiptHandlerProvider); err != nil {
+			// Avoid wrapping a nil reactor in a non-nil interface
+			var fnVotes rpc.FnVoteHistory
+			if fnConsensusReactor := backend.FnConsensusReactor(); fnConsensusReactor != nil {
+				fnVotes = fnConsensusReactor
+			}
+			var pluginHealth rpc.PluginHealthProvider
+			if externalLoader != nil {
+				pluginHealth = externalLoader
+				go externalLoader.Supervise(externalPluginSuperviseInterval, make(chan struct{}))
+			}
+
+			if err := initQueryService(
+				app, chainID, cfg, loader, app.ReceiptHandlerProvider, fnVotes, pluginHealth,
+			); err != nil {
 				return err
 			}
 
@@ -424,6 +497,10 @@ func newRunCommand() *cobra.Command {
 				if err := startGatewayReactors(chainID, fnRegistry, cfg, nodeSigner); err != nil {
 					return err
 				}
+				err := startFnRegistrySync(chainID, fnRegistry, cfg, nodeSigner, fnRecordsBlockCommits)
+				if err != nil {
+					return err
+				}
 			}
 
 			if err := startPlasmaOracle(chainID, cfg.PlasmaCash); err != nil {
@@ -451,9 +528,15 @@ loom contract default:0x81ee596ba88eF371a51d4B535E07cB243A8C692d
 `
 
 type contractInfo struct {
-	Name    string
-	Address string
-	Owner   string
+	Name     string
+	Address  string
+	Owner    string
+	Versions []contractVersionInfo `json:",omitempty"`
+}
+
+type contractVersionInfo struct {
+	PluginName string
+	Height     int64
 }
 
 func contractInfoCommand() *cobra.Command {
@@ -468,15 +551,27 @@ func contractInfoCommand() *cobra.Command {
 			if err != nil {
 				return err
 			}
-			rpcclient := client.NewDAppChainRPCClient(flags.ChainID, flags.URI+"/rpc", flags.URI+"/query")
-			resp, err := rpcclient.GetContractRecord(addr)
-			if err != nil {
+			// The response is fetched directly because the DAppChain client doesn't return the
+			// contract versions.
+			var rm json.RawMessage
+			params := map[string]interface{}{"contract": addr.String()}
+			if err := client.NewJSONRPCClient(flags.URI+"/query").Call("contractrecord", params, "1", &rm); err != nil {
+				return err
+			}
+			var resp rpc.ContractRecordResponse
+			if err := amino.NewCodec().UnmarshalJSON(rm, &resp); err != nil {
 				return err
 			}
 			contractInfoResp := &contractInfo{
-				Name:    resp.GetContractName(),
-				Address: loom.UnmarshalAddressPB(resp.GetContractAddress()).String(),
-				Owner:   loom.UnmarshalAddressPB(resp.GetCreatorAddress()).String(),
+				Name:    resp.ContractName,
+				Address: loom.UnmarshalAddressPB(resp.ContractAddress).String(),
+				Owner:   loom.UnmarshalAddressPB(resp.CreatorAddress).String(),
+			}
+			for _, v := range resp.Versions {
+				contractInfoResp.Versions = append(contractInfoResp.Versions, contractVersionInfo{
+					PluginName: v.PluginName,
+					Height:     v.Height,
+				})
 			}
 
 			out, err := json.MarshalIndent(contractInfoResp, "", "  ")
@@ -488,6 +583,11 @@ func contractInfoCommand() *cobra.Command {
 		},
 	}
 	cli.AddContractStaticCallFlags(cmd.Flags(), &flags)
+	cmd.AddCommand(
+		contractMetadataCommand(),
+		transferOwnershipCommand(),
+		setContractMetadataCommand(),
+	)
 	return cmd
 }
 
@@ -517,6 +617,38 @@ func startFeatureAutoEnabler(
 	return nil
 }
 
+// Starts a routine that keeps the Fns registered with the fnConsensus reactor in sync with the
+// Fn records stored in the ChainConfig contract, only if the dynamic Fn registry is in use.
+func startFnRegistrySync(
+	chainID string,
+	fnRegistry fnConsensus.FnRegistry,
+	cfg *config.Config,
+	nodeSigner glAuth.Signer,
+	blockCommits <-chan int64,
+) error {
+	registry, ok := fnRegistry.(*fnConsensus.DynamicFnRegistry)
+	if !ok {
+		return nil
+	}
+
+	if err := registerGatewayFnFactories(chainID, registry, cfg, nodeSigner); err != nil {
+		return err
+	}
+
+	if cfg.DataOracle.ContractEnabled {
+		oracleFnFactory := dataoracle.NewFnFactory(chainID, cfg.DataOracle, nodeSigner)
+		if err := registry.RegisterFactory(dataoracle.FnFactoryName, oracleFnFactory); err != nil {
+			return err
+		}
+	}
+
+	routine := chainconfig.NewFnRegistryRoutine(
+		cfg.ChainConfig, chainID, nodeSigner, registry, blockCommits, log.Default,
+	)
+	go routine.RunWithRecovery()
+	return nil
+}
+
 func startPlasmaOracle(chainID string, cfg *plasmaConfig.PlasmaCashSerializableConfig) error {
 	plasmaCfg, err := plasmaConfig.LoadSerializableConfig(chainID, cfg)
 	if err != nil {
@@ -839,6 +971,17 @@ func loadApp(
 	}
 	evm.LogEthDbBatch = cfg.LogEthDbBatch
 
+	vmManager.Register(vm.VMType_WASM, func(state loomchain.State) (vm.VM, error) {
+		if !state.FeatureEnabled(features.WasmVMFeature, false) {
+			return nil, errors.New("Wasm contracts are not enabled")
+		}
+		wasmVM, err := wasm.NewWasmVM(state, createRegistry(state), eventHandler, log.Default)
+		if err != nil {
+			return nil, err
+		}
+		return wasmVM, nil
+	})
+
 	deployTxHandler := &vm.DeployTxHandler{
 		Manager:                vmManager,
 		CreateRegistry:         createRegistry,
@@ -861,9 +1004,21 @@ func loadApp(
 			1: migrations.DPOSv3Migration,
 			2: migrations.GatewayMigration,
 			3: migrations.GatewayMigration,
+			4: migrations.AddressMapperRecoveryAdminMigration,
 		},
 	}
 
+	upgradeContractTxHandler := &tx_handler.UpgradeContractTxHandler{
+		CreateRegistry: createRegistry,
+		Loader:         loader,
+	}
+	transferOwnershipTxHandler := &tx_handler.TransferOwnershipTxHandler{
+		CreateRegistry: createRegistry,
+	}
+	setContractMetadataTxHandler := &tx_handler.SetContractMetadataTxHandler{
+		CreateRegistry: createRegistry,
+	}
+
 	gen, err := config.ReadGenesis(cfg.GenesisPath())
 	if err != nil {
 		return nil, err
@@ -936,12 +1091,30 @@ func loadApp(
 	router.HandleDeliverTx(2, loomchain.GeneratePassthroughRouteHandler(callTxHandler))
 	router.HandleDeliverTx(3, loomchain.GeneratePassthroughRouteHandler(migrationTxHandler))
 	router.HandleDeliverTx(4, loomchain.GeneratePassthroughRouteHandler(ethTxHandler))
+	router.HandleDeliverTx(
+		tx_handler.UpgradeContractTxID, loomchain.GeneratePassthroughRouteHandler(upgradeContractTxHandler),
+	)
+	router.HandleDeliverTx(
+		tx_handler.TransferOwnershipTxID, loomchain.GeneratePassthroughRouteHandler(transferOwnershipTxHandler),
+	)
+	router.HandleDeliverTx(
+		tx_handler.SetContractMetadataTxID, loomchain.GeneratePassthroughRouteHandler(setContractMetadataTxHandler),
+	)
 
 	// TODO: Write this in more elegant way
 	router.HandleCheckTx(1, loomchain.GenerateConditionalRouteHandler(isEvmTx, loomchain.NoopTxHandler, deployTxHandler))
 	router.HandleCheckTx(2, loomchain.GenerateConditionalRouteHandler(isEvmTx, loomchain.NoopTxHandler, callTxHandler))
 	router.HandleCheckTx(3, loomchain.GenerateConditionalRouteHandler(isEvmTx, loomchain.NoopTxHandler, migrationTxHandler))
 	router.HandleCheckTx(4, loomchain.GenerateConditionalRouteHandler(isEvmTx, loomchain.NoopTxHandler, ethTxHandler))
+	router.HandleCheckTx(
+		tx_handler.UpgradeContractTxID, loomchain.GeneratePassthroughRouteHandler(upgradeContractTxHandler),
+	)
+	router.HandleCheckTx(
+		tx_handler.TransferOwnershipTxID, loomchain.GeneratePassthroughRouteHandler(transferOwnershipTxHandler),
+	)
+	router.HandleCheckTx(
+		tx_handler.SetContractMetadataTxID, loomchain.GeneratePassthroughRouteHandler(setContractMetadataTxHandler),
+	)
 
 	txMiddleWare := []loomchain.TxMiddleware{
 		loomchain.LogTxMiddleware,
@@ -957,20 +1130,20 @@ func loadApp(
 		getContractStaticCtx("addressmapper", vmManager),
 	))
 
+	txMiddleWare = append(txMiddleWare, throttle.NewFeeMarketMiddleware(getContractCtx("coin", vmManager)))
+
 	createKarmaContractCtx := getContractCtx("karma", vmManager)
 
-	if cfg.Karma.Enabled {
-		txMiddleWare = append(txMiddleWare, throttle.GetKarmaMiddleWare(
-			cfg.Karma.Enabled,
-			cfg.Karma.MaxCallCount,
-			cfg.Karma.SessionDuration,
-			createKarmaContractCtx,
-		))
-	}
+	// The karma & tx limiter middlewares are always added because once the throttle:block-window
+	// feature is enabled they must run on every node regardless of the local config.
+	txMiddleWare = append(txMiddleWare, throttle.GetKarmaMiddleWare(
+		cfg.Karma.Enabled,
+		cfg.Karma.MaxCallCount,
+		cfg.Karma.SessionDuration,
+		createKarmaContractCtx,
+	))
 
-	if cfg.TxLimiter.Enabled {
-		txMiddleWare = append(txMiddleWare, throttle.NewTxLimiterMiddleware(cfg.TxLimiter))
-	}
+	txMiddleWare = append(txMiddleWare, throttle.NewTxLimiterMiddleware(cfg.TxLimiter))
 
 	if cfg.ContractTxLimiter.Enabled {
 		contextFactory := getContractCtx("user-deployer-whitelist", vmManager)
@@ -979,6 +1152,12 @@ func loadApp(
 		)
 	}
 
+	if cfg.AccessControl.ContractEnabled {
+		txMiddleWare = append(
+			txMiddleWare, throttle.NewAccessControlMiddleware(getContractStaticCtx("accesscontrol", vmManager)),
+		)
+	}
+
 	if cfg.DeployerWhitelist.ContractEnabled {
 		contextFactory := getContractCtx("deployerwhitelist", vmManager)
 		dwMiddleware, err := throttle.NewDeployerWhitelistMiddleware(contextFactory)
@@ -1047,6 +1226,17 @@ func loadApp(
 
 	nonceTxHandler := auth.NewNonceHandler()
 	txMiddleWare = append(txMiddleWare, nonceTxHandler.TxMiddleware(appStore))
+	// Txs are only charged once the nonce has been validated so replayed txs can't drain accounts
+	txMiddleWare = append(txMiddleWare, throttle.NewFeeCollectorMiddleware(
+		appStore, getContractCtx("coin", vmManager),
+	))
+	txMiddleWare = append(txMiddleWare, throttle.NewBlockWindowRecorderMiddleware(appStore))
+
+	if cfg.RateLimit.ContractEnabled {
+		txMiddleWare = append(txMiddleWare, throttle.NewRateLimitMiddleware(
+			appStore, getContractCtx("ratelimit", vmManager),
+		))
+	}
 
 	if cfg.GoContractDeployerWhitelist.Enabled {
 		goDeployers, err := cfg.GoContractDeployerWhitelist.DeployerAddresses(chainID)
@@ -1093,6 +1283,28 @@ func loadApp(
 		return m, nil
 	}
 
+	createGovernanceManager := func(state loomchain.State) (loomchain.GovernanceManager, error) {
+		if !cfg.Governance.ContractEnabled || !state.FeatureEnabled(features.GovernanceFeature, false) {
+			return nil, nil
+		}
+		createPluginVM := func(state loomchain.State) (*plugin.PluginVM, error) {
+			pvm, err := vmManager.InitVM(vm.VMType_PLUGIN, state)
+			if err != nil {
+				return nil, err
+			}
+			return pvm.(*plugin.PluginVM), nil
+		}
+
+		m, err := plugin.NewGovernanceManager(state, createPluginVM)
+		if err != nil {
+			if err == plugin.ErrGovernanceContractNotFound {
+				return nil, nil
+			}
+			return nil, err
+		}
+		return m, nil
+	}
+
 	if !cfg.Karma.Enabled && cfg.Karma.UpkeepEnabled {
 		logger.Info("Karma disabled, upkeep enabled ignored")
 	}
@@ -1129,6 +1341,7 @@ func loadApp(
 		ReceiptHandlerProvider:      receiptHandlerProvider,
 		CreateValidatorManager:      createValidatorsManager,
 		CreateChainConfigManager:    createChainConfigManager,
+		CreateGovernanceManager:     createGovernanceManager,
 		CreateContractUpkeepHandler: createContractUpkeepHandler,
 		EventStore:                  eventStore,
 		GetValidatorSet:             getValidatorSet,
@@ -1161,6 +1374,16 @@ func deployContract(
 		return err
 	}
 
+	pluginHash, err := contractCfg.PluginHash()
+	if err != nil {
+		return err
+	}
+	if pluginHash != nil {
+		if err := plugin.SetPluginHash(state, contractCfg.Location, pluginHash); err != nil {
+			return err
+		}
+	}
+
 	callerAddr := plugin.CreateAddress(rootAddr, uint64(index))
 	_, addr, err := vm.Create(callerAddr, initCode, loom.NewBigUIntFromInt(0))
 	if err != nil {
@@ -1228,7 +1451,8 @@ func initBackend(cfg *config.Config, abciServerAddr string, fnRegistry fnConsens
 
 func initQueryService(
 	app *loomchain.Application, chainID string, cfg *config.Config, loader plugin.Loader,
-	receiptHandlerProvider loomchain.ReceiptHandlerProvider,
+	receiptHandlerProvider loomchain.ReceiptHandlerProvider, fnVotes rpc.FnVoteHistory,
+	pluginHealth rpc.PluginHealthProvider,
 ) error {
 	// metrics
 	fieldKeys := []string{"method", "error"}
@@ -1291,7 +1515,10 @@ func initQueryService(
 	}
 	var qsvc rpc.QueryService = rpc.NewInstrumentingMiddleWare(requestCount, requestLatency, qs)
 	logger := log.Root.With("module", "query-server")
-	err = rpc.RPCServer(qsvc, chainID, logger, bus, cfg.RPCBindAddress, cfg.UnsafeRPCEnabled, cfg.UnsafeRPCBindAddress)
+	err = rpc.RPCServer(
+		qsvc, chainID, logger, bus, cfg.RPCBindAddress, cfg.UnsafeRPCEnabled, cfg.UnsafeRPCBindAddress, fnVotes,
+		pluginHealth,
+	)
 	if err != nil {
 		return err
 	}
@@ -1328,6 +1555,7 @@ func main() {
 		newDeployCommand(),
 		newDeployGoCommand(),
 		newMigrationCommand(),
+		newUpgradeContractCommand(),
 		callCommand,
 		newGenKeyCommand(),
 		newYubiHsmCommand(),
@@ -1347,6 +1575,10 @@ func main() {
 		chaincfgcmd.NewChainCfgCommand(),
 		deployer.NewDeployCommand(),
 		userdeployer.NewUserDeployCommand(),
+		ratelimitcmd.NewRateLimitCommand(),
+		accesscontrolcmd.NewAccessControlCommand(),
+		govcmd.NewGovernanceCommand(),
+		oraclecmd.NewDataOracleCommand(),
 		dbg.NewDebugCommand(),
 		contractInfoCommand(),
 	)
@@ -16,3 +16,12 @@ func startGatewayReactors(
 ) error {
 	return nil
 }
+
+func registerGatewayFnFactories(
+	chainID string,
+	registry *fnConsensus.DynamicFnRegistry,
+	cfg *config.Config,
+	nodeSigner glAuth.Signer,
+) error {
+	return nil
+}
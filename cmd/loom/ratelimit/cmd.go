@@ -0,0 +1,198 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/loomnetwork/go-loom"
+	"github.com/loomnetwork/go-loom/cli"
+	rl "github.com/loomnetwork/loomchain/builtin/plugins/ratelimit"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	rlContractName = "ratelimit"
+)
+
+type policyInfo struct {
+	ID          uint64
+	Account     string `json:",omitempty"`
+	Contract    string `json:",omitempty"`
+	Method      string `json:",omitempty"`
+	MaxTxs      uint64
+	BlockWindow uint64
+	PerAccount  bool
+}
+
+func NewRateLimitCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rate-limit <command>",
+		Short: "Rate Limit CLI",
+	}
+
+	cmd.AddCommand(
+		setPolicyCmd(),
+		removePolicyCmd(),
+		listPoliciesCmd(),
+	)
+	return cmd
+}
+
+const setPolicyCmdExample = `
+# Limit each account to 10 transfer calls to an EVM contract every 100 blocks
+loom rate-limit set-policy 10 100 --contract 0x7262d4c97c7B93937E4810D289b7320e9dA82857 \
+  --method 0xa9059cbb --per-account
+
+# Change the limit of an existing policy
+loom rate-limit set-policy 20 100 --id 1 --contract 0x7262d4c97c7B93937E4810D289b7320e9dA82857 \
+  --method 0xa9059cbb --per-account
+`
+
+func setPolicyCmd() *cobra.Command {
+	var flags cli.ContractCallFlags
+	var id uint64
+	var account, contractAddr, method string
+	var perAccount bool
+	cmd := &cobra.Command{
+		Use:     "set-policy <max txs> <block window>",
+		Short:   "Create a new rate-limit policy, or replace an existing one",
+		Example: setPolicyCmdExample,
+		Args:    cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			maxTxs, err := strconv.ParseUint(args[0], 10, 64)
+			if err != nil {
+				return errors.Wrap(err, "invalid max txs")
+			}
+			blockWindow, err := strconv.ParseUint(args[1], 10, 64)
+			if err != nil {
+				return errors.Wrap(err, "invalid block window")
+			}
+
+			policy := &rl.Policy{
+				Id:          id,
+				Method:      method,
+				MaxTxs:      maxTxs,
+				BlockWindow: blockWindow,
+				PerAccount:  perAccount,
+			}
+			if account != "" {
+				addr, err := cli.ParseAddress(account, flags.ChainID)
+				if err != nil {
+					return err
+				}
+				policy.Account = addr.MarshalPB()
+			}
+			if contractAddr != "" {
+				addr, err := cli.ParseAddress(contractAddr, flags.ChainID)
+				if err != nil {
+					return err
+				}
+				policy.Contract = addr.MarshalPB()
+			}
+
+			cmd.SilenceUsage = true
+
+			var resp rl.SetPolicyResponse
+			req := &rl.SetPolicyRequest{Policy: policy}
+			if err := cli.CallContractWithFlags(&flags, rlContractName, "SetPolicy", req, &resp); err != nil {
+				return err
+			}
+			fmt.Printf("policy %d set\n", resp.Id)
+			return nil
+		},
+	}
+
+	cmdFlags := cmd.Flags()
+	cmdFlags.Uint64Var(&id, "id", 0, "ID of the policy to replace, a new policy is created if not specified")
+	cmdFlags.StringVar(&account, "account", "", "Only limit txs sent by this account")
+	cmdFlags.StringVar(&contractAddr, "contract", "", "Only limit txs sent to this contract")
+	cmdFlags.StringVar(
+		&method, "method", "",
+		"Only limit calls to this method (method name for Go contracts, hex-encoded selector for EVM contracts)",
+	)
+	cmdFlags.BoolVar(&perAccount, "per-account", false, "Apply the limit to each sender individually")
+	cli.AddContractCallFlags(cmdFlags, &flags)
+	return cmd
+}
+
+const removePolicyCmdExample = `
+loom rate-limit remove-policy 1
+`
+
+func removePolicyCmd() *cobra.Command {
+	var flags cli.ContractCallFlags
+	cmd := &cobra.Command{
+		Use:     "remove-policy <policy id>",
+		Short:   "Remove a rate-limit policy",
+		Example: removePolicyCmdExample,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := strconv.ParseUint(args[0], 10, 64)
+			if err != nil {
+				return errors.Wrap(err, "invalid policy ID")
+			}
+
+			cmd.SilenceUsage = true
+
+			req := &rl.RemovePolicyRequest{Id: id}
+			return cli.CallContractWithFlags(&flags, rlContractName, "RemovePolicy", req, nil)
+		},
+	}
+	cli.AddContractCallFlags(cmd.Flags(), &flags)
+	return cmd
+}
+
+const listPoliciesCmdExample = `
+loom rate-limit list-policies
+`
+
+func listPoliciesCmd() *cobra.Command {
+	var flags cli.ContractCallFlags
+	cmd := &cobra.Command{
+		Use:     "list-policies",
+		Short:   "Display all rate-limit policies",
+		Example: listPoliciesCmdExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			req := &rl.ListPoliciesRequest{}
+			var resp rl.ListPoliciesResponse
+			if err := cli.StaticCallContractWithFlags(&flags, rlContractName, "ListPolicies", req, &resp); err != nil {
+				return err
+			}
+
+			policies := []*policyInfo{}
+			for _, policy := range resp.Policies {
+				policies = append(policies, getPolicyInfo(policy))
+			}
+
+			output, err := json.MarshalIndent(policies, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(output))
+			return nil
+		},
+	}
+	cli.AddContractStaticCallFlags(cmd.Flags(), &flags)
+	return cmd
+}
+
+func getPolicyInfo(policy *rl.Policy) *policyInfo {
+	info := &policyInfo{
+		ID:          policy.Id,
+		Method:      policy.Method,
+		MaxTxs:      policy.MaxTxs,
+		BlockWindow: policy.BlockWindow,
+		PerAccount:  policy.PerAccount,
+	}
+	if policy.Account != nil {
+		info.Account = loom.UnmarshalAddressPB(policy.Account).String()
+	}
+	if policy.Contract != nil {
+		info.Contract = loom.UnmarshalAddressPB(policy.Contract).String()
+	}
+	return info
+}
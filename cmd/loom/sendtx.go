@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"math/big"
+	"path/filepath"
 	"strconv"
 	"strings"
 
@@ -13,7 +14,11 @@ import (
 	"github.com/gogo/protobuf/jsonpb"
 	"github.com/gogo/protobuf/proto"
 	"github.com/loomnetwork/loomchain/config"
+	"github.com/loomnetwork/loomchain/feemarket"
 	"github.com/loomnetwork/loomchain/registry"
+	"github.com/loomnetwork/loomchain/tx_handler"
+	lvm "github.com/loomnetwork/loomchain/vm"
+	"github.com/loomnetwork/loomchain/wasm"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -24,6 +29,7 @@ import (
 	"github.com/loomnetwork/go-loom/client"
 	"github.com/loomnetwork/go-loom/common/evmcompat"
 	lcrypto "github.com/loomnetwork/go-loom/crypto"
+	"github.com/loomnetwork/go-loom/types"
 	"github.com/loomnetwork/go-loom/vm"
 )
 
@@ -41,6 +47,56 @@ func setChainFlags(fs *pflag.FlagSet) {
 	fs.StringVarP(&cli.TxFlags.HsmConfigFile, "hsmconfig", "", "", "hsm config file")
 	fs.StringVar(&cli.TxFlags.Algo, "algo", "ed25519", "Signing algo: ed25519, secp256k1, tron")
 	fs.StringVar(&cli.TxFlags.CallerChainID, "caller-chain", "", "Overrides chain ID of caller")
+	fs.StringVar(
+		&txMaxFee, "max-fee", "",
+		"Max fee (in LOOM wei) to pay for the tx, required when the fee market is enabled",
+	)
+}
+
+// Max fee the sender is willing to pay for a tx, txs are only wrapped in a FeeTx if it's set.
+var txMaxFee string
+
+// Signs & commits a tx that wraps the given MessageTx, if a max fee was specified via --max-fee
+// the tx is wrapped in a FeeTx.
+func commitMessageTx(
+	rpcclient *client.DAppChainRPCClient, signer auth.Signer, txID types.TxID, msgTx *vm.MessageTx,
+) ([]byte, error) {
+	msgTxBytes, err := proto.Marshal(msgTx)
+	if err != nil {
+		return nil, err
+	}
+	tx := &types.Transaction{
+		Id:   uint32(txID),
+		Data: msgTxBytes,
+	}
+	if txMaxFee == "" {
+		return rpcclient.CommitTx(signer, tx)
+	}
+	maxFee, ok := new(big.Int).SetString(txMaxFee, 0)
+	if !ok || maxFee.Sign() < 0 {
+		return nil, errors.Errorf("invalid max fee %s", txMaxFee)
+	}
+	feeTx, err := feemarket.NewFeeTx(tx, loom.NewBigUInt(maxFee))
+	if err != nil {
+		return nil, err
+	}
+	return rpcclient.CommitTx(signer, feeTx)
+}
+
+// Signs & commits a tx that wraps the given DeployTx, CallTx, or MigrationTx.
+func commitVMTx(
+	rpcclient *client.DAppChainRPCClient, signer auth.Signer, txID types.TxID, from, to loom.Address,
+	tx proto.Message,
+) ([]byte, error) {
+	txBytes, err := proto.Marshal(tx)
+	if err != nil {
+		return nil, err
+	}
+	return commitMessageTx(rpcclient, signer, txID, &vm.MessageTx{
+		From: from.MarshalPB(),
+		To:   to.MarshalPB(),
+		Data: txBytes,
+	})
 }
 
 func newMigrationCommand() *cobra.Command {
@@ -82,7 +138,10 @@ func migrationTx(migrationId uint32, privFile, algo, callerChainID string, input
 	}
 	rpcclient := client.NewDAppChainRPCClient(cli.TxFlags.ChainID, cli.TxFlags.URI+"/rpc", cli.TxFlags.URI+"/query")
 
-	_, err = rpcclient.CommitMigrationTx(clientAddr, signer, migrationId, inputParamsBytes)
+	_, err = commitVMTx(rpcclient, signer, types.TxID_MIGRATION, clientAddr, loom.Address{}, &vm.MigrationTx{
+		ID:    migrationId,
+		Input: inputParamsBytes,
+	})
 	if err != nil {
 		return err
 	}
@@ -90,6 +149,56 @@ func migrationTx(migrationId uint32, privFile, algo, callerChainID string, input
 	return nil
 }
 
+func newUpgradeContractCommand() *cobra.Command {
+	var tx registry.UpgradeContractTx
+	cmd := &cobra.Command{
+		Use:   "upgrade-contract",
+		Short: "Upgrade a Go contract to another version of its plugin, can only be done by the contract owner",
+		Example: "loom upgrade-contract --name mycontract --plugin mycontract:1.1.0 --height 1000 -k " +
+			"owner_priv_key",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := commitRegistryTx(tx_handler.UpgradeContractTxID, &tx); err != nil {
+				return err
+			}
+			fmt.Printf("Contract %s will be upgraded to %s at height %d\n", tx.ContractName, tx.PluginName, tx.Height)
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&tx.ContractName, "name", "n", "", "name of the contract")
+	cmd.Flags().StringVar(&tx.PluginName, "plugin", "", "name & version of the new plugin, e.g. mycontract:1.1.0")
+	cmd.Flags().Int64Var(&tx.Height, "height", 0, "height of the first block the new plugin will be used in")
+	cmd.Flags().StringVarP(&cli.TxFlags.PrivFile, "key", "k", "", "private key file")
+	setChainFlags(cmd.Flags())
+	return cmd
+}
+
+// Signs & commits a tx that modifies a contract record, the tx is wrapped in a MessageTx sent by the
+// account of the private key in cli.TxFlags.
+func commitRegistryTx(txID uint32, tx proto.Message) error {
+	callerChainID := cli.TxFlags.CallerChainID
+	if callerChainID == "" {
+		callerChainID = cli.TxFlags.ChainID
+	}
+	clientAddr, signer, err := caller(cli.TxFlags.PrivFile, "", cli.TxFlags.Algo, callerChainID)
+	if err != nil {
+		return errors.Wrapf(err, "initialization failed")
+	}
+	if signer == nil {
+		return fmt.Errorf("invalid private key")
+	}
+
+	txBytes, err := proto.Marshal(tx)
+	if err != nil {
+		return err
+	}
+	rpcclient := client.NewDAppChainRPCClient(cli.TxFlags.ChainID, cli.TxFlags.URI+"/rpc", cli.TxFlags.URI+"/query")
+	_, err = commitMessageTx(rpcclient, signer, types.TxID(txID), &vm.MessageTx{
+		From: clientAddr.MarshalPB(),
+		Data: txBytes,
+	})
+	return err
+}
+
 func newDeployGoCommand() *cobra.Command {
 	var code string
 	var flags deployTxFlags
@@ -151,7 +260,11 @@ func deployGoTx(initFile, privFile, pubFile, algo, callerChainID string) error {
 			return errors.Wrap(err, "failed to load contract code")
 		}
 
-		respB, err := rpcclient.CommitDeployTx(clientAddr, signer, vm.VMType_PLUGIN, initCode, contract.Name)
+		respB, err := commitVMTx(rpcclient, signer, types.TxID_DEPLOY, clientAddr, loom.Address{}, &vm.DeployTx{
+			VmType: vm.VMType_PLUGIN,
+			Code:   initCode,
+			Name:   contract.Name,
+		})
 		if err != nil {
 			fmt.Printf("Error, %v, deploying contact %s\n", err, contract.Name)
 			continue
@@ -189,12 +302,16 @@ func newDeployCommand() *cobra.Command {
 				return err
 			}
 			fmt.Println("New contract deployed with address: ", addr)
-			fmt.Println("Runtime bytecode: ", runBytecode)
-			fmt.Println("Transaction receipt: ", hex.EncodeToString(txReceipt))
+			if !isWasmFile(flags.Bytecode) {
+				fmt.Println("Runtime bytecode: ", runBytecode)
+				fmt.Println("Transaction receipt: ", hex.EncodeToString(txReceipt))
+			}
 			return nil
 		},
 	}
-	deployCmd.Flags().StringVarP(&flags.Bytecode, "bytecode", "b", "", "bytecode file")
+	deployCmd.Flags().StringVarP(
+		&flags.Bytecode, "bytecode", "b", "", "bytecode file, either hex-encoded EVM bytecode or a .wasm module",
+	)
 	deployCmd.Flags().StringVarP(&flags.PublicFile, "address", "a", "", "address file")
 	deployCmd.Flags().StringVarP(&flags.Name, "name", "n", "", "contract name")
 	deployCmd.Flags().StringVarP(&cli.TxFlags.PrivFile, "key", "k", "", "private key file")
@@ -218,12 +335,22 @@ func deployTx(
 	if err != nil {
 		return *new(loom.Address), nil, nil, errors.Wrapf(err, "reading deployment file")
 	}
-	if string(bytetext[0:2]) == "0x" {
-		bytetext = bytetext[2:]
-	}
-	bytecode, err := hex.DecodeString(string(bytetext))
-	if err != nil {
-		return *new(loom.Address), nil, nil, errors.Wrapf(err, "decoding the data in deployment file")
+	vmType := vm.VMType_EVM
+	var bytecode []byte
+	if isWasmFile(bcFile) {
+		if !wasm.IsWasm(bytetext) {
+			return *new(loom.Address), nil, nil, errors.Errorf("%s is not a Wasm module", bcFile)
+		}
+		vmType = lvm.VMType_WASM
+		bytecode = bytetext
+	} else {
+		if string(bytetext[0:2]) == "0x" {
+			bytetext = bytetext[2:]
+		}
+		bytecode, err = hex.DecodeString(string(bytetext))
+		if err != nil {
+			return *new(loom.Address), nil, nil, errors.Wrapf(err, "decoding the data in deployment file")
+		}
 	}
 
 	value := big.NewInt(0)
@@ -237,7 +364,12 @@ func deployTx(
 	}
 
 	rpcclient := client.NewDAppChainRPCClient(cli.TxFlags.ChainID, cli.TxFlags.URI+"/rpc", cli.TxFlags.URI+"/query")
-	respB, err := rpcclient.CommitDeployTxWithValue(clientAddr, signer, vm.VMType_EVM, bytecode, name, value)
+	respB, err := commitVMTx(rpcclient, signer, types.TxID_DEPLOY, clientAddr, loom.Address{}, &vm.DeployTx{
+		VmType: vmType,
+		Code:   bytecode,
+		Name:   name,
+		Value:  &types.BigUInt{Value: *loom.NewBigUInt(value)},
+	})
 	if err != nil {
 		return *new(loom.Address), nil, nil, errors.Wrapf(err, "CommitDeployTx")
 	}
@@ -247,12 +379,20 @@ func deployTx(
 		return *new(loom.Address), nil, nil, errors.Wrapf(err, "unmarshalling response")
 	}
 	addr := loom.UnmarshalAddressPB(response.Contract)
+	if vmType == lvm.VMType_WASM {
+		// The output of a Wasm deployment is whatever the init function of the contract returned
+		return addr, nil, nil, nil
+	}
 	output := vm.DeployResponseData{}
 	err = proto.Unmarshal(response.Output, &output)
 
 	return addr, output.Bytecode, output.TxHash, errors.Wrapf(err, "unmarshalling output")
 }
 
+func isWasmFile(path string) bool {
+	return filepath.Ext(path) == ".wasm"
+}
+
 func staticCallTx(addr, name, input, privFile, publicFile, algo, callerChainID string) ([]byte, error) {
 	rpcclient := client.NewDAppChainRPCClient(cli.TxFlags.ChainID, cli.TxFlags.URI+"/rpc", cli.TxFlags.URI+"/query")
 	var contractLocalAddr loom.LocalAddress
@@ -421,7 +561,11 @@ func callTx(addr, name, input, privFile, publicFile, algo, callerChainID, valueS
 		}
 	}
 
-	return rpcclient.CommitCallTxWithValue(clientAddr, contractAddr, signer, vm.VMType_EVM, incode, value)
+	return commitVMTx(rpcclient, signer, types.TxID_CALL, clientAddr, contractAddr, &vm.CallTx{
+		VmType: vm.VMType_EVM,
+		Input:  incode,
+		Value:  &types.BigUInt{Value: *loom.NewBigUInt(value)},
+	})
 }
 
 type getBlockByNumerTxFlags struct {
@@ -94,6 +94,18 @@ type Config struct {
 	// UserDeployerWhitelist
 	UserDeployerWhitelist *UserDeployerWhitelistConfig
 
+	// RateLimit
+	RateLimit *RateLimitConfig
+
+	// AccessControl
+	AccessControl *AccessControlConfig
+
+	// Governance
+	Governance *GovernanceConfig
+
+	// DataOracle
+	DataOracle *DataOracleConfig
+
 	// Transfer gateway
 	TransferGateway         *TransferGatewayConfig
 	LoomCoinTransferGateway *TransferGatewayConfig
@@ -175,12 +187,15 @@ type Metrics struct {
 type FnConsensusConfig struct {
 	Enabled bool
 	Reactor *fnConsensus.ReactorConfigParsable
+	// Register the Fns described by the Fn records in the ChainConfig contract
+	DynamicFnsEnabled bool
 }
 
 func DefaultFnConsensusConfig() *FnConsensusConfig {
 	return &FnConsensusConfig{
-		Enabled: false,
-		Reactor: fnConsensus.DefaultReactorConfigParsable(),
+		Enabled:           false,
+		Reactor:           fnConsensus.DefaultReactorConfigParsable(),
+		DynamicFnsEnabled: false,
 	}
 }
 
@@ -258,6 +273,21 @@ type UserDeployerWhitelistConfig struct {
 	ContractEnabled bool
 }
 
+type RateLimitConfig struct {
+	// Enables the RateLimit contract & the middleware that enforces the policies stored in it
+	ContractEnabled bool
+}
+
+type AccessControlConfig struct {
+	// Enables the AccessControl contract & the middleware that enforces the access lists stored in it
+	ContractEnabled bool
+}
+
+type GovernanceConfig struct {
+	// Enables the Governance contract, and the execution of passed proposals at the end of each block
+	ContractEnabled bool
+}
+
 func DefaultDBBackendConfig() *DBBackendConfig {
 	return &DBBackendConfig{
 		CacheSizeMegs:   1042, //1 Gigabyte
@@ -317,6 +347,47 @@ func DefaultUserDeployerWhitelistConfig() *UserDeployerWhitelistConfig {
 	}
 }
 
+func DefaultRateLimitConfig() *RateLimitConfig {
+	return &RateLimitConfig{
+		ContractEnabled: false,
+	}
+}
+
+func DefaultAccessControlConfig() *AccessControlConfig {
+	return &AccessControlConfig{
+		ContractEnabled: false,
+	}
+}
+
+type DataOracleConfig struct {
+	// Allow deployment of the DataOracle contract
+	ContractEnabled bool
+	// DAppChain URI data oracle Fns should use to query the chain
+	DAppChainReadURI string
+	// DAppChain URI data oracle Fns should use to submit data to the chain
+	DAppChainWriteURI string
+	// Hosts the http & rpc sources of data oracle Fns are allowed to connect to, sources that
+	// specify any other host are rejected.
+	AllowedHosts []string
+	// Maps feed IDs to the local files the values of feeds with file sources are read from, file
+	// paths can't be specified on-chain.
+	FileSources map[string]string
+}
+
+func DefaultDataOracleConfig(rpcProxyPort int32) *DataOracleConfig {
+	return &DataOracleConfig{
+		ContractEnabled:   false,
+		DAppChainReadURI:  fmt.Sprintf("http://127.0.0.1:%d/query", rpcProxyPort),
+		DAppChainWriteURI: fmt.Sprintf("http://127.0.0.1:%d/rpc", rpcProxyPort),
+	}
+}
+
+func DefaultGovernanceConfig() *GovernanceConfig {
+	return &GovernanceConfig{
+		ContractEnabled: false,
+	}
+}
+
 //Structure for LOOM ENV
 
 type Env struct {
@@ -460,6 +531,10 @@ func DefaultConfig() *Config {
 	cfg.ChainConfig = DefaultChainConfigConfig(cfg.RPCProxyPort)
 	cfg.DeployerWhitelist = DefaultDeployerWhitelistConfig()
 	cfg.UserDeployerWhitelist = DefaultUserDeployerWhitelistConfig()
+	cfg.RateLimit = DefaultRateLimitConfig()
+	cfg.AccessControl = DefaultAccessControlConfig()
+	cfg.Governance = DefaultGovernanceConfig()
+	cfg.DataOracle = DefaultDataOracleConfig(cfg.RPCProxyPort)
 	cfg.DBBackendConfig = DefaultDBBackendConfig()
 	cfg.PrometheusPushGateway = DefaultPrometheusPushGatewayConfig()
 	cfg.EventDispatcher = events.DefaultEventDispatcherConfig()
@@ -653,6 +728,40 @@ DeployerWhitelist:
 #
 UserDeployerWhitelist:
   ContractEnabled: {{ .UserDeployerWhitelist.ContractEnabled }}
+
+#
+# RateLimit
+#
+RateLimit:
+  ContractEnabled: {{ .RateLimit.ContractEnabled }}
+
+#
+# AccessControl
+#
+AccessControl:
+  ContractEnabled: {{ .AccessControl.ContractEnabled }}
+
+#
+# Governance
+#
+Governance:
+  ContractEnabled: {{ .Governance.ContractEnabled }}
+
+#
+# DataOracle
+#
+DataOracle:
+  ContractEnabled: {{ .DataOracle.ContractEnabled }}
+  DAppChainReadURI: {{ .DataOracle.DAppChainReadURI }}
+  DAppChainWriteURI: {{ .DataOracle.DAppChainWriteURI }}
+  AllowedHosts:
+  {{- range .DataOracle.AllowedHosts}}
+    - "{{. -}}"
+  {{- end}}
+  FileSources:
+    {{- range $k, $v := .DataOracle.FileSources}}
+    "{{$k}}": "{{$v -}}"
+    {{- end}}
 #
 # SampleGoContractEnabled
 #
@@ -790,11 +899,16 @@ Web3:
 {{- if .FnConsensus }}
 FnConsensus:
   Enabled: {{ .FnConsensus.Enabled }}
+  # Register the Fns described by the Fn records in the ChainConfig contract
+  DynamicFnsEnabled: {{ .FnConsensus.DynamicFnsEnabled }}
   {{- if .FnConsensus.Reactor }}
   Reactor:
     # Set to false to make the node forward messages without tracking consensus state
     IsValidator: {{ .FnConsensus.Reactor.IsValidator }}
     FnVoteSigningThreshold: {{ .FnConsensus.Reactor.FnVoteSigningThreshold }}
+    # Number of seconds completed & expired vote sets are retained for inspection, 0 disables
+    # the vote history
+    VoteHistoryRetentionInSeconds: {{ .FnConsensus.Reactor.VoteHistoryRetentionInSeconds }}
     {{- if .FnConsensus.Reactor.OverrideValidators }}
     OverrideValidators:
       {{- range $i, $v := .FnConsensus.Reactor.OverrideValidators }}
@@ -1,7 +1,11 @@
 package genesis
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"strings"
 
 	cctypes "github.com/loomnetwork/go-loom/builtin/types/chainconfig"
 	lvm "github.com/loomnetwork/go-loom/vm"
@@ -16,12 +20,27 @@ type ContractConfig struct {
 	Name       string          `json:"name,omitempty"`
 	Location   string          `json:"location"`
 	Init       json.RawMessage `json:"init"`
+	// Hex encoded SHA-256 hash of the plugin binary the contract should be loaded from, only
+	// applies to contracts loaded from external plugins.
+	PluginSHA256 string `json:"pluginSha256,omitempty"`
 }
 
 func (c ContractConfig) VMType() lvm.VMType {
 	return lvm.VMType(lvm.VMType_value[c.VMTypeName])
 }
 
+// PluginHash returns the decoded SHA-256 hash of the plugin binary, or nil if no hash was specified.
+func (c ContractConfig) PluginHash() ([]byte, error) {
+	if c.PluginSHA256 == "" {
+		return nil, nil
+	}
+	hash, err := hex.DecodeString(strings.TrimPrefix(c.PluginSHA256, "0x"))
+	if err != nil || len(hash) != sha256.Size {
+		return nil, fmt.Errorf("invalid plugin SHA-256 hash specified for contract %s", c.Name)
+	}
+	return hash, nil
+}
+
 type Genesis struct {
 	Contracts []ContractConfig `json:"contracts"`
 	Config    cctypes.Config   `json:"config"`
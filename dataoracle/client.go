@@ -0,0 +1,89 @@
+package dataoracle
+
+import (
+	"sync"
+
+	goloom "github.com/loomnetwork/go-loom"
+	"github.com/loomnetwork/go-loom/auth"
+	"github.com/loomnetwork/go-loom/client"
+	oracleplugin "github.com/loomnetwork/loomchain/builtin/plugins/dataoracle"
+	"github.com/pkg/errors"
+)
+
+// DataOracleClient is used by the data oracle Fns to interact with the DataOracle contract.
+type DataOracleClient struct {
+	loomClient *client.DAppChainRPCClient
+	caller     goloom.Address
+	signer     auth.Signer
+
+	mtx          sync.Mutex
+	contract     *client.Contract
+	contractAddr goloom.Address
+}
+
+// NewDataOracleClient returns a new instance of DataOracleClient, the address of the DataOracle
+// contract is resolved the first time the client is used.
+func NewDataOracleClient(
+	chainID, readURI, writeURI string, signer auth.Signer,
+) *DataOracleClient {
+	return &DataOracleClient{
+		loomClient: client.NewDAppChainRPCClient(chainID, writeURI, readURI),
+		caller: goloom.Address{
+			ChainID: chainID,
+			Local:   goloom.LocalAddressFromPublicKey(signer.PublicKey()),
+		},
+		signer: signer,
+	}
+}
+
+func (c *DataOracleClient) getContract() (*client.Contract, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.contract == nil {
+		addr, err := c.loomClient.Resolve("dataoracle")
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to resolve DataOracle contract address")
+		}
+		c.contract = client.NewContract(c.loomClient, addr.Local)
+		c.contractAddr = goloom.Address{ChainID: c.caller.ChainID, Local: addr.Local}
+	}
+	return c.contract, nil
+}
+
+// Address returns the address of the DataOracle contract.
+func (c *DataOracleClient) Address() (goloom.Address, error) {
+	if _, err := c.getContract(); err != nil {
+		return goloom.Address{}, err
+	}
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	return c.contractAddr, nil
+}
+
+func (c *DataOracleClient) GetFeed(feedID string) (*oracleplugin.Feed, error) {
+	contract, err := c.getContract()
+	if err != nil {
+		return nil, err
+	}
+	var resp oracleplugin.GetFeedResponse
+	if _, err := contract.StaticCall(
+		"GetFeed",
+		&oracleplugin.GetFeedRequest{FeedId: feedID},
+		c.caller,
+		&resp,
+	); err != nil {
+		return nil, err
+	}
+	return resp.Feed, nil
+}
+
+func (c *DataOracleClient) SubmitData(req *oracleplugin.SubmitDataRequest) error {
+	contract, err := c.getContract()
+	if err != nil {
+		return err
+	}
+	_, err = contract.Call("SubmitData", req, c.signer, nil)
+	return err
+}
@@ -0,0 +1,185 @@
+package dataoracle
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/gogo/protobuf/proto"
+	goloom "github.com/loomnetwork/go-loom"
+	"github.com/loomnetwork/go-loom/auth"
+	oracleplugin "github.com/loomnetwork/loomchain/builtin/plugins/dataoracle"
+	"github.com/loomnetwork/loomchain/config"
+	"github.com/loomnetwork/loomchain/fnConsensus"
+	"github.com/loomnetwork/loomchain/log"
+	"github.com/pkg/errors"
+)
+
+// FnFactoryName is the name of the factory that creates data oracle Fns from Fn records stored in
+// the ChainConfig contract.
+const FnFactoryName = "dataoracle"
+
+// FnParams are the JSON encoded params of the Fn record of a data oracle Fn, e.g.
+// {"feed_id":"ETH/USD","source":{"type":"http","url":"https://example.com/price","path":"usd"}}
+type FnParams struct {
+	FeedID string        `json:"feed_id"`
+	Source *SourceConfig `json:"source"`
+}
+
+// Subset of the DataOracle contract methods used by the Fn.
+type oracleContract interface {
+	// Address returns the address of the DataOracle contract, including the chain ID.
+	Address() (goloom.Address, error)
+	GetFeed(feedID string) (*oracleplugin.Feed, error)
+	SubmitData(req *oracleplugin.SubmitDataRequest) error
+}
+
+// Fn submits the values of a data feed to the DataOracle contract.
+//
+// In each round every validator fetches the current value of the feed from its source and signs
+// it, the validators then reach consensus on the round number (rather than the value, which
+// usually differs a little between validators). Once consensus is reached the signed observations
+// of all the validators that took part are submitted to the contract, which stores the median.
+type Fn struct {
+	feedID   string
+	source   Source
+	contract oracleContract
+	signer   auth.Signer
+}
+
+var _ fnConsensus.Fn = &Fn{}
+
+func newFn(feedID string, source Source, contract oracleContract, signer auth.Signer) *Fn {
+	return &Fn{
+		feedID:   feedID,
+		source:   source,
+		contract: contract,
+		signer:   signer,
+	}
+}
+
+// NewFnFactory returns a factory that creates data oracle Fns from Fn records.
+func NewFnFactory(chainID string, cfg *config.DataOracleConfig, signer auth.Signer) fnConsensus.FnFactory {
+	return func(fnID string, params []byte) (fnConsensus.Fn, error) {
+		var fnParams FnParams
+		if err := json.Unmarshal(params, &fnParams); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal data oracle Fn params")
+		}
+		if fnParams.FeedID == "" || fnParams.Source == nil {
+			return nil, errors.New("data oracle Fn params must specify a feed ID & source")
+		}
+		source, err := newFnSource(fnParams.FeedID, fnParams.Source, cfg)
+		if err != nil {
+			return nil, err
+		}
+		contract := NewDataOracleClient(chainID, cfg.DAppChainReadURI, cfg.DAppChainWriteURI, signer)
+		return newFn(fnParams.FeedID, source, contract, signer), nil
+	}
+}
+
+// newFnSource creates the source of a data oracle Fn. The source config comes from the Fn record
+// stored on-chain, so it's not allowed to specify local files, or to connect to hosts that aren't
+// allowed by the node config. File sources must be configured in the node config.
+func newFnSource(feedID string, srcCfg *SourceConfig, cfg *config.DataOracleConfig) (Source, error) {
+	if srcCfg.File != "" {
+		return nil, errors.New("data oracle Fn params can't specify a file")
+	}
+	if srcCfg.Type == FileSourceType {
+		file, ok := cfg.FileSources[feedID]
+		if !ok {
+			return nil, fmt.Errorf("no file source configured for feed %s", feedID)
+		}
+		fileCfg := *srcCfg
+		fileCfg.File = file
+		return NewSource(&fileCfg)
+	}
+	return NewRestrictedSource(srcCfg, cfg.AllowedHosts)
+}
+
+// GetMessageAndSignature returns the next round of the feed as the message, and the value
+// observed by this node (signed with the node key) as the signature.
+func (f *Fn) GetMessageAndSignature(ctx []byte) ([]byte, []byte, error) {
+	feed, err := f.contract.GetFeed(f.feedID)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to load feed %s", f.feedID)
+	}
+
+	rawValue, err := f.source.Fetch()
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to fetch value of feed %s", f.feedID)
+	}
+	value, err := ParseValue(rawValue, feed.Decimals)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	contractAddr, err := f.contract.Address()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	round := feed.LastRound + 1
+	message, err := proto.Marshal(&oracleplugin.Round{
+		FeedId: f.feedID,
+		Round:  round,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	signature, err := proto.Marshal(&oracleplugin.Observation{
+		FeedId:    f.feedID,
+		Round:     round,
+		Value:     value,
+		PublicKey: f.signer.PublicKey(),
+		Signature: f.signer.Sign(oracleplugin.ObservationHash(contractAddr, f.feedID, feed.Epoch, round, value)),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return message, signature, nil
+}
+
+// SubmitMultiSignedMessage submits the observations made by the validators that reached
+// consensus on the given round to the DataOracle contract.
+func (f *Fn) SubmitMultiSignedMessage(ctx []byte, message []byte, signatures [][]byte) {
+	if err := f.submit(message, signatures); err != nil {
+		log.Error("Failed to submit data oracle observations", "feed", f.feedID, "err", err)
+	}
+}
+
+func (f *Fn) submit(message []byte, signatures [][]byte) error {
+	var round oracleplugin.Round
+	if err := proto.Unmarshal(message, &round); err != nil {
+		return errors.Wrap(err, "failed to unmarshal round")
+	}
+	if round.FeedId != f.feedID {
+		return fmt.Errorf("round is for feed %s", round.FeedId)
+	}
+
+	req := &oracleplugin.SubmitDataRequest{
+		FeedId: round.FeedId,
+		Round:  round.Round,
+	}
+	for _, signature := range signatures {
+		if signature == nil {
+			continue
+		}
+		var obs oracleplugin.Observation
+		if err := proto.Unmarshal(signature, &obs); err != nil {
+			return errors.Wrap(err, "failed to unmarshal observation")
+		}
+		req.Observations = append(req.Observations, &obs)
+	}
+
+	// All the validators that reached consensus will attempt to submit the same round, only the
+	// first submission will succeed, so there's no point in submitting a round that's already
+	// been stored.
+	feed, err := f.contract.GetFeed(f.feedID)
+	if err != nil {
+		return err
+	}
+	if feed.LastRound >= round.Round {
+		return nil
+	}
+	return f.contract.SubmitData(req)
+}
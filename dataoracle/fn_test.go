@@ -0,0 +1,128 @@
+package dataoracle
+
+import (
+	"crypto/rand"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	loom "github.com/loomnetwork/go-loom"
+	"github.com/loomnetwork/go-loom/auth"
+	oracleplugin "github.com/loomnetwork/loomchain/builtin/plugins/dataoracle"
+	"github.com/loomnetwork/loomchain/config"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ed25519"
+)
+
+type fakeOracleContract struct {
+	addr      loom.Address
+	feed      *oracleplugin.Feed
+	submitted []*oracleplugin.SubmitDataRequest
+}
+
+func (c *fakeOracleContract) Address() (loom.Address, error) {
+	return c.addr, nil
+}
+
+func (c *fakeOracleContract) GetFeed(feedID string) (*oracleplugin.Feed, error) {
+	return c.feed, nil
+}
+
+func (c *fakeOracleContract) SubmitData(req *oracleplugin.SubmitDataRequest) error {
+	c.submitted = append(c.submitted, req)
+	c.feed.LastRound = req.Round
+	return nil
+}
+
+func TestFnRound(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dataoracle")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	contract := &fakeOracleContract{
+		addr: loom.MustParseAddress("default:0x5cecd1f7261e1f4c684e297be3edf03b825e01c4"),
+		feed: &oracleplugin.Feed{Id: "ETH/USD", Decimals: 2, LastRound: 7, Epoch: 2},
+	}
+
+	// Each validator observes a slightly different value, but they should all agree on the message
+	fns := make([]*Fn, 3)
+	values := []string{"100.01", "100.02", "99.99"}
+	for i := range fns {
+		_, privKey, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		file := filepath.Join(dir, values[i])
+		require.NoError(t, ioutil.WriteFile(file, []byte(values[i]), 0644))
+		source, err := NewSource(&SourceConfig{Type: FileSourceType, File: file})
+		require.NoError(t, err)
+		fns[i] = newFn("ETH/USD", source, contract, auth.NewEd25519Signer(privKey))
+	}
+
+	var message []byte
+	signatures := make([][]byte, len(fns)+1)
+	for i, fn := range fns {
+		msg, sig, err := fn.GetMessageAndSignature(nil)
+		require.NoError(t, err)
+		if message != nil {
+			require.Equal(t, message, msg)
+		}
+		message = msg
+		signatures[i] = sig
+	}
+	// Validators that didn't vote have nil signatures
+	signatures[len(fns)] = nil
+
+	var round oracleplugin.Round
+	require.NoError(t, proto.Unmarshal(message, &round))
+	require.Equal(t, uint64(8), round.Round)
+
+	fns[0].SubmitMultiSignedMessage(nil, message, signatures)
+	require.Len(t, contract.submitted, 1)
+	req := contract.submitted[0]
+	require.Equal(t, uint64(8), req.Round)
+	require.Len(t, req.Observations, 3)
+	expectedValues := []int64{10001, 10002, 9999}
+	for i, obs := range req.Observations {
+		require.Equal(t, expectedValues[i], obs.Value)
+		require.True(t, ed25519.Verify(
+			obs.PublicKey,
+			oracleplugin.ObservationHash(contract.addr, obs.FeedId, contract.feed.Epoch, obs.Round, obs.Value),
+			obs.Signature,
+		))
+	}
+
+	// Other validators shouldn't resubmit a round that's already been stored
+	fns[1].SubmitMultiSignedMessage(nil, message, signatures)
+	require.Len(t, contract.submitted, 1)
+}
+
+func TestFnFactory(t *testing.T) {
+	_, privKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	cfg := config.DefaultDataOracleConfig(46658)
+	cfg.FileSources = map[string]string{"ETH/USD": "/tmp/eth"}
+	cfg.AllowedHosts = []string{"example.com"}
+	factory := NewFnFactory("default", cfg, auth.NewEd25519Signer(privKey))
+
+	fn, err := factory("oracle:eth", []byte(`{"feed_id":"ETH/USD","source":{"type":"file"}}`))
+	require.NoError(t, err)
+	require.Equal(t, "ETH/USD", fn.(*Fn).feedID)
+	require.Equal(t, "/tmp/eth", fn.(*Fn).source.(*FileSource).file)
+
+	_, err = factory("oracle:eth", []byte(`{"feed_id":"ETH/USD","source":{"type":"http","url":"https://example.com/eth"}}`))
+	require.NoError(t, err)
+
+	// Fn params can't specify files or hosts that aren't allowed by the node config
+	_, err = factory("oracle:eth", []byte(`{"feed_id":"ETH/USD","source":{"type":"file","file":"/etc/passwd"}}`))
+	require.Error(t, err)
+	_, err = factory("oracle:btc", []byte(`{"feed_id":"BTC/USD","source":{"type":"file"}}`))
+	require.Error(t, err)
+	_, err = factory("oracle:eth", []byte(`{"feed_id":"ETH/USD","source":{"type":"http","url":"http://127.0.0.1:8080"}}`))
+	require.Error(t, err)
+
+	_, err = factory("oracle:eth", []byte(`{"feed_id":"ETH/USD"}`))
+	require.Error(t, err)
+	_, err = factory("oracle:eth", []byte(`{"feed_id":"ETH/USD","source":{"type":"ftp"}}`))
+	require.Error(t, err)
+}
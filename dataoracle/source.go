@@ -0,0 +1,316 @@
+package dataoracle
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	HTTPSourceType = "http"
+	FileSourceType = "file"
+	RPCSourceType  = "rpc"
+
+	defaultSourceTimeout = 10 * time.Second
+	// Max number of bytes of JSON that will be decoded from a source.
+	maxSourceResponseSize = 1024 * 1024
+)
+
+// Source fetches the current value of a data feed from somewhere outside the chain.
+type Source interface {
+	// Fetch returns the current value as a decimal string, e.g. "123.45", or a 0x prefixed hex
+	// encoded integer.
+	Fetch() (string, error)
+}
+
+// SourceConfig describes where the values of a data feed should be fetched from.
+type SourceConfig struct {
+	// One of http, file, or rpc
+	Type string `json:"type"`
+	// URL of the HTTP endpoint or JSON-RPC server (http & rpc sources)
+	URL string `json:"url,omitempty"`
+	// Path to the file containing the value (file source)
+	File string `json:"file,omitempty"`
+	// JSON-RPC method to call (rpc source)
+	Method string `json:"method,omitempty"`
+	// JSON-RPC method params (rpc source)
+	Params []interface{} `json:"params,omitempty"`
+	// Dot separated path to the value within the JSON document returned by the source, array
+	// elements are selected by index, e.g. "data.prices.0.usd". For rpc sources the path is
+	// relative to the result of the call. If empty the whole document must be the value.
+	Path string `json:"path,omitempty"`
+	// Max number of seconds to wait for the source to respond (http & rpc sources)
+	TimeoutInSeconds int64 `json:"timeout,omitempty"`
+}
+
+// NewSource creates a Source from the given config. The source can read any local file and
+// connect to any host, so the config must come from a trusted source, use NewRestrictedSource
+// for configs stored on-chain.
+func NewSource(cfg *SourceConfig) (Source, error) {
+	return newSource(cfg, &http.Client{})
+}
+
+// NewRestrictedSource creates a Source from the given config, the source isn't allowed to read
+// local files, and can only connect to the given hosts (redirects to other hosts are rejected).
+// Hosts are matched against either the hostname or the host:port of the source URL.
+func NewRestrictedSource(cfg *SourceConfig, allowedHosts []string) (Source, error) {
+	if cfg.Type == FileSourceType || cfg.File != "" {
+		return nil, errors.New("file sources aren't allowed")
+	}
+	checkHost := func(u *url.URL) error {
+		for _, host := range allowedHosts {
+			if strings.EqualFold(host, u.Hostname()) || strings.EqualFold(host, u.Host) {
+				return nil
+			}
+		}
+		return fmt.Errorf("host %s isn't allowed", u.Host)
+	}
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid source URL")
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported URL scheme %s", u.Scheme)
+	}
+	if err := checkHost(u); err != nil {
+		return nil, err
+	}
+	return newSource(cfg, &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return errors.New("stopped after 10 redirects")
+			}
+			return checkHost(req.URL)
+		},
+	})
+}
+
+func newSource(cfg *SourceConfig, httpClient *http.Client) (Source, error) {
+	httpClient.Timeout = defaultSourceTimeout
+	if cfg.TimeoutInSeconds > 0 {
+		httpClient.Timeout = time.Duration(cfg.TimeoutInSeconds) * time.Second
+	}
+
+	switch cfg.Type {
+	case HTTPSourceType:
+		if cfg.URL == "" {
+			return nil, errors.New("http source URL not specified")
+		}
+		return &HTTPSource{url: cfg.URL, path: cfg.Path, client: httpClient}, nil
+	case FileSourceType:
+		if cfg.File == "" {
+			return nil, errors.New("file source path not specified")
+		}
+		return &FileSource{file: cfg.File, path: cfg.Path}, nil
+	case RPCSourceType:
+		if cfg.URL == "" || cfg.Method == "" {
+			return nil, errors.New("rpc source URL & method must be specified")
+		}
+		return &RPCSource{
+			url:    cfg.URL,
+			method: cfg.Method,
+			params: cfg.Params,
+			path:   cfg.Path,
+			client: httpClient,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported source type %s", cfg.Type)
+	}
+}
+
+// HTTPSource fetches a value from a JSON document served over HTTP.
+type HTTPSource struct {
+	url    string
+	path   string
+	client *http.Client
+}
+
+func (s *HTTPSource) Fetch() (string, error) {
+	resp, err := s.client.Get(s.url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected HTTP status %d", resp.StatusCode)
+	}
+	doc, err := decodeJSON(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	return extractValue(doc, s.path)
+}
+
+// FileSource reads a value from a local file, the file must either contain nothing but the value,
+// or a JSON document the value can be extracted from.
+type FileSource struct {
+	file string
+	path string
+}
+
+func (s *FileSource) Fetch() (string, error) {
+	data, err := ioutil.ReadFile(s.file)
+	if err != nil {
+		return "", err
+	}
+	if s.path == "" {
+		return strings.TrimSpace(string(data)), nil
+	}
+	doc, err := decodeJSON(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	return extractValue(doc, s.path)
+}
+
+// RPCSource fetches a value from another chain (or any other service) via a JSON-RPC 2.0 call,
+// e.g. eth_call can be used to read a value from an Ethereum contract.
+type RPCSource struct {
+	url    string
+	method string
+	params []interface{}
+	path   string
+	client *http.Client
+}
+
+type rpcRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	ID      int           `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+func (s *RPCSource) Fetch() (string, error) {
+	params := s.params
+	if params == nil {
+		params = []interface{}{}
+	}
+	reqBody, err := json.Marshal(&rpcRequest{
+		JSONRPC: "2.0",
+		ID:      1,
+		Method:  s.method,
+		Params:  params,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := s.client.Post(s.url, "application/json", bytes.NewReader(reqBody))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected HTTP status %d", resp.StatusCode)
+	}
+	doc, err := decodeJSON(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	obj, ok := doc.(map[string]interface{})
+	if !ok {
+		return "", errors.New("invalid JSON-RPC response")
+	}
+	if rpcErr, exists := obj["error"]; exists && rpcErr != nil {
+		return "", fmt.Errorf("JSON-RPC call failed: %v", rpcErr)
+	}
+	result, exists := obj["result"]
+	if !exists {
+		return "", errors.New("JSON-RPC response has no result")
+	}
+	return extractValue(result, s.path)
+}
+
+func decodeJSON(r io.Reader) (interface{}, error) {
+	decoder := json.NewDecoder(io.LimitReader(r, maxSourceResponseSize))
+	// Numbers are decoded as strings to avoid losing precision
+	decoder.UseNumber()
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, errors.Wrap(err, "failed to decode JSON")
+	}
+	return doc, nil
+}
+
+// extractValue walks the given path through a decoded JSON document, the value at the end of the
+// path must be a number or a string.
+func extractValue(doc interface{}, path string) (string, error) {
+	node := doc
+	if path != "" {
+		for _, key := range strings.Split(path, ".") {
+			switch n := node.(type) {
+			case map[string]interface{}:
+				child, exists := n[key]
+				if !exists {
+					return "", fmt.Errorf("key %s not found", key)
+				}
+				node = child
+			case []interface{}:
+				idx, err := strconv.Atoi(key)
+				if err != nil || idx < 0 || idx >= len(n) {
+					return "", fmt.Errorf("invalid array index %s", key)
+				}
+				node = n[idx]
+			default:
+				return "", fmt.Errorf("can't select %s from a JSON value", key)
+			}
+		}
+	}
+
+	switch v := node.(type) {
+	case json.Number:
+		return v.String(), nil
+	case string:
+		return v, nil
+	default:
+		return "", fmt.Errorf("value at path '%s' is not a number or string", path)
+	}
+}
+
+// ParseValue converts a value returned by a Source into an integer scaled by the given number of
+// decimals, e.g. "1.235" with 2 decimals is converted to 124 (values are rounded half away from
+// zero).
+func ParseValue(value string, decimals uint32) (int64, error) {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "0x") || strings.HasPrefix(value, "0X") {
+		// Hex values (e.g. returned by eth_call) are assumed to already be scaled
+		n, ok := new(big.Int).SetString(value[2:], 16)
+		if !ok || !n.IsInt64() {
+			return 0, fmt.Errorf("invalid hex value %s", value)
+		}
+		return n.Int64(), nil
+	}
+
+	r, ok := new(big.Rat).SetString(value)
+	if !ok {
+		return 0, fmt.Errorf("invalid value %s", value)
+	}
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	r.Mul(r, new(big.Rat).SetInt(scale))
+
+	// Round half away from zero
+	num := new(big.Int).Abs(r.Num())
+	quo, rem := new(big.Int).QuoRem(num, r.Denom(), new(big.Int))
+	if new(big.Int).Mul(rem, big.NewInt(2)).Cmp(r.Denom()) >= 0 {
+		quo.Add(quo, big.NewInt(1))
+	}
+	if r.Sign() < 0 {
+		quo.Neg(quo)
+	}
+	if !quo.IsInt64() {
+		return 0, fmt.Errorf("value %s is out of range", value)
+	}
+	return quo.Int64(), nil
+}
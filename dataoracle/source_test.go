@@ -0,0 +1,172 @@
+package dataoracle
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseValue(t *testing.T) {
+	tests := []struct {
+		value    string
+		decimals uint32
+		expected int64
+	}{
+		{"123", 0, 123},
+		{"1.235", 2, 124},
+		{"1.234", 2, 123},
+		{"-1.235", 2, -124},
+		{" 42.5 ", 0, 43},
+		{"0.00000001", 8, 1},
+		{"0x2a", 2, 42},
+	}
+	for _, test := range tests {
+		v, err := ParseValue(test.value, test.decimals)
+		require.NoError(t, err, test.value)
+		require.Equal(t, test.expected, v, test.value)
+	}
+
+	_, err := ParseValue("abc", 2)
+	require.Error(t, err)
+	_, err = ParseValue("100000000000", 18)
+	require.Error(t, err)
+}
+
+func TestHTTPSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"prices":[{"usd":123.456789012345678},{"usd":"7.5"}]}}`))
+	}))
+	defer server.Close()
+
+	source, err := NewSource(&SourceConfig{Type: HTTPSourceType, URL: server.URL, Path: "data.prices.0.usd"})
+	require.NoError(t, err)
+	v, err := source.Fetch()
+	require.NoError(t, err)
+	require.Equal(t, "123.456789012345678", v)
+
+	source, err = NewSource(&SourceConfig{Type: HTTPSourceType, URL: server.URL, Path: "data.prices.1.usd"})
+	require.NoError(t, err)
+	v, err = source.Fetch()
+	require.NoError(t, err)
+	require.Equal(t, "7.5", v)
+
+	source, err = NewSource(&SourceConfig{Type: HTTPSourceType, URL: server.URL, Path: "data.prices.2.usd"})
+	require.NoError(t, err)
+	_, err = source.Fetch()
+	require.Error(t, err)
+
+	source, err = NewSource(&SourceConfig{Type: HTTPSourceType, URL: server.URL, Path: "data"})
+	require.NoError(t, err)
+	_, err = source.Fetch()
+	require.Error(t, err)
+}
+
+func TestFileSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dataoracle")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	plainFile := filepath.Join(dir, "value.txt")
+	require.NoError(t, ioutil.WriteFile(plainFile, []byte("99.5\n"), 0644))
+	source, err := NewSource(&SourceConfig{Type: FileSourceType, File: plainFile})
+	require.NoError(t, err)
+	v, err := source.Fetch()
+	require.NoError(t, err)
+	require.Equal(t, "99.5", v)
+
+	jsonFile := filepath.Join(dir, "value.json")
+	require.NoError(t, ioutil.WriteFile(jsonFile, []byte(`{"value": 12}`), 0644))
+	source, err = NewSource(&SourceConfig{Type: FileSourceType, File: jsonFile, Path: "value"})
+	require.NoError(t, err)
+	v, err = source.Fetch()
+	require.NoError(t, err)
+	require.Equal(t, "12", v)
+}
+
+func TestRPCSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		if req.Method != "eth_call" {
+			w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"method not found"}}`))
+			return
+		}
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x00000000000000000000000000000000000000000000000000000000000003e8"}`))
+	}))
+	defer server.Close()
+
+	source, err := NewSource(&SourceConfig{
+		Type:   RPCSourceType,
+		URL:    server.URL,
+		Method: "eth_call",
+		Params: []interface{}{map[string]string{"to": "0x0", "data": "0x0"}, "latest"},
+	})
+	require.NoError(t, err)
+	v, err := source.Fetch()
+	require.NoError(t, err)
+	parsed, err := ParseValue(v, 0)
+	require.NoError(t, err)
+	require.Equal(t, int64(1000), parsed)
+
+	source, err = NewSource(&SourceConfig{Type: RPCSourceType, URL: server.URL, Method: "eth_foo"})
+	require.NoError(t, err)
+	_, err = source.Fetch()
+	require.Error(t, err)
+}
+
+func TestRestrictedSource(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/redirect":
+			http.Redirect(w, r, strings.Replace(server.URL, "127.0.0.1", "localhost", 1), http.StatusFound)
+		case "/large":
+			w.Write([]byte(`{"value":"`))
+			w.Write(bytes.Repeat([]byte("1"), maxSourceResponseSize))
+			w.Write([]byte(`"}`))
+		default:
+			w.Write([]byte(`{"value":12}`))
+		}
+	}))
+	defer server.Close()
+	allowedHosts := []string{"127.0.0.1"}
+
+	source, err := NewRestrictedSource(
+		&SourceConfig{Type: HTTPSourceType, URL: server.URL, Path: "value"}, allowedHosts,
+	)
+	require.NoError(t, err)
+	v, err := source.Fetch()
+	require.NoError(t, err)
+	require.Equal(t, "12", v)
+
+	// redirects to hosts that aren't allowed are rejected
+	source, err = NewRestrictedSource(
+		&SourceConfig{Type: HTTPSourceType, URL: server.URL + "/redirect", Path: "value"}, allowedHosts,
+	)
+	require.NoError(t, err)
+	_, err = source.Fetch()
+	require.Error(t, err)
+
+	// responses that exceed the size limit are rejected
+	source, err = NewRestrictedSource(
+		&SourceConfig{Type: HTTPSourceType, URL: server.URL + "/large", Path: "value"}, allowedHosts,
+	)
+	require.NoError(t, err)
+	_, err = source.Fetch()
+	require.Error(t, err)
+
+	_, err = NewRestrictedSource(&SourceConfig{Type: HTTPSourceType, URL: server.URL}, []string{"example.com"})
+	require.Error(t, err)
+	_, err = NewRestrictedSource(&SourceConfig{Type: RPCSourceType, URL: "file:///etc/passwd", Method: "eth_call"}, allowedHosts)
+	require.Error(t, err)
+	_, err = NewRestrictedSource(&SourceConfig{Type: FileSourceType, File: "/etc/passwd"}, allowedHosts)
+	require.Error(t, err)
+}
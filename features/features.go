@@ -30,6 +30,8 @@ const (
 
 	// Enables support for mapping DAppChain accounts to Binance accounts
 	AddressMapperVersion1_1 = "addrmapper:v1.1"
+	// Enables rotation of the foreign account mapped to a DAppChain account
+	AddressMapperVersion1_2 = "addrmapper:v1.2"
 
 	// Enables processing of txs via MultiChainSignatureTxMiddleware, there's a feature flag per
 	// allowed chain ID, e.g. auth:sigtx:default, auth:sigtx:eth
@@ -57,6 +59,15 @@ const (
 	DPOSVersion3_7 = "dpos:v3.7"
 	// Enables stripping of voting power from jailed validators
 	DPOSVersion3_8 = "dpos:v3.8"
+	// Enables jailing & slashing of validators that double sign
+	DPOSVersion3_9 = "dpos:v3.9"
+	// Enables auto-compounding of delegator rewards in DPOS v3
+	DPOSVersion3_10 = "dpos:v3.10"
+	// Enables recording of per-election summaries in DPOS v3
+	DPOSVersion3_11 = "dpos:v3.11"
+	// Enables the unbonding queue in DPOS v3, unbonded tokens remain slashable until the unbonding
+	// period ends
+	DPOSVersion3_12 = "dpos:v3.12"
 
 	// Enables rewards to be distributed even when a delegator owns less than 0.01% of the validator's stake
 	// Also makes whitelists give bonuses correctly if whitelist locktime tier is set to be 0-3 (else defaults to 5%)
@@ -119,6 +130,18 @@ const (
 	// Enables checking of minimum required build number on node startup.
 	ChainCfgVersion1_4 = "chaincfg:v1.4"
 
+	// Enables weighting of feature & config change votes by validator power in the ChainConfig contract.
+	ChainCfgVersion1_5 = "chaincfg:v1.5"
+
+	// Enables scheduling of feature activation at a specific block height in the ChainConfig contract.
+	ChainCfgVersion1_6 = "chaincfg:v1.6"
+
+	// Enables management of the Fns registered with the fnConsensus reactor via the ChainConfig contract.
+	ChainCfgVersion1_7 = "chaincfg:v1.7"
+
+	// Enables scheduling of external plugin upgrades via the ChainConfig contract.
+	ChainCfgVersion1_8 = "chaincfg:v1.8"
+
 	// Enables the EthTxHandler for processing signed RLP endoed Ethereum txs.
 	EthTxFeature = "tx:eth"
 
@@ -132,6 +155,12 @@ const (
 	CoinVersion1_2Feature = "coin:v1.2"
 	// Enables minting & burning via Binance Gateway
 	CoinVersion1_3Feature = "coin:v1.3"
+	// Enables vesting accounts in the Coin contract
+	CoinVersion1_4Feature = "coin:v1.4"
+	// Enables balance snapshots in the Coin & ETHCoin contracts
+	CoinVersion1_5Feature = "coin:v1.5"
+	// Enables batch transfers in the Coin & ETHCoin contracts
+	CoinVersion1_6Feature = "coin:v1.6"
 
 	// Force ReceiptHandler to write BloomFilter and EVM TxHash only to receipts_db, otherwise it'll
 	// write BloomFilter and EVM TxHash to both receipts_db & app.db.
@@ -143,6 +172,40 @@ const (
 	// Enable option to allow checking the registry error
 	DeployTxVersion1_1Feature = "deploytx:v1.1"
 
+	// Enables the fee market, txs must declare a max fee & are charged the current base fee.
+	FeeMarketFeature = "tx:fee-market"
+
+	// Enables gas metering of Go contract calls & deployments, txs that exceed the tx gas limit fail.
+	// Only the operations Go contracts perform via the contract context are metered, not CPU time.
+	GoContractGasMeteringFeature = "vm:go-gas-metering"
+
+	// Enables deployment & execution of Wasm contracts.
+	WasmVMFeature = "vm:wasm"
+
+	// Enables processing of UpgradeContractTx, and loading of the plugin versions Go contracts have
+	// been upgraded to.
+	ContractUpgradeTxFeature = "tx:upgrade-contract"
+
+	// Enables processing of TransferOwnershipTx & SetContractMetadataTx.
+	ContractRecordTxFeature = "tx:contract-record"
+
+	// Enables enforcement of the rate-limit policies stored in the RateLimit contract.
+	RateLimitFeature = "tx:rate-limit"
+
+	// Enables enforcement of the account allow & deny lists stored in the AccessControl contract.
+	AccessControlFeature = "tx:access-control"
+
+	// Enables tallying & execution of proposals submitted to the Governance contract.
+	GovernanceFeature = "governance:v1"
+
+	// Switches the tx limiter & karma throttle from in-memory sessions measured in seconds to
+	// sessions measured in blocks that are stored in the app state, the session lengths & limits
+	// are taken from the ThrottleBlockWindow.* on-chain config settings. Once a session length is
+	// set the corresponding throttle runs on every node even if it's disabled in loom.yml, so the
+	// Karma contract must be deployed before the karma session length is set. Txs that fail still
+	// count towards the limits, as they do for the RateLimit contract policies.
+	ThrottleBlockWindowFeature = "throttle:block-window"
+
 	// Restrict the value of call & deploy txs to non-negative amounts
 	CheckTxValueFeature = "tx:check-value"
 
@@ -0,0 +1,274 @@
+package feemarket
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/gogo/protobuf/proto"
+	loom "github.com/loomnetwork/go-loom"
+	"github.com/loomnetwork/go-loom/types"
+	"github.com/loomnetwork/loomchain/store"
+	"github.com/pkg/errors"
+)
+
+const (
+	// SettingPrefix is the prefix of all the fee market settings that can be changed via the
+	// ChainConfig contract, e.g. FeeMarket.MinBaseFee
+	SettingPrefix = "FeeMarket."
+
+	configKey = "feemarket:config"
+	stateKey  = "feemarket:state"
+
+	// Maximum number of empty blocks that are taken into account when adjusting the base fee,
+	// the base fee will have dropped to the minimum long before this many blocks anyway.
+	maxEmptyBlockAdjustments = 128
+)
+
+var (
+	// ErrMaxFeeTooLow is returned when the max fee specified in a FeeTx is lower than the current
+	// base fee.
+	ErrMaxFeeTooLow = errors.New("[FeeMarket] max fee is lower than the current base fee")
+	// ErrMissingFee is returned when a tx doesn't declare a max fee while the fee market is enabled.
+	ErrMissingFee = errors.New("[FeeMarket] tx doesn't specify a max fee")
+)
+
+type Config = FeeMarketConfig
+
+// DefaultConfig returns the fee market config that's used until it's changed via the ChainConfig
+// contract.
+func DefaultConfig() *Config {
+	return &Config{
+		MinBaseFee:               loom.BigZeroPB(),
+		TargetTxsPerBlock:        50,
+		BaseFeeChangeDenominator: 8,
+		BurnPercentage:           50,
+	}
+}
+
+// LoadConfig loads the fee market config from the given kv store.
+func LoadConfig(kvStore store.KVReader) (*Config, error) {
+	cfg := DefaultConfig()
+	cfgBytes := kvStore.Get([]byte(configKey))
+	if len(cfgBytes) > 0 {
+		if err := proto.UnmarshalMerge(cfgBytes, cfg); err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
+
+// SaveConfig saves the fee market config to the given kv store.
+func SaveConfig(kvStore store.KVWriter, cfg *Config) error {
+	cfgBytes, err := proto.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	kvStore.Set([]byte(configKey), cfgBytes)
+	return nil
+}
+
+// IsSetting returns true if the given config setting name refers to a fee market setting.
+func IsSetting(name string) bool {
+	return strings.HasPrefix(name, SettingPrefix)
+}
+
+// SetConfigSetting updates a fee market config setting, the name of the setting must include
+// the SettingPrefix.
+func SetConfigSetting(cfg *Config, name, value string) error {
+	switch strings.TrimPrefix(name, SettingPrefix) {
+	case "MinBaseFee":
+		fee, err := parseBigUInt(value)
+		if err != nil {
+			return errors.Wrapf(err, "invalid value for %s", name)
+		}
+		cfg.MinBaseFee = &types.BigUInt{Value: *fee}
+	case "TargetTxsPerBlock":
+		v, err := strconv.ParseUint(value, 10, 64)
+		if err != nil || v == 0 {
+			return fmt.Errorf("invalid value for %s: %s", name, value)
+		}
+		cfg.TargetTxsPerBlock = v
+	case "BaseFeeChangeDenominator":
+		v, err := strconv.ParseUint(value, 10, 64)
+		if err != nil || v == 0 {
+			return fmt.Errorf("invalid value for %s: %s", name, value)
+		}
+		cfg.BaseFeeChangeDenominator = v
+	case "BurnPercentage":
+		v, err := strconv.ParseUint(value, 10, 64)
+		if err != nil || v > 100 {
+			return fmt.Errorf("invalid value for %s: %s", name, value)
+		}
+		cfg.BurnPercentage = v
+	default:
+		return fmt.Errorf("unknown fee market setting %s", name)
+	}
+	return nil
+}
+
+// ChangeConfigSetting updates the value of the given fee market setting in the kv store.
+func ChangeConfigSetting(kvStore store.KVStore, name, value string) error {
+	cfg, err := LoadConfig(kvStore)
+	if err != nil {
+		return err
+	}
+	if err := SetConfigSetting(cfg, name, value); err != nil {
+		return err
+	}
+	return SaveConfig(kvStore, cfg)
+}
+
+func parseBigUInt(value string) (*loom.BigUInt, error) {
+	v, ok := new(big.Int).SetString(value, 10)
+	if !ok || v.Sign() < 0 {
+		return nil, fmt.Errorf("%s is not a valid unsigned integer", value)
+	}
+	return loom.NewBigUInt(v), nil
+}
+
+func loadState(kvStore store.KVReader) (*FeeMarketState, error) {
+	var st FeeMarketState
+	stateBytes := kvStore.Get([]byte(stateKey))
+	if len(stateBytes) == 0 {
+		return nil, nil
+	}
+	if err := proto.Unmarshal(stateBytes, &st); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+func saveState(kvStore store.KVWriter, st *FeeMarketState) error {
+	stateBytes, err := proto.Marshal(st)
+	if err != nil {
+		return err
+	}
+	kvStore.Set([]byte(stateKey), stateBytes)
+	return nil
+}
+
+// NextBaseFee computes the base fee of a block from the base fee & number of txs of the
+// preceding block. The base fee goes up when the preceding block contained more txs than the
+// target, and goes down when it contained less.
+func NextBaseFee(cfg *Config, baseFee *loom.BigUInt, txCount uint64) *loom.BigUInt {
+	minFee := loom.NewBigUIntFromInt(0)
+	if cfg.MinBaseFee != nil {
+		minFee = &cfg.MinBaseFee.Value
+	}
+	target := cfg.TargetTxsPerBlock
+	if target == 0 || cfg.BaseFeeChangeDenominator == 0 || txCount == target {
+		return maxBigUInt(baseFee, minFee)
+	}
+
+	var diff uint64
+	if txCount > target {
+		diff = txCount - target
+	} else {
+		diff = target - txCount
+	}
+	delta := loom.NewBigUIntFromInt(0)
+	delta.Mul(baseFee, loom.NewBigUInt(new(big.Int).SetUint64(diff)))
+	delta.Div(delta, loom.NewBigUInt(new(big.Int).SetUint64(target)))
+	delta.Div(delta, loom.NewBigUInt(new(big.Int).SetUint64(cfg.BaseFeeChangeDenominator)))
+
+	next := loom.NewBigUIntFromInt(0)
+	if txCount > target {
+		// make sure the base fee can grow out of zero
+		if delta.Sign() == 0 {
+			delta = loom.NewBigUIntFromInt(1)
+		}
+		next.Add(baseFee, delta)
+	} else if baseFee.Cmp(delta) > 0 {
+		next.Sub(baseFee, delta)
+	}
+	return maxBigUInt(next, minFee)
+}
+
+// CurrentBaseFee returns the base fee that should be charged for txs in the block at the given
+// height.
+func CurrentBaseFee(kvStore store.KVReader, cfg *Config, height int64) (*loom.BigUInt, error) {
+	st, err := loadState(kvStore)
+	if err != nil {
+		return nil, err
+	}
+	return currentBaseFee(st, cfg, height), nil
+}
+
+func currentBaseFee(st *FeeMarketState, cfg *Config, height int64) *loom.BigUInt {
+	if st == nil || st.BaseFee == nil {
+		return NextBaseFee(cfg, loom.NewBigUIntFromInt(0), cfg.TargetTxsPerBlock)
+	}
+	if st.Height >= height {
+		return &st.BaseFee.Value
+	}
+	baseFee := NextBaseFee(cfg, &st.BaseFee.Value, st.TxCount)
+	// Blocks that didn't contain any fee paying txs aren't recorded, so account for them here.
+	for h := st.Height + 1; (h < height) && (h-st.Height <= maxEmptyBlockAdjustments); h++ {
+		baseFee = NextBaseFee(cfg, baseFee, 0)
+	}
+	return baseFee
+}
+
+// RecordTx records a fee paying tx in the block at the given height, and returns the base fee
+// the tx should be charged.
+func RecordTx(kvStore store.KVStore, cfg *Config, height int64) (*loom.BigUInt, error) {
+	st, err := loadState(kvStore)
+	if err != nil {
+		return nil, err
+	}
+	if st == nil || st.Height != height {
+		baseFee := currentBaseFee(st, cfg, height)
+		st = &FeeMarketState{
+			Height:  height,
+			BaseFee: &types.BigUInt{Value: *baseFee},
+		}
+	}
+	st.TxCount++
+	if err := saveState(kvStore, st); err != nil {
+		return nil, err
+	}
+	return &st.BaseFee.Value, nil
+}
+
+// UnwrapFeeTx extracts the max fee & the inner tx from a serialized FeeTx.
+func UnwrapFeeTx(txBytes []byte) ([]byte, *loom.BigUInt, error) {
+	var tx FeeTx
+	if err := proto.Unmarshal(txBytes, &tx); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to unmarshal FeeTx")
+	}
+	if tx.MaxFee == nil || len(tx.Inner) == 0 {
+		return nil, nil, ErrMissingFee
+	}
+	return tx.Inner, &tx.MaxFee.Value, nil
+}
+
+// NewFeeTx wraps the given Transaction in a FeeTx with the given max fee. DAppChainRPCClient.CommitTx
+// wraps whatever tx it's given in a NonceTx, so clients can send a fee paying tx by passing the
+// FeeTx to CommitTx instead of the Transaction.
+func NewFeeTx(tx *types.Transaction, maxFee *loom.BigUInt) (*FeeTx, error) {
+	txBytes, err := proto.Marshal(tx)
+	if err != nil {
+		return nil, err
+	}
+	return &FeeTx{
+		Inner:  txBytes,
+		MaxFee: &types.BigUInt{Value: *maxFee},
+	}, nil
+}
+
+// WrapFeeTx wraps a serialized Transaction in a FeeTx with the given max fee.
+func WrapFeeTx(txBytes []byte, maxFee *loom.BigUInt) ([]byte, error) {
+	return proto.Marshal(&FeeTx{
+		Inner:  txBytes,
+		MaxFee: &types.BigUInt{Value: *maxFee},
+	})
+}
+
+func maxBigUInt(a, b *loom.BigUInt) *loom.BigUInt {
+	if a.Cmp(b) >= 0 {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,90 @@
+package feemarket
+
+import (
+	"testing"
+
+	loom "github.com/loomnetwork/go-loom"
+	"github.com/loomnetwork/loomchain/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNextBaseFee(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.TargetTxsPerBlock = 10
+
+	// full block (2x target) raises the base fee by 1/8
+	fee := NextBaseFee(cfg, loom.NewBigUIntFromInt(800), 20)
+	require.Equal(t, int64(900), fee.Int64())
+	// empty block lowers the base fee by 1/8
+	fee = NextBaseFee(cfg, loom.NewBigUIntFromInt(800), 0)
+	require.Equal(t, int64(700), fee.Int64())
+	// block at target leaves the base fee unchanged
+	fee = NextBaseFee(cfg, loom.NewBigUIntFromInt(800), 10)
+	require.Equal(t, int64(800), fee.Int64())
+	// base fee can grow out of zero
+	fee = NextBaseFee(cfg, loom.NewBigUIntFromInt(0), 11)
+	require.Equal(t, int64(1), fee.Int64())
+
+	// base fee doesn't drop below the minimum
+	require.NoError(t, SetConfigSetting(cfg, "FeeMarket.MinBaseFee", "750"))
+	fee = NextBaseFee(cfg, loom.NewBigUIntFromInt(800), 0)
+	require.Equal(t, int64(750), fee.Int64())
+}
+
+func TestChangeConfigSetting(t *testing.T) {
+	kvStore := store.NewMemStore()
+	require.True(t, IsSetting("FeeMarket.BurnPercentage"))
+	require.False(t, IsSetting("Evm.GasLimit"))
+
+	require.NoError(t, ChangeConfigSetting(kvStore, "FeeMarket.BurnPercentage", "20"))
+	require.NoError(t, ChangeConfigSetting(kvStore, "FeeMarket.TargetTxsPerBlock", "100"))
+	require.Error(t, ChangeConfigSetting(kvStore, "FeeMarket.BurnPercentage", "101"))
+	require.Error(t, ChangeConfigSetting(kvStore, "FeeMarket.TargetTxsPerBlock", "0"))
+	require.Error(t, ChangeConfigSetting(kvStore, "FeeMarket.MinBaseFee", "-1"))
+	require.Error(t, ChangeConfigSetting(kvStore, "FeeMarket.Unknown", "1"))
+
+	cfg, err := LoadConfig(kvStore)
+	require.NoError(t, err)
+	require.Equal(t, uint64(20), cfg.BurnPercentage)
+	require.Equal(t, uint64(100), cfg.TargetTxsPerBlock)
+	require.Equal(t, DefaultConfig().BaseFeeChangeDenominator, cfg.BaseFeeChangeDenominator)
+}
+
+func TestRecordTx(t *testing.T) {
+	kvStore := store.NewMemStore()
+	cfg := DefaultConfig()
+	cfg.TargetTxsPerBlock = 1
+	require.NoError(t, SetConfigSetting(cfg, "FeeMarket.MinBaseFee", "800"))
+
+	// all txs in a block are charged the same base fee
+	for i := 0; i < 3; i++ {
+		fee, err := RecordTx(kvStore, cfg, 10)
+		require.NoError(t, err)
+		require.Equal(t, int64(800), fee.Int64())
+	}
+
+	// 3 txs in the previous block vs target of 1 raises the base fee by 2/8
+	fee, err := CurrentBaseFee(kvStore, cfg, 11)
+	require.NoError(t, err)
+	require.Equal(t, int64(1000), fee.Int64())
+	fee, err = RecordTx(kvStore, cfg, 11)
+	require.NoError(t, err)
+	require.Equal(t, int64(1000), fee.Int64())
+
+	// empty blocks bring the base fee back down to the minimum
+	fee, err = CurrentBaseFee(kvStore, cfg, 20)
+	require.NoError(t, err)
+	require.Equal(t, int64(800), fee.Int64())
+}
+
+func TestWrapFeeTx(t *testing.T) {
+	txBytes, err := WrapFeeTx([]byte{1, 2, 3}, loom.NewBigUIntFromInt(5))
+	require.NoError(t, err)
+	inner, maxFee, err := UnwrapFeeTx(txBytes)
+	require.NoError(t, err)
+	require.Equal(t, []byte{1, 2, 3}, inner)
+	require.Equal(t, int64(5), maxFee.Int64())
+
+	_, _, err = UnwrapFeeTx([]byte{})
+	require.Equal(t, ErrMissingFee, err)
+}
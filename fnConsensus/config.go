@@ -4,10 +4,14 @@ import (
 	"encoding/hex"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/tendermint/tendermint/crypto"
 )
 
+// Default number of seconds completed & expired vote sets are retained in the vote history.
+const defaultVoteHistoryRetentionInSeconds int64 = 7 * 24 * 60 * 60
+
 type OverrideValidatorParsable struct {
 	Address     string
 	VotingPower int64
@@ -22,6 +26,9 @@ type ReactorConfigParsable struct {
 	OverrideValidators     []*OverrideValidatorParsable
 	FnVoteSigningThreshold SigningThreshold
 	IsValidator            bool
+	// Number of seconds completed & expired vote sets are retained in fnConsensus.db,
+	// zero disables the vote history.
+	VoteHistoryRetentionInSeconds int64
 }
 
 func (r *ReactorConfigParsable) Parse() (*ReactorConfig, error) {
@@ -55,13 +62,19 @@ func (r *ReactorConfigParsable) Parse() (*ReactorConfig, error) {
 		}
 	}
 
+	if r.VoteHistoryRetentionInSeconds < 0 {
+		return nil, fmt.Errorf("vote history retention period can't be negative")
+	}
+
+	reactorConfig.VoteHistoryRetention = time.Duration(r.VoteHistoryRetentionInSeconds) * time.Second
 	reactorConfig.IsValidator = r.IsValidator
 	return reactorConfig, nil
 }
 
 func DefaultReactorConfigParsable() *ReactorConfigParsable {
 	return &ReactorConfigParsable{
-		FnVoteSigningThreshold: Maj23SigningThreshold,
+		FnVoteSigningThreshold:        Maj23SigningThreshold,
+		VoteHistoryRetentionInSeconds: defaultVoteHistoryRetentionInSeconds,
 	}
 }
 
@@ -69,4 +82,5 @@ type ReactorConfig struct {
 	FnVoteSigningThreshold SigningThreshold
 	OverrideValidators     []*OverrideValidator
 	IsValidator            bool
+	VoteHistoryRetention   time.Duration
 }
@@ -0,0 +1,149 @@
+package fnConsensus
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+)
+
+// FnFactory creates an Fn from the factory specific params stored in the on-chain record of the Fn.
+type FnFactory func(fnID string, params []byte) (Fn, error)
+
+// FnRecord describes an Fn that should be registered with the reactor from the activation height
+// onwards, or unregistered if the record is marked as removed.
+type FnRecord struct {
+	FnID             string
+	Factory          string
+	Params           []byte
+	ActivationHeight uint64
+	Removed          bool
+}
+
+// DynamicFnRegistry is an FnRegistry whose Fns are described by records stored on-chain. The
+// registry should be synced with the records every time a block is committed, which will register
+// any Fns whose records took effect at or before the committed height, and unregister any removed
+// ones.
+// Fns can still be registered directly via Set, such Fns are not affected by syncing, and any
+// records that use the same fnID as a directly registered Fn are ignored.
+type DynamicFnRegistry struct {
+	*InMemoryFnRegistry
+
+	mtx       sync.Mutex
+	factories map[string]FnFactory
+	// Records of the Fns currently registered via Sync
+	records map[string]*FnRecord
+	// Block height of the records the registry was last synced with
+	height int64
+}
+
+func NewDynamicFnRegistry() *DynamicFnRegistry {
+	return &DynamicFnRegistry{
+		InMemoryFnRegistry: NewInMemoryFnRegistry(),
+		factories:          make(map[string]FnFactory),
+		records:            make(map[string]*FnRecord),
+	}
+}
+
+// RegisterFactory makes a factory available to Fn records, must be called before the registry is
+// synced with any records that reference the factory.
+func (r *DynamicFnRegistry) RegisterFactory(name string, factory FnFactory) error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if _, exists := r.factories[name]; exists {
+		return fmt.Errorf("Fn factory %s is already registered", name)
+	}
+	r.factories[name] = factory
+	return nil
+}
+
+// Height returns the block height of the records the registry was last synced with.
+func (r *DynamicFnRegistry) Height() int64 {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	return r.height
+}
+
+// Sync registers & unregisters Fns so that the registry matches the given records, which must have
+// been loaded from the given committed block height. Only the latest record of each Fn that has
+// taken effect at the given height is applied, records that will take effect at a later height are
+// ignored until the registry is synced at that height. Records loaded from an older block height
+// than the one the registry was last synced with are ignored. Any records that can't be applied
+// are skipped, and the error encountered while applying the last of those is returned.
+func (r *DynamicFnRegistry) Sync(height int64, records []*FnRecord) error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if height < r.height {
+		return nil
+	}
+	r.height = height
+
+	newRecords := make(map[string]*FnRecord, len(records))
+	for _, record := range records {
+		if record.ActivationHeight > uint64(height) {
+			continue
+		}
+		if current, exists := newRecords[record.FnID]; exists && current.ActivationHeight > record.ActivationHeight {
+			continue
+		}
+		newRecords[record.FnID] = record
+	}
+	for fnID, record := range newRecords {
+		if record.Removed {
+			delete(newRecords, fnID)
+		}
+	}
+
+	for fnID := range r.records {
+		if _, exists := newRecords[fnID]; !exists {
+			r.Unset(fnID)
+			delete(r.records, fnID)
+		}
+	}
+
+	var lastErr error
+	for _, record := range records {
+		if newRecords[record.FnID] != record {
+			continue
+		}
+		fnID := record.FnID
+		existing := r.records[fnID]
+		if existing == nil && r.Get(fnID) != nil {
+			// Fns registered directly take precedence over records
+			continue
+		}
+		if existing != nil && existing.Factory == record.Factory && bytes.Equal(existing.Params, record.Params) {
+			continue
+		}
+		if err := r.apply(record, existing != nil); err != nil {
+			lastErr = fmt.Errorf("failed to register Fn %s: %v", fnID, err)
+		}
+	}
+	return lastErr
+}
+
+// Creates an Fn from the given record and registers it, replacing the Fn previously created from
+// an older version of the record (if any).
+func (r *DynamicFnRegistry) apply(record *FnRecord, replace bool) error {
+	factory, exists := r.factories[record.Factory]
+	if !exists {
+		return fmt.Errorf("unknown Fn factory %s", record.Factory)
+	}
+
+	fn, err := factory(record.FnID, record.Params)
+	if err != nil {
+		return err
+	}
+
+	if replace {
+		r.Unset(record.FnID)
+		delete(r.records, record.FnID)
+	}
+	if err := r.Set(record.FnID, fn); err != nil {
+		return err
+	}
+	r.records[record.FnID] = record
+	return nil
+}
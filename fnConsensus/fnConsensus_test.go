@@ -1,9 +1,12 @@
 package fnConsensus
 
 import (
+	"bytes"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
+	cmn "github.com/tendermint/tendermint/libs/common"
 	dbm "github.com/tendermint/tendermint/libs/db"
 )
 
@@ -24,3 +27,174 @@ func TestUnmarshalReactorState(t *testing.T) {
 	require.NoError(t, err)
 	require.NotNil(t, rs.Messages)
 }
+
+type mockFn struct{}
+
+func (m *mockFn) GetMessageAndSignature(ctx []byte) ([]byte, []byte, error) {
+	return nil, nil, nil
+}
+
+func (m *mockFn) SubmitMultiSignedMessage(ctx []byte, key []byte, signatures [][]byte) {}
+
+type mockAggregateFn struct {
+	mockFn
+}
+
+func (m *mockAggregateFn) AggregateSignatures(signatures [][]byte) ([]byte, error) {
+	return bytes.Join(signatures, nil), nil
+}
+
+func (m *mockAggregateFn) SubmitAggregateSignedMessage(
+	ctx []byte, message []byte, aggregateSignature []byte, signerBitmap []byte,
+) {
+}
+
+func (m *mockAggregateFn) FnOptions() FnOptions {
+	return FnOptions{AggregateSignatures: true}
+}
+
+func TestFnRegistryOptions(t *testing.T) {
+	registry := NewInMemoryFnRegistry()
+
+	require.NoError(t, registry.Set("default", &mockFn{}))
+	opts := registry.GetOptions("default")
+	require.Equal(t, proposeIntervalInSeconds, opts.proposeInterval())
+	require.Equal(t, commitIntervalInSeconds, opts.commitInterval())
+	require.False(t, opts.AggregateSignatures)
+
+	require.NoError(t, registry.Set("slow", &mockFn{}, WithCadence(60, 30)))
+	opts = registry.GetOptions("slow")
+	require.Equal(t, int64(60), opts.proposeInterval())
+	require.Equal(t, int64(30), opts.commitInterval())
+
+	require.Equal(t, ErrInvalidFnInterval, registry.Set("fast", &mockFn{}, WithCadence(1, 1)))
+	require.Equal(t, ErrInvalidFnInterval, registry.Set("inverted", &mockFn{}, WithCadence(10, 20)))
+	require.Equal(t, ErrFnCantAggregateSignatures, registry.Set("bls", &mockFn{}, WithAggregateSignatures()))
+
+	// options provided by the Fn itself are used unless overridden
+	require.NoError(t, registry.Set("bls", &mockAggregateFn{}, WithCadence(20, 10)))
+	opts = registry.GetOptions("bls")
+	require.True(t, opts.AggregateSignatures)
+	require.Equal(t, int64(20), opts.proposeInterval())
+}
+
+func TestNextFnBoundary(t *testing.T) {
+	intervals := map[string]int64{"a": 10, "b": 15, "c": 5}
+	fnInterval := func(fnID string) int64 { return intervals[fnID] }
+
+	wait, fnIDs := nextFnBoundary(100, []string{"a", "b", "c"}, fnInterval, 10)
+	require.Equal(t, int64(5), wait)
+	require.Equal(t, []string{"b", "c"}, fnIDs)
+
+	wait, fnIDs = nextFnBoundary(101, []string{"b", "a"}, fnInterval, 10)
+	require.Equal(t, int64(4), wait)
+	require.Equal(t, []string{"b"}, fnIDs)
+
+	wait, fnIDs = nextFnBoundary(103, nil, fnInterval, 10)
+	require.Equal(t, int64(7), wait)
+	require.Len(t, fnIDs, 0)
+}
+
+func TestSignerBitmap(t *testing.T) {
+	bitArray := cmn.NewBitArray(10)
+	bitArray.SetIndex(0, true)
+	bitArray.SetIndex(3, true)
+	bitArray.SetIndex(9, true)
+	require.Equal(t, []byte{0x09, 0x02}, signerBitmap(bitArray))
+}
+
+func TestVoteHistory(t *testing.T) {
+	db := dbm.NewMemDB()
+	now := time.Now()
+
+	records := []*VoteRecord{
+		{FnID: "a", Nonce: 1, Outcome: VoteOutcomeCommitted, CompletedAt: now.Add(-3 * time.Hour).Unix()},
+		{FnID: "b", Nonce: 1, Outcome: VoteOutcomeExpired, CompletedAt: now.Add(-2 * time.Hour).Unix()},
+		{FnID: "a", Nonce: 2, Outcome: VoteOutcomeCommitted, CompletedAt: now.Add(-1 * time.Hour).Unix()},
+		{FnID: "a", Nonce: 3, Outcome: VoteOutcomeCommitted, CompletedAt: now.Unix()},
+	}
+	for _, record := range records {
+		require.NoError(t, saveVoteRecord(db, record))
+	}
+
+	history, err := loadVoteHistory(db, "", 0)
+	require.NoError(t, err)
+	require.Len(t, history, 4)
+	require.Equal(t, int64(3), history[0].Nonce)
+	require.Equal(t, "b", history[2].FnID)
+
+	history, err = loadVoteHistory(db, "a", 2)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	require.Equal(t, int64(3), history[0].Nonce)
+	require.Equal(t, int64(2), history[1].Nonce)
+
+	pruneVoteHistory(db, now.Add(-90*time.Minute))
+	history, err = loadVoteHistory(db, "", 0)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	require.Equal(t, "a", history[1].FnID)
+	require.Equal(t, int64(2), history[1].Nonce)
+}
+
+func TestMostVotedHash(t *testing.T) {
+	require.Nil(t, mostVotedHash([][]byte{nil, nil}))
+	require.Equal(t, []byte{2}, mostVotedHash([][]byte{{1}, nil, {2}, {2}}))
+}
+
+func TestDynamicFnRegistrySync(t *testing.T) {
+	registry := NewDynamicFnRegistry()
+	created := map[string]int{}
+	require.NoError(t, registry.RegisterFactory("mock", func(fnID string, params []byte) (Fn, error) {
+		created[fnID]++
+		return &mockFn{}, nil
+	}))
+	require.Error(t, registry.RegisterFactory("mock", nil))
+
+	// Fns registered directly take precedence over records
+	staticFn := &mockFn{}
+	require.NoError(t, registry.Set("static", staticFn))
+
+	records := []*FnRecord{
+		{FnID: "a", Factory: "mock", ActivationHeight: 10},
+		{FnID: "b", Factory: "mock", Params: []byte{1}, ActivationHeight: 5},
+		{FnID: "b", Factory: "mock", Params: []byte{2}, ActivationHeight: 12},
+		{FnID: "c", Factory: "mock", ActivationHeight: 11},
+		{FnID: "c", ActivationHeight: 12, Removed: true},
+		{FnID: "d", Factory: "unknown", ActivationHeight: 10},
+		{FnID: "static", Factory: "mock", ActivationHeight: 10},
+	}
+	require.Error(t, registry.Sync(10, records))
+	require.Equal(t, int64(10), registry.Height())
+	require.NotNil(t, registry.Get("a"))
+	require.NotNil(t, registry.Get("b"))
+	require.Nil(t, registry.Get("c"))
+	require.Nil(t, registry.Get("d"))
+	require.Equal(t, staticFn, registry.Get("static"))
+	require.Equal(t, 0, created["static"])
+
+	// records only take effect once the registry is synced at their activation height
+	require.Error(t, registry.Sync(11, records))
+	require.NotNil(t, registry.Get("c"))
+	require.Equal(t, 1, created["b"])
+
+	require.Error(t, registry.Sync(12, records))
+	require.NotNil(t, registry.Get("a"))
+	require.Equal(t, 1, created["a"])
+	require.Equal(t, 2, created["b"])
+	require.Nil(t, registry.Get("c"))
+	require.Equal(t, staticFn, registry.Get("static"))
+
+	// unchanged records are left alone, removed ones are unregistered
+	require.NoError(t, registry.Sync(13, []*FnRecord{
+		{FnID: "b", Factory: "mock", Params: []byte{2}, ActivationHeight: 12},
+	}))
+	require.Nil(t, registry.Get("a"))
+	require.NotNil(t, registry.Get("b"))
+	require.Equal(t, 2, created["b"])
+
+	// records loaded from an older block are ignored
+	require.NoError(t, registry.Sync(5, []*FnRecord{}))
+	require.NotNil(t, registry.Get("b"))
+	require.Equal(t, int64(13), registry.Height())
+}
@@ -48,7 +48,8 @@ const (
 	// MaxMsgSize is the max number of bytes that can sent on a P2P channel
 	MaxMsgSize = 2 * 1000 * 1024 // 2MB
 
-	// Denotes interval (synced across nodes) between two proposals
+	// Denotes default interval (synced across nodes) between two proposals, can be overridden for
+	// each Fn via FnOptions
 	proposeIntervalInSeconds int64 = 10
 	commitIntervalInSeconds  int64 = 5
 
@@ -68,6 +69,10 @@ type FnConsensusReactor struct {
 	state    *ReactorState
 	stateMtx sync.Mutex
 
+	// Tracks when this node started voting on each vote set (fnID:nonce -> Unix timestamp in seconds),
+	// only used to populate the vote history.
+	voteSetStartTimes map[string]int64
+
 	db        dbm.DB // fnConsensus.db
 	tmStateDB dbm.DB // TM state.db to load current validator set from
 	chainID   string
@@ -114,27 +119,54 @@ func NewFnConsensusReactor(
 	}
 
 	reactor := &FnConsensusReactor{
-		connectedPeers: make(map[p2p.ID]p2p.Peer),
-		db:             db,
-		chainID:        chainID,
-		tmStateDB:      tmStateDB,
-		fnRegistry:     fnRegistry,
-		privValidator:  privValidator,
-		cfg:            parsedConfig,
+		connectedPeers:    make(map[p2p.ID]p2p.Peer),
+		voteSetStartTimes: make(map[string]int64),
+		db:                db,
+		chainID:           chainID,
+		tmStateDB:         tmStateDB,
+		fnRegistry:        fnRegistry,
+		privValidator:     privValidator,
+		cfg:               parsedConfig,
 	}
 
 	reactor.BaseReactor = *p2p.NewBaseReactor("FnConsensusReactor", reactor)
 	return reactor, nil
 }
 
-func (f *FnConsensusReactor) safeSubmitMultiSignedMessage(fnID string, fn Fn, message []byte, signatures [][]byte) {
+// Submits the message along with the signatures of the validators that agreed on it to the Fn,
+// the signatures are aggregated first if the Fn was registered with aggregate signatures enabled.
+func (f *FnConsensusReactor) safeSubmitMultiSignedMessage(
+	fnID string, fn Fn, message []byte, majResponse *FnAggregateExecutionResponse,
+) {
 	defer func() {
 		err := recover()
 		if err != nil {
 			f.Logger.Error("panicked while invoking SubmitMultiSignedMessage", "error", err)
 		}
 	}()
-	fn.SubmitMultiSignedMessage(nil, message, signatures)
+
+	signatures := safeCopyDoubleArray(majResponse.OracleSignatures)
+	aggregateFn, ok := fn.(AggregateSignatureFn)
+	if !ok || !f.fnRegistry.GetOptions(fnID).AggregateSignatures {
+		fn.SubmitMultiSignedMessage(nil, message, signatures)
+		submittedMessageCount.With("fnID", fnID).Add(1)
+		return
+	}
+
+	signerSignatures := make([][]byte, 0, len(signatures))
+	for _, signature := range signatures {
+		if signature != nil {
+			signerSignatures = append(signerSignatures, signature)
+		}
+	}
+	aggregateSignature, err := aggregateFn.AggregateSignatures(signerSignatures)
+	if err != nil {
+		f.Logger.Error("failed to aggregate signatures", "fnID", fnID, "err", err)
+		return
+	}
+	aggregateFn.SubmitAggregateSignedMessage(
+		nil, message, aggregateSignature, signerBitmap(majResponse.SignatureBitArray),
+	)
 	submittedMessageCount.With("fnID", fnID).Add(1)
 }
 
@@ -237,10 +269,29 @@ func calculateMessageHash(message []byte) ([]byte, error) {
 	return hash.Sum(nil), nil
 }
 
-func calculateSleepTimeForCommit(areWeValidator bool) time.Duration {
-	currentEpochTime := time.Now().Unix()
-	baseTimeToSleep := commitIntervalInSeconds - currentEpochTime%commitIntervalInSeconds
+// Returns the number of seconds from now until the next propose (or commit) boundary of any of
+// the given Fns, and the IDs of the Fns whose boundary it is. Boundaries are aligned to the Unix
+// epoch so that they're synced across nodes. If there are no Fns the default interval is used.
+func nextFnBoundary(
+	now int64, fnIDs []string, fnInterval func(fnID string) int64, defaultInterval int64,
+) (int64, []string) {
+	secondsUntilBoundary := defaultInterval - now%defaultInterval
+	dueFnIDs := make([]string, 0, len(fnIDs))
+	for _, fnID := range fnIDs {
+		interval := fnInterval(fnID)
+		wait := interval - now%interval
+		if len(dueFnIDs) == 0 || wait < secondsUntilBoundary {
+			secondsUntilBoundary = wait
+			dueFnIDs = append(dueFnIDs[:0], fnID)
+		} else if wait == secondsUntilBoundary {
+			dueFnIDs = append(dueFnIDs, fnID)
+		}
+	}
+	sort.Strings(dueFnIDs)
+	return secondsUntilBoundary, dueFnIDs
+}
 
+func calculateSleepTimeForCommit(areWeValidator bool, baseTimeToSleep int64) time.Duration {
 	const maxBoundForVariableComponent = 2 * time.Second
 	const baseCommitDelay = 100 * time.Millisecond
 
@@ -253,10 +304,7 @@ func calculateSleepTimeForCommit(areWeValidator bool) time.Duration {
 		baseCommitDelay
 }
 
-func calculateSleepTimeForPropose(areWeValidator bool) time.Duration {
-	currentEpochTime := time.Now().Unix()
-	baseTimeToSleep := proposeIntervalInSeconds - currentEpochTime%proposeIntervalInSeconds
-
+func calculateSleepTimeForPropose(areWeValidator bool, baseTimeToSleep int64) time.Duration {
 	const baseProposalDelay = 500 * time.Millisecond
 	const maxBoundForVariableComponent = 2 * time.Second
 
@@ -346,7 +394,12 @@ func (f *FnConsensusReactor) commitRoutine() {
 
 OUTER_LOOP:
 	for {
-		commitSleepTime := calculateSleepTimeForCommit(areWeValidator)
+		secondsUntilCommit, fnIDs := nextFnBoundary(
+			time.Now().Unix(), f.fnRegistry.GetAll(),
+			func(fnID string) int64 { return f.fnRegistry.GetOptions(fnID).commitInterval() },
+			commitIntervalInSeconds,
+		)
+		commitSleepTime := calculateSleepTimeForCommit(areWeValidator, secondsUntilCommit)
 		commitTimer := time.NewTimer(commitSleepTime)
 
 		select {
@@ -354,9 +407,6 @@ OUTER_LOOP:
 			commitTimer.Stop()
 			break OUTER_LOOP
 		case <-commitTimer.C:
-			fnIDs := f.fnRegistry.GetAll()
-			sort.Strings(fnIDs)
-
 			fnsEligibleForCommit := make([]string, 0, len(fnIDs))
 
 			f.stateMtx.Lock()
@@ -393,7 +443,12 @@ OUTER_LOOP:
 		// Align to minutes, to make sure this routine runs at almost same time across all nodes
 		// Not strictly required
 		// state and other variables will be same as the one initialized in second case statement
-		proposeSleepTime := calculateSleepTimeForPropose(areWeValidator)
+		secondsUntilPropose, fnIDs := nextFnBoundary(
+			time.Now().Unix(), f.fnRegistry.GetAll(),
+			func(fnID string) int64 { return f.fnRegistry.GetOptions(fnID).proposeInterval() },
+			proposeIntervalInSeconds,
+		)
+		proposeSleepTime := calculateSleepTimeForPropose(areWeValidator, secondsUntilPropose)
 		proposeTimer := time.NewTimer(proposeSleepTime)
 
 		select {
@@ -408,9 +463,6 @@ OUTER_LOOP:
 				break
 			}
 
-			fnIDs := f.fnRegistry.GetAll()
-			sort.Strings(fnIDs)
-
 			fnsEligibleForVoting := make([]string, 0, len(fnIDs))
 
 			f.stateMtx.Lock()
@@ -509,12 +561,15 @@ func (f *FnConsensusReactor) vote(fnID string, fn Fn, currentValidators *types.V
 			fnID,
 			fn,
 			safeCopyBytes(f.state.Messages[fnID].Payload),
-			safeCopyDoubleArray(aggregateExecutionResponse.OracleSignatures),
+			aggregateExecutionResponse,
 		)
+		f.trackVoteSetStart(voteSet)
+		f.recordVoteSet(voteSet, aggregateExecutionResponse, VoteOutcomeCommitted)
 		return
 	}
 
 	f.state.CurrentVoteSets[fnID] = voteSet
+	f.trackVoteSetStart(voteSet)
 
 	if err := saveReactorState(f.db, f.state, true); err != nil {
 		f.Logger.Error(
@@ -570,6 +625,7 @@ func (f *FnConsensusReactor) commit(fnID string) {
 			"FnConsensusReactor: Invalid VoteSet found",
 			"VoteSet", currentVoteSet, "err", err, "method", commitMethodID)
 
+		f.recordVoteSet(currentVoteSet, nil, VoteOutcomeExpired)
 		delete(f.state.CurrentVoteSets, fnID)
 
 		if err := saveReactorState(f.db, f.state, true); err != nil {
@@ -644,12 +700,17 @@ func (f *FnConsensusReactor) commit(fnID string) {
 						fnID,
 						fn,
 						safeCopyBytes(f.state.Messages[fnID].Payload),
-						safeCopyDoubleArray(majExecutionResponse.OracleSignatures),
+						majExecutionResponse,
 					)
 				}
 			}
 		}
 
+		f.recordVoteSet(
+			currentVoteSet,
+			currentVoteSet.MajResponse(f.cfg.FnVoteSigningThreshold, currentValidators),
+			VoteOutcomeCommitted,
+		)
 		f.state.CurrentNonces[fnID]++
 		nonceGauge.With("fnID", fnID).Set(float64(f.state.CurrentNonces[fnID]))
 		f.state.PreviousValidatorSet = currentValidators
@@ -809,6 +870,15 @@ func (f *FnConsensusReactor) handleMaj23VoteSetChannel(sender p2p.Peer, msgBytes
 
 		// If we have found maj23 voteset with a nonce equal or greater than our current nonce,
 		// our current vote set is clearly outdated, and should be removed.
+		if currentVoteSet := f.state.CurrentVoteSets[remoteFnID]; currentVoteSet != nil &&
+			currentVoteSet.Nonce != remoteMajVoteSet.Nonce {
+			f.recordVoteSet(currentVoteSet, nil, VoteOutcomeExpired)
+		}
+		f.recordVoteSet(
+			remoteMajVoteSet,
+			remoteMajVoteSet.MajResponse(f.cfg.FnVoteSigningThreshold, validatorSetWhichSignedRemoteVoteSet),
+			VoteOutcomeCommitted,
+		)
 		delete(f.state.CurrentVoteSets, remoteFnID)
 
 		needToExcludeSender = true
@@ -905,7 +975,11 @@ func (f *FnConsensusReactor) handleVoteSetChannelMessage(sender p2p.Peer, msgByt
 
 	// Remote voteset is more trustworthy, so replace
 	case 1:
+		if currentVoteSet != nil {
+			f.recordVoteSet(currentVoteSet, nil, VoteOutcomeExpired)
+		}
 		f.state.CurrentVoteSets[fnID] = remoteVoteSet
+		f.trackVoteSetStart(remoteVoteSet)
 		f.state.CurrentNonces[fnID] = remoteVoteSet.Nonce
 
 		currentVoteSet = remoteVoteSet
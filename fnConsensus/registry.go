@@ -7,6 +7,13 @@ import (
 
 var ErrFnIDIsTaken = errors.New("FnID is already used by another Fn Object")
 var ErrFnObjCantNil = errors.New("FnObj cant be nil")
+var ErrInvalidFnInterval = errors.New("Fn propose & commit intervals must be at least 3 seconds, " +
+	"and the commit interval can't exceed the propose interval")
+var ErrFnCantAggregateSignatures = errors.New("Fn doesn't implement AggregateSignatureFn")
+
+// Min number of seconds between proposals (or commits) for any Fn, the reactor adds up to a few
+// seconds of random delay to each proposal & commit so shorter intervals would overlap.
+const minFnIntervalInSeconds int64 = 3
 
 // Fn object once registered, will be invoked by Reactor at various point in state cycle
 // It should contain pluggable business logic to construct/submit message and signature
@@ -25,23 +32,103 @@ type Fn interface {
 	SubmitMultiSignedMessage(ctx []byte, key []byte, signatures [][]byte)
 }
 
+// AggregateSignatureFn can be implemented by an Fn whose signatures can be aggregated (e.g. BLS
+// signatures). If such an Fn is registered with aggregate signatures enabled the reactor will
+// submit a single aggregate signature along with a bitmap of the validators that signed, instead
+// of a signature from each validator.
+type AggregateSignatureFn interface {
+	Fn
+	// Aggregates the given signatures (ordered by validator index) into a single signature.
+	AggregateSignatures(signatures [][]byte) ([]byte, error)
+	// Invoked instead of SubmitMultiSignedMessage once the reactor reaches the vote threshold for the
+	// given message. Bit i of the signer bitmap (i%8-th least significant bit of byte i/8) is set if
+	// the validator at index i in the validator set contributed to the aggregate signature.
+	SubmitAggregateSignedMessage(ctx []byte, message []byte, aggregateSignature []byte, signerBitmap []byte)
+}
+
+// FnOptions control how the reactor reaches consensus for an Fn.
+// NOTE: All the validators must use the same options for an Fn, otherwise they'll end up voting
+// at different times and may never reach consensus.
+type FnOptions struct {
+	// Number of seconds between proposals (synced across nodes), zero means the default is used.
+	ProposeIntervalInSeconds int64
+	// Number of seconds between commits (synced across nodes), zero means the default is used.
+	CommitIntervalInSeconds int64
+	// Indicates whether the signatures should be aggregated before being submitted, only valid if
+	// the Fn implements AggregateSignatureFn.
+	AggregateSignatures bool
+}
+
+func (o FnOptions) proposeInterval() int64 {
+	if o.ProposeIntervalInSeconds == 0 {
+		return proposeIntervalInSeconds
+	}
+	return o.ProposeIntervalInSeconds
+}
+
+func (o FnOptions) commitInterval() int64 {
+	if o.CommitIntervalInSeconds == 0 {
+		return commitIntervalInSeconds
+	}
+	return o.CommitIntervalInSeconds
+}
+
+func (o FnOptions) validate(fnObj Fn) error {
+	if o.proposeInterval() < minFnIntervalInSeconds || o.commitInterval() < minFnIntervalInSeconds ||
+		o.commitInterval() > o.proposeInterval() {
+		return ErrInvalidFnInterval
+	}
+	if _, ok := fnObj.(AggregateSignatureFn); o.AggregateSignatures && !ok {
+		return ErrFnCantAggregateSignatures
+	}
+	return nil
+}
+
+// FnOptionsProvider can be implemented by an Fn to specify its own default options, any options
+// passed to FnRegistry.Set are applied on top of these.
+type FnOptionsProvider interface {
+	FnOptions() FnOptions
+}
+
+// FnOption modifies the options an Fn is registered with.
+type FnOption func(*FnOptions)
+
+// WithCadence sets the number of seconds between proposals & commits for an Fn.
+func WithCadence(proposeIntervalInSeconds, commitIntervalInSeconds int64) FnOption {
+	return func(o *FnOptions) {
+		o.ProposeIntervalInSeconds = proposeIntervalInSeconds
+		o.CommitIntervalInSeconds = commitIntervalInSeconds
+	}
+}
+
+// WithAggregateSignatures enables aggregation of the signatures submitted to an Fn.
+func WithAggregateSignatures() FnOption {
+	return func(o *FnOptions) {
+		o.AggregateSignatures = true
+	}
+}
+
 // FnRegistry acts as a registry which stores multiple Fn objects by their IDs
 // And allows reactor to query Fns at time of propose and validation.
 type FnRegistry interface {
 	Get(fnID string) Fn
-	Set(fnID string, fnObj Fn) error
+	// GetOptions returns the options the Fn with the given ID was registered with.
+	GetOptions(fnID string) FnOptions
+	Set(fnID string, fnObj Fn, opts ...FnOption) error
 	GetAll() []string
 }
 
 // InMemoryFnRegistry is a transient registry that needs to be rebuilt upon restart.
 type InMemoryFnRegistry struct {
-	mtx   sync.RWMutex
-	fnMap map[string]Fn
+	mtx       sync.RWMutex
+	fnMap     map[string]Fn
+	fnOptsMap map[string]FnOptions
 }
 
 func NewInMemoryFnRegistry() *InMemoryFnRegistry {
 	return &InMemoryFnRegistry{
-		fnMap: make(map[string]Fn),
+		fnMap:     make(map[string]Fn),
+		fnOptsMap: make(map[string]FnOptions),
 	}
 }
 
@@ -67,11 +154,29 @@ func (f *InMemoryFnRegistry) Get(fnID string) Fn {
 	return f.fnMap[fnID]
 }
 
-func (f *InMemoryFnRegistry) Set(fnID string, fnObj Fn) error {
+func (f *InMemoryFnRegistry) GetOptions(fnID string) FnOptions {
+	f.mtx.RLock()
+	defer f.mtx.RUnlock()
+
+	return f.fnOptsMap[fnID]
+}
+
+func (f *InMemoryFnRegistry) Set(fnID string, fnObj Fn, opts ...FnOption) error {
 	if fnObj == nil {
 		return ErrFnObjCantNil
 	}
 
+	var fnOpts FnOptions
+	if provider, ok := fnObj.(FnOptionsProvider); ok {
+		fnOpts = provider.FnOptions()
+	}
+	for _, opt := range opts {
+		opt(&fnOpts)
+	}
+	if err := fnOpts.validate(fnObj); err != nil {
+		return err
+	}
+
 	f.mtx.Lock()
 	defer f.mtx.Unlock()
 
@@ -81,5 +186,19 @@ func (f *InMemoryFnRegistry) Set(fnID string, fnObj Fn) error {
 	}
 
 	f.fnMap[fnID] = fnObj
+	f.fnOptsMap[fnID] = fnOpts
 	return nil
 }
+
+// Unset removes the Fn with the given ID from the registry, returns false if there's no such Fn.
+func (f *InMemoryFnRegistry) Unset(fnID string) bool {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	if _, exists := f.fnMap[fnID]; !exists {
+		return false
+	}
+	delete(f.fnMap, fnID)
+	delete(f.fnOptsMap, fnID)
+	return true
+}
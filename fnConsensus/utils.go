@@ -1,5 +1,7 @@
 package fnConsensus
 
+import cmn "github.com/tendermint/tendermint/libs/common"
+
 func safeCopyBytes(originalBytes []byte) []byte {
 	if originalBytes == nil {
 		return nil
@@ -23,3 +25,19 @@ func safeCopyDoubleArray(originalArray [][]byte) [][]byte {
 
 	return copiedArray
 }
+
+// Converts the given bit array to a bitmap where bit i (i%8-th least significant bit of byte i/8)
+// is set if the i-th element of the bit array is set.
+func signerBitmap(bitArray *cmn.BitArray) []byte {
+	if bitArray == nil {
+		return nil
+	}
+
+	bitmap := make([]byte, (bitArray.Size()+7)/8)
+	for i := 0; i < bitArray.Size(); i++ {
+		if bitArray.GetIndex(i) {
+			bitmap[i/8] |= 1 << uint(i%8)
+		}
+	}
+	return bitmap
+}
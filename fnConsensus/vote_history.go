@@ -0,0 +1,254 @@
+package fnConsensus
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tendermint/tendermint/crypto"
+	cmn "github.com/tendermint/tendermint/libs/common"
+	dbm "github.com/tendermint/tendermint/libs/db"
+)
+
+const (
+	// Vote history keys are composed of this prefix, followed by the time the vote set was completed
+	// (in seconds since Unix epoch), the fnID, and the nonce.
+	voteHistoryKeyPrefix = "fnConsensusReactor:votes:"
+	// Follows voteHistoryKeyPrefix in lexicographical order, used to bound iteration over the history.
+	voteHistoryKeyPrefixEnd = "fnConsensusReactor:votes;"
+
+	// Max number of vote records returned by a single history query.
+	maxVoteHistoryQueryLimit = 1000
+)
+
+var ErrVoteHistoryUnavailable = errors.New("vote history is only tracked by validators")
+
+// VoteOutcome indicates how a voting round ended.
+type VoteOutcome string
+
+const (
+	// The vote set reached the signing threshold.
+	VoteOutcomeCommitted VoteOutcome = "committed"
+	// The vote set was discarded before it reached the signing threshold.
+	VoteOutcomeExpired VoteOutcome = "expired"
+	// The vote set is still being voted on, such records are never persisted.
+	VoteOutcomePending VoteOutcome = "pending"
+)
+
+// VoteRecord summarizes a single voting round of an Fn.
+type VoteRecord struct {
+	FnID        string       `json:"fn_id"`
+	Nonce       int64        `json:"nonce"`
+	MessageHash cmn.HexBytes `json:"message_hash"`
+	// Addresses of the validators that signed the vote set.
+	Signers []crypto.Address `json:"signers"`
+	// Addresses of the validators that voted for the message hash, only set for committed votes.
+	Agreed  []crypto.Address `json:"agreed"`
+	Outcome VoteOutcome      `json:"outcome"`
+	// Unix timestamps (in seconds) of when this node started & stopped tracking the vote set,
+	// StartedAt will be zero if the vote set was started before the node was last restarted.
+	StartedAt   int64 `json:"started_at"`
+	CompletedAt int64 `json:"completed_at"`
+}
+
+// ResultFnVotes is returned by the fn_votes admin RPC endpoint.
+type ResultFnVotes struct {
+	Votes []*VoteRecord `json:"votes"`
+}
+
+// Creates a vote record from the given vote set, the message hash is taken from the given
+// aggregate response if it's not nil, otherwise the hash with the most votes is used.
+func newVoteRecord(
+	voteSet *FnVoteSet, majResponse *FnAggregateExecutionResponse, outcome VoteOutcome,
+	startedAt int64, completedAt int64,
+) *VoteRecord {
+	record := &VoteRecord{
+		FnID:        voteSet.GetFnID(),
+		Nonce:       voteSet.Nonce,
+		Outcome:     outcome,
+		StartedAt:   startedAt,
+		CompletedAt: completedAt,
+	}
+
+	for _, address := range voteSet.ActiveValidators() {
+		record.Signers = append(record.Signers, crypto.Address(address))
+	}
+
+	if majResponse != nil {
+		record.MessageHash = majResponse.Hash
+		for i := 0; i < majResponse.SignatureBitArray.Size(); i++ {
+			if majResponse.SignatureBitArray.GetIndex(i) && i < len(voteSet.ValidatorAddresses) {
+				record.Agreed = append(record.Agreed, crypto.Address(voteSet.ValidatorAddresses[i]))
+			}
+		}
+	} else if voteSet.Payload != nil && voteSet.Payload.Response != nil {
+		record.MessageHash = mostVotedHash(voteSet.Payload.Response.Hashes)
+	}
+	return record
+}
+
+// Returns the hash that occurs most frequently in the given list, ignoring nil hashes.
+func mostVotedHash(hashes [][]byte) []byte {
+	var result []byte
+	maxCount := 0
+	for i, hash := range hashes {
+		if hash == nil {
+			continue
+		}
+		count := 0
+		for _, other := range hashes[i:] {
+			if bytes.Equal(hash, other) {
+				count++
+			}
+		}
+		if count > maxCount {
+			maxCount = count
+			result = hash
+		}
+	}
+	return result
+}
+
+func voteHistoryKey(record *VoteRecord) []byte {
+	key := make([]byte, 0, len(voteHistoryKeyPrefix)+8+len(record.FnID)+1+8)
+	key = append(key, voteHistoryKeyPrefix...)
+	key = append(key, uint64ToBytes(uint64(record.CompletedAt))...)
+	key = append(key, record.FnID...)
+	key = append(key, ':')
+	key = append(key, uint64ToBytes(uint64(record.Nonce))...)
+	return key
+}
+
+func uint64ToBytes(n uint64) []byte {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, n)
+	return buf
+}
+
+func saveVoteRecord(db dbm.DB, record *VoteRecord) error {
+	marshalledBytes, err := cdc.MarshalBinaryLengthPrefixed(record)
+	if err != nil {
+		return err
+	}
+	db.Set(voteHistoryKey(record), marshalledBytes)
+	return nil
+}
+
+// Removes all the vote records that were completed before the given time.
+func pruneVoteHistory(db dbm.DB, before time.Time) {
+	end := make([]byte, 0, len(voteHistoryKeyPrefix)+8)
+	end = append(end, voteHistoryKeyPrefix...)
+	end = append(end, uint64ToBytes(uint64(before.Unix()))...)
+
+	iter := db.Iterator([]byte(voteHistoryKeyPrefix), end)
+	keys := [][]byte{}
+	for ; iter.Valid(); iter.Next() {
+		keys = append(keys, iter.Key())
+	}
+	iter.Close()
+
+	for _, key := range keys {
+		db.Delete(key)
+	}
+}
+
+// Loads the most recent vote records (newest first), optionally filtered by fnID.
+func loadVoteHistory(db dbm.DB, fnID string, limit int) ([]*VoteRecord, error) {
+	if limit <= 0 || limit > maxVoteHistoryQueryLimit {
+		limit = maxVoteHistoryQueryLimit
+	}
+
+	iter := db.ReverseIterator([]byte(voteHistoryKeyPrefix), []byte(voteHistoryKeyPrefixEnd))
+	defer iter.Close()
+
+	records := []*VoteRecord{}
+	for ; iter.Valid() && len(records) < limit; iter.Next() {
+		record := &VoteRecord{}
+		if err := cdc.UnmarshalBinaryLengthPrefixed(iter.Value(), record); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal vote record")
+		}
+		if fnID != "" && record.FnID != fnID {
+			continue
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func voteSetStartKey(fnID string, nonce int64) string {
+	return fmt.Sprintf("%s:%d", fnID, nonce)
+}
+
+// Records the time this node started voting on the given vote set, unless it was already recorded.
+// Must be called with f.stateMtx locked.
+func (f *FnConsensusReactor) trackVoteSetStart(voteSet *FnVoteSet) {
+	key := voteSetStartKey(voteSet.GetFnID(), voteSet.Nonce)
+	if _, ok := f.voteSetStartTimes[key]; !ok {
+		f.voteSetStartTimes[key] = time.Now().Unix()
+	}
+}
+
+// Persists the outcome of the given vote set to the vote history, and prunes any records that have
+// outlived the retention period. Must be called with f.stateMtx locked.
+func (f *FnConsensusReactor) recordVoteSet(
+	voteSet *FnVoteSet, majResponse *FnAggregateExecutionResponse, outcome VoteOutcome,
+) {
+	key := voteSetStartKey(voteSet.GetFnID(), voteSet.Nonce)
+	startedAt := f.voteSetStartTimes[key]
+	delete(f.voteSetStartTimes, key)
+
+	if f.cfg.VoteHistoryRetention == 0 {
+		return
+	}
+
+	now := time.Now()
+	record := newVoteRecord(voteSet, majResponse, outcome, startedAt, now.Unix())
+	if err := saveVoteRecord(f.db, record); err != nil {
+		f.Logger.Error(
+			"FnConsensusReactor: unable to save vote record",
+			"fnID", record.FnID, "nonce", record.Nonce, "err", err,
+		)
+		return
+	}
+	pruneVoteHistory(f.db, now.Add(-f.cfg.VoteHistoryRetention))
+}
+
+// VoteHistory returns the vote sets that are currently being voted on, followed by the most recent
+// completed & expired vote sets (newest first). If fnID is not empty only the vote sets of the
+// corresponding Fn will be returned. The limit only applies to completed & expired vote sets.
+func (f *FnConsensusReactor) VoteHistory(fnID string, limit int) (*ResultFnVotes, error) {
+	if !f.cfg.IsValidator {
+		return nil, ErrVoteHistoryUnavailable
+	}
+
+	f.stateMtx.Lock()
+	defer f.stateMtx.Unlock()
+
+	if f.state == nil {
+		return nil, ErrVoteHistoryUnavailable
+	}
+
+	fnIDs := make([]string, 0, len(f.state.CurrentVoteSets))
+	for currentFnID := range f.state.CurrentVoteSets {
+		if fnID == "" || fnID == currentFnID {
+			fnIDs = append(fnIDs, currentFnID)
+		}
+	}
+	sort.Strings(fnIDs)
+
+	votes := make([]*VoteRecord, 0, len(fnIDs))
+	for _, currentFnID := range fnIDs {
+		voteSet := f.state.CurrentVoteSets[currentFnID]
+		startedAt := f.voteSetStartTimes[voteSetStartKey(currentFnID, voteSet.Nonce)]
+		votes = append(votes, newVoteRecord(voteSet, nil, VoteOutcomePending, startedAt, 0))
+	}
+
+	history, err := loadVoteHistory(f.db, fnID, limit)
+	if err != nil {
+		return nil, err
+	}
+	return &ResultFnVotes{Votes: append(votes, history...)}, nil
+}
@@ -0,0 +1,278 @@
+package gasmeter
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/loomnetwork/loomchain/store"
+	"github.com/pkg/errors"
+)
+
+const (
+	// SettingPrefix is the prefix of all the gas meter settings that can be changed via the
+	// ChainConfig contract, e.g. GasMeter.TxGasLimit
+	SettingPrefix = "GasMeter."
+
+	configKey = "gasmeter:config"
+)
+
+// ErrOutOfGas is returned when a tx consumes more gas than the tx gas limit allows.
+var ErrOutOfGas = errors.New("[GasMeter] out of gas")
+
+type Config = GasMeterConfig
+
+// DefaultConfig returns the gas meter config that's used until it's changed via the ChainConfig
+// contract.
+func DefaultConfig() *Config {
+	return &Config{
+		TxGasLimit:     10000000,
+		ReadCost:       200,
+		ReadByteCost:   3,
+		WriteCost:      2000,
+		WriteByteCost:  30,
+		DeleteCost:     1000,
+		RangeCost:      500,
+		RangeEntryCost: 200,
+		EventCost:      400,
+		EventByteCost:  8,
+		CallCost:       700,
+
+		WasmInstructionCost: 1,
+		WasmMemoryPageCost:  2000,
+	}
+}
+
+// LoadConfig loads the gas meter config from the given kv store.
+func LoadConfig(kvStore store.KVReader) (*Config, error) {
+	cfg := DefaultConfig()
+	cfgBytes := kvStore.Get([]byte(configKey))
+	if len(cfgBytes) > 0 {
+		if err := proto.UnmarshalMerge(cfgBytes, cfg); err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
+
+// SaveConfig saves the gas meter config to the given kv store.
+func SaveConfig(kvStore store.KVWriter, cfg *Config) error {
+	cfgBytes, err := proto.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	kvStore.Set([]byte(configKey), cfgBytes)
+	return nil
+}
+
+// IsSetting returns true if the given config setting name refers to a gas meter setting.
+func IsSetting(name string) bool {
+	return strings.HasPrefix(name, SettingPrefix)
+}
+
+func settingFields(cfg *Config) map[string]*uint64 {
+	return map[string]*uint64{
+		"TxGasLimit":     &cfg.TxGasLimit,
+		"ReadCost":       &cfg.ReadCost,
+		"ReadByteCost":   &cfg.ReadByteCost,
+		"WriteCost":      &cfg.WriteCost,
+		"WriteByteCost":  &cfg.WriteByteCost,
+		"DeleteCost":     &cfg.DeleteCost,
+		"RangeCost":      &cfg.RangeCost,
+		"RangeEntryCost": &cfg.RangeEntryCost,
+		"EventCost":      &cfg.EventCost,
+		"EventByteCost":  &cfg.EventByteCost,
+		"CallCost":       &cfg.CallCost,
+
+		"WasmInstructionCost": &cfg.WasmInstructionCost,
+		"WasmMemoryPageCost":  &cfg.WasmMemoryPageCost,
+	}
+}
+
+// SetConfigSetting updates a gas meter config setting, the name of the setting must include
+// the SettingPrefix.
+func SetConfigSetting(cfg *Config, name, value string) error {
+	field, ok := settingFields(cfg)[strings.TrimPrefix(name, SettingPrefix)]
+	if !ok {
+		return fmt.Errorf("unknown gas meter setting %s", name)
+	}
+	v, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid value for %s: %s", name, value)
+	}
+	// The gas used by a tx is reported to Tendermint as an int64
+	if name == SettingPrefix+"TxGasLimit" && v > math.MaxInt64 {
+		return fmt.Errorf("invalid value for %s: %s", name, value)
+	}
+	// Wasm contracts must consume gas as they execute, otherwise they could loop forever
+	if (name == SettingPrefix+"TxGasLimit" || name == SettingPrefix+"WasmInstructionCost") && v == 0 {
+		return fmt.Errorf("invalid value for %s: %s", name, value)
+	}
+	*field = v
+	return nil
+}
+
+// ChangeConfigSetting updates the value of the given gas meter setting in the kv store.
+func ChangeConfigSetting(kvStore store.KVStore, name, value string) error {
+	cfg, err := LoadConfig(kvStore)
+	if err != nil {
+		return err
+	}
+	if err := SetConfigSetting(cfg, name, value); err != nil {
+		return err
+	}
+	return SaveConfig(kvStore, cfg)
+}
+
+// GasMeter tracks the gas consumed by the Go contracts executed by a single tx.
+//
+// Go contracts are only charged for the operations they perform via the contract context (KV store
+// access, events, and calls to other contracts), the CPU time they spend between those operations
+// isn't metered, so the tx gas limit doesn't bound the execution time of a Go contract. Wasm
+// contracts are also charged for each instruction they execute.
+//
+// There's no execution deadline for Go contracts either, a wall clock deadline would expire at a
+// different point on each node, so nodes would disagree on the outcome of the tx. A Go contract
+// stuck in a loop that doesn't touch the contract context will never run out of gas, and will stall
+// every node that executes it. Go contracts are compiled into the node or loaded from plugin
+// binaries whose hashes are pinned on-chain, so this is only guarded against by reviewing the code
+// of every Go contract that's deployed.
+//
+// The KV store & event interfaces Go contracts use don't return errors, so when a tx runs out of
+// gas the meter panics with ErrOutOfGas, the panic must be recovered with RecoverOutOfGas by the
+// code that invoked the contract. Once a meter runs out of gas all subsequent attempts to consume
+// gas will panic, so a contract can't keep going by ignoring the error returned by a nested call.
+//
+// External plugins access the context via gRPC, so a meter may be used by multiple goroutines.
+type GasMeter struct {
+	cfg      *Config
+	mutex    sync.Mutex
+	used     uint64
+	outOfGas bool
+}
+
+// NewGasMeter creates a gas meter that uses the costs & tx gas limit from the given config.
+func NewGasMeter(cfg *Config) *GasMeter {
+	return &GasMeter{cfg: cfg}
+}
+
+// Limit returns the max amount of gas the tx can consume.
+func (m *GasMeter) Limit() uint64 {
+	return m.cfg.TxGasLimit
+}
+
+// GasUsed returns the amount of gas consumed so far, a tx that ran out of gas consumes the whole
+// gas limit.
+func (m *GasMeter) GasUsed() uint64 {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.used
+}
+
+// IsOutOfGas returns true if the tx has exceeded the gas limit.
+func (m *GasMeter) IsOutOfGas() bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.outOfGas
+}
+
+// ConsumeGas adds the given amount to the gas used by the tx, panics with ErrOutOfGas if the tx
+// gas limit is exceeded.
+func (m *GasMeter) ConsumeGas(amount uint64) {
+	if !m.consumeGas(amount) {
+		panic(ErrOutOfGas)
+	}
+}
+
+func (m *GasMeter) consumeGas(amount uint64) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.outOfGas {
+		return false
+	}
+	if amount > m.cfg.TxGasLimit-m.used {
+		m.used = m.cfg.TxGasLimit
+		m.outOfGas = true
+		return false
+	}
+	m.used += amount
+	return true
+}
+
+// ConsumeRead consumes the gas required to read a value of the given size.
+func (m *GasMeter) ConsumeRead(valueLen int) {
+	m.ConsumeGas(m.cfg.ReadCost + m.cfg.ReadByteCost*uint64(valueLen))
+}
+
+// ConsumeWrite consumes the gas required to write a key & value of the given size.
+func (m *GasMeter) ConsumeWrite(keyLen, valueLen int) {
+	m.ConsumeGas(m.cfg.WriteCost + m.cfg.WriteByteCost*uint64(keyLen+valueLen))
+}
+
+// ConsumeDelete consumes the gas required to delete a key.
+func (m *GasMeter) ConsumeDelete() {
+	m.ConsumeGas(m.cfg.DeleteCost)
+}
+
+// ConsumeRange consumes the gas required to iterate over the given number of entries.
+func (m *GasMeter) ConsumeRange(numEntries int) {
+	m.ConsumeGas(m.cfg.RangeCost + m.cfg.RangeEntryCost*uint64(numEntries))
+}
+
+// ConsumeRangeEntry consumes the gas required to read a single entry of the given size (key &
+// value) while iterating over a range, the base cost of the range is charged by ConsumeRange.
+func (m *GasMeter) ConsumeRangeEntry(entryLen int) {
+	m.ConsumeGas(m.cfg.RangeEntryCost + m.cfg.ReadByteCost*uint64(entryLen))
+}
+
+// ConsumeEvent consumes the gas required to emit an event with a body of the given size.
+func (m *GasMeter) ConsumeEvent(bodyLen int) {
+	m.ConsumeGas(m.cfg.EventCost + m.cfg.EventByteCost*uint64(bodyLen))
+}
+
+// ConsumeCall consumes the gas required to call another contract.
+func (m *GasMeter) ConsumeCall() {
+	m.ConsumeGas(m.cfg.CallCost)
+}
+
+// ConsumeInstructions consumes the gas required to execute the given number of Wasm instructions.
+func (m *GasMeter) ConsumeInstructions(n int) {
+	m.ConsumeGas(m.cfg.WasmInstructionCost * uint64(n))
+}
+
+// ConsumeMemoryPages consumes the gas required to allocate the given number of pages of Wasm
+// linear memory.
+func (m *GasMeter) ConsumeMemoryPages(n int) {
+	m.ConsumeGas(m.cfg.WasmMemoryPageCost * uint64(n))
+}
+
+// RecoverOutOfGas must be deferred by code that executes metered contracts, if the contract ran
+// out of gas the panic is recovered and ErrOutOfGas is stored in the given error. Any other panic
+// is re-raised.
+func RecoverOutOfGas(err *error) {
+	if r := recover(); r != nil {
+		if r != ErrOutOfGas {
+			panic(r)
+		}
+		*err = ErrOutOfGas
+	}
+}
+
+type contextKey struct{}
+
+// WithGasMeter returns a copy of the given context that carries the given gas meter.
+func WithGasMeter(ctx context.Context, meter *GasMeter) context.Context {
+	return context.WithValue(ctx, contextKey{}, meter)
+}
+
+// FromContext returns the gas meter carried by the given context, or nil if the context doesn't
+// carry one.
+func FromContext(ctx context.Context) *GasMeter {
+	meter, _ := ctx.Value(contextKey{}).(*GasMeter)
+	return meter
+}
@@ -0,0 +1,57 @@
+package gasmeter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/loomnetwork/loomchain/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangeConfigSetting(t *testing.T) {
+	kvStore := store.NewMemStore()
+	require.True(t, IsSetting("GasMeter.TxGasLimit"))
+	require.False(t, IsSetting("FeeMarket.BurnPercentage"))
+
+	require.NoError(t, ChangeConfigSetting(kvStore, "GasMeter.TxGasLimit", "5000"))
+	require.NoError(t, ChangeConfigSetting(kvStore, "GasMeter.WriteCost", "100"))
+	require.Error(t, ChangeConfigSetting(kvStore, "GasMeter.TxGasLimit", "0"))
+	require.Error(t, ChangeConfigSetting(kvStore, "GasMeter.TxGasLimit", "9223372036854775808"))
+	require.Error(t, ChangeConfigSetting(kvStore, "GasMeter.WasmInstructionCost", "0"))
+	require.Error(t, ChangeConfigSetting(kvStore, "GasMeter.ReadCost", "-1"))
+	require.Error(t, ChangeConfigSetting(kvStore, "GasMeter.Unknown", "1"))
+
+	cfg, err := LoadConfig(kvStore)
+	require.NoError(t, err)
+	require.Equal(t, uint64(5000), cfg.TxGasLimit)
+	require.Equal(t, uint64(100), cfg.WriteCost)
+	require.Equal(t, DefaultConfig().ReadCost, cfg.ReadCost)
+}
+
+func TestGasMeter(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.TxGasLimit = 1000
+	cfg.ReadCost = 100
+	cfg.ReadByteCost = 10
+	meter := NewGasMeter(cfg)
+
+	meter.ConsumeRead(5)
+	require.Equal(t, uint64(150), meter.GasUsed())
+	meter.ConsumeGas(850)
+	require.Equal(t, uint64(1000), meter.GasUsed())
+	require.False(t, meter.IsOutOfGas())
+
+	consume := func(amount uint64) (err error) {
+		defer RecoverOutOfGas(&err)
+		meter.ConsumeGas(amount)
+		return nil
+	}
+	require.Equal(t, ErrOutOfGas, consume(1))
+	require.True(t, meter.IsOutOfGas())
+	require.Equal(t, uint64(1000), meter.GasUsed())
+	// once out of gas the meter should refuse to consume any more gas
+	require.Equal(t, ErrOutOfGas, consume(0))
+
+	require.Nil(t, FromContext(context.Background()))
+	require.Equal(t, meter, FromContext(WithGasMeter(context.Background(), meter)))
+}
@@ -0,0 +1,10 @@
+// +build !evm
+
+package migrations
+
+import "github.com/pkg/errors"
+
+// AddressMapperRecoveryAdminMigration is a placeholder used in non-evm builds.
+func AddressMapperRecoveryAdminMigration(ctx *MigrationContext, parameters []byte) error {
+	return errors.New("This is not implemented")
+}
@@ -0,0 +1,28 @@
+// +build evm
+
+package migrations
+
+import (
+	"bytes"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/loomnetwork/loomchain/builtin/plugins/address_mapper"
+	"github.com/pkg/errors"
+)
+
+// AddressMapperRecoveryAdminMigration sets the recovery admin of the Address Mapper contract, the
+// parameters are a JSON encoded AddressMapperSetRecoveryAdminRequest, e.g.
+// {"recoveryAdmin":{"chainId":"default","local":"..."}}
+func AddressMapperRecoveryAdminMigration(ctx *MigrationContext, parameters []byte) error {
+	req := address_mapper.SetRecoveryAdminRequest{}
+	if err := jsonpb.Unmarshal(bytes.NewBuffer(parameters), &req); err != nil {
+		return errors.Wrap(err, "failed to unmarshal migration parameters")
+	}
+
+	amCtx, err := ctx.ContractContext("addressmapper")
+	if err != nil {
+		return err
+	}
+
+	return address_mapper.ResetRecoveryAdmin(amCtx, &req)
+}
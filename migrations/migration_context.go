@@ -66,6 +66,15 @@ func (mc *MigrationContext) DeployContract(contractCfg *genesiscfg.ContractConfi
 	if err != nil {
 		return loom.Address{}, err
 	}
+	pluginHash, err := contractCfg.PluginHash()
+	if err != nil {
+		return loom.Address{}, err
+	}
+	if pluginHash != nil {
+		if err := plugin.SetPluginHash(mc.state, contractCfg.Location, pluginHash); err != nil {
+			return loom.Address{}, err
+		}
+	}
 	_, addr, err := vm.Create(mc.caller, initCode, loom.NewBigUIntFromInt(0))
 	if err != nil {
 		return loom.Address{}, err
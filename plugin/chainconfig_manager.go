@@ -5,6 +5,7 @@ import (
 
 	"github.com/loomnetwork/go-loom"
 	contract "github.com/loomnetwork/go-loom/plugin/contractpb"
+	"github.com/loomnetwork/go-loom/util"
 	"github.com/loomnetwork/loomchain"
 	"github.com/loomnetwork/loomchain/builtin/plugins/chainconfig"
 	"github.com/loomnetwork/loomchain/features"
@@ -17,11 +18,65 @@ var (
 	ErrChainConfigContractNotFound = errors.New("[ChainConfigManager] ChainContract contract not found")
 )
 
+// Prefix of the app state keys that map the name & version of a plugin to the version of the
+// plugin binary that should be loaded in its place.
+var pluginVersionPrefix = []byte("plugin-version")
+
+func pluginVersionKey(pluginName string) []byte {
+	return util.PrefixKey(pluginVersionPrefix, []byte(pluginName))
+}
+
+// upgradedPluginName returns the name & version of the plugin that should be loaded in place of
+// the given plugin, which will only differ from the given plugin if an upgrade has been applied.
+// When upgrades are chained the stored version is always the final one (see applyPluginUpgrade),
+// so a single lookup is sufficient.
+func upgradedPluginName(state loomchain.State, pluginName string) string {
+	version := state.Get(pluginVersionKey(pluginName))
+	if len(version) == 0 {
+		return pluginName
+	}
+	return withPluginVersion(pluginName, string(version))
+}
+
+// Replaces the version in the given plugin name, e.g. "mycontract:1.0.0" -> "mycontract:1.1.0".
+func withPluginVersion(pluginName string, version string) string {
+	meta, err := ParseMeta(pluginName)
+	if err != nil {
+		return pluginName
+	}
+	return meta.Name + ":" + version
+}
+
+// setPluginVersion records the version of the plugin binary that should be loaded in place of the
+// given plugin, the record is removed if the plugin has been upgraded back to its own version.
+func setPluginVersion(state loomchain.State, pluginName string, version string) {
+	if withPluginVersion(pluginName, version) == pluginName {
+		state.Delete(pluginVersionKey(pluginName))
+		return
+	}
+	state.Set(pluginVersionKey(pluginName), []byte(version))
+}
+
+// applyPluginUpgrade switches the given plugin to a new version. Any plugins that were previously
+// upgraded to the given plugin are switched to the new version as well, so that contracts deployed
+// with an older version follow the whole chain of upgrades.
+func applyPluginUpgrade(state loomchain.State, pluginName string, version string) {
+	for _, entry := range state.Range(pluginVersionPrefix) {
+		if withPluginVersion(string(entry.Key), string(entry.Value)) == pluginName {
+			setPluginVersion(state, string(entry.Key), version)
+		}
+	}
+	setPluginVersion(state, pluginName, version)
+}
+
 // ChainConfigManager implements loomchain.ChainConfigManager interface
 type ChainConfigManager struct {
 	ctx   contract.Context
 	state loomchain.State
 	build uint64
+	// Used to stop the processes of plugins that are no longer in use after an upgrade, nil if the
+	// loader doesn't spawn plugin processes.
+	unloader PluginUnloader
 }
 
 // NewChainConfigManager attempts to create an instance of ChainConfigManager.
@@ -40,10 +95,12 @@ func NewChainConfigManager(pvm *PluginVM, state loomchain.State) (*ChainConfigMa
 	if err != nil {
 		build = 0
 	}
+	unloader, _ := pvm.Loader.(PluginUnloader)
 	return &ChainConfigManager{
-		ctx:   ctx,
-		state: state,
-		build: build,
+		ctx:      ctx,
+		state:    state,
+		build:    build,
+		unloader: unloader,
 	}, nil
 }
 
@@ -101,3 +158,35 @@ func (c *ChainConfigManager) UpdateConfig() (int, error) {
 	}
 	return len(settings), nil
 }
+
+// ApplyPluginUpgrades switches the plugins that have upgrades scheduled at (or before) the given
+// block height to their new versions, and stops the processes of the plugins that were replaced.
+func (c *ChainConfigManager) ApplyPluginUpgrades(blockHeight int64) error {
+	if !c.state.FeatureEnabled(features.ChainCfgVersion1_8, false) {
+		return nil
+	}
+
+	upgrades, err := chainconfig.HarvestPluginUpgrades(c.ctx, uint64(blockHeight))
+	if err != nil {
+		return err
+	}
+
+	for _, upgrade := range upgrades {
+		if len(upgrade.PluginSha256) > 0 {
+			newPluginName := withPluginVersion(upgrade.PluginName, upgrade.Version)
+			if err := SetPluginHash(c.state, newPluginName, upgrade.PluginSha256); err != nil {
+				return err
+			}
+		}
+		applyPluginUpgrade(c.state, upgrade.PluginName, upgrade.Version)
+		c.ctx.Logger().Info(
+			"applied plugin upgrade", "plugin", upgrade.PluginName, "version", upgrade.Version,
+		)
+		// No contract loads the replaced plugin anymore, if it's needed again (e.g. the upgrade is
+		// reverted) the loader will spawn a new process for it.
+		if c.unloader != nil {
+			c.unloader.UnloadPlugin(upgrade.PluginName)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package plugin
+
+import (
+	"context"
+	"testing"
+
+	"github.com/loomnetwork/loomchain"
+	"github.com/loomnetwork/loomchain/store"
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/tendermint/abci/types"
+)
+
+func TestApplyPluginUpgrade(t *testing.T) {
+	state := loomchain.NewStoreState(context.Background(), store.NewMemStore(), abci.Header{}, nil, nil)
+
+	applyPluginUpgrade(state, "mycontract:1.0.0", "1.1.0")
+	require.Equal(t, "mycontract:1.1.0", upgradedPluginName(state, "mycontract:1.0.0"))
+	require.Equal(t, "mycontract:1.1.0", upgradedPluginName(state, "mycontract:1.1.0"))
+
+	// contracts deployed with the original version should follow the whole chain of upgrades
+	applyPluginUpgrade(state, "mycontract:1.1.0", "1.2.0")
+	require.Equal(t, "mycontract:1.2.0", upgradedPluginName(state, "mycontract:1.0.0"))
+	require.Equal(t, "mycontract:1.2.0", upgradedPluginName(state, "mycontract:1.1.0"))
+	require.Equal(t, "othercontract:1.0.0", upgradedPluginName(state, "othercontract:1.0.0"))
+
+	// reverting to an earlier version shouldn't create a cycle
+	applyPluginUpgrade(state, "mycontract:1.2.0", "1.0.0")
+	require.Equal(t, "mycontract:1.0.0", upgradedPluginName(state, "mycontract:1.0.0"))
+	require.Equal(t, "mycontract:1.0.0", upgradedPluginName(state, "mycontract:1.1.0"))
+	require.Equal(t, "mycontract:1.0.0", upgradedPluginName(state, "mycontract:1.2.0"))
+	require.False(t, state.Has(pluginVersionKey("mycontract:1.0.0")))
+}
@@ -1,22 +1,31 @@
 package plugin
 
 import (
+	"bytes"
 	"context"
-	"errors"
+	"encoding/hex"
 	"io/ioutil"
 	"os"
 	"os/exec"
 	"path"
 	"regexp"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/go-kit/kit/metrics"
+	kitprometheus "github.com/go-kit/kit/metrics/prometheus"
 	extplugin "github.com/hashicorp/go-plugin"
+	"github.com/pkg/errors"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
 	"google.golang.org/grpc"
 
 	loom "github.com/loomnetwork/go-loom"
 	"github.com/loomnetwork/go-loom/plugin"
 	"github.com/loomnetwork/go-loom/plugin/types"
+	"github.com/loomnetwork/loomchain/gasmeter"
+	"github.com/loomnetwork/loomchain/log"
 	"github.com/loomnetwork/loomchain/vm"
 )
 
@@ -95,18 +104,169 @@ func loadExternal(path string) *extplugin.Client {
 	return extplugin.NewClient(cfg)
 }
 
+const (
+	// Delay before the second restart attempt of a plugin process that keeps exiting, the delay is
+	// doubled for each subsequent attempt (the first attempt is made immediately).
+	minPluginRestartDelay = 1 * time.Second
+	maxPluginRestartDelay = 1 * time.Minute
+	// Plugin processes that stay up at least this long are considered stable, so if they exit the
+	// restart backoff starts from scratch.
+	pluginStableUptime = 5 * time.Minute
+)
+
+var (
+	// ErrPluginRestarting is returned while a plugin process that exited is waiting to be restarted.
+	ErrPluginRestarting = errors.New("plugin process exited, waiting to restart")
+
+	pluginRunningGauge metrics.Gauge
+	pluginExitCount    metrics.Counter
+	pluginRestartCount metrics.Counter
+)
+
+func init() {
+	pluginRunningGauge = kitprometheus.NewGaugeFrom(
+		stdprometheus.GaugeOpts{
+			Namespace: "loomchain",
+			Subsystem: "external_plugin",
+			Name:      "running",
+			Help:      "Indicates whether the plugin process is running (1) or not (0)",
+		}, []string{"plugin"},
+	)
+	pluginExitCount = kitprometheus.NewCounterFrom(
+		stdprometheus.CounterOpts{
+			Namespace: "loomchain",
+			Subsystem: "external_plugin",
+			Name:      "exit_count",
+			Help:      "Number of times the plugin process exited unexpectedly",
+		}, []string{"plugin"},
+	)
+	pluginRestartCount = kitprometheus.NewCounterFrom(
+		stdprometheus.CounterOpts{
+			Namespace: "loomchain",
+			Subsystem: "external_plugin",
+			Name:      "restart_count",
+			Help:      "Number of times the plugin process was restarted",
+		}, []string{"plugin"},
+	)
+}
+
+// PluginHealth describes the state of an external plugin process.
+type PluginHealth struct {
+	// Name & version of the plugin, e.g. "mycontract:1.0.0"
+	Name string `json:"name"`
+	Path string `json:"path"`
+	// Hex encoded SHA-256 hash of the binary the process was spawned from
+	SHA256   string `json:"sha256"`
+	Running  bool   `json:"running"`
+	Restarts int    `json:"restarts"`
+	// Unix timestamps (in seconds), zero if the corresponding event hasn't occurred yet
+	StartedAt     int64 `json:"started_at"`
+	LastExitAt    int64 `json:"last_exit_at"`
+	NextRestartAt int64 `json:"next_restart_at"`
+}
+
+// ResultPluginHealth is returned by the plugin_health admin RPC endpoint.
+type ResultPluginHealth struct {
+	Plugins []*PluginHealth `json:"plugins"`
+}
+
+// pluginProcess tracks the process spawned for an external plugin.
+type pluginProcess struct {
+	name   string
+	path   string
+	client *extplugin.Client
+	// Hash of the binary the process was spawned from
+	sha256 []byte
+	// Hash the binary must match in order for the process to be restarted, nil if the process can be
+	// restarted from any binary.
+	expectedSHA256 []byte
+	startedAt      time.Time
+	restarts       int
+	// Number of times the process exited without staying up long enough to be considered stable
+	failures      int
+	exitRecorded  bool
+	lastExitAt    time.Time
+	nextRestartAt time.Time
+}
+
+// Returns the delay before the restart attempt that follows the given number of consecutive failures.
+func pluginRestartDelay(failures int) time.Duration {
+	if failures <= 1 {
+		return 0
+	}
+	delay := minPluginRestartDelay
+	for i := 2; i < failures && delay < maxPluginRestartDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxPluginRestartDelay {
+		delay = maxPluginRestartDelay
+	}
+	return delay
+}
+
+// Records that the process was found to have exited at the given time, unless the exit has
+// already been recorded.
+func (p *pluginProcess) recordExit(now time.Time) {
+	if p.exitRecorded {
+		return
+	}
+	p.exitRecorded = true
+	if now.Sub(p.startedAt) >= pluginStableUptime {
+		p.failures = 0
+	}
+	p.failures++
+	p.lastExitAt = now
+	p.nextRestartAt = now.Add(pluginRestartDelay(p.failures))
+	pluginExitCount.With("plugin", p.name).Add(1)
+	pluginRunningGauge.With("plugin", p.name).Set(0)
+}
+
+func (p *pluginProcess) start(client *extplugin.Client, now time.Time) {
+	p.client = client
+	p.startedAt = now
+	p.exitRecorded = false
+	pluginRunningGauge.With("plugin", p.name).Set(1)
+}
+
+func (p *pluginProcess) health() *PluginHealth {
+	h := &PluginHealth{
+		Name:     p.name,
+		Path:     p.path,
+		SHA256:   hex.EncodeToString(p.sha256),
+		Running:  !p.client.Exited(),
+		Restarts: p.restarts,
+	}
+	if !p.startedAt.IsZero() {
+		h.StartedAt = p.startedAt.Unix()
+	}
+	if !p.lastExitAt.IsZero() {
+		h.LastExitAt = p.lastExitAt.Unix()
+	}
+	if !h.Running && !p.nextRestartAt.IsZero() {
+		h.NextRestartAt = p.nextRestartAt.Unix()
+	}
+	return h
+}
+
+// ExternalLoader loads contracts from plugin binaries in the plugins dir, each plugin binary is
+// spawned as a separate process the first time a contract that uses it is loaded. If a plugin
+// process exits it's restarted the next time a contract that uses it is loaded, with an
+// exponential backoff between repeated restarts. If a plugin has an expected hash the loader will
+// refuse to spawn a process from a plugin binary that doesn't match the hash.
 type ExternalLoader struct {
-	Dir     string
-	clients map[string]*extplugin.Client
-	mu      sync.Mutex
+	Dir       string
+	processes map[string]*pluginProcess
+	mu        sync.Mutex
 }
 
 var _ Loader = &ExternalLoader{}
+var _ HashVerifyingLoader = &ExternalLoader{}
+var _ PluginUnloader = &ExternalLoader{}
 
 func NewExternalLoader(dir string) *ExternalLoader {
 	return &ExternalLoader{
-		Dir:     dir,
-		clients: make(map[string]*extplugin.Client),
+		Dir:       dir,
+		processes: make(map[string]*pluginProcess),
 	}
 }
 
@@ -115,20 +275,46 @@ func (l *ExternalLoader) UnloadContracts() { l.Kill() }
 func (l *ExternalLoader) Kill() {
 	var wg sync.WaitGroup
 	l.mu.Lock()
-	for _, client := range l.clients {
+	for _, process := range l.processes {
 		wg.Add(1)
 
 		go func(client *extplugin.Client) {
 			client.Kill()
 			wg.Done()
-		}(client)
+		}(process.client)
 	}
 	l.mu.Unlock()
 	wg.Wait()
 }
 
+// UnloadPlugin kills the process spawned for the named plugin, if there is one. The process is
+// killed in the background since it may take a while to exit, and it's forgotten immediately so a
+// new process will be spawned if the plugin is loaded again.
+func (l *ExternalLoader) UnloadPlugin(name string) {
+	l.mu.Lock()
+	process := l.processes[name]
+	delete(l.processes, name)
+	l.mu.Unlock()
+
+	if process == nil {
+		return
+	}
+	pluginRunningGauge.With("plugin", name).Set(0)
+	log.Info("Stopping external plugin", "plugin", name)
+	go process.client.Kill()
+}
+
 func (l *ExternalLoader) LoadContract(name string, blockHeight int64) (plugin.Contract, error) {
-	client, err := l.loadClient(name)
+	return l.loadContract(name, nil)
+}
+
+// LoadVerifiedContract loads the named contract from a plugin binary that matches the given hash.
+func (l *ExternalLoader) LoadVerifiedContract(name string, blockHeight int64, sha256 []byte) (plugin.Contract, error) {
+	return l.loadContract(name, sha256)
+}
+
+func (l *ExternalLoader) loadContract(name string, expectedHash []byte) (plugin.Contract, error) {
+	client, err := l.loadClient(name, expectedHash)
 	if err != nil {
 		return nil, err
 	}
@@ -141,33 +327,131 @@ func (l *ExternalLoader) LoadContract(name string, blockHeight int64) (plugin.Co
 	return fetchContract(rpcClient)
 }
 
-func (l *ExternalLoader) loadClient(name string) (*extplugin.Client, error) {
+// PluginHealth returns the state of all the plugin processes spawned by the loader, sorted by name.
+func (l *ExternalLoader) PluginHealth() (*ResultPluginHealth, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	plugins := make([]*PluginHealth, 0, len(l.processes))
+	for _, process := range l.processes {
+		plugins = append(plugins, process.health())
+	}
+	sort.Slice(plugins, func(i, j int) bool {
+		return plugins[i].Name < plugins[j].Name
+	})
+	return &ResultPluginHealth{Plugins: plugins}, nil
+}
+
+func (l *ExternalLoader) loadClient(name string, expectedHash []byte) (*extplugin.Client, error) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	var err error
-	client := l.clients[name]
-	if client == nil {
-		client, err = l.loadClientFull(name)
+	now := time.Now()
+	process := l.processes[name]
+	if process == nil {
+		path, err := l.findPlugin(name)
+		if err != nil {
+			return nil, err
+		}
+		hash, err := verifyPluginBinary(name, path, expectedHash)
 		if err != nil {
 			return nil, err
 		}
 
-		l.clients[name] = client
+		process = &pluginProcess{name: name, path: path, sha256: hash, expectedSHA256: expectedHash}
+		process.start(loadExternal(path), now)
+		l.processes[name] = process
+		return process.client, nil
 	}
 
-	return client, nil
+	if expectedHash != nil {
+		// The process may have been spawned before the hash was known
+		if !bytes.Equal(process.sha256, expectedHash) {
+			pluginHashMismatchCount.With("plugin", name).Add(1)
+			return nil, errors.Wrapf(
+				ErrPluginHashMismatch, "plugin %s was started from %s, expected hash %x, got %x",
+				name, process.path, expectedHash, process.sha256,
+			)
+		}
+		process.expectedSHA256 = expectedHash
+	}
+
+	if err := l.ensureRunning(process, now); err != nil {
+		return nil, err
+	}
+	return process.client, nil
 }
 
-func (l *ExternalLoader) loadClientFull(name string) (*extplugin.Client, error) {
+// Restarts the given plugin process if it has exited, unless it's still waiting for the restart
+// backoff to elapse. Must be called with l.mu locked.
+func (l *ExternalLoader) ensureRunning(process *pluginProcess, now time.Time) error {
+	if !process.client.Exited() {
+		return nil
+	}
+
+	process.recordExit(now)
+	if now.Before(process.nextRestartAt) {
+		return errors.Wrapf(
+			ErrPluginRestarting, "%s will be restarted in %v", process.name, process.nextRestartAt.Sub(now),
+		)
+	}
+
+	// The binary may have been replaced since the process was first spawned
+	hash, err := verifyPluginBinary(process.name, process.path, process.expectedSHA256)
+	if err != nil {
+		process.failures++
+		process.nextRestartAt = now.Add(pluginRestartDelay(process.failures))
+		log.Error("Failed to restart external plugin", "plugin", process.name, "err", err)
+		return err
+	}
+
+	process.client.Kill()
+	process.restarts++
+	process.sha256 = hash
+	process.start(loadExternal(process.path), now)
+	pluginRestartCount.With("plugin", process.name).Add(1)
+	log.Info("Restarted external plugin", "plugin", process.name, "restarts", process.restarts)
+
+	// Spawn the process right away, rather than waiting for the next contract call.
+	if _, err := process.client.Client(); err != nil {
+		log.Error("Failed to restart external plugin", "plugin", process.name, "err", err)
+	}
+	return nil
+}
+
+// Supervise periodically checks the plugin processes spawned by the loader, and restarts any that
+// have exited, so that they're ready by the time a contract that uses them is called again.
+// This function blocks until the quit channel is closed.
+func (l *ExternalLoader) Supervise(interval time.Duration, quit <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-quit:
+			return
+		case <-ticker.C:
+			l.mu.Lock()
+			now := time.Now()
+			for _, process := range l.processes {
+				// Processes that are still waiting to be restarted will be retried on the next tick
+				_ = l.ensureRunning(process, now)
+			}
+			l.mu.Unlock()
+		}
+	}
+}
+
+// Returns the path of the plugin binary with the given name & version.
+func (l *ExternalLoader) findPlugin(name string) (string, error) {
 	files, err := discoverExec(l.Dir)
 	if err != nil {
-		return nil, ErrPluginNotFound
+		return "", ErrPluginNotFound
 	}
 
 	meta, err := ParseMeta(name)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
 	var found string
@@ -188,12 +472,15 @@ func (l *ExternalLoader) loadClientFull(name string) (*extplugin.Client, error)
 	}
 
 	if found == "" {
-		return nil, ErrPluginNotFound
+		return "", ErrPluginNotFound
 	}
 
-	return loadExternal(path.Join(l.Dir, found)), nil
+	return path.Join(l.Dir, found), nil
 }
 
+// GRPCAPIServer exposes the contract context to external plugins. When a contract is executed by
+// a metered tx the context panics if the tx runs out of gas, so the handlers that touch metered
+// parts of the context recover the panic and return ErrOutOfGas to the plugin instead.
 type GRPCAPIServer struct {
 	sctx plugin.StaticContext
 	ctx  plugin.Context
@@ -201,10 +488,13 @@ type GRPCAPIServer struct {
 
 var (
 	errVolatileCall = errors.New("calling volatile method from static context")
-	defaultCallOpts = []grpc.CallOption{grpc.CallContentSubtype("gogoproto")}
+	// Go contracts can only call Go & EVM contracts
+	errWasmCallNotSupported = errors.New("Go contracts can't call Wasm contracts")
+	defaultCallOpts         = []grpc.CallOption{grpc.CallContentSubtype("gogoproto")}
 )
 
-func (s *GRPCAPIServer) Range(ctx context.Context, req *types.RangeRequest) (*types.RangeResponse, error) {
+func (s *GRPCAPIServer) Range(ctx context.Context, req *types.RangeRequest) (_ *types.RangeResponse, err error) {
+	defer gasmeter.RecoverOutOfGas(&err)
 	data := s.sctx.Range(req.Prefix)
 	res := make([]*types.RangeEntry, len(data))
 
@@ -220,13 +510,15 @@ func (s *GRPCAPIServer) Range(ctx context.Context, req *types.RangeRequest) (*ty
 	}, nil
 }
 
-func (s *GRPCAPIServer) Get(ctx context.Context, req *types.GetRequest) (*types.GetResponse, error) {
+func (s *GRPCAPIServer) Get(ctx context.Context, req *types.GetRequest) (_ *types.GetResponse, err error) {
+	defer gasmeter.RecoverOutOfGas(&err)
 	return &types.GetResponse{
 		Value: s.sctx.Get(req.Key),
 	}, nil
 }
 
-func (s *GRPCAPIServer) Has(ctx context.Context, req *types.HasRequest) (*types.HasResponse, error) {
+func (s *GRPCAPIServer) Has(ctx context.Context, req *types.HasRequest) (_ *types.HasResponse, err error) {
+	defer gasmeter.RecoverOutOfGas(&err)
 	return &types.HasResponse{
 		Value: s.sctx.Has(req.Key),
 	}, nil
@@ -240,18 +532,23 @@ func (s *GRPCAPIServer) GetEvmTxReceipt(
 	return &ret, err
 }
 
-func (s *GRPCAPIServer) StaticCall(ctx context.Context, req *types.CallRequest) (*types.CallResponse, error) {
+func (s *GRPCAPIServer) StaticCall(ctx context.Context, req *types.CallRequest) (_ *types.CallResponse, err error) {
+	defer gasmeter.RecoverOutOfGas(&err)
 	if s.sctx == nil {
 		return nil, errVolatileCall
 	}
 	addr := loom.UnmarshalAddressPB(req.Address)
 	var ret []byte
-	var err error
 
-	if req.VmType == vm.VMType_PLUGIN {
+	switch req.VmType {
+	case vm.VMType_PLUGIN:
 		ret, err = s.sctx.StaticCall(addr, req.Input)
-	} else {
+	case vm.VMType_EVM:
 		ret, err = s.sctx.StaticCallEVM(addr, req.Input)
+	case vm.VMType_WASM:
+		return nil, errWasmCallNotSupported
+	default:
+		return nil, errors.Wrapf(vm.ErrUnknownVMType, "VM type %d", req.VmType)
 	}
 	if err != nil {
 		return nil, err
@@ -267,7 +564,8 @@ func (s *GRPCAPIServer) Resolve(ctx context.Context, req *types.ResolveRequest)
 	return &types.ResolveResponse{Address: addr.MarshalPB()}, nil
 }
 
-func (s *GRPCAPIServer) Emit(ctx context.Context, req *types.EmitRequest) (*types.EmitResponse, error) {
+func (s *GRPCAPIServer) Emit(ctx context.Context, req *types.EmitRequest) (_ *types.EmitResponse, err error) {
+	defer gasmeter.RecoverOutOfGas(&err)
 	s.ctx.EmitTopics(req.Data, req.Topics...)
 	return &types.EmitResponse{}, nil
 }
@@ -279,7 +577,8 @@ func (s *GRPCAPIServer) FeatureEnabled(ctx context.Context, req *types.FeatureEn
 	}, nil
 }
 
-func (s *GRPCAPIServer) Set(ctx context.Context, req *types.SetRequest) (*types.SetResponse, error) {
+func (s *GRPCAPIServer) Set(ctx context.Context, req *types.SetRequest) (_ *types.SetResponse, err error) {
+	defer gasmeter.RecoverOutOfGas(&err)
 	if s.ctx == nil {
 		return nil, errVolatileCall
 	}
@@ -291,7 +590,8 @@ func (s *GRPCAPIServer) Set(ctx context.Context, req *types.SetRequest) (*types.
 	return &types.SetResponse{}, nil
 }
 
-func (s *GRPCAPIServer) Delete(ctx context.Context, req *types.DeleteRequest) (*types.DeleteResponse, error) {
+func (s *GRPCAPIServer) Delete(ctx context.Context, req *types.DeleteRequest) (_ *types.DeleteResponse, err error) {
+	defer gasmeter.RecoverOutOfGas(&err)
 	if s.ctx == nil {
 		return nil, errVolatileCall
 	}
@@ -299,16 +599,17 @@ func (s *GRPCAPIServer) Delete(ctx context.Context, req *types.DeleteRequest) (*
 	return &types.DeleteResponse{}, nil
 }
 
-func (s *GRPCAPIServer) Call(ctx context.Context, req *types.CallRequest) (*types.CallResponse, error) {
+func (s *GRPCAPIServer) Call(ctx context.Context, req *types.CallRequest) (_ *types.CallResponse, err error) {
+	defer gasmeter.RecoverOutOfGas(&err)
 	if s.ctx == nil {
 		return nil, errVolatileCall
 	}
 	addr := loom.UnmarshalAddressPB(req.Address)
 	var ret []byte
-	var err error
-	if req.VmType == vm.VMType_PLUGIN {
+	switch req.VmType {
+	case vm.VMType_PLUGIN:
 		ret, err = s.ctx.Call(addr, req.Input)
-	} else {
+	case vm.VMType_EVM:
 		var value *loom.BigUInt
 		if req.Value == nil {
 			value = loom.NewBigUIntFromInt(0)
@@ -316,6 +617,10 @@ func (s *GRPCAPIServer) Call(ctx context.Context, req *types.CallRequest) (*type
 			value = &req.Value.Value
 		}
 		ret, err = s.ctx.CallEVM(addr, req.Input, value)
+	case vm.VMType_WASM:
+		return nil, errWasmCallNotSupported
+	default:
+		return nil, errors.Wrapf(vm.ErrUnknownVMType, "VM type %d", req.VmType)
 	}
 	if err != nil {
 		return nil, err
@@ -1,6 +1,16 @@
 package plugin
 
-import "testing"
+import (
+	"crypto/sha256"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
 
 func TestPluginSoLoader(t *testing.T) {
 	e := NewExternalLoader("")
@@ -19,3 +29,67 @@ func TestPluginSoLoader(t *testing.T) {
 	}
 
 }
+
+func TestPluginRestartDelay(t *testing.T) {
+	require.Equal(t, time.Duration(0), pluginRestartDelay(1))
+	require.Equal(t, minPluginRestartDelay, pluginRestartDelay(2))
+	require.Equal(t, 2*minPluginRestartDelay, pluginRestartDelay(3))
+	require.Equal(t, 4*minPluginRestartDelay, pluginRestartDelay(4))
+	require.Equal(t, maxPluginRestartDelay, pluginRestartDelay(100))
+}
+
+func TestPluginProcessRecordExit(t *testing.T) {
+	now := time.Now()
+	p := &pluginProcess{name: "test:1.0.0"}
+	p.start(nil, now)
+
+	// first exit should be restarted immediately
+	now = now.Add(time.Second)
+	p.recordExit(now)
+	require.Equal(t, 1, p.failures)
+	require.Equal(t, now, p.nextRestartAt)
+	// exit should only be recorded once per process
+	p.recordExit(now.Add(time.Second))
+	require.Equal(t, 1, p.failures)
+	require.Equal(t, now, p.lastExitAt)
+
+	// process that keeps exiting should be restarted with a backoff
+	p.start(nil, now)
+	now = now.Add(time.Second)
+	p.recordExit(now)
+	require.Equal(t, 2, p.failures)
+	require.Equal(t, now.Add(minPluginRestartDelay), p.nextRestartAt)
+
+	// process that stayed up for a while should be restarted immediately
+	p.start(nil, now)
+	now = now.Add(pluginStableUptime)
+	p.recordExit(now)
+	require.Equal(t, 1, p.failures)
+	require.Equal(t, now, p.nextRestartAt)
+}
+
+func TestExternalLoaderVerifiesPluginHash(t *testing.T) {
+	dir, err := ioutil.TempDir("", "plugins")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	binary := []byte("#!/bin/sh\nexit 1\n")
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "test.1.0.0"), binary, 0755))
+
+	hash, err := verifyPluginBinary("test:1.0.0", filepath.Join(dir, "test.1.0.0"), nil)
+	require.NoError(t, err)
+	expectedHash := sha256.Sum256(binary)
+	require.Equal(t, expectedHash[:], hash)
+
+	// loader should refuse to spawn a plugin process from a binary that doesn't match the hash
+	e := NewExternalLoader(dir)
+	wrongHash := sha256.Sum256([]byte("something else"))
+	_, err = e.LoadVerifiedContract("test:1.0.0", 0, wrongHash[:])
+	require.Equal(t, ErrPluginHashMismatch, errors.Cause(err))
+	health, err := e.PluginHealth()
+	require.NoError(t, err)
+	require.Len(t, health.Plugins, 0)
+
+	_, err = e.LoadVerifiedContract("missing:1.0.0", 0, wrongHash[:])
+	require.Equal(t, ErrPluginNotFound, err)
+}
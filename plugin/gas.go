@@ -0,0 +1,50 @@
+package plugin
+
+import (
+	"github.com/loomnetwork/go-loom/plugin"
+	"github.com/loomnetwork/loomchain"
+	"github.com/loomnetwork/loomchain/gasmeter"
+)
+
+// meteredState charges the gas meter of the current tx for the KV store operations performed by a
+// Go contract.
+type meteredState struct {
+	loomchain.State
+	meter *gasmeter.GasMeter
+}
+
+var _ loomchain.State = &meteredState{}
+
+func newMeteredState(state loomchain.State, meter *gasmeter.GasMeter) *meteredState {
+	return &meteredState{
+		State: state,
+		meter: meter,
+	}
+}
+
+func (s *meteredState) Get(key []byte) []byte {
+	value := s.State.Get(key)
+	s.meter.ConsumeRead(len(value))
+	return value
+}
+
+func (s *meteredState) Has(key []byte) bool {
+	s.meter.ConsumeRead(0)
+	return s.State.Has(key)
+}
+
+func (s *meteredState) Range(prefix []byte) plugin.RangeData {
+	entries := s.State.Range(prefix)
+	s.meter.ConsumeRange(len(entries))
+	return entries
+}
+
+func (s *meteredState) Set(key, value []byte) {
+	s.meter.ConsumeWrite(len(key), len(value))
+	s.State.Set(key, value)
+}
+
+func (s *meteredState) Delete(key []byte) {
+	s.meter.ConsumeDelete()
+	s.State.Delete(key)
+}
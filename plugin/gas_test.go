@@ -0,0 +1,142 @@
+package plugin
+
+import (
+	"context"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/loomnetwork/go-loom"
+	loom_plugin "github.com/loomnetwork/go-loom/plugin"
+	contract "github.com/loomnetwork/go-loom/plugin/contractpb"
+	"github.com/loomnetwork/go-loom/testdata"
+	"github.com/loomnetwork/loomchain"
+	"github.com/loomnetwork/loomchain/gasmeter"
+	registry "github.com/loomnetwork/loomchain/registry/factory"
+	"github.com/loomnetwork/loomchain/store"
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/tendermint/abci/types"
+)
+
+var vmCaller = loom.MustParseAddress("chain:0xb16a379ec18d4093666f8f38b11a3071c920207d")
+
+// Contract that keeps writing to storage until it runs out of gas
+type gasGuzzlerContract struct {
+	Target loom.Address
+}
+
+func (c *gasGuzzlerContract) Meta() (loom_plugin.Meta, error) {
+	return loom_plugin.Meta{
+		Name:    "gasguzzler",
+		Version: "0.0.1",
+	}, nil
+}
+
+func (c *gasGuzzlerContract) Init(ctx contract.Context, req *loom_plugin.Request) error {
+	return nil
+}
+
+func (c *gasGuzzlerContract) Write(ctx contract.Context, args *testdata.CallArgs) error {
+	return ctx.Set([]byte("key"), args)
+}
+
+func (c *gasGuzzlerContract) Loop(ctx contract.Context, args *testdata.CallArgs) error {
+	for i := uint64(0); ; i++ {
+		key := make([]byte, 8)
+		binary.BigEndian.PutUint64(key, i)
+		if err := ctx.Set(key, args); err != nil {
+			return err
+		}
+	}
+}
+
+// Calls the Loop method of the target contract & ignores the error
+func (c *gasGuzzlerContract) CallLoop(ctx contract.Context, args *testdata.CallArgs) error {
+	contract.CallMethod(ctx, c.Target, "Loop", args, nil)
+	return nil
+}
+
+func newMeteredPluginVM(t *testing.T, loader Loader, meter *gasmeter.GasMeter) *PluginVM {
+	block := abci.Header{
+		ChainID: "chain",
+		Height:  int64(34),
+		Time:    time.Unix(123456789, 0),
+	}
+	ctx := gasmeter.WithGasMeter(context.Background(), meter)
+	state := loomchain.NewStoreState(ctx, store.NewMemStore(), block, nil, nil)
+	createRegistry, err := registry.NewRegistryFactory(registry.LatestRegistryVersion)
+	require.NoError(t, err)
+	return NewPluginVM(loader, state, createRegistry(state), nil, nil, nil, nil, nil)
+}
+
+func callGasGuzzler(vm *PluginVM, contractAddr loom.Address, method string) error {
+	args, err := proto.Marshal(&testdata.CallArgs{})
+	if err != nil {
+		return err
+	}
+	body, err := proto.Marshal(&loom_plugin.ContractMethodCall{Method: method, Args: args})
+	if err != nil {
+		return err
+	}
+	input, err := proto.Marshal(&loom_plugin.Request{
+		ContentType: loom_plugin.EncodingType_PROTOBUF3,
+		Accept:      loom_plugin.EncodingType_PROTOBUF3,
+		Body:        body,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = vm.Call(vmCaller, contractAddr, input, loom.NewBigUIntFromInt(0))
+	return err
+}
+
+func deployGasGuzzler(t *testing.T, vm *PluginVM) loom.Address {
+	init, err := proto.Marshal(&loom_plugin.Request{
+		ContentType: loom_plugin.EncodingType_PROTOBUF3,
+		Accept:      loom_plugin.EncodingType_PROTOBUF3,
+	})
+	require.NoError(t, err)
+	code, err := proto.Marshal(&PluginCode{Name: "gasguzzler:0.0.1", Input: init})
+	require.NoError(t, err)
+	_, addr, err := vm.Create(vmCaller, code, loom.NewBigUIntFromInt(0))
+	require.NoError(t, err)
+	return addr
+}
+
+func TestGasMeteredContractCall(t *testing.T) {
+	guzzler := &gasGuzzlerContract{}
+	cfg := gasmeter.DefaultConfig()
+	cfg.TxGasLimit = 100000
+	meter := gasmeter.NewGasMeter(cfg)
+	vm := newMeteredPluginVM(t, NewStaticLoader(contract.MakePluginContract(guzzler)), meter)
+	addr := deployGasGuzzler(t, vm)
+
+	used := meter.GasUsed()
+	require.NoError(t, callGasGuzzler(vm, addr, "Write"))
+	require.True(t, meter.GasUsed()-used >= cfg.WriteCost)
+	require.False(t, meter.IsOutOfGas())
+
+	// a contract that never stops writing to storage should be stopped once it runs out of gas
+	require.Equal(t, gasmeter.ErrOutOfGas, callGasGuzzler(vm, addr, "Loop"))
+	require.True(t, meter.IsOutOfGas())
+	require.Equal(t, cfg.TxGasLimit, meter.GasUsed())
+
+	// subsequent calls made by the same tx should fail too
+	require.Equal(t, gasmeter.ErrOutOfGas, callGasGuzzler(vm, addr, "Write"))
+}
+
+func TestGasMeteredNestedContractCall(t *testing.T) {
+	guzzler := &gasGuzzlerContract{}
+	cfg := gasmeter.DefaultConfig()
+	cfg.TxGasLimit = 100000
+	meter := gasmeter.NewGasMeter(cfg)
+	vm := newMeteredPluginVM(t, NewStaticLoader(contract.MakePluginContract(guzzler)), meter)
+	addr := deployGasGuzzler(t, vm)
+	guzzler.Target = addr
+
+	// the caller ignores the out of gas error returned by the nested call, but should still fail
+	require.Equal(t, gasmeter.ErrOutOfGas, callGasGuzzler(vm, addr, "CallLoop"))
+	require.True(t, meter.IsOutOfGas())
+	require.Equal(t, cfg.TxGasLimit, meter.GasUsed())
+}
@@ -0,0 +1,96 @@
+package plugin
+
+import (
+	"github.com/loomnetwork/go-loom"
+	contract "github.com/loomnetwork/go-loom/plugin/contractpb"
+	"github.com/loomnetwork/loomchain"
+	"github.com/loomnetwork/loomchain/builtin/plugins/governance"
+	regcommon "github.com/loomnetwork/loomchain/registry"
+	"github.com/loomnetwork/loomchain/store"
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrGovernanceContractNotFound indicates that the Governance contract hasn't been deployed yet.
+	ErrGovernanceContractNotFound = errors.New("[GovernanceManager] Governance contract not found")
+)
+
+// GovernanceManager implements loomchain.GovernanceManager interface
+type GovernanceManager struct {
+	ctx      contract.Context
+	state    loomchain.State
+	createVM func(state loomchain.State) (*PluginVM, error)
+}
+
+// NewGovernanceManager attempts to create an instance of GovernanceManager, createVM is used to
+// create a PluginVM for each proposal that's executed so that the proposal can be executed in
+// isolation from the rest of the state.
+func NewGovernanceManager(
+	state loomchain.State, createVM func(state loomchain.State) (*PluginVM, error),
+) (*GovernanceManager, error) {
+	ctx, err := governanceContractContext(state, createVM)
+	if err != nil {
+		return nil, err
+	}
+	return &GovernanceManager{
+		ctx:      ctx,
+		state:    state,
+		createVM: createVM,
+	}, nil
+}
+
+func governanceContractContext(
+	state loomchain.State, createVM func(state loomchain.State) (*PluginVM, error),
+) (contract.Context, error) {
+	pvm, err := createVM(state)
+	if err != nil {
+		return nil, err
+	}
+	caller := loom.RootAddress(pvm.State.Block().ChainID)
+	contractAddr, err := pvm.Registry.Resolve("governance")
+	if err != nil {
+		if err == regcommon.ErrNotFound {
+			return nil, ErrGovernanceContractNotFound
+		}
+		return nil, err
+	}
+	readOnly := false
+	return contract.WrapPluginContext(pvm.CreateContractContext(caller, contractAddr, readOnly)), nil
+}
+
+// ExecuteProposals tallies the votes on proposals whose voting period has ended, executes the ones
+// that passed, and activates the features enabled by them. Each proposal is executed in its own
+// store tx, if the execution of a proposal fails none of its changes are persisted, and the
+// proposal is marked as failed.
+func (m *GovernanceManager) ExecuteProposals(blockHeight int64) error {
+	proposals, err := governance.ProcessProposals(m.ctx, uint64(blockHeight), m.executeProposal)
+	if err != nil {
+		return err
+	}
+
+	for _, proposal := range proposals {
+		m.ctx.Logger().Info(
+			"Governance proposal processed",
+			"proposal", proposal.Id, "kind", proposal.Kind, "status", proposal.Status,
+		)
+		if proposal.Kind == governance.EnableFeatureProposal && proposal.Status == governance.ProposalExecuted {
+			m.state.SetFeature(proposal.FeatureName, true)
+		}
+	}
+	return nil
+}
+
+func (m *GovernanceManager) executeProposal(proposal *governance.Proposal) error {
+	storeTx := store.WrapAtomic(m.state).BeginTx()
+	ctx, err := governanceContractContext(m.state.WithStore(storeTx), m.createVM)
+	if err != nil {
+		storeTx.Rollback()
+		return err
+	}
+	if err := governance.ExecuteProposal(ctx, proposal); err != nil {
+		storeTx.Rollback()
+		return err
+	}
+	storeTx.Commit()
+	return nil
+}
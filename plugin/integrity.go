@@ -0,0 +1,109 @@
+package plugin
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"os"
+
+	"github.com/go-kit/kit/metrics"
+	kitprometheus "github.com/go-kit/kit/metrics/prometheus"
+	lp "github.com/loomnetwork/go-loom/plugin"
+	"github.com/loomnetwork/go-loom/util"
+	"github.com/loomnetwork/loomchain"
+	"github.com/pkg/errors"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	// ErrPluginHashMismatch is returned when the hash of a plugin binary doesn't match the hash
+	// recorded on-chain for the plugin.
+	ErrPluginHashMismatch = errors.New("plugin binary doesn't match the expected SHA-256 hash")
+
+	pluginHashMismatchCount metrics.Counter
+)
+
+func init() {
+	pluginHashMismatchCount = kitprometheus.NewCounterFrom(
+		stdprometheus.CounterOpts{
+			Namespace: "loomchain",
+			Subsystem: "external_plugin",
+			Name:      "hash_mismatch_count",
+			Help:      "Number of times a plugin binary was rejected because its hash didn't match the expected hash",
+		}, []string{"plugin"},
+	)
+}
+
+// Prefix of the app state keys that map the name & version of a plugin to the expected SHA-256
+// hash of the plugin binary.
+var pluginHashPrefix = []byte("plugin-hash")
+
+func pluginHashKey(pluginName string) []byte {
+	return util.PrefixKey(pluginHashPrefix, []byte(pluginName))
+}
+
+// SetPluginHash records the expected SHA-256 hash of the binary of the given plugin, e.g.
+// "mycontract:1.0.0". Once set, contracts will only be loaded from a plugin binary matching the hash.
+func SetPluginHash(state loomchain.State, pluginName string, hash []byte) error {
+	if len(hash) != sha256.Size {
+		return errors.Errorf("invalid SHA-256 hash for plugin %s", pluginName)
+	}
+	state.Set(pluginHashKey(pluginName), hash)
+	return nil
+}
+
+// LoadContract loads the named contract with the given loader, if an expected hash has been
+// recorded for the plugin the contract will only be loaded from a plugin binary that matches the hash.
+func LoadContract(loader Loader, state loomchain.State, pluginName string) (lp.Contract, error) {
+	blockHeight := state.Block().Height
+	expectedHash := expectedPluginHash(state, pluginName)
+	if expectedHash == nil {
+		return loader.LoadContract(pluginName, blockHeight)
+	}
+	hvLoader, ok := loader.(HashVerifyingLoader)
+	if !ok {
+		return nil, errors.Wrapf(ErrPluginHashMismatch, "unable to verify hash of plugin %s", pluginName)
+	}
+	return hvLoader.LoadVerifiedContract(pluginName, blockHeight, expectedHash)
+}
+
+// expectedPluginHash returns the SHA-256 hash recorded for the given plugin, or nil if there is none.
+func expectedPluginHash(state loomchain.State, pluginName string) []byte {
+	hash := state.Get(pluginHashKey(pluginName))
+	if len(hash) == 0 {
+		return nil
+	}
+	return hash
+}
+
+// Computes the SHA-256 hash of the file at the given path.
+func hashFile(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// verifyPluginBinary checks that the plugin binary at the given path matches the expected hash,
+// returns the actual hash of the binary.
+func verifyPluginBinary(pluginName, path string, expected []byte) ([]byte, error) {
+	actual, err := hashFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to hash plugin binary %s", path)
+	}
+	if expected != nil && !bytes.Equal(actual, expected) {
+		pluginHashMismatchCount.With("plugin", pluginName).Add(1)
+		return nil, errors.Wrapf(
+			ErrPluginHashMismatch, "refusing to start plugin %s from %s, expected hash %x, got %x",
+			pluginName, path, expected, actual,
+		)
+	}
+	return actual, nil
+}
@@ -14,6 +14,18 @@ type Loader interface {
 	UnloadContracts()
 }
 
+// HashVerifyingLoader is implemented by loaders that load contracts from plugin binaries, and can
+// check that a binary matches the expected SHA-256 hash before loading a contract from it.
+type HashVerifyingLoader interface {
+	LoadVerifiedContract(name string, blockHeight int64, sha256 []byte) (plugin.Contract, error)
+}
+
+// PluginUnloader is implemented by loaders that spawn a process for each plugin, and can stop the
+// process of a plugin that's no longer in use.
+type PluginUnloader interface {
+	UnloadPlugin(name string)
+}
+
 type MultiLoader struct {
 	loaders                []Loader
 	knownSuccessfulLoaders map[string]Loader
@@ -64,6 +76,36 @@ func (m *MultiLoader) LoadContract(name string, blockHeight int64) (plugin.Contr
 	return nil, ErrPluginNotFound
 }
 
+// LoadVerifiedContract loads the named contract from a plugin binary that matches the given hash,
+// loaders that are unable to verify the hash of the contracts they load are skipped.
+func (m *MultiLoader) LoadVerifiedContract(name string, blockHeight int64, sha256 []byte) (plugin.Contract, error) {
+	for _, loader := range m.loaders {
+		verifyingLoader, ok := loader.(HashVerifyingLoader)
+		if !ok {
+			continue
+		}
+
+		contract, err := verifyingLoader.LoadVerifiedContract(name, blockHeight, sha256)
+		if err == ErrPluginNotFound {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		return contract, nil
+	}
+
+	return nil, ErrPluginNotFound
+}
+
+// UnloadPlugin stops the process spawned for the named plugin by any of the loaders.
+func (m *MultiLoader) UnloadPlugin(name string) {
+	for _, loader := range m.loaders {
+		if unloader, ok := loader.(PluginUnloader); ok {
+			unloader.UnloadPlugin(name)
+		}
+	}
+}
+
 func (m *MultiLoader) UnloadContracts() {
 	for _, loader := range m.loaders {
 		loader.UnloadContracts()
@@ -82,6 +82,8 @@ func (m *ValidatorsManagerV3) BeginBlock(req abci.RequestBeginBlock, currentHeig
 		}
 	}
 
+	doubleSignSlashingEnabled := m.ctx.FeatureEnabled(features.DPOSVersion3_9, false)
+
 	for _, evidence := range req.ByzantineValidators {
 		// DuplicateVoteEvidence is the only type of evidence currently
 		// implemented in tendermint but we don't get access to this via the
@@ -89,6 +91,16 @@ func (m *ValidatorsManagerV3) BeginBlock(req abci.RequestBeginBlock, currentHeig
 		// The conflicting vote data is kept within the consensus engine itself.
 		m.ctx.Logger().Debug("DPOS BeginBlock", "ByzantineEvidence", fmt.Sprintf("%v+", evidence))
 
+		if doubleSignSlashingEnabled {
+			err := dposv3.SlashDoubleSignEvidence(
+				m.ctx, evidence.Validator.Address, evidence.Height, currentHeight, candidates,
+			)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
 		if evidence.Height > (currentHeight - 100) {
 			m.ctx.Logger().Debug("DPOS BeginBlock Byzantine Slashing", "FreshEvidenceHeight", evidence.Height, "CurrentHeight", currentHeight)
 			//err := m.SlashDoubleSign(evidence.Validator.Address)
@@ -18,6 +18,8 @@ import (
 	"github.com/loomnetwork/loomchain"
 	"github.com/loomnetwork/loomchain/auth"
 	levm "github.com/loomnetwork/loomchain/evm"
+	"github.com/loomnetwork/loomchain/features"
+	"github.com/loomnetwork/loomchain/gasmeter"
 	"github.com/loomnetwork/loomchain/registry"
 	"github.com/loomnetwork/loomchain/vm"
 	"github.com/pkg/errors"
@@ -74,33 +76,75 @@ func (vm *PluginVM) CreateContractContext(
 	addr loom.Address,
 	readOnly bool,
 ) *contractContext {
+	state := vm.State.WithPrefix(loom.DataPrefix(addr))
+	gasMeter := gasmeter.FromContext(vm.State.Context())
+	if gasMeter != nil {
+		state = newMeteredState(state, gasMeter)
+	}
 	return &contractContext{
 		caller:       caller,
 		address:      addr,
-		State:        vm.State.WithPrefix(loom.DataPrefix(addr)),
+		State:        state,
 		VM:           vm,
 		Registry:     vm.Registry,
 		eventHandler: vm.EventHandler,
 		readOnly:     readOnly,
 		req:          &Request{},
 		logger:       vm.logger,
+		gasMeter:     gasMeter,
 	}
 }
 
+func (vm *PluginVM) loadContract(pluginName string) (lp.Contract, error) {
+	return LoadContract(vm.Loader, vm.State, pluginName)
+}
+
+// Returns the name & version of the plugin the contract at the given address has been upgraded to
+// by the current block, or the given plugin name if the contract hasn't been upgraded.
+func (vm *PluginVM) contractPluginName(addr loom.Address, pluginName string) string {
+	record, err := vm.Registry.GetRecord(addr)
+	if err != nil {
+		// The contract is being deployed, or the registry doesn't store contract records
+		return pluginName
+	}
+	if upgradedName := record.PluginNameAt(vm.State.Block().Height); upgradedName != "" {
+		return upgradedName
+	}
+	return pluginName
+}
+
 func (vm *PluginVM) run(
 	caller,
 	addr loom.Address,
 	code,
 	input []byte,
 	readOnly bool,
-) ([]byte, error) {
+) (_ []byte, err error) {
+	if gasMeter := gasmeter.FromContext(vm.State.Context()); gasMeter != nil {
+		if gasMeter.IsOutOfGas() {
+			return nil, gasmeter.ErrOutOfGas
+		}
+		defer func() {
+			// The contract may have ignored the error returned by a nested call that ran out of gas
+			if gasMeter.IsOutOfGas() {
+				err = gasmeter.ErrOutOfGas
+			}
+		}()
+		defer gasmeter.RecoverOutOfGas(&err)
+	}
+
 	var pluginCode PluginCode
-	err := proto.Unmarshal(code, &pluginCode)
+	err = proto.Unmarshal(code, &pluginCode)
 	if err != nil {
 		return nil, err
 	}
 
-	contract, err := vm.Loader.LoadContract(pluginCode.Name, vm.State.Block().Height)
+	pluginName := pluginCode.Name
+	if vm.State.FeatureEnabled(features.ContractUpgradeTxFeature, false) {
+		pluginName = vm.contractPluginName(addr, pluginName)
+	}
+
+	contract, err := vm.loadContract(upgradedPluginName(vm.State, pluginName))
 	if err != nil {
 		return nil, err
 	}
@@ -117,7 +161,7 @@ func (vm *PluginVM) run(
 	}
 
 	contractCtx := vm.CreateContractContext(caller, addr, readOnly)
-	contractCtx.pluginName = pluginCode.Name
+	contractCtx.pluginName = pluginName
 	contractCtx.req = req
 
 	var res *Response
@@ -233,26 +277,38 @@ type contractContext struct {
 	pluginName   string
 	logger       *loom.Logger
 	req          *Request
+	// Nil unless the contract is being executed by a tx with gas metering enabled
+	gasMeter *gasmeter.GasMeter
 }
 
 var _ lp.Context = &contractContext{}
 
 func (c *contractContext) Call(addr loom.Address, input []byte) ([]byte, error) {
+	c.consumeCallGas()
 	return c.VM.Call(c.address, addr, input, loom.NewBigUIntFromInt(0))
 }
 
 func (c *contractContext) CallEVM(addr loom.Address, input []byte, value *loom.BigUInt) ([]byte, error) {
+	c.consumeCallGas()
 	return c.VM.CallEVM(c.address, addr, input, value)
 }
 
 func (c *contractContext) StaticCall(addr loom.Address, input []byte) ([]byte, error) {
+	c.consumeCallGas()
 	return c.VM.StaticCall(c.address, addr, input)
 }
 
 func (c *contractContext) StaticCallEVM(addr loom.Address, input []byte) ([]byte, error) {
+	c.consumeCallGas()
 	return c.VM.StaticCallEVM(c.address, addr, input)
 }
 
+func (c *contractContext) consumeCallGas() {
+	if c.gasMeter != nil {
+		c.gasMeter.ConsumeCall()
+	}
+}
+
 func (c *contractContext) Resolve(name string) (loom.Address, error) {
 	return c.Registry.Resolve(name)
 }
@@ -312,6 +368,9 @@ func (c *contractContext) EmitTopics(event []byte, topics ...string) {
 	if c.readOnly {
 		return
 	}
+	if c.gasMeter != nil {
+		c.gasMeter.ConsumeEvent(len(event))
+	}
 	data := types.EventData{
 		Topics:          topics,
 		Caller:          c.caller.MarshalPB(),
@@ -332,7 +391,7 @@ func (c *contractContext) ContractRecord(contractAddr loom.Address) (*lp.Contrac
 	return &lp.ContractRecord{
 		ContractName:    rec.Name,
 		ContractAddress: loom.UnmarshalAddressPB(rec.Address),
-		CreatorAddress:  loom.UnmarshalAddressPB(rec.Owner),
+		CreatorAddress:  loom.UnmarshalAddressPB(rec.CreatorAddress()),
 	}, nil
 }
 
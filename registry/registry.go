@@ -4,6 +4,7 @@ import (
 	"errors"
 
 	"github.com/loomnetwork/go-loom"
+	"github.com/loomnetwork/go-loom/types"
 )
 
 var (
@@ -11,6 +12,8 @@ var (
 	ErrNotFound          = errors.New("name is not registered")
 	ErrInvalidVersion    = errors.New("invalid registry version")
 	ErrNotImplemented    = errors.New("not implemented in this registry version")
+	ErrInvalidUpgrade    = errors.New("invalid contract upgrade")
+	ErrInvalidMetadata   = errors.New("invalid contract metadata")
 )
 
 // Registry stores contract meta data.
@@ -23,4 +26,29 @@ type Registry interface {
 	Resolve(contractName string) (loom.Address, error)
 	// GetRecord looks up the meta data previously stored for the given contract
 	GetRecord(contractAddr loom.Address) (*Record, error)
+	// UpgradeContract adds a new version to the meta data of the contract matching the given name
+	UpgradeContract(contractName string, version *ContractVersion) error
+	// TransferOwnership changes the owner stored in the meta data of the given contract
+	TransferOwnership(contractAddr, newOwner loom.Address) error
+	// SetMetadata replaces the metadata stored in the meta data of the given contract
+	SetMetadata(contractAddr loom.Address, metadata *ContractMetadata) error
+}
+
+// PluginNameAt returns the name & version of the plugin the contract has been upgraded to by the
+// given block height, or an empty string if the contract hasn't been upgraded by then.
+func (r *Record) PluginNameAt(height int64) string {
+	for i := len(r.Versions) - 1; i >= 0; i-- {
+		if r.Versions[i].Height <= height {
+			return r.Versions[i].PluginName
+		}
+	}
+	return ""
+}
+
+// CreatorAddress returns the address of the account that deployed the contract.
+func (r *Record) CreatorAddress() *types.Address {
+	if r.Creator != nil {
+		return r.Creator
+	}
+	return r.Owner
 }
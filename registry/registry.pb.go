@@ -20,9 +20,16 @@ var _ = math.Inf
 const _ = proto.GoGoProtoPackageIsVersion2 // please upgrade the proto package
 
 type Record struct {
-	Name                 string         `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
-	Address              *types.Address `protobuf:"bytes,2,opt,name=address" json:"address,omitempty"`
-	Owner                *types.Address `protobuf:"bytes,3,opt,name=owner" json:"owner,omitempty"`
+	Name    string         `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Address *types.Address `protobuf:"bytes,2,opt,name=address" json:"address,omitempty"`
+	Owner   *types.Address `protobuf:"bytes,3,opt,name=owner" json:"owner,omitempty"`
+	// Versions of the plugin the contract has been upgraded to, in the order the upgrades were
+	// made, empty if the contract has never been upgraded.
+	Versions []*ContractVersion `protobuf:"bytes,4,rep,name=versions" json:"versions,omitempty"`
+	Metadata *ContractMetadata  `protobuf:"bytes,5,opt,name=metadata" json:"metadata,omitempty"`
+	// Account that deployed the contract, only set once the ownership of the contract has been
+	// transferred, until then the creator is the owner.
+	Creator              *types.Address `protobuf:"bytes,6,opt,name=creator" json:"creator,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
 	XXX_unrecognized     []byte         `json:"-"`
 	XXX_sizecache        int32          `json:"-"`
@@ -32,7 +39,7 @@ func (m *Record) Reset()         { *m = Record{} }
 func (m *Record) String() string { return proto.CompactTextString(m) }
 func (*Record) ProtoMessage()    {}
 func (*Record) Descriptor() ([]byte, []int) {
-	return fileDescriptor_registry_8f33b09e6c22f9ce, []int{0}
+	return fileDescriptor_registry_1f6455565c7f2e67, []int{0}
 }
 func (m *Record) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_Record.Unmarshal(m, b)
@@ -73,25 +80,346 @@ func (m *Record) GetOwner() *types.Address {
 	return nil
 }
 
+func (m *Record) GetVersions() []*ContractVersion {
+	if m != nil {
+		return m.Versions
+	}
+	return nil
+}
+
+func (m *Record) GetMetadata() *ContractMetadata {
+	if m != nil {
+		return m.Metadata
+	}
+	return nil
+}
+
+func (m *Record) GetCreator() *types.Address {
+	if m != nil {
+		return m.Creator
+	}
+	return nil
+}
+
+// ContractVersion specifies the plugin that implements a Go contract from a particular height.
+type ContractVersion struct {
+	// Name & version of the plugin, e.g. "mycontract:1.1.0"
+	PluginName string `protobuf:"bytes,1,opt,name=plugin_name,json=pluginName,proto3" json:"plugin_name,omitempty"`
+	// Height of the first block in which the plugin will be loaded in place of the previous version.
+	Height               int64    `protobuf:"varint,2,opt,name=height,proto3" json:"height,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ContractVersion) Reset()         { *m = ContractVersion{} }
+func (m *ContractVersion) String() string { return proto.CompactTextString(m) }
+func (*ContractVersion) ProtoMessage()    {}
+func (*ContractVersion) Descriptor() ([]byte, []int) {
+	return fileDescriptor_registry_1f6455565c7f2e67, []int{1}
+}
+func (m *ContractVersion) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ContractVersion.Unmarshal(m, b)
+}
+func (m *ContractVersion) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ContractVersion.Marshal(b, m, deterministic)
+}
+func (dst *ContractVersion) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ContractVersion.Merge(dst, src)
+}
+func (m *ContractVersion) XXX_Size() int {
+	return xxx_messageInfo_ContractVersion.Size(m)
+}
+func (m *ContractVersion) XXX_DiscardUnknown() {
+	xxx_messageInfo_ContractVersion.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ContractVersion proto.InternalMessageInfo
+
+func (m *ContractVersion) GetPluginName() string {
+	if m != nil {
+		return m.PluginName
+	}
+	return ""
+}
+
+func (m *ContractVersion) GetHeight() int64 {
+	if m != nil {
+		return m.Height
+	}
+	return 0
+}
+
+// UpgradeContractTx points a named Go contract at a new plugin version from the given height,
+// the contract retains its address & storage. Can only be sent by the owner of the contract.
+type UpgradeContractTx struct {
+	ContractName         string   `protobuf:"bytes,1,opt,name=contract_name,json=contractName,proto3" json:"contract_name,omitempty"`
+	PluginName           string   `protobuf:"bytes,2,opt,name=plugin_name,json=pluginName,proto3" json:"plugin_name,omitempty"`
+	Height               int64    `protobuf:"varint,3,opt,name=height,proto3" json:"height,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *UpgradeContractTx) Reset()         { *m = UpgradeContractTx{} }
+func (m *UpgradeContractTx) String() string { return proto.CompactTextString(m) }
+func (*UpgradeContractTx) ProtoMessage()    {}
+func (*UpgradeContractTx) Descriptor() ([]byte, []int) {
+	return fileDescriptor_registry_1f6455565c7f2e67, []int{2}
+}
+func (m *UpgradeContractTx) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_UpgradeContractTx.Unmarshal(m, b)
+}
+func (m *UpgradeContractTx) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_UpgradeContractTx.Marshal(b, m, deterministic)
+}
+func (dst *UpgradeContractTx) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UpgradeContractTx.Merge(dst, src)
+}
+func (m *UpgradeContractTx) XXX_Size() int {
+	return xxx_messageInfo_UpgradeContractTx.Size(m)
+}
+func (m *UpgradeContractTx) XXX_DiscardUnknown() {
+	xxx_messageInfo_UpgradeContractTx.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UpgradeContractTx proto.InternalMessageInfo
+
+func (m *UpgradeContractTx) GetContractName() string {
+	if m != nil {
+		return m.ContractName
+	}
+	return ""
+}
+
+func (m *UpgradeContractTx) GetPluginName() string {
+	if m != nil {
+		return m.PluginName
+	}
+	return ""
+}
+
+func (m *UpgradeContractTx) GetHeight() int64 {
+	if m != nil {
+		return m.Height
+	}
+	return 0
+}
+
+// ContractMetadata describes the interface & source of a contract so that tools such as block
+// explorers can decode calls to it, all fields are optional.
+type ContractMetadata struct {
+	// JSON ABI of an EVM contract
+	Abi string `protobuf:"bytes,1,opt,name=abi,proto3" json:"abi,omitempty"`
+	// Serialized FileDescriptorSet describing the protobuf messages of a Go contract
+	ProtoDescriptor []byte `protobuf:"bytes,2,opt,name=proto_descriptor,json=protoDescriptor,proto3" json:"proto_descriptor,omitempty"`
+	// URL of the contract source
+	SourceUrl string `protobuf:"bytes,3,opt,name=source_url,json=sourceUrl,proto3" json:"source_url,omitempty"`
+	// SHA-256 hash of the verified contract source
+	SourceHash           []byte   `protobuf:"bytes,4,opt,name=source_hash,json=sourceHash,proto3" json:"source_hash,omitempty"`
+	Description          string   `protobuf:"bytes,5,opt,name=description,proto3" json:"description,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ContractMetadata) Reset()         { *m = ContractMetadata{} }
+func (m *ContractMetadata) String() string { return proto.CompactTextString(m) }
+func (*ContractMetadata) ProtoMessage()    {}
+func (*ContractMetadata) Descriptor() ([]byte, []int) {
+	return fileDescriptor_registry_1f6455565c7f2e67, []int{3}
+}
+func (m *ContractMetadata) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ContractMetadata.Unmarshal(m, b)
+}
+func (m *ContractMetadata) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ContractMetadata.Marshal(b, m, deterministic)
+}
+func (dst *ContractMetadata) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ContractMetadata.Merge(dst, src)
+}
+func (m *ContractMetadata) XXX_Size() int {
+	return xxx_messageInfo_ContractMetadata.Size(m)
+}
+func (m *ContractMetadata) XXX_DiscardUnknown() {
+	xxx_messageInfo_ContractMetadata.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ContractMetadata proto.InternalMessageInfo
+
+func (m *ContractMetadata) GetAbi() string {
+	if m != nil {
+		return m.Abi
+	}
+	return ""
+}
+
+func (m *ContractMetadata) GetProtoDescriptor() []byte {
+	if m != nil {
+		return m.ProtoDescriptor
+	}
+	return nil
+}
+
+func (m *ContractMetadata) GetSourceUrl() string {
+	if m != nil {
+		return m.SourceUrl
+	}
+	return ""
+}
+
+func (m *ContractMetadata) GetSourceHash() []byte {
+	if m != nil {
+		return m.SourceHash
+	}
+	return nil
+}
+
+func (m *ContractMetadata) GetDescription() string {
+	if m != nil {
+		return m.Description
+	}
+	return ""
+}
+
+// TransferOwnershipTx transfers the ownership of a contract to another account. Can only be sent
+// by the owner of the contract.
+type TransferOwnershipTx struct {
+	Contract             *types.Address `protobuf:"bytes,1,opt,name=contract" json:"contract,omitempty"`
+	NewOwner             *types.Address `protobuf:"bytes,2,opt,name=new_owner,json=newOwner" json:"new_owner,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
+}
+
+func (m *TransferOwnershipTx) Reset()         { *m = TransferOwnershipTx{} }
+func (m *TransferOwnershipTx) String() string { return proto.CompactTextString(m) }
+func (*TransferOwnershipTx) ProtoMessage()    {}
+func (*TransferOwnershipTx) Descriptor() ([]byte, []int) {
+	return fileDescriptor_registry_1f6455565c7f2e67, []int{4}
+}
+func (m *TransferOwnershipTx) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TransferOwnershipTx.Unmarshal(m, b)
+}
+func (m *TransferOwnershipTx) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TransferOwnershipTx.Marshal(b, m, deterministic)
+}
+func (dst *TransferOwnershipTx) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TransferOwnershipTx.Merge(dst, src)
+}
+func (m *TransferOwnershipTx) XXX_Size() int {
+	return xxx_messageInfo_TransferOwnershipTx.Size(m)
+}
+func (m *TransferOwnershipTx) XXX_DiscardUnknown() {
+	xxx_messageInfo_TransferOwnershipTx.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TransferOwnershipTx proto.InternalMessageInfo
+
+func (m *TransferOwnershipTx) GetContract() *types.Address {
+	if m != nil {
+		return m.Contract
+	}
+	return nil
+}
+
+func (m *TransferOwnershipTx) GetNewOwner() *types.Address {
+	if m != nil {
+		return m.NewOwner
+	}
+	return nil
+}
+
+// SetContractMetadataTx replaces the metadata of a contract. Can only be sent by the owner of the
+// contract.
+type SetContractMetadataTx struct {
+	Contract             *types.Address    `protobuf:"bytes,1,opt,name=contract" json:"contract,omitempty"`
+	Metadata             *ContractMetadata `protobuf:"bytes,2,opt,name=metadata" json:"metadata,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *SetContractMetadataTx) Reset()         { *m = SetContractMetadataTx{} }
+func (m *SetContractMetadataTx) String() string { return proto.CompactTextString(m) }
+func (*SetContractMetadataTx) ProtoMessage()    {}
+func (*SetContractMetadataTx) Descriptor() ([]byte, []int) {
+	return fileDescriptor_registry_1f6455565c7f2e67, []int{5}
+}
+func (m *SetContractMetadataTx) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SetContractMetadataTx.Unmarshal(m, b)
+}
+func (m *SetContractMetadataTx) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SetContractMetadataTx.Marshal(b, m, deterministic)
+}
+func (dst *SetContractMetadataTx) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SetContractMetadataTx.Merge(dst, src)
+}
+func (m *SetContractMetadataTx) XXX_Size() int {
+	return xxx_messageInfo_SetContractMetadataTx.Size(m)
+}
+func (m *SetContractMetadataTx) XXX_DiscardUnknown() {
+	xxx_messageInfo_SetContractMetadataTx.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SetContractMetadataTx proto.InternalMessageInfo
+
+func (m *SetContractMetadataTx) GetContract() *types.Address {
+	if m != nil {
+		return m.Contract
+	}
+	return nil
+}
+
+func (m *SetContractMetadataTx) GetMetadata() *ContractMetadata {
+	if m != nil {
+		return m.Metadata
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*Record)(nil), "Record")
+	proto.RegisterType((*ContractVersion)(nil), "ContractVersion")
+	proto.RegisterType((*UpgradeContractTx)(nil), "UpgradeContractTx")
+	proto.RegisterType((*ContractMetadata)(nil), "ContractMetadata")
+	proto.RegisterType((*TransferOwnershipTx)(nil), "TransferOwnershipTx")
+	proto.RegisterType((*SetContractMetadataTx)(nil), "SetContractMetadataTx")
 }
 
 func init() {
-	proto.RegisterFile("github.com/loomnetwork/loomchain/registry/registry.proto", fileDescriptor_registry_8f33b09e6c22f9ce)
-}
-
-var fileDescriptor_registry_8f33b09e6c22f9ce = []byte{
-	// 163 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0xb2, 0x48, 0xcf, 0x2c, 0xc9,
-	0x28, 0x4d, 0xd2, 0x4b, 0xce, 0xcf, 0xd5, 0xcf, 0xc9, 0xcf, 0xcf, 0xcd, 0x4b, 0x2d, 0x29, 0xcf,
-	0x2f, 0xca, 0x06, 0xb3, 0x93, 0x33, 0x12, 0x33, 0xf3, 0xf4, 0x8b, 0x52, 0xd3, 0x33, 0x8b, 0x4b,
-	0x8a, 0x2a, 0xe1, 0x0c, 0xbd, 0x82, 0xa2, 0xfc, 0x92, 0x7c, 0x29, 0x03, 0x1c, 0x3a, 0xd3, 0xf3,
-	0x75, 0x41, 0x5c, 0xfd, 0x92, 0xca, 0x82, 0xd4, 0x62, 0x08, 0x09, 0xd1, 0xa1, 0x94, 0xc0, 0xc5,
-	0x16, 0x94, 0x9a, 0x9c, 0x5f, 0x94, 0x22, 0x24, 0xc4, 0xc5, 0x92, 0x97, 0x98, 0x9b, 0x2a, 0xc1,
-	0xa8, 0xc0, 0xa8, 0xc1, 0x19, 0x04, 0x66, 0x0b, 0x29, 0x71, 0xb1, 0x27, 0xa6, 0xa4, 0x14, 0xa5,
-	0x16, 0x17, 0x4b, 0x30, 0x29, 0x30, 0x6a, 0x70, 0x1b, 0x71, 0xe8, 0x39, 0x42, 0xf8, 0x41, 0x30,
-	0x09, 0x21, 0x39, 0x2e, 0xd6, 0xfc, 0xf2, 0xbc, 0xd4, 0x22, 0x09, 0x66, 0x34, 0x15, 0x10, 0xe1,
-	0x24, 0x36, 0xb0, 0x45, 0xc6, 0x80, 0x00, 0x00, 0x00, 0xff, 0xff, 0x38, 0x51, 0x62, 0x0c, 0xd6,
-	0x00, 0x00, 0x00,
+	proto.RegisterFile("github.com/loomnetwork/loomchain/registry/registry.proto", fileDescriptor_registry_1f6455565c7f2e67)
+}
+
+var fileDescriptor_registry_1f6455565c7f2e67 = []byte{
+	// 449 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x52, 0xdf, 0x8b, 0xd3, 0x40,
+	0x10, 0x26, 0x4d, 0xaf, 0x26, 0xd3, 0x93, 0xeb, 0xad, 0x28, 0x41, 0x50, 0x4b, 0x54, 0xa8, 0xe0,
+	0xb5, 0x72, 0xbe, 0xf8, 0x2a, 0xfa, 0x20, 0x82, 0x0a, 0x6b, 0xcf, 0xd7, 0xb2, 0x4d, 0xc6, 0x64,
+	0x31, 0xdd, 0x8d, 0xb3, 0x5b, 0x7b, 0xfd, 0xb3, 0xfc, 0x93, 0xfc, 0x4f, 0x24, 0x9b, 0x1f, 0xc4,
+	0x80, 0x7a, 0x2f, 0xcb, 0xcc, 0xf7, 0xcd, 0x7e, 0xdf, 0xee, 0xcc, 0xc0, 0xab, 0x4c, 0xda, 0x7c,
+	0xbf, 0x5d, 0x26, 0x7a, 0xb7, 0x2a, 0xb4, 0xde, 0x29, 0xb4, 0x07, 0x4d, 0xdf, 0x5c, 0x9c, 0xe4,
+	0x42, 0xaa, 0x15, 0x61, 0x26, 0x8d, 0xa5, 0x63, 0x17, 0x2c, 0x4b, 0xd2, 0x56, 0xdf, 0x7f, 0xf1,
+	0x97, 0x9b, 0x99, 0xbe, 0xa8, 0xd2, 0x95, 0x3d, 0x96, 0x68, 0xea, 0xb3, 0xbe, 0x11, 0xff, 0xf2,
+	0x60, 0xc2, 0x31, 0xd1, 0x94, 0x32, 0x06, 0x63, 0x25, 0x76, 0x18, 0x79, 0x73, 0x6f, 0x11, 0x72,
+	0x17, 0xb3, 0x18, 0x6e, 0x89, 0x34, 0x25, 0x34, 0x26, 0x1a, 0xcd, 0xbd, 0xc5, 0xf4, 0x32, 0x58,
+	0xbe, 0xae, 0x73, 0xde, 0x12, 0xec, 0x21, 0x9c, 0xe8, 0x83, 0x42, 0x8a, 0xfc, 0x41, 0x45, 0x0d,
+	0xb3, 0xe7, 0x10, 0xfc, 0x40, 0x32, 0x52, 0x2b, 0x13, 0x8d, 0xe7, 0xfe, 0x62, 0x7a, 0x39, 0x5b,
+	0xbe, 0xd1, 0xca, 0x92, 0x48, 0xec, 0x97, 0x9a, 0xe0, 0x5d, 0x05, 0xbb, 0x80, 0x60, 0x87, 0x56,
+	0xa4, 0xc2, 0x8a, 0xe8, 0xc4, 0x09, 0x9e, 0x77, 0xd5, 0x1f, 0x1a, 0x82, 0x77, 0x25, 0xd5, 0x03,
+	0x13, 0x42, 0x61, 0x35, 0x45, 0x93, 0xe1, 0x03, 0x1b, 0x22, 0x7e, 0x0f, 0x67, 0x03, 0x3f, 0xf6,
+	0x08, 0xa6, 0x65, 0xb1, 0xcf, 0xa4, 0xda, 0xf4, 0xbe, 0x0c, 0x35, 0xf4, 0xb1, 0xfa, 0xf8, 0x3d,
+	0x98, 0xe4, 0x28, 0xb3, 0xdc, 0xba, 0x7f, 0xfb, 0xbc, 0xc9, 0xe2, 0xef, 0x70, 0x7e, 0x55, 0x66,
+	0x24, 0x52, 0x6c, 0x25, 0xd7, 0xd7, 0xec, 0x31, 0xdc, 0x4e, 0x9a, 0xac, 0xaf, 0x77, 0xda, 0x82,
+	0x4e, 0x71, 0x60, 0x39, 0xfa, 0x87, 0xa5, 0xff, 0x87, 0xe5, 0x4f, 0x0f, 0x66, 0xc3, 0x0e, 0xb0,
+	0x19, 0xf8, 0x62, 0x2b, 0x1b, 0xa3, 0x2a, 0x64, 0xcf, 0x60, 0xe6, 0x46, 0xba, 0x49, 0xd1, 0x24,
+	0x24, 0xcb, 0xaa, 0x25, 0x95, 0xc9, 0x29, 0x3f, 0x73, 0xf8, 0xdb, 0x0e, 0x66, 0x0f, 0x00, 0x8c,
+	0xde, 0x53, 0x82, 0x9b, 0x3d, 0x15, 0xce, 0x2d, 0xe4, 0x61, 0x8d, 0x5c, 0x51, 0x51, 0xbd, 0xb4,
+	0xa1, 0x73, 0x61, 0xf2, 0x68, 0xec, 0x44, 0x9a, 0x1b, 0xef, 0x84, 0xc9, 0xd9, 0x1c, 0xa6, 0xad,
+	0x89, 0xd4, 0xca, 0x8d, 0x29, 0xe4, 0x7d, 0x28, 0xde, 0xc2, 0x9d, 0x35, 0x09, 0x65, 0xbe, 0x22,
+	0x7d, 0xaa, 0x96, 0xc0, 0xe4, 0xb2, 0x5c, 0x5f, 0xb3, 0x27, 0x10, 0xb4, 0x3d, 0x89, 0xbc, 0xc1,
+	0xb8, 0x3a, 0x86, 0x3d, 0x85, 0x50, 0xe1, 0x61, 0x53, 0x2f, 0xd5, 0x70, 0xed, 0x02, 0x85, 0x07,
+	0x27, 0x19, 0x17, 0x70, 0xf7, 0x33, 0xda, 0x61, 0x67, 0x6e, 0xec, 0xd2, 0x5f, 0xb4, 0xd1, 0x7f,
+	0x17, 0x6d, 0x3b, 0x71, 0x4d, 0x7c, 0xf9, 0x7b, 0x00, 0xa8, 0x42, 0xf4, 0xd3, 0x9d, 0x03, 0x00,
+	0x00,
 }
@@ -80,6 +80,18 @@ func (r *StateRegistry) GetRecord(contractAddr loom.Address) (*common.Record, er
 	return nil, common.ErrNotImplemented
 }
 
+func (r *StateRegistry) UpgradeContract(contractName string, version *common.ContractVersion) error {
+	return common.ErrNotImplemented
+}
+
+func (r *StateRegistry) TransferOwnership(contractAddr, newOwner loom.Address) error {
+	return common.ErrNotImplemented
+}
+
+func (r *StateRegistry) SetMetadata(contractAddr loom.Address, metadata *common.ContractMetadata) error {
+	return common.ErrNotImplemented
+}
+
 func validateName(name string) error {
 	if len(name) < minNameLen {
 		return errors.New("name length too short")
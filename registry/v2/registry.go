@@ -1,7 +1,7 @@
 package registry
 
 import (
-	"errors"
+	"crypto/sha256"
 	"regexp"
 
 	proto "github.com/gogo/protobuf/proto"
@@ -10,11 +10,18 @@ import (
 	"github.com/loomnetwork/go-loom/util"
 	"github.com/loomnetwork/loomchain"
 	common "github.com/loomnetwork/loomchain/registry"
+	"github.com/pkg/errors"
 )
 
 const (
 	minNameLen = 1
 	maxNameLen = 255
+
+	// Limits on the size of contract metadata, every node has to store it
+	maxAbiLen             = 64 * 1024
+	maxProtoDescriptorLen = 64 * 1024
+	maxSourceURLLen       = 1024
+	maxDescriptionLen     = 1024
 )
 
 var (
@@ -104,6 +111,75 @@ func (r *StateRegistry) GetRecord(contractAddr loom.Address) (*common.Record, er
 	return &record, nil
 }
 
+// UpgradeContract appends the given version to the record of the named contract. Versions must
+// be added in the order of the heights they take effect at, and can't take effect before the
+// next block.
+func (r *StateRegistry) UpgradeContract(contractName string, version *common.ContractVersion) error {
+	if version.PluginName == "" {
+		return errors.Wrap(common.ErrInvalidUpgrade, "plugin name not specified")
+	}
+	if version.Height <= r.State.Block().Height {
+		return errors.Wrapf(
+			common.ErrInvalidUpgrade, "upgrade height must be greater than %d", r.State.Block().Height,
+		)
+	}
+	contractAddr, err := r.Resolve(contractName)
+	if err != nil {
+		return err
+	}
+	record, err := r.GetRecord(contractAddr)
+	if err != nil {
+		return err
+	}
+	if n := len(record.Versions); n > 0 && record.Versions[n-1].Height >= version.Height {
+		return errors.Wrapf(
+			common.ErrInvalidUpgrade, "upgrade height must be greater than %d", record.Versions[n-1].Height,
+		)
+	}
+	record.Versions = append(record.Versions, version)
+	return r.setRecord(contractAddr, record)
+}
+
+// TransferOwnership changes the owner of the given contract, the creator of the contract is
+// preserved.
+func (r *StateRegistry) TransferOwnership(contractAddr, newOwner loom.Address) error {
+	if newOwner.IsEmpty() {
+		return errors.New("new owner not specified")
+	}
+	record, err := r.GetRecord(contractAddr)
+	if err != nil {
+		return err
+	}
+	if record.Creator == nil {
+		record.Creator = record.Owner
+	}
+	record.Owner = newOwner.MarshalPB()
+	return r.setRecord(contractAddr, record)
+}
+
+// SetMetadata replaces the metadata of the given contract, or clears it if the given metadata is
+// nil.
+func (r *StateRegistry) SetMetadata(contractAddr loom.Address, metadata *common.ContractMetadata) error {
+	if err := validateMetadata(metadata); err != nil {
+		return err
+	}
+	record, err := r.GetRecord(contractAddr)
+	if err != nil {
+		return err
+	}
+	record.Metadata = metadata
+	return r.setRecord(contractAddr, record)
+}
+
+func (r *StateRegistry) setRecord(contractAddr loom.Address, record *common.Record) error {
+	recBytes, err := proto.Marshal(record)
+	if err != nil {
+		return err
+	}
+	r.State.Set(contractRecordKey(contractAddr), recBytes)
+	return nil
+}
+
 func validateName(name string) error {
 	if len(name) < minNameLen {
 		return errors.New("name length too short")
@@ -119,3 +195,25 @@ func validateName(name string) error {
 
 	return nil
 }
+
+func validateMetadata(metadata *common.ContractMetadata) error {
+	if metadata == nil {
+		return nil
+	}
+	if len(metadata.SourceHash) != 0 && len(metadata.SourceHash) != sha256.Size {
+		return errors.Wrap(common.ErrInvalidMetadata, "source hash must be a SHA-256 hash")
+	}
+	if len(metadata.Abi) > maxAbiLen {
+		return errors.Wrap(common.ErrInvalidMetadata, "ABI too long")
+	}
+	if len(metadata.ProtoDescriptor) > maxProtoDescriptorLen {
+		return errors.Wrap(common.ErrInvalidMetadata, "proto descriptor too long")
+	}
+	if len(metadata.SourceUrl) > maxSourceURLLen {
+		return errors.Wrap(common.ErrInvalidMetadata, "source URL too long")
+	}
+	if len(metadata.Description) > maxDescriptionLen {
+		return errors.Wrap(common.ErrInvalidMetadata, "description too long")
+	}
+	return nil
+}
@@ -1,9 +1,18 @@
 package registry
 
 import (
+	"context"
+	"strings"
 	"testing"
 
+	loom "github.com/loomnetwork/go-loom"
+	"github.com/loomnetwork/loomchain"
+	common "github.com/loomnetwork/loomchain/registry"
+	"github.com/loomnetwork/loomchain/store"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/tendermint/abci/types"
 )
 
 func TestValidateName(t *testing.T) {
@@ -12,3 +21,76 @@ func TestValidateName(t *testing.T) {
 
 	assert.NotNil(t, validateName("foo@bar"))
 }
+
+func TestUpgradeContract(t *testing.T) {
+	state := loomchain.NewStoreState(context.Background(), store.NewMemStore(), abci.Header{Height: 10}, nil, nil)
+	reg := &StateRegistry{State: state}
+	contractAddr := loom.MustParseAddress("chain:0xb16a379ec18d4093666f8f38b11a3071c920207d")
+	owner := loom.MustParseAddress("chain:0x5cecd1f7261e1f4c684e297be3edf03b825e01c4")
+	require.NoError(t, reg.Register("mycontract", contractAddr, owner))
+
+	upgrade := func(pluginName string, height int64) error {
+		return reg.UpgradeContract("mycontract", &common.ContractVersion{
+			PluginName: pluginName,
+			Height:     height,
+		})
+	}
+	// Upgrades can't take effect retroactively, or out of order
+	require.Equal(t, common.ErrInvalidUpgrade, errors.Cause(upgrade("mycontract:1.1.0", 10)))
+	require.NoError(t, upgrade("mycontract:1.1.0", 20))
+	require.Equal(t, common.ErrInvalidUpgrade, errors.Cause(upgrade("mycontract:1.2.0", 20)))
+	require.NoError(t, upgrade("mycontract:1.2.0", 30))
+	require.Equal(t, common.ErrInvalidUpgrade, errors.Cause(upgrade("", 40)))
+	err := reg.UpgradeContract("othercontract", &common.ContractVersion{
+		PluginName: "othercontract:1.1.0",
+		Height:     40,
+	})
+	require.Equal(t, common.ErrNotFound, err)
+
+	record, err := reg.GetRecord(contractAddr)
+	require.NoError(t, err)
+	require.Len(t, record.Versions, 2)
+	require.Equal(t, "", record.PluginNameAt(19))
+	require.Equal(t, "mycontract:1.1.0", record.PluginNameAt(20))
+	require.Equal(t, "mycontract:1.1.0", record.PluginNameAt(29))
+	require.Equal(t, "mycontract:1.2.0", record.PluginNameAt(30))
+}
+
+func TestTransferOwnershipAndSetMetadata(t *testing.T) {
+	state := loomchain.NewStoreState(context.Background(), store.NewMemStore(), abci.Header{}, nil, nil)
+	reg := &StateRegistry{State: state}
+	contractAddr := loom.MustParseAddress("chain:0xb16a379ec18d4093666f8f38b11a3071c920207d")
+	owner := loom.MustParseAddress("chain:0x5cecd1f7261e1f4c684e297be3edf03b825e01c4")
+	newOwner := loom.MustParseAddress("chain:0xfa4c7920accfd66b86f5fd0e69682a79f762d49e")
+	require.Equal(t, common.ErrNotFound, reg.TransferOwnership(contractAddr, newOwner))
+	require.NoError(t, reg.Register("mycontract", contractAddr, owner))
+
+	require.Error(t, reg.TransferOwnership(contractAddr, loom.Address{}))
+	require.NoError(t, reg.TransferOwnership(contractAddr, newOwner))
+	// The creator should be preserved across subsequent transfers
+	require.NoError(t, reg.TransferOwnership(contractAddr, contractAddr))
+	require.NoError(t, reg.TransferOwnership(contractAddr, newOwner))
+
+	err := reg.SetMetadata(contractAddr, &common.ContractMetadata{SourceHash: []byte{1, 2, 3}})
+	require.Equal(t, common.ErrInvalidMetadata, errors.Cause(err))
+	err = reg.SetMetadata(contractAddr, &common.ContractMetadata{Abi: strings.Repeat("a", maxAbiLen+1)})
+	require.Equal(t, common.ErrInvalidMetadata, errors.Cause(err))
+	err = reg.SetMetadata(contractAddr, &common.ContractMetadata{
+		ProtoDescriptor: make([]byte, maxProtoDescriptorLen+1),
+	})
+	require.Equal(t, common.ErrInvalidMetadata, errors.Cause(err))
+	require.NoError(t, reg.SetMetadata(contractAddr, &common.ContractMetadata{
+		SourceUrl:   "https://github.com/loomnetwork/mycontract",
+		Description: "My contract",
+	}))
+
+	record, err := reg.GetRecord(contractAddr)
+	require.NoError(t, err)
+	require.Equal(t, newOwner, loom.UnmarshalAddressPB(record.Owner))
+	require.Equal(t, owner, loom.UnmarshalAddressPB(record.CreatorAddress()))
+	require.Equal(t, "My contract", record.Metadata.Description)
+	// Ownership changes shouldn't affect name resolution
+	addr, err := reg.Resolve("mycontract")
+	require.NoError(t, err)
+	require.Equal(t, contractAddr, addr)
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/loomnetwork/go-loom/plugin/types"
 
 	"github.com/loomnetwork/loomchain/config"
+	"github.com/loomnetwork/loomchain/registry"
 	"github.com/loomnetwork/loomchain/rpc/eth"
 	"github.com/loomnetwork/loomchain/vm"
 )
@@ -270,6 +271,16 @@ func (m *MockQueryService) GetContractRecord(addr string) (*types.ContractRecord
 	return nil, nil
 }
 
+func (m *MockQueryService) GetContractDetails(addr string) (*ContractDetailsResponse, error) {
+	m.MethodsCalled = append([]string{"GetContractDetails"}, m.MethodsCalled...)
+	return nil, nil
+}
+
+func (m *MockQueryService) GetContractMetadata(addr string) (*registry.ContractMetadata, error) {
+	m.MethodsCalled = append([]string{"GetContractMetadata"}, m.MethodsCalled...)
+	return nil, nil
+}
+
 func (m *MockQueryService) DPOSTotalStaked() (*DPOSTotalStakedResponse, error) {
 	m.MethodsCalled = append([]string{"DposTotalStaked"}, m.MethodsCalled...)
 	return nil, nil
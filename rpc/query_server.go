@@ -35,6 +35,8 @@ import (
 	"github.com/loomnetwork/loomchain/eth/subs"
 	"github.com/loomnetwork/loomchain/eth/utils"
 	levm "github.com/loomnetwork/loomchain/evm"
+	"github.com/loomnetwork/loomchain/features"
+	"github.com/loomnetwork/loomchain/feemarket"
 	"github.com/loomnetwork/loomchain/log"
 	lcp "github.com/loomnetwork/loomchain/plugin"
 	hsmpv "github.com/loomnetwork/loomchain/privval/hsm"
@@ -46,6 +48,7 @@ import (
 	blockindex "github.com/loomnetwork/loomchain/store/block_index"
 	evmaux "github.com/loomnetwork/loomchain/store/evm_aux"
 	lvm "github.com/loomnetwork/loomchain/vm"
+	"github.com/loomnetwork/loomchain/wasm"
 )
 
 const (
@@ -170,10 +173,15 @@ func (s *QueryServer) Query(caller, contract string, query []byte, vmType vm.VMT
 
 	snapshot := s.StateProvider.ReadOnlyState()
 	defer snapshot.Release()
-	if vmType == lvm.VMType_PLUGIN {
+	switch vmType {
+	case lvm.VMType_PLUGIN:
 		return s.queryPlugin(snapshot, callerAddr, contractAddr, query)
-	} else {
+	case lvm.VMType_EVM:
 		return s.queryEvm(snapshot, callerAddr, contractAddr, query)
+	case lvm.VMType_WASM:
+		return s.queryWasm(snapshot, callerAddr, contractAddr, query)
+	default:
+		return nil, errors.Wrapf(lvm.ErrUnknownVMType, "VM type %d", vmType)
 	}
 }
 
@@ -255,6 +263,21 @@ func (s *QueryServer) queryPlugin(state loomchain.State, caller, contract loom.A
 	return resp.Body, nil
 }
 
+func (s *QueryServer) queryWasm(state loomchain.State, caller, contract loom.Address, query []byte) ([]byte, error) {
+	if !state.FeatureEnabled(features.WasmVMFeature, false) {
+		return nil, errors.New("Wasm contracts are not enabled")
+	}
+	callerAddr, err := auth.ResolveAccountAddress(caller, state, s.AuthCfg, s.createAddressMapperCtx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to resolve account address")
+	}
+	vm, err := wasm.NewWasmVM(state, s.CreateRegistry(state), nil, log.Default)
+	if err != nil {
+		return nil, err
+	}
+	return vm.StaticCall(callerAddr, contract, query)
+}
+
 func (s *QueryServer) queryEvm(state loomchain.State, caller, contract loom.Address, query []byte) ([]byte, error) {
 	callerAddr, err := auth.ResolveAccountAddress(caller, state, s.AuthCfg, s.createAddressMapperCtx)
 	if err != nil {
@@ -604,11 +627,60 @@ func (s *QueryServer) GetContractRecord(contractAddrStr string) (*types.Contract
 	k := &types.ContractRecordResponse{
 		ContractName:    rec.Name,
 		ContractAddress: rec.Address,
-		CreatorAddress:  rec.Owner,
+		CreatorAddress:  rec.CreatorAddress(),
 	}
 	return k, nil
 }
 
+// ContractDetailsResponse contains the parts of a contract record that aren't returned by
+// GetContractRecord, the current owner of the contract, and the versions of the plugin a Go
+// contract has been upgraded to.
+type ContractDetailsResponse struct {
+	ContractName    string                      `json:"contract_name,omitempty"`
+	ContractAddress *gtypes.Address             `json:"contract_address,omitempty"`
+	OwnerAddress    *gtypes.Address             `json:"owner_address,omitempty"`
+	Versions        []*registry.ContractVersion `json:"versions,omitempty"`
+}
+
+func (s *QueryServer) GetContractDetails(contractAddrStr string) (*ContractDetailsResponse, error) {
+	contractAddr, err := loom.ParseAddress(contractAddrStr)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := s.StateProvider.ReadOnlyState()
+	defer snapshot.Release()
+
+	reg := s.CreateRegistry(snapshot)
+	rec, err := reg.GetRecord(contractAddr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "no contract exists at %s", contractAddr.String())
+	}
+	return &ContractDetailsResponse{
+		ContractName:    rec.Name,
+		ContractAddress: rec.Address,
+		OwnerAddress:    rec.Owner,
+		Versions:        rec.Versions,
+	}, nil
+}
+
+// GetContractMetadata returns the metadata the owner of a contract has attached to the contract,
+// or nil if the contract has no metadata.
+func (s *QueryServer) GetContractMetadata(contractAddrStr string) (*registry.ContractMetadata, error) {
+	contractAddr, err := loom.ParseAddress(contractAddrStr)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := s.StateProvider.ReadOnlyState()
+	defer snapshot.Release()
+
+	reg := s.CreateRegistry(snapshot)
+	rec, err := reg.GetRecord(contractAddr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "no contract exists at %s", contractAddr.String())
+	}
+	return rec.Metadata, nil
+}
+
 type DPOSTotalStakedResponse struct {
 	TotalStaked *gtypes.BigUInt
 }
@@ -1202,7 +1274,22 @@ func (s *QueryServer) EthEstimateGas(query eth.JsonTxCallObject) (eth.Quantity,
 }
 
 func (s *QueryServer) EthGasPrice() (eth.Quantity, error) {
-	return eth.Quantity("0x0"), nil
+	snapshot := s.StateProvider.ReadOnlyState()
+	defer snapshot.Release()
+
+	if !snapshot.FeatureEnabled(features.FeeMarketFeature, false) {
+		return eth.Quantity("0x0"), nil
+	}
+
+	cfg, err := feemarket.LoadConfig(snapshot)
+	if err != nil {
+		return "", err
+	}
+	baseFee, err := feemarket.CurrentBaseFee(snapshot, cfg, snapshot.Block().Height+1)
+	if err != nil {
+		return "", err
+	}
+	return eth.EncBigInt(*baseFee.Int), nil
 }
 
 func (s *QueryServer) EthNetVersion() (string, error) {
@@ -466,4 +466,11 @@ func testQueryServerGetContractRecord(t *testing.T) {
 		_, err := rpcClient.Call("contractrecord", params, resp)
 		require.NotNil(t, err)
 	})
+	t.Run("Contract details query should return error", func(t *testing.T) {
+		params := map[string]interface{}{}
+		params["contract"] = ""
+		resp := &ContractDetailsResponse{}
+		_, err := rpcClient.Call("contractdetails", params, resp)
+		require.NotNil(t, err)
+	})
 }
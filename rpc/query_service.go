@@ -16,7 +16,10 @@ import (
 	"github.com/loomnetwork/loomchain"
 	"github.com/loomnetwork/loomchain/config"
 	"github.com/loomnetwork/loomchain/eth/subs"
+	"github.com/loomnetwork/loomchain/fnConsensus"
 	"github.com/loomnetwork/loomchain/log"
+	lcp "github.com/loomnetwork/loomchain/plugin"
+	"github.com/loomnetwork/loomchain/registry"
 	"github.com/loomnetwork/loomchain/rpc/eth"
 	"github.com/loomnetwork/loomchain/vm"
 )
@@ -63,6 +66,8 @@ type QueryService interface {
 
 	ContractEvents(fromBlock uint64, toBlock uint64, contract string) (*types.ContractEventsResult, error)
 	GetContractRecord(contractAddr string) (*types.ContractRecordResponse, error)
+	GetContractDetails(contractAddr string) (*ContractDetailsResponse, error)
+	GetContractMetadata(contractAddr string) (*registry.ContractMetadata, error)
 	DPOSTotalStaked() (*DPOSTotalStakedResponse, error)
 	GetCanonicalTxHash(block, txIndex uint64, evmTxHash eth.Data) (eth.Data, error)
 
@@ -132,6 +137,8 @@ func MakeQueryServiceHandler(svc QueryService, logger log.TMLogger, bus *QueryEv
 	routes["evmsubscribe"] = rpcserver.NewWSRPCFunc(svc.EvmSubscribe, "method,filter")
 	routes["contractevents"] = rpcserver.NewRPCFunc(svc.ContractEvents, "fromBlock,toBlock,contract")
 	routes["contractrecord"] = rpcserver.NewRPCFunc(svc.GetContractRecord, "contract")
+	routes["contractdetails"] = rpcserver.NewRPCFunc(svc.GetContractDetails, "contract")
+	routes["contractmetadata"] = rpcserver.NewRPCFunc(svc.GetContractMetadata, "contract")
 	routes["dpos_total_staked"] = rpcserver.NewRPCFunc(svc.DPOSTotalStaked, "")
 	routes["canonical_tx_hash"] = rpcserver.NewRPCFunc(svc.GetCanonicalTxHash, "block,txIndex,evmTxHash")
 	rpcserver.RegisterRPCFuncs(wsmux, routes, codec, logger)
@@ -212,8 +219,22 @@ func MakeEthQueryServiceHandler(logger log.TMLogger, hub *Hub, routes map[string
 	return mux
 }
 
-// MakeUnsafeQueryServiceHandler returns a http handler for unsafe RPC routes
-func MakeUnsafeQueryServiceHandler(logger log.TMLogger) http.Handler {
+// FnVoteHistory provides access to the vote history tracked by the fnConsensus reactor.
+type FnVoteHistory interface {
+	VoteHistory(fnID string, limit int) (*fnConsensus.ResultFnVotes, error)
+}
+
+// PluginHealthProvider provides access to the state of the external plugin processes.
+type PluginHealthProvider interface {
+	PluginHealth() (*lcp.ResultPluginHealth, error)
+}
+
+// MakeUnsafeQueryServiceHandler returns a http handler for unsafe RPC routes, the fn_votes route
+// is only available if fnVotes is not nil, and the plugin_health route is only available if
+// pluginHealth is not nil.
+func MakeUnsafeQueryServiceHandler(
+	logger log.TMLogger, fnVotes FnVoteHistory, pluginHealth PluginHealthProvider,
+) http.Handler {
 	codec := amino.NewCodec()
 	mux := http.NewServeMux()
 	routes := map[string]*rpcserver.RPCFunc{}
@@ -226,6 +247,13 @@ func MakeUnsafeQueryServiceHandler(logger log.TMLogger) http.Handler {
 	routes["unsafe_stop_cpu_profiler"] = rpcserver.NewRPCFunc(rpccore.UnsafeStopCPUProfiler, "")
 	routes["unsafe_write_heap_profile"] = rpcserver.NewRPCFunc(rpccore.UnsafeWriteHeapProfile, "filename")
 
+	if fnVotes != nil {
+		routes["fn_votes"] = rpcserver.NewRPCFunc(fnVotes.VoteHistory, "fnID,limit")
+	}
+	if pluginHealth != nil {
+		routes["plugin_health"] = rpcserver.NewRPCFunc(pluginHealth.PluginHealth, "")
+	}
+
 	rpcserver.RegisterRPCFuncs(mux, routes, codec, logger)
 	return mux
 }
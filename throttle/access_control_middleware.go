@@ -0,0 +1,54 @@
+package throttle
+
+import (
+	"github.com/gogo/protobuf/proto"
+	"github.com/loomnetwork/go-loom/plugin/contractpb"
+	"github.com/loomnetwork/loomchain"
+	"github.com/loomnetwork/loomchain/auth"
+	ac "github.com/loomnetwork/loomchain/builtin/plugins/access_control"
+	"github.com/loomnetwork/loomchain/features"
+	"github.com/pkg/errors"
+)
+
+// NewAccessControlMiddleware creates a middleware function that rejects txs from accounts that are
+// denied by the AccessControl contract, or that aren't in its allow list while allowlist-only mode
+// is enabled.
+func NewAccessControlMiddleware(
+	createAccessControlCtx func(state loomchain.State) (contractpb.StaticContext, error),
+) loomchain.TxMiddlewareFunc {
+	return loomchain.TxMiddlewareFunc(func(
+		state loomchain.State,
+		txBytes []byte,
+		next loomchain.TxHandlerFunc,
+		isCheckTx bool,
+	) (res loomchain.TxHandlerResult, err error) {
+		if !state.FeatureEnabled(features.AccessControlFeature, false) {
+			return next(state, txBytes, isCheckTx)
+		}
+
+		var nonceTx auth.NonceTx
+		if err := proto.Unmarshal(txBytes, &nonceTx); err != nil {
+			return res, errors.Wrap(err, "throttle: unwrap nonce Tx")
+		}
+		var tx loomchain.Transaction
+		if err := proto.Unmarshal(nonceTx.Inner, &tx); err != nil {
+			return res, errors.New("throttle: unmarshal tx")
+		}
+
+		contractAddr, _, err := getTxTarget(&tx)
+		if err != nil {
+			return res, err
+		}
+
+		ctx, err := createAccessControlCtx(state)
+		if err != nil {
+			return res, errors.Wrap(err, "throttle: context creation")
+		}
+
+		if err := ac.CheckAccess(ctx, auth.Origin(state.Context()), contractAddr); err != nil {
+			return res, err
+		}
+
+		return next(state, txBytes, isCheckTx)
+	})
+}
@@ -0,0 +1,82 @@
+// +build evm
+
+package throttle
+
+import (
+	"context"
+	"testing"
+
+	"github.com/loomnetwork/go-loom"
+	goloomplugin "github.com/loomnetwork/go-loom/plugin"
+	"github.com/loomnetwork/go-loom/plugin/contractpb"
+	"github.com/loomnetwork/go-loom/types"
+	"github.com/loomnetwork/loomchain"
+	"github.com/loomnetwork/loomchain/auth"
+	ac "github.com/loomnetwork/loomchain/builtin/plugins/access_control"
+	"github.com/loomnetwork/loomchain/features"
+	"github.com/loomnetwork/loomchain/store"
+	"github.com/loomnetwork/loomchain/vm"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/tendermint/abci/types"
+)
+
+func TestAccessControlMiddleware(t *testing.T) {
+	acOwner := loom.MustParseAddress("chain:0xb16a379ec18d4093666f8f38b11a3071c920207d")
+	allowedAddr := loom.MustParseAddress("chain:0x5cecd1f7261e1f4c684e297be3edf03b825e01c4")
+	deniedAddr := loom.MustParseAddress("chain:0xfa4c7920accfd66b86f5fd0e69682a79f762d49e")
+	partlyDeniedAddr := loom.MustParseAddress("chain:0x5cecd1f7261e1f4c684e297be3edf03b825e01c5")
+	deniedContract := loom.MustParseAddress("chain:0x5cecd1f7261e1f4c684e297be3edf03b825e01ab")
+	otherContract := loom.MustParseAddress("chain:0x5cecd1f7261e1f4c684e297be3edf03b825e01ac")
+
+	fakeCtx := goloomplugin.CreateFakeContext(acOwner, acOwner)
+	acAddr := fakeCtx.CreateContract(ac.Contract)
+	acCtx := contractpb.WrapPluginContext(fakeCtx.WithAddress(acAddr))
+	acContract := &ac.AccessControl{}
+	require.NoError(t, acContract.Init(acCtx, &ac.InitRequest{
+		Owner: acOwner.MarshalPB(),
+		AllowedAccounts: []*ac.AllowedAccount{
+			{Account: allowedAddr.MarshalPB()},
+		},
+		DeniedAccounts: []*ac.DeniedAccount{
+			{Account: deniedAddr.MarshalPB()},
+			{Account: partlyDeniedAddr.MarshalPB(), Contracts: []*types.Address{deniedContract.MarshalPB()}},
+		},
+	}))
+
+	acMiddleware := NewAccessControlMiddleware(
+		func(state loomchain.State) (contractpb.StaticContext, error) {
+			return acCtx, nil
+		},
+	)
+	state := loomchain.NewStoreState(context.Background(), store.NewMemStore(), abci.Header{Height: 1}, nil, nil)
+	processTx := func(sender, to loom.Address) error {
+		ctx := context.WithValue(state.Context(), auth.ContextKeyOrigin, sender)
+		txBytes := mockSignedTx(t, uint64(1), types.TxID_CALL, vm.VMType_EVM, to).Inner
+		_, err := acMiddleware.ProcessTx(state.WithContext(ctx), txBytes,
+			func(state loomchain.State, txBytes []byte, isCheckTx bool) (loomchain.TxHandlerResult, error) {
+				return loomchain.TxHandlerResult{}, nil
+			}, false,
+		)
+		return errors.Cause(err)
+	}
+
+	// access lists aren't enforced until the feature is enabled
+	require.NoError(t, processTx(deniedAddr, otherContract))
+
+	state.SetFeature(features.AccessControlFeature, true)
+	require.NoError(t, processTx(allowedAddr, otherContract))
+	require.Equal(t, ac.ErrAccountDenied, processTx(deniedAddr, otherContract))
+	require.Equal(t, ac.ErrAccountDenied, processTx(partlyDeniedAddr, deniedContract))
+	require.NoError(t, processTx(partlyDeniedAddr, otherContract))
+
+	// only the allowed accounts & the owner can send txs in allowlist-only mode
+	require.NoError(t, acContract.SetAllowlistOnly(acCtx, &ac.SetAllowlistOnlyRequest{Enabled: true}))
+	require.NoError(t, processTx(allowedAddr, otherContract))
+	require.Equal(t, ac.ErrAccountNotAllowed, processTx(partlyDeniedAddr, otherContract))
+	require.NoError(t, processTx(acOwner, otherContract))
+
+	// the owner is exempt from the deny list too
+	require.NoError(t, acContract.DenyAccount(acCtx, &ac.DenyAccountRequest{Account: acOwner.MarshalPB()}))
+	require.NoError(t, processTx(acOwner, otherContract))
+}
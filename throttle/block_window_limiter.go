@@ -0,0 +1,161 @@
+package throttle
+
+import (
+	"context"
+	"encoding/binary"
+
+	"github.com/loomnetwork/go-loom/util"
+	"github.com/loomnetwork/loomchain"
+	"github.com/loomnetwork/loomchain/store"
+	"github.com/pkg/errors"
+	"github.com/ulule/limiter"
+)
+
+var (
+	blockWindowLimiterPrefix = []byte("throttle")
+	// Key under which each limiter stores the window its counters belong to
+	currentWindowKey = []byte("window")
+	// Prefix of the counters of each limiter
+	windowCountersPrefix = []byte("counters")
+)
+
+// blockWindowLimiter is a rate limiter that keeps its counters in the app state instead of memory,
+// and measures sessions in blocks instead of wall-clock time. Every node processing the same txs
+// at the same height reaches the same decision, and the counters survive node restarts.
+// Counters are only kept for the current window, they're deleted as soon as a new window starts.
+// Txs are checked against the limits by Check, and only counted once their nonce has been validated
+// by the middleware returned by NewBlockWindowRecorderMiddleware.
+type blockWindowLimiter struct {
+	name string
+}
+
+func newBlockWindowLimiter(name string) *blockWindowLimiter {
+	return &blockWindowLimiter{
+		name: name,
+	}
+}
+
+func (l *blockWindowLimiter) currentWindowKey() []byte {
+	return util.PrefixKey(blockWindowLimiterPrefix, []byte(l.name), currentWindowKey)
+}
+
+func (l *blockWindowLimiter) countersPrefix(window uint64) []byte {
+	windowBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(windowBytes, window)
+	return util.PrefixKey(blockWindowLimiterPrefix, []byte(l.name), windowCountersPrefix, windowBytes)
+}
+
+// startWindow deletes the counters of the previous window if the given window hasn't started yet.
+func (l *blockWindowLimiter) startWindow(kvStore store.KVStore, window uint64) {
+	prevWindow, ok := l.currentWindow(kvStore)
+	if ok {
+		if prevWindow == window {
+			return
+		}
+		prefix := l.countersPrefix(prevWindow)
+		for _, entry := range kvStore.Range(prefix) {
+			kvStore.Delete(util.PrefixKey(prefix, entry.Key))
+		}
+	}
+	windowBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(windowBytes, window)
+	kvStore.Set(l.currentWindowKey(), windowBytes)
+}
+
+func (l *blockWindowLimiter) currentWindow(kvStore store.KVReader) (uint64, bool) {
+	windowBytes := kvStore.Get(l.currentWindowKey())
+	if len(windowBytes) != 8 {
+		return 0, false
+	}
+	return binary.BigEndian.Uint64(windowBytes), true
+}
+
+func (l *blockWindowLimiter) count(kvStore store.KVReader, window uint64, key string) int64 {
+	if curWindow, ok := l.currentWindow(kvStore); !ok || curWindow != window {
+		return 0
+	}
+	counterBytes := kvStore.Get(util.PrefixKey(l.countersPrefix(window), []byte(key)))
+	if len(counterBytes) != 8 {
+		return 0
+	}
+	return int64(binary.BigEndian.Uint64(counterBytes))
+}
+
+// Check returns the limiter state the counter for the given key would be in if a tx was counted
+// within the window the given block height falls in, the counter itself isn't modified. The
+// returned blockWindowTx should be passed to withBlockWindowTx to count the tx.
+func (l *blockWindowLimiter) Check(
+	kvStore store.KVReader, height int64, blocksPerWindow int64, key string, limit int64,
+) (limiter.Context, blockWindowTx, error) {
+	if blocksPerWindow <= 0 {
+		return limiter.Context{}, blockWindowTx{}, errors.New("throttle: block window must be greater than zero")
+	}
+
+	window := uint64(height / blocksPerWindow)
+	count := l.count(kvStore, window, key) + 1
+	remaining := limit - count
+	if remaining < 0 {
+		remaining = 0
+	}
+	return limiter.Context{
+		Limit:     limit,
+		Remaining: remaining,
+		Reached:   count > limit,
+	}, blockWindowTx{limiter: l, window: window, key: key}, nil
+}
+
+// record increments the counter for the given key within the given window, the counters of the
+// previous window are deleted when a new window starts.
+func (l *blockWindowLimiter) record(kvStore store.KVStore, window uint64, key string) {
+	l.startWindow(kvStore, window)
+	counterBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(counterBytes, uint64(l.count(kvStore, window, key)+1))
+	kvStore.Set(util.PrefixKey(l.countersPrefix(window), []byte(key)), counterBytes)
+}
+
+// blockWindowTx identifies the counter a tx that passed a block window limit check should be
+// counted against.
+type blockWindowTx struct {
+	limiter *blockWindowLimiter
+	window  uint64
+	key     string
+}
+
+// Context key of the block window counters the current tx should be counted against.
+const contextKeyBlockWindowTxs = contextKey("blockwindowtxs")
+
+// withBlockWindowTx returns a copy of the given state that will count the current tx against the
+// given counter once it reaches the middleware returned by NewBlockWindowRecorderMiddleware.
+func withBlockWindowTx(state loomchain.State, tx blockWindowTx) loomchain.State {
+	txs, _ := state.Context().Value(contextKeyBlockWindowTxs).([]blockWindowTx)
+	txs = append(txs[:len(txs):len(txs)], tx)
+	return state.WithContext(context.WithValue(state.Context(), contextKeyBlockWindowTxs, txs))
+}
+
+// NewBlockWindowRecorderMiddleware returns middleware that counts each tx against the block window
+// sessions checked by the karma & tx limiter middlewares. It must be placed after the nonce
+// middleware, so that replayed txs can't use up the sessions of the accounts that signed them.
+// In DeliverTx the counters are written directly to the given store, so txs that fail still count
+// towards the limits, just like they do for the policies enforced by the rate-limit middleware.
+func NewBlockWindowRecorderMiddleware(kvStore store.KVStore) loomchain.TxMiddlewareFunc {
+	return loomchain.TxMiddlewareFunc(func(
+		state loomchain.State,
+		txBytes []byte,
+		next loomchain.TxHandlerFunc,
+		isCheckTx bool,
+	) (loomchain.TxHandlerResult, error) {
+		// CheckTx changes are always discarded, so there's no point writing them to the store.
+		txs, _ := state.Context().Value(contextKeyBlockWindowTxs).([]blockWindowTx)
+		if isCheckTx || len(txs) == 0 {
+			return next(state, txBytes, isCheckTx)
+		}
+
+		counterStoreTx := store.WrapAtomic(kvStore).BeginTx()
+		defer counterStoreTx.Rollback()
+		for _, tx := range txs {
+			tx.limiter.record(counterStoreTx, tx.window, tx.key)
+		}
+		counterStoreTx.Commit()
+		return next(state, txBytes, isCheckTx)
+	})
+}
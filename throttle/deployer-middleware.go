@@ -104,7 +104,8 @@ func NewDeployerWhitelistMiddleware(
 				return res, errors.Wrap(err, "failed to unmarshal DeployTx")
 			}
 
-			if deployTx.VmType == vm.VMType_PLUGIN {
+			// Wasm contracts require the same permission as Go contracts
+			if deployTx.VmType == vm.VMType_PLUGIN || deployTx.VmType == vm.VMType_WASM {
 				origin := auth.Origin(state.Context())
 				ctx, err := createDeployerWhitelistCtx(state)
 				if err != nil {
@@ -122,6 +123,8 @@ func NewDeployerWhitelistMiddleware(
 				if err := isAllowedToDeployEVM(ctx, origin); err != nil {
 					return res, err
 				}
+			} else {
+				return res, errors.Wrapf(vm.ErrUnknownVMType, "VM type %d", deployTx.VmType)
 			}
 
 		case types.TxID_MIGRATION:
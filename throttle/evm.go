@@ -15,3 +15,12 @@ func isEthDeploy(txBytes []byte) (bool, error) {
 	}
 	return tx.To() == nil, nil
 }
+
+// ethTxInput returns the input data of an Ethereum tx.
+func ethTxInput(txBytes []byte) ([]byte, error) {
+	var tx types.Transaction
+	if err := rlp.DecodeBytes(txBytes, &tx); err != nil {
+		return nil, errors.Wrap(err, "decoding ethereum transaction")
+	}
+	return tx.Data(), nil
+}
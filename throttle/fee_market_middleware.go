@@ -0,0 +1,174 @@
+package throttle
+
+import (
+	"context"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/loomnetwork/go-loom"
+	"github.com/loomnetwork/go-loom/plugin/contractpb"
+	"github.com/loomnetwork/loomchain"
+	"github.com/loomnetwork/loomchain/auth"
+	"github.com/loomnetwork/loomchain/builtin/plugins/coin"
+	"github.com/loomnetwork/loomchain/features"
+	"github.com/loomnetwork/loomchain/feemarket"
+	"github.com/loomnetwork/loomchain/store"
+	"github.com/pkg/errors"
+)
+
+type contextKey string
+
+// Context key of the base fee the current tx should be charged in DeliverTx.
+const contextKeyTxFee = contextKey("txfee")
+
+// NewFeeMarketMiddleware returns middleware that checks the max fee declared by each tx against the
+// current base fee. When the fee market is enabled every NonceTx must wrap a FeeTx, this middleware
+// strips the FeeTx so the middleware & handlers further down the chain only see the NonceTx &
+// Transaction. In CheckTx the sender's balance is checked against the base fee, in DeliverTx the
+// base fee is passed on to the middleware returned by NewFeeCollectorMiddleware, which charges the
+// sender once the tx nonce has been validated.
+func NewFeeMarketMiddleware(
+	createCoinCtx func(state loomchain.State) (contractpb.Context, error),
+) loomchain.TxMiddlewareFunc {
+	return loomchain.TxMiddlewareFunc(func(
+		state loomchain.State,
+		txBytes []byte,
+		next loomchain.TxHandlerFunc,
+		isCheckTx bool,
+	) (res loomchain.TxHandlerResult, err error) {
+		if !state.FeatureEnabled(features.FeeMarketFeature, false) {
+			return next(state, txBytes, isCheckTx)
+		}
+
+		var nonceTx auth.NonceTx
+		if err := proto.Unmarshal(txBytes, &nonceTx); err != nil {
+			return res, errors.Wrap(err, "failed to unmarshal NonceTx")
+		}
+
+		innerTxBytes, maxFee, err := feemarket.UnwrapFeeTx(nonceTx.Inner)
+		if err != nil {
+			return res, err
+		}
+
+		cfg, err := feemarket.LoadConfig(state)
+		if err != nil {
+			return res, errors.Wrap(err, "failed to load fee market config")
+		}
+
+		// In CheckTx the state reflects the last committed block, while in DeliverTx it reflects the
+		// block being executed, either way the tx will be included in the block after that.
+		height := state.Block().Height
+		if isCheckTx {
+			height++
+		}
+		baseFee, err := feemarket.CurrentBaseFee(state, cfg, height)
+		if err != nil {
+			return res, errors.Wrap(err, "failed to compute base fee")
+		}
+
+		if maxFee.Cmp(baseFee) < 0 {
+			return res, errors.Wrapf(
+				feemarket.ErrMaxFeeTooLow, "max fee %s, base fee %s", maxFee.String(), baseFee.String(),
+			)
+		}
+
+		if isCheckTx {
+			origin := auth.Origin(state.Context())
+			coinCtx, err := createCoinCtx(state)
+			if err != nil {
+				return res, errors.Wrap(err, "failed to create Coin contract context")
+			}
+			balance, err := coin.BalanceOfAddress(coinCtx, origin)
+			if err != nil {
+				return res, err
+			}
+			if balance.Cmp(baseFee) < 0 {
+				return res, errors.Errorf(
+					"[FeeMarket] %s can't afford the base fee %s", origin.String(), baseFee.String(),
+				)
+			}
+		} else {
+			state = state.WithContext(context.WithValue(state.Context(), contextKeyTxFee, baseFee))
+		}
+
+		nonceTx.Inner = innerTxBytes
+		nonceTxBytes, err := proto.Marshal(&nonceTx)
+		if err != nil {
+			return res, errors.Wrap(err, "failed to marshal NonceTx")
+		}
+		return next(state, nonceTxBytes, isCheckTx)
+	})
+}
+
+// NewFeeCollectorMiddleware returns middleware that charges the sender of each tx the base fee
+// determined by the middleware returned by NewFeeMarketMiddleware. It must be placed after the
+// nonce middleware, so that replayed txs are rejected before the sender is charged. The fee is
+// collected, and the tx recorded in the block usage, by writing directly to the given store, so
+// these changes are persisted even if the tx fails.
+func NewFeeCollectorMiddleware(
+	kvStore store.KVStore,
+	createCoinCtx func(state loomchain.State) (contractpb.Context, error),
+) loomchain.TxMiddlewareFunc {
+	return loomchain.TxMiddlewareFunc(func(
+		state loomchain.State,
+		txBytes []byte,
+		next loomchain.TxHandlerFunc,
+		isCheckTx bool,
+	) (res loomchain.TxHandlerResult, err error) {
+		if isCheckTx || !state.FeatureEnabled(features.FeeMarketFeature, false) {
+			return next(state, txBytes, isCheckTx)
+		}
+
+		baseFee, ok := state.Context().Value(contextKeyTxFee).(*loom.BigUInt)
+		if !ok {
+			return res, errors.New("[FeeMarket] tx fee hasn't been determined")
+		}
+		cfg, err := feemarket.LoadConfig(state)
+		if err != nil {
+			return res, errors.Wrap(err, "failed to load fee market config")
+		}
+		if err := collectTxFee(kvStore, state, createCoinCtx, cfg, state.Block().Height, baseFee); err != nil {
+			return res, err
+		}
+		return next(state, txBytes, isCheckTx)
+	})
+}
+
+// collectTxFee charges the tx sender the base fee, and records the tx in the block usage that
+// determines the base fee of the next block. Unlike the rest of the changes made by the tx these
+// changes are written directly to the given store, so they persist even if the tx fails.
+func collectTxFee(
+	kvStore store.KVStore,
+	state loomchain.State,
+	createCoinCtx func(state loomchain.State) (contractpb.Context, error),
+	cfg *feemarket.Config,
+	height int64,
+	baseFee *loom.BigUInt,
+) error {
+	feeStoreTx := store.WrapAtomic(kvStore).BeginTx()
+	defer feeStoreTx.Rollback()
+
+	feeState := state.WithStore(feeStoreTx)
+	coinCtx, err := createCoinCtx(feeState)
+	if err != nil {
+		return errors.Wrap(err, "failed to create Coin contract context")
+	}
+	validators := make([]loom.Address, 0, len(state.Validators()))
+	for _, v := range state.Validators() {
+		validators = append(validators, loom.Address{
+			ChainID: state.Block().ChainID,
+			Local:   loom.LocalAddressFromPublicKey(v.PubKey),
+		})
+	}
+	origin := auth.Origin(state.Context())
+	if err := coin.CollectTxFee(coinCtx, origin, baseFee, validators, cfg.BurnPercentage); err != nil {
+		return errors.Wrap(err, "[FeeMarket] failed to collect tx fee")
+	}
+	if _, err := feemarket.RecordTx(feeState, cfg, height); err != nil {
+		return errors.Wrap(err, "[FeeMarket] failed to record tx")
+	}
+	// The nonce may not be incremented when a tx fails, in which case the tx could be replayed to
+	// charge the sender again, so the nonce that was consumed by the tx is persisted with the fee.
+	auth.SetNonce(feeStoreTx, origin, auth.Nonce(state, origin))
+	feeStoreTx.Commit()
+	return nil
+}
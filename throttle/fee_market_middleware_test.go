@@ -0,0 +1,135 @@
+package throttle
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/loomnetwork/go-loom"
+	"github.com/loomnetwork/go-loom/plugin/contractpb"
+	"github.com/loomnetwork/go-loom/types"
+	"github.com/loomnetwork/loomchain"
+	"github.com/loomnetwork/loomchain/auth"
+	"github.com/loomnetwork/loomchain/builtin/plugins/coin"
+	"github.com/loomnetwork/loomchain/features"
+	"github.com/loomnetwork/loomchain/feemarket"
+	"github.com/loomnetwork/loomchain/plugin"
+	registry "github.com/loomnetwork/loomchain/registry/factory"
+	"github.com/loomnetwork/loomchain/store"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/tendermint/abci/types"
+)
+
+func TestFeeMarketMiddleware(t *testing.T) {
+	sender := loom.MustParseAddress("chain:0xb16a379ec18d4093666f8f38b11a3071c920207d")
+	pauper := loom.MustParseAddress("chain:0x5cecd1f7261e1f4c684e297be3edf03b825e01c4")
+	coinAddr := loom.MustParseAddress("chain:0xfa4c7920accfd66b86f5fd0e69682a79f762d49e")
+	kvStore := store.NewMemStore()
+
+	createRegistry, err := registry.NewRegistryFactory(registry.LatestRegistryVersion)
+	require.NoError(t, err)
+	createCoinCtx := func(state loomchain.State) (contractpb.Context, error) {
+		pvm := plugin.NewPluginVM(nil, state, createRegistry(state), nil, nil, nil, nil, nil)
+		return plugin.NewInternalContractContext("coin", pvm, false)
+	}
+
+	state := loomchain.NewStoreState(context.Background(), kvStore, abci.Header{Height: 1}, nil, nil)
+	state.SetFeature(features.FeeMarketFeature, true)
+	require.NoError(t, feemarket.ChangeConfigSetting(kvStore, "FeeMarket.MinBaseFee", "1000"))
+	require.NoError(t, createRegistry(state).Register("coin", coinAddr, coinAddr))
+	ctx, err := createCoinCtx(state)
+	require.NoError(t, err)
+	require.NoError(t, (&coin.Coin{}).Init(ctx, &coin.InitRequest{
+		Accounts: []*coin.InitialAccount{
+			{Owner: sender.MarshalPB(), Balance: 1},
+		},
+	}))
+	initialBalance, err := coin.BalanceOfAddress(ctx, sender)
+	require.NoError(t, err)
+	balanceOf := func(addr loom.Address) *loom.BigUInt {
+		ctx, err := createCoinCtx(loomchain.NewStoreState(context.Background(), kvStore, abci.Header{}, nil, nil))
+		require.NoError(t, err)
+		balance, err := coin.BalanceOfAddress(ctx, addr)
+		require.NoError(t, err)
+		return balance
+	}
+
+	makeTx := func(seq uint64, maxFee int64) []byte {
+		tx := &types.Transaction{Id: uint32(types.TxID_CALL), Data: []byte{1, 2, 3}}
+		var innerTx proto.Message = tx
+		if maxFee >= 0 {
+			feeTx, err := feemarket.NewFeeTx(tx, loom.NewBigUIntFromInt(maxFee))
+			require.NoError(t, err)
+			innerTx = feeTx
+		}
+		innerTxBytes, err := proto.Marshal(innerTx)
+		require.NoError(t, err)
+		txBytes, err := proto.Marshal(&auth.NonceTx{Inner: innerTxBytes, Sequence: seq})
+		require.NoError(t, err)
+		return txBytes
+	}
+
+	fmm := NewFeeMarketMiddleware(createCoinCtx)
+	nonceMiddleware := auth.NewNonceHandler().TxMiddleware(kvStore)
+	fcm := NewFeeCollectorMiddleware(kvStore, createCoinCtx)
+	errTxFailed := errors.New("tx failed")
+	processTx := func(origin loom.Address, txBytes []byte, isCheckTx bool, txErr error) error {
+		// Like the app, only commit the changes made by the tx if it succeeds.
+		storeTx := store.WrapAtomic(kvStore).BeginTx()
+		defer storeTx.Rollback()
+		state := loomchain.NewStoreState(context.Background(), storeTx, abci.Header{Height: 10}, nil, nil)
+		ctx := context.WithValue(state.Context(), auth.ContextKeyOrigin, origin)
+		handler := func(state loomchain.State, txBytes []byte, isCheckTx bool) (loomchain.TxHandlerResult, error) {
+			return loomchain.TxHandlerResult{}, txErr
+		}
+		_, err := fmm.ProcessTx(state.WithContext(ctx), txBytes,
+			func(state loomchain.State, txBytes []byte, isCheckTx bool) (loomchain.TxHandlerResult, error) {
+				return nonceMiddleware.ProcessTx(state, txBytes,
+					func(state loomchain.State, txBytes []byte, isCheckTx bool) (loomchain.TxHandlerResult, error) {
+						return fcm.ProcessTx(state, txBytes, handler, isCheckTx)
+					}, isCheckTx)
+			}, isCheckTx)
+		if err == nil && !isCheckTx {
+			storeTx.Commit()
+		}
+		return err
+	}
+	requireCharged := func(numTxs int64) {
+		expected := loom.NewBigUIntFromInt(0)
+		expected.Sub(initialBalance, loom.NewBigUIntFromInt(numTxs*1000))
+		require.Equal(t, expected.String(), balanceOf(sender).String())
+	}
+
+	// tx that pays the base fee is charged
+	require.NoError(t, processTx(sender, makeTx(1, 1500), true, nil))
+	requireCharged(0)
+	require.NoError(t, processTx(sender, makeTx(1, 1500), false, nil))
+	requireCharged(1)
+
+	// tx that doesn't pay the base fee is rejected & isn't charged
+	require.Equal(t, feemarket.ErrMaxFeeTooLow, errors.Cause(processTx(sender, makeTx(2, 999), false, nil)))
+	requireCharged(1)
+
+	// tx without a fee is rejected
+	require.Error(t, processTx(sender, makeTx(2, -1), false, nil))
+	requireCharged(1)
+
+	// tx from an account that can't afford the base fee is rejected
+	require.Error(t, processTx(pauper, makeTx(1, 1000), true, nil))
+	require.Equal(t, coin.ErrSenderBalanceTooLow, errors.Cause(processTx(pauper, makeTx(1, 1000), false, nil)))
+	pauperState := loomchain.NewStoreState(context.Background(), kvStore, abci.Header{}, nil, nil)
+	require.Equal(t, uint64(0), auth.Nonce(pauperState, pauper))
+
+	// replayed tx is rejected by the nonce check before the sender is charged
+	require.Error(t, processTx(sender, makeTx(1, 1500), false, nil))
+	requireCharged(1)
+
+	// failed tx is still charged, and can't be replayed to charge the sender again
+	require.Equal(t, errTxFailed, processTx(sender, makeTx(2, 1000), false, errTxFailed))
+	requireCharged(2)
+	require.Error(t, processTx(sender, makeTx(2, 1000), false, nil))
+	requireCharged(2)
+	require.NoError(t, processTx(sender, makeTx(3, 1000), false, nil))
+	requireCharged(3)
+}
@@ -12,14 +12,21 @@ import (
 	"github.com/loomnetwork/go-loom/types"
 	"github.com/loomnetwork/loomchain"
 	"github.com/loomnetwork/loomchain/auth"
+	"github.com/loomnetwork/loomchain/blockwindow"
 	"github.com/loomnetwork/loomchain/builtin/plugins/karma"
 	"github.com/loomnetwork/loomchain/eth/utils"
+	"github.com/loomnetwork/loomchain/features"
 	"github.com/loomnetwork/loomchain/vm"
 	"github.com/pkg/errors"
 )
 
 const karmaMiddlewareThrottleKey = "ThrottleTxMiddleWare"
 
+// GetKarmaMiddleWare returns middleware that limits the number of txs each account can send per
+// session based on its karma. When karmaEnabled is false the middleware does nothing, unless the
+// throttle:block-window feature is enabled and a karma session length has been set in the on-chain
+// config. Block window sessions are stored in the app state, so they're enforced by every node
+// regardless of its local config.
 func GetKarmaMiddleWare(
 	karmaEnabled bool,
 	maxCallCount int64,
@@ -33,7 +40,15 @@ func GetKarmaMiddleWare(
 		next loomchain.TxHandlerFunc,
 		isCheckTx bool,
 	) (res loomchain.TxHandlerResult, err error) {
-		if !karmaEnabled {
+		// Block window sessions & limits are stored on-chain so every node applies the same ones
+		var bwCfg *blockwindow.Config
+		if state.FeatureEnabled(features.ThrottleBlockWindowFeature, false) {
+			if bwCfg, err = blockwindow.LoadConfig(state); err != nil {
+				return res, errors.Wrap(err, "failed to load block window config")
+			}
+		}
+		blockWindowEnabled := bwCfg != nil && bwCfg.KarmaSessionDurationBlocks > 0
+		if !karmaEnabled && !blockWindowEnabled {
 			return next(state, txBytes, isCheckTx)
 		}
 
@@ -152,14 +167,22 @@ func GetKarmaMiddleWare(
 				return res, fmt.Errorf("not enough karma %v to depoy, required %v", originKarmaTotal, config.MinKarmaToDeploy)
 			}
 		} else {
-			if maxCallCount <= 0 {
-				return res, errors.Errorf("max call count %d non positive", maxCallCount)
+			maxCalls := th.maxCallCount
+			var sessionDurationBlocks int64
+			if blockWindowEnabled {
+				maxCalls = bwCfg.KarmaMaxCallCount
+				sessionDurationBlocks = bwCfg.KarmaSessionDurationBlocks
+			}
+			if maxCalls <= 0 {
+				return res, errors.Errorf("max call count %d non positive", maxCalls)
 			}
-			callCount := th.maxCallCount + originKarmaTotal
-			if originKarmaTotal > math.MaxInt64-th.maxCallCount {
+			callCount := maxCalls + originKarmaTotal
+			if originKarmaTotal > math.MaxInt64-maxCalls {
 				callCount = math.MaxInt64
 			}
-			err := th.runThrottle(state, nonceTx.Sequence, origin, callCount, tx.Id, karmaMiddlewareThrottleKey)
+			state, err = th.runThrottle(
+				state, nonceTx.Sequence, origin, callCount, tx.Id, karmaMiddlewareThrottleKey, sessionDurationBlocks,
+			)
 			if err != nil {
 				return res, errors.Wrap(err, "call karma throttle")
 			}
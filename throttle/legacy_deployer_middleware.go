@@ -41,7 +41,8 @@ func GetGoDeployTxMiddleWare(allowedDeployers []loom.Address) loomchain.TxMiddle
 			return res, errors.Wrapf(err, "unmarshal call tx %v", msg.Data)
 		}
 
-		if deployTx.VmType == vm.VMType_PLUGIN {
+		// Wasm contracts require the same permission as Go contracts
+		if deployTx.VmType == vm.VMType_PLUGIN || deployTx.VmType == vm.VMType_WASM {
 			origin := auth.Origin(state.Context())
 			for _, allowed := range allowedDeployers {
 				if 0 == origin.Compare(allowed) {
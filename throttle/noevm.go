@@ -9,3 +9,7 @@ import (
 func isEthDeploy(_ []byte) (bool, error) {
 	return false, errors.New("ethereum transactions not supported in non evm build")
 }
+
+func ethTxInput(_ []byte) ([]byte, error) {
+	return nil, errors.New("ethereum transactions not supported in non evm build")
+}
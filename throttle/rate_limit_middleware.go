@@ -0,0 +1,136 @@
+package throttle
+
+import (
+	"encoding/hex"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/loomnetwork/go-loom"
+	"github.com/loomnetwork/go-loom/plugin"
+	"github.com/loomnetwork/go-loom/plugin/contractpb"
+	ltypes "github.com/loomnetwork/go-loom/types"
+	"github.com/loomnetwork/loomchain"
+	"github.com/loomnetwork/loomchain/auth"
+	"github.com/loomnetwork/loomchain/builtin/plugins/ratelimit"
+	"github.com/loomnetwork/loomchain/features"
+	"github.com/loomnetwork/loomchain/store"
+	"github.com/loomnetwork/loomchain/vm"
+	"github.com/pkg/errors"
+)
+
+// NewRateLimitMiddleware creates a middleware function that enforces the rate-limit policies
+// stored in the RateLimit contract. Tx counts are stored in the contract and windows are based on
+// block height, so unlike the TxLimiter & ContractTxLimiter middlewares this middleware is
+// applied in both CheckTx & DeliverTx. In DeliverTx the counters are written directly to the given
+// store, so txs that fail still count towards the limits. This middleware must be placed after the
+// nonce middleware, so that replayed txs can't use up the limits of the accounts that signed them,
+// which means it receives the Transaction rather than the NonceTx.
+func NewRateLimitMiddleware(
+	kvStore store.KVStore,
+	createRateLimitCtx func(state loomchain.State) (contractpb.Context, error),
+) loomchain.TxMiddlewareFunc {
+	return loomchain.TxMiddlewareFunc(func(
+		state loomchain.State,
+		txBytes []byte,
+		next loomchain.TxHandlerFunc,
+		isCheckTx bool,
+	) (res loomchain.TxHandlerResult, err error) {
+		if !state.FeatureEnabled(features.RateLimitFeature, false) {
+			return next(state, txBytes, isCheckTx)
+		}
+
+		var tx loomchain.Transaction
+		if err := proto.Unmarshal(txBytes, &tx); err != nil {
+			return res, errors.New("throttle: unmarshal tx")
+		}
+
+		contractAddr, method, err := getTxTarget(&tx)
+		if err != nil {
+			return res, err
+		}
+
+		// CheckTx changes are always discarded, so there's no point writing them to the store.
+		counterState := state
+		var counterStoreTx store.KVStoreTx
+		if !isCheckTx {
+			counterStoreTx = store.WrapAtomic(kvStore).BeginTx()
+			defer counterStoreTx.Rollback()
+			counterState = state.WithStore(counterStoreTx)
+		}
+
+		ctx, err := createRateLimitCtx(counterState)
+		if err != nil {
+			return res, errors.Wrap(err, "throttle: context creation")
+		}
+
+		origin := auth.Origin(state.Context())
+		if err := ratelimit.RecordTx(ctx, origin, contractAddr, method, state.Block().Height); err != nil {
+			return res, err
+		}
+		if counterStoreTx != nil {
+			counterStoreTx.Commit()
+		}
+
+		return next(state, txBytes, isCheckTx)
+	})
+}
+
+// getTxTarget returns the address of the contract called by the given tx, and the name of the
+// method that's called. Go contract methods are identified by name, EVM contract methods are
+// identified by the hex-encoded 4-byte method selector. If the tx doesn't call a contract the
+// returned address will be nil.
+func getTxTarget(tx *loomchain.Transaction) (*loom.Address, string, error) {
+	var msg vm.MessageTx
+	switch ltypes.TxID(tx.Id) {
+	case ltypes.TxID_CALL:
+		if err := proto.Unmarshal(tx.Data, &msg); err != nil {
+			return nil, "", errors.Wrapf(err, "unmarshal message tx %v", tx.Data)
+		}
+		var callTx vm.CallTx
+		if err := proto.Unmarshal(msg.Data, &callTx); err != nil {
+			return nil, "", errors.Wrapf(err, "unmarshal call tx %v", msg.Data)
+		}
+		contractAddr := loom.UnmarshalAddressPB(msg.To)
+
+		switch callTx.VmType {
+		case vm.VMType_PLUGIN:
+			var req plugin.Request
+			if err := proto.Unmarshal(callTx.Input, &req); err != nil {
+				return nil, "", errors.Wrap(err, "unmarshal Request")
+			}
+			var methodCall plugin.ContractMethodCall
+			if err := proto.Unmarshal(req.Body, &methodCall); err != nil {
+				return nil, "", errors.Wrap(err, "unmarshal ContractMethodCall")
+			}
+			return &contractAddr, methodCall.Method, nil
+		case vm.VMType_EVM:
+			return &contractAddr, evmMethodSelector(callTx.Input), nil
+		case vm.VMType_WASM:
+			// Wasm contracts are invoked via a single entry point, so calls don't name a method
+			return &contractAddr, "", nil
+		default:
+			return nil, "", errors.Wrapf(vm.ErrUnknownVMType, "VM type %d", callTx.VmType)
+		}
+
+	case ltypes.TxID_ETHEREUM:
+		if err := proto.Unmarshal(tx.Data, &msg); err != nil {
+			return nil, "", errors.Wrapf(err, "unmarshal message tx %v", tx.Data)
+		}
+		if msg.To == nil {
+			return nil, "", nil
+		}
+		input, err := ethTxInput(msg.Data)
+		if err != nil {
+			return nil, "", err
+		}
+		contractAddr := loom.UnmarshalAddressPB(msg.To)
+		return &contractAddr, evmMethodSelector(input), nil
+	}
+	return nil, "", nil
+}
+
+func evmMethodSelector(input []byte) string {
+	if len(input) < 4 {
+		return ""
+	}
+	return "0x" + hex.EncodeToString(input[:4])
+}
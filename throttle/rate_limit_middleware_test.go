@@ -0,0 +1,85 @@
+package throttle
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/loomnetwork/go-loom"
+	"github.com/loomnetwork/go-loom/plugin/contractpb"
+	"github.com/loomnetwork/go-loom/types"
+	"github.com/loomnetwork/loomchain"
+	"github.com/loomnetwork/loomchain/auth"
+	"github.com/loomnetwork/loomchain/builtin/plugins/ratelimit"
+	"github.com/loomnetwork/loomchain/features"
+	"github.com/loomnetwork/loomchain/plugin"
+	registry "github.com/loomnetwork/loomchain/registry/factory"
+	"github.com/loomnetwork/loomchain/store"
+	"github.com/loomnetwork/loomchain/vm"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/tendermint/abci/types"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	sender := loom.MustParseAddress("chain:0xb16a379ec18d4093666f8f38b11a3071c920207d")
+	rateLimitAddr := loom.MustParseAddress("chain:0x5cecd1f7261e1f4c684e297be3edf03b825e01c4")
+	evmContractAddr := loom.MustParseAddress("chain:0xfa4c7920accfd66b86f5fd0e69682a79f762d49e")
+	kvStore := store.NewMemStore()
+
+	createRegistry, err := registry.NewRegistryFactory(registry.LatestRegistryVersion)
+	require.NoError(t, err)
+	createRateLimitCtx := func(state loomchain.State) (contractpb.Context, error) {
+		pvm := plugin.NewPluginVM(nil, state, createRegistry(state), nil, nil, nil, nil, nil)
+		return plugin.NewInternalContractContext("ratelimit", pvm, false)
+	}
+
+	state := loomchain.NewStoreState(context.Background(), kvStore, abci.Header{Height: 1}, nil, nil)
+	state.SetFeature(features.RateLimitFeature, true)
+	require.NoError(t, createRegistry(state).Register("ratelimit", rateLimitAddr, rateLimitAddr))
+	ctx, err := createRateLimitCtx(state)
+	require.NoError(t, err)
+	require.NoError(t, (&ratelimit.RateLimit{}).Init(ctx, &ratelimit.InitRequest{
+		Owner: sender.MarshalPB(),
+		Policies: []*ratelimit.Policy{
+			{MaxTxs: 2, BlockWindow: 10},
+		},
+	}))
+
+	callTx, err := proto.Marshal(&vm.CallTx{VmType: vm.VMType_EVM, Input: []byte{1, 2, 3, 4}})
+	require.NoError(t, err)
+	messageTx, err := proto.Marshal(&vm.MessageTx{Data: callTx, To: evmContractAddr.MarshalPB()})
+	require.NoError(t, err)
+	txBytes, err := proto.Marshal(&loomchain.Transaction{Id: uint32(types.TxID_CALL), Data: messageTx})
+	require.NoError(t, err)
+
+	rlm := NewRateLimitMiddleware(kvStore, createRateLimitCtx)
+	errTxFailed := errors.New("tx failed")
+	processTx := func(height int64, isCheckTx bool, txErr error) error {
+		// Like the app, only commit the changes made by the tx if it succeeds.
+		storeTx := store.WrapAtomic(kvStore).BeginTx()
+		defer storeTx.Rollback()
+		state := loomchain.NewStoreState(context.Background(), storeTx, abci.Header{Height: height}, nil, nil)
+		ctx := context.WithValue(state.Context(), auth.ContextKeyOrigin, sender)
+		next := func(state loomchain.State, txBytes []byte, isCheckTx bool) (loomchain.TxHandlerResult, error) {
+			return loomchain.TxHandlerResult{}, txErr
+		}
+		_, err := rlm.ProcessTx(state.WithContext(ctx), txBytes, next, isCheckTx)
+		if err == nil && !isCheckTx {
+			storeTx.Commit()
+		}
+		return err
+	}
+
+	require.NoError(t, processTx(10, false, nil))
+	require.Equal(t, errTxFailed, processTx(11, false, errTxFailed))
+	// The failed tx should still count towards the limit
+	require.Equal(t, ratelimit.ErrRateLimitExceeded, errors.Cause(processTx(12, true, nil)))
+	require.Equal(t, ratelimit.ErrRateLimitExceeded, errors.Cause(processTx(12, false, nil)))
+
+	// Counters are reset in the next window
+	require.NoError(t, processTx(20, true, nil))
+	require.Equal(t, errTxFailed, processTx(20, false, errTxFailed))
+	require.NoError(t, processTx(21, false, nil))
+	require.Equal(t, ratelimit.ErrRateLimitExceeded, errors.Cause(processTx(22, false, nil)))
+}
@@ -25,6 +25,7 @@ type Throttle struct {
 	callLimiterPool      map[string]*limiter.Limiter
 	deployLimiterPool    map[string]*limiter.Limiter
 	karmaContractAddress loom.Address
+	blockLimiter         *blockWindowLimiter
 
 	lastAddress        string
 	lastLimiterContext limiter.Context
@@ -32,6 +33,9 @@ type Throttle struct {
 	lastId             uint32
 }
 
+// NewThrottle creates a Throttle that keeps its sessions in memory, unless the throttle:block-window
+// feature is enabled and a karma session length has been set in the on-chain config, in which
+// case sessions are measured in blocks and stored in the app state.
 func NewThrottle(
 	sessionDuration int64,
 	maxCallCount int64,
@@ -42,6 +46,7 @@ func NewThrottle(
 		callLimiterPool:      make(map[string]*limiter.Limiter),
 		deployLimiterPool:    make(map[string]*limiter.Limiter),
 		karmaContractAddress: loom.Address{},
+		blockLimiter:         newBlockWindowLimiter("karma"),
 	}
 }
 
@@ -84,12 +89,36 @@ func (t *Throttle) getLimiterContext(
 	}
 }
 
+// runThrottle counts the tx against the origin's session, if sessionDurationBlocks is greater than
+// zero sessions are measured in blocks and stored in the app state, in which case the tx is only
+// counted once it reaches the middleware returned by NewBlockWindowRecorderMiddleware, so the
+// returned state must be passed down the middleware chain.
 func (t *Throttle) runThrottle(
 	state loomchain.State, nonce uint64, origin loom.Address, limit int64, txId uint32, key string,
-) error {
+	sessionDurationBlocks int64,
+) (loomchain.State, error) {
+	if sessionDurationBlocks > 0 {
+		limitCtx, bwTx, err := t.blockLimiter.Check(
+			state, state.Block().Height, sessionDurationBlocks, origin.String()+":"+key, limit,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "block window limiter context")
+		}
+		if limitCtx.Reached {
+			return nil, fmt.Errorf(
+				"Out of transactions of id %v, for current session: %d out of %d; Try after %v blocks!",
+				txId,
+				limitCtx.Limit-limitCtx.Remaining,
+				limitCtx.Limit,
+				sessionDurationBlocks,
+			)
+		}
+		return withBlockWindowTx(state, bwTx), nil
+	}
+
 	limitCtx, err := t.getLimiterContext(state.Context(), nonce, limit, txId, key)
 	if err != nil {
-		return errors.Wrap(err, "deploy limiter context")
+		return nil, errors.Wrap(err, "deploy limiter context")
 	}
 
 	if limitCtx.Reached {
@@ -100,9 +129,9 @@ func (t *Throttle) runThrottle(
 			limitCtx.Limit,
 			t.sessionDuration,
 		)
-		return errors.New(message)
+		return nil, errors.New(message)
 	}
-	return nil
+	return state, nil
 }
 
 func (t *Throttle) getKarmaForTransaction(
@@ -7,6 +7,8 @@ import (
 	"github.com/loomnetwork/go-loom"
 	"github.com/loomnetwork/loomchain"
 	"github.com/loomnetwork/loomchain/auth"
+	"github.com/loomnetwork/loomchain/blockwindow"
+	"github.com/loomnetwork/loomchain/features"
 	"github.com/pkg/errors"
 	"github.com/ulule/limiter"
 	"github.com/ulule/limiter/drivers/store/memory"
@@ -39,6 +41,7 @@ func (c *TxLimiterConfig) Clone() *TxLimiterConfig {
 
 type txLimiter struct {
 	*limiter.Limiter
+	blockLimiter *blockWindowLimiter
 }
 
 func newTxLimiter(cfg *TxLimiterConfig) *txLimiter {
@@ -50,6 +53,7 @@ func newTxLimiter(cfg *TxLimiterConfig) *txLimiter {
 				Limit:  cfg.MaxTxsPerSession,
 			},
 		),
+		blockLimiter: newBlockWindowLimiter("txlimiter"),
 	}
 }
 
@@ -67,6 +71,12 @@ func (txl *txLimiter) isAccountLimitReached(account loom.Address) bool {
 // can be configured in loom.yml. Since this middleware only runs in CheckTx the rate limit can
 // differ between nodes on the same cluster, and private nodes don't really need to run the rate
 // limiter at all.
+//
+// Once the throttle:block-window feature is enabled, and a session length has been set in the
+// on-chain config, the middleware runs in both CheckTx and DeliverTx on every node (even if it's
+// disabled in loom.yml), sessions are measured in blocks, the tx counts are stored in the app
+// state, and the limit from the on-chain config is used instead of the one in loom.yml. Txs are
+// counted by the middleware returned by NewBlockWindowRecorderMiddleware.
 func NewTxLimiterMiddleware(cfg *TxLimiterConfig) loomchain.TxMiddlewareFunc {
 	txl := newTxLimiter(cfg)
 	return loomchain.TxMiddlewareFunc(func(
@@ -75,7 +85,15 @@ func NewTxLimiterMiddleware(cfg *TxLimiterConfig) loomchain.TxMiddlewareFunc {
 		next loomchain.TxHandlerFunc,
 		isCheckTx bool,
 	) (loomchain.TxHandlerResult, error) {
-		if !isCheckTx {
+		var bwCfg *blockwindow.Config
+		if state.FeatureEnabled(features.ThrottleBlockWindowFeature, false) {
+			var err error
+			if bwCfg, err = blockwindow.LoadConfig(state); err != nil {
+				return loomchain.TxHandlerResult{}, errors.Wrap(err, "failed to load block window config")
+			}
+		}
+		blockWindowEnabled := bwCfg != nil && bwCfg.TxLimiterSessionDurationBlocks > 0
+		if !blockWindowEnabled && (!isCheckTx || !cfg.Enabled) {
 			return next(state, txBytes, isCheckTx)
 		}
 
@@ -84,7 +102,19 @@ func NewTxLimiterMiddleware(cfg *TxLimiterConfig) loomchain.TxMiddlewareFunc {
 			return loomchain.TxHandlerResult{}, errors.New("throttle: transaction has no origin [get-karma]")
 		}
 
-		if txl.isAccountLimitReached(origin) {
+		if blockWindowEnabled {
+			lmtCtx, bwTx, err := txl.blockLimiter.Check(
+				state, state.Block().Height, bwCfg.TxLimiterSessionDurationBlocks, origin.String(),
+				bwCfg.TxLimiterMaxTxsPerSession,
+			)
+			if err != nil {
+				return loomchain.TxHandlerResult{}, err
+			}
+			if lmtCtx.Reached {
+				return loomchain.TxHandlerResult{}, ErrTxLimitReached
+			}
+			state = withBlockWindowTx(state, bwTx)
+		} else if txl.isAccountLimitReached(origin) {
 			return loomchain.TxHandlerResult{}, errors.New("tx limit reached, try again later")
 		}
 
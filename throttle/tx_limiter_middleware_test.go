@@ -0,0 +1,92 @@
+package throttle
+
+import (
+	"context"
+	"testing"
+
+	"github.com/loomnetwork/go-loom"
+	"github.com/loomnetwork/loomchain"
+	"github.com/loomnetwork/loomchain/auth"
+	"github.com/loomnetwork/loomchain/blockwindow"
+	"github.com/loomnetwork/loomchain/features"
+	"github.com/loomnetwork/loomchain/store"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/tendermint/abci/types"
+)
+
+func TestTxLimiterBlockWindow(t *testing.T) {
+	sender1 := loom.MustParseAddress("chain:0xb16a379ec18d4093666f8f38b11a3071c920207d")
+	sender2 := loom.MustParseAddress("chain:0x5cecd1f7261e1f4c684e297be3edf03b825e01c4")
+	kvStore := store.NewMemStore()
+	require.NoError(t, blockwindow.SaveConfig(kvStore, &blockwindow.Config{
+		TxLimiterSessionDurationBlocks: 10,
+		TxLimiterMaxTxsPerSession:      2,
+	}))
+
+	state := loomchain.NewStoreState(context.Background(), kvStore, abci.Header{}, nil, nil)
+	state.SetFeature(features.ThrottleBlockWindowFeature, true)
+
+	// The limits in the local config should be ignored in favor of the on-chain ones, and the limits
+	// should be enforced even if the tx limiter is disabled in the local config.
+	localCfg := &TxLimiterConfig{
+		Enabled:          false,
+		SessionDuration:  60,
+		MaxTxsPerSession: 100,
+	}
+	txl := NewTxLimiterMiddleware(localCfg)
+	recorder := NewBlockWindowRecorderMiddleware(kvStore)
+	errTxFailed := errors.New("tx failed")
+	processFailingTx := func(height int64, sender loom.Address, isCheckTx bool, txErr error) error {
+		// Like the app, only commit the changes made by the tx if it succeeds.
+		storeTx := store.WrapAtomic(kvStore).BeginTx()
+		defer storeTx.Rollback()
+		state := loomchain.NewStoreState(context.Background(), storeTx, abci.Header{Height: height}, nil, nil)
+		ctx := context.WithValue(state.Context(), auth.ContextKeyOrigin, sender)
+		next := func(state loomchain.State, txBytes []byte, isCheckTx bool) (loomchain.TxHandlerResult, error) {
+			return loomchain.TxHandlerResult{}, txErr
+		}
+		_, err := txl.ProcessTx(state.WithContext(ctx), nil,
+			func(state loomchain.State, txBytes []byte, isCheckTx bool) (loomchain.TxHandlerResult, error) {
+				return recorder.ProcessTx(state, txBytes, next, isCheckTx)
+			}, isCheckTx)
+		if err == nil && !isCheckTx {
+			storeTx.Commit()
+		}
+		return err
+	}
+	processTx := func(height int64, sender loom.Address, isCheckTx bool) error {
+		return processFailingTx(height, sender, isCheckTx, nil)
+	}
+	countersPrefix := func(window uint64) []byte {
+		return newBlockWindowLimiter("txlimiter").countersPrefix(window)
+	}
+
+	// Txs are only counted in DeliverTx, and failed txs still count towards the limit
+	require.NoError(t, processTx(10, sender1, true))
+	require.NoError(t, processTx(10, sender1, true))
+	require.NoError(t, processTx(10, sender1, false))
+	require.Equal(t, errTxFailed, processFailingTx(11, sender1, false, errTxFailed))
+	require.Equal(t, ErrTxLimitReached, processTx(12, sender1, true))
+	require.Equal(t, ErrTxLimitReached, processTx(12, sender1, false))
+	require.NoError(t, processTx(12, sender2, false))
+	require.Len(t, kvStore.Range(countersPrefix(1)), 2)
+
+	// Counters are stored in the app state so a new limiter (e.g. after a node restart) picks up
+	// where the previous one left off.
+	txl = NewTxLimiterMiddleware(localCfg)
+	require.Equal(t, ErrTxLimitReached, processTx(19, sender1, true))
+
+	// Counters are reset in the next window, and the counters of the previous window are pruned
+	require.NoError(t, processTx(20, sender1, false))
+	require.Len(t, kvStore.Range(countersPrefix(1)), 0)
+	require.Len(t, kvStore.Range(countersPrefix(2)), 1)
+
+	// The session length can be changed on-chain
+	require.NoError(t, blockwindow.ChangeConfigSetting(
+		kvStore, blockwindow.SettingPrefix+"TxLimiterSessionDurationBlocks", "100",
+	))
+	require.NoError(t, processTx(21, sender1, false))
+	require.NoError(t, processTx(22, sender1, false))
+	require.Equal(t, ErrTxLimitReached, processTx(99, sender1, false))
+}
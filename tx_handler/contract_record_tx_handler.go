@@ -0,0 +1,110 @@
+package tx_handler
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	loom "github.com/loomnetwork/go-loom"
+	"github.com/loomnetwork/loomchain"
+	"github.com/loomnetwork/loomchain/features"
+	regcommon "github.com/loomnetwork/loomchain/registry"
+	registry "github.com/loomnetwork/loomchain/registry/factory"
+)
+
+const (
+	// TransferOwnershipTxID is the ID of the tx that wraps TransferOwnershipTx.
+	TransferOwnershipTxID uint32 = 6
+	// SetContractMetadataTxID is the ID of the tx that wraps SetContractMetadataTx.
+	SetContractMetadataTxID uint32 = 7
+)
+
+var (
+	// ErrNotContractRecordOwner indicates that the sender of a TransferOwnershipTx or
+	// SetContractMetadataTx doesn't own the contract.
+	ErrNotContractRecordOwner = errors.New("[ContractRecordTxHandler] sender is not the contract owner")
+)
+
+// TransferOwnershipTxHandler handles TransferOwnershipTx(s).
+type TransferOwnershipTxHandler struct {
+	CreateRegistry registry.RegistryFactoryFunc
+}
+
+func (h *TransferOwnershipTxHandler) ProcessTx(
+	state loomchain.State,
+	txBytes []byte,
+	isCheckTx bool,
+) (loomchain.TxHandlerResult, error) {
+	var r loomchain.TxHandlerResult
+
+	if !state.FeatureEnabled(features.ContractRecordTxFeature, false) {
+		return r, fmt.Errorf("TransferOwnershipTx feature hasn't been enabled")
+	}
+
+	var tx regcommon.TransferOwnershipTx
+	caller, err := unmarshalMessageTx(state, txBytes, &tx)
+	if err != nil {
+		return r, err
+	}
+	if tx.Contract == nil || tx.NewOwner == nil {
+		return r, errors.New("contract & new owner must be specified")
+	}
+
+	reg := h.CreateRegistry(state)
+	contractAddr := loom.UnmarshalAddressPB(tx.Contract)
+	if err := checkContractRecordOwner(reg, contractAddr, caller); err != nil {
+		return r, err
+	}
+	if err := reg.TransferOwnership(contractAddr, loom.UnmarshalAddressPB(tx.NewOwner)); err != nil {
+		return r, errors.Wrapf(err, "failed to transfer ownership of contract %s", contractAddr.String())
+	}
+	return r, nil
+}
+
+// SetContractMetadataTxHandler handles SetContractMetadataTx(s).
+type SetContractMetadataTxHandler struct {
+	CreateRegistry registry.RegistryFactoryFunc
+}
+
+func (h *SetContractMetadataTxHandler) ProcessTx(
+	state loomchain.State,
+	txBytes []byte,
+	isCheckTx bool,
+) (loomchain.TxHandlerResult, error) {
+	var r loomchain.TxHandlerResult
+
+	if !state.FeatureEnabled(features.ContractRecordTxFeature, false) {
+		return r, fmt.Errorf("SetContractMetadataTx feature hasn't been enabled")
+	}
+
+	var tx regcommon.SetContractMetadataTx
+	caller, err := unmarshalMessageTx(state, txBytes, &tx)
+	if err != nil {
+		return r, err
+	}
+	if tx.Contract == nil {
+		return r, errors.New("contract must be specified")
+	}
+
+	reg := h.CreateRegistry(state)
+	contractAddr := loom.UnmarshalAddressPB(tx.Contract)
+	if err := checkContractRecordOwner(reg, contractAddr, caller); err != nil {
+		return r, err
+	}
+	if err := reg.SetMetadata(contractAddr, tx.Metadata); err != nil {
+		return r, errors.Wrapf(err, "failed to set metadata of contract %s", contractAddr.String())
+	}
+	return r, nil
+}
+
+// Returns ErrNotContractRecordOwner if the given contract isn't owned by the given account.
+func checkContractRecordOwner(reg regcommon.Registry, contractAddr, caller loom.Address) error {
+	record, err := reg.GetRecord(contractAddr)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load record of contract %s", contractAddr.String())
+	}
+	if loom.UnmarshalAddressPB(record.Owner).Compare(caller) != 0 {
+		return ErrNotContractRecordOwner
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package tx_handler
+
+import (
+	"context"
+	"crypto/sha256"
+	"testing"
+
+	proto "github.com/gogo/protobuf/proto"
+	loom "github.com/loomnetwork/go-loom"
+	"github.com/loomnetwork/go-loom/vm"
+	"github.com/loomnetwork/loomchain"
+	"github.com/loomnetwork/loomchain/auth"
+	"github.com/loomnetwork/loomchain/features"
+	regcommon "github.com/loomnetwork/loomchain/registry"
+	registry "github.com/loomnetwork/loomchain/registry/factory"
+	"github.com/loomnetwork/loomchain/store"
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/tendermint/abci/types"
+)
+
+func TestContractRecordTxHandlers(t *testing.T) {
+	owner := loom.MustParseAddress("chain:0x5cecd1f7261e1f4c684e297be3edf03b825e01c4")
+	newOwner := loom.MustParseAddress("chain:0xb16a379ec18d4093666f8f38b11a3071c920207d")
+	contractAddr := loom.MustParseAddress("chain:0xfa4c7920accfd66b86f5fd0e69682a79f762d49e")
+	state := loomchain.NewStoreState(context.Background(), store.NewMemStore(), abci.Header{}, nil, nil)
+
+	createRegistry, err := registry.NewRegistryFactory(registry.LatestRegistryVersion)
+	require.NoError(t, err)
+	require.NoError(t, createRegistry(state).Register("", contractAddr, owner))
+
+	transferHandler := &TransferOwnershipTxHandler{CreateRegistry: createRegistry}
+	metadataHandler := &SetContractMetadataTxHandler{CreateRegistry: createRegistry}
+	send := func(handler loomchain.TxHandler, sender loom.Address, tx proto.Message) error {
+		s := state.WithContext(context.WithValue(state.Context(), auth.ContextKeyOrigin, sender))
+		_, err := handler.ProcessTx(s, mockContractRecordTx(t, sender, tx), false)
+		return err
+	}
+	transfer := func(sender, to loom.Address) error {
+		return send(transferHandler, sender, &regcommon.TransferOwnershipTx{
+			Contract: contractAddr.MarshalPB(),
+			NewOwner: to.MarshalPB(),
+		})
+	}
+	setMetadata := func(sender loom.Address, metadata *regcommon.ContractMetadata) error {
+		return send(metadataHandler, sender, &regcommon.SetContractMetadataTx{
+			Contract: contractAddr.MarshalPB(),
+			Metadata: metadata,
+		})
+	}
+	sourceHash := sha256.Sum256([]byte("contract source"))
+	metadata := &regcommon.ContractMetadata{
+		Abi:         `[{"type":"function","name":"foo","inputs":[],"outputs":[]}]`,
+		SourceUrl:   "https://github.com/loomnetwork/mycontract",
+		SourceHash:  sourceHash[:],
+		Description: "My contract",
+	}
+
+	// Expect an error if the feature is not enabled
+	require.Error(t, transfer(owner, newOwner))
+	require.Error(t, setMetadata(owner, metadata))
+
+	state.SetFeature(features.ContractRecordTxFeature, true)
+	require.Equal(t, ErrNotContractRecordOwner, transfer(newOwner, newOwner))
+	require.Equal(t, ErrNotContractRecordOwner, setMetadata(newOwner, metadata))
+	require.Error(t, setMetadata(owner, &regcommon.ContractMetadata{SourceHash: []byte{1, 2, 3}}))
+
+	require.NoError(t, setMetadata(owner, metadata))
+	require.NoError(t, transfer(owner, newOwner))
+	// Only the new owner should be able to modify the record now
+	require.Equal(t, ErrNotContractRecordOwner, setMetadata(owner, nil))
+	require.Equal(t, ErrNotContractRecordOwner, transfer(owner, owner))
+
+	record, err := createRegistry(state).GetRecord(contractAddr)
+	require.NoError(t, err)
+	require.Equal(t, newOwner, loom.UnmarshalAddressPB(record.Owner))
+	require.True(t, proto.Equal(metadata, record.Metadata))
+
+	require.NoError(t, setMetadata(newOwner, nil))
+	record, err = createRegistry(state).GetRecord(contractAddr)
+	require.NoError(t, err)
+	require.Nil(t, record.Metadata)
+}
+
+func mockContractRecordTx(t *testing.T, from loom.Address, tx proto.Message) []byte {
+	txBytes, err := proto.Marshal(tx)
+	require.NoError(t, err)
+
+	messageTx, err := proto.Marshal(&vm.MessageTx{
+		Data: txBytes,
+		From: from.MarshalPB(),
+	})
+	require.NoError(t, err)
+	return messageTx
+}
@@ -0,0 +1,33 @@
+package tx_handler
+
+import (
+	"fmt"
+
+	proto "github.com/gogo/protobuf/proto"
+	"github.com/pkg/errors"
+
+	loom "github.com/loomnetwork/go-loom"
+	"github.com/loomnetwork/loomchain"
+	"github.com/loomnetwork/loomchain/auth"
+	"github.com/loomnetwork/loomchain/vm"
+)
+
+// Unmarshals the given MessageTx & the tx it wraps, and returns the sender of the tx, which must
+// match the origin of the tx.
+func unmarshalMessageTx(state loomchain.State, txBytes []byte, tx proto.Message) (loom.Address, error) {
+	var msg vm.MessageTx
+	if err := proto.Unmarshal(txBytes, &msg); err != nil {
+		return loom.Address{}, err
+	}
+
+	origin := auth.Origin(state.Context())
+	caller := loom.UnmarshalAddressPB(msg.From)
+	if caller.Compare(origin) != 0 {
+		return loom.Address{}, fmt.Errorf("Origin doesn't match caller: - %v != %v", origin, caller)
+	}
+
+	if err := proto.Unmarshal(msg.Data, tx); err != nil {
+		return loom.Address{}, errors.Wrapf(err, "failed to unmarshal %s", proto.MessageName(tx))
+	}
+	return caller, nil
+}
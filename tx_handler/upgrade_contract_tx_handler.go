@@ -0,0 +1,91 @@
+package tx_handler
+
+import (
+	"fmt"
+
+	proto "github.com/gogo/protobuf/proto"
+	"github.com/pkg/errors"
+
+	loom "github.com/loomnetwork/go-loom"
+	"github.com/loomnetwork/loomchain"
+	"github.com/loomnetwork/loomchain/features"
+	"github.com/loomnetwork/loomchain/plugin"
+	regcommon "github.com/loomnetwork/loomchain/registry"
+	registry "github.com/loomnetwork/loomchain/registry/factory"
+)
+
+// UpgradeContractTxID is the ID of the tx that wraps UpgradeContractTx.
+const UpgradeContractTxID uint32 = 5
+
+var (
+	// ErrNotContractOwner indicates that the sender of an UpgradeContractTx doesn't own the contract.
+	ErrNotContractOwner = errors.New("[UpgradeContractTxHandler] sender is not the contract owner")
+)
+
+// UpgradeContractTxHandler handles UpgradeContractTx(s).
+type UpgradeContractTxHandler struct {
+	CreateRegistry registry.RegistryFactoryFunc
+	// Loader is used to check that the plugin a contract is upgraded to can be loaded by this node,
+	// and matches the plugin hash recorded on-chain (if any).
+	Loader plugin.Loader
+}
+
+func (h *UpgradeContractTxHandler) ProcessTx(
+	state loomchain.State,
+	txBytes []byte,
+	isCheckTx bool,
+) (loomchain.TxHandlerResult, error) {
+	var r loomchain.TxHandlerResult
+
+	if !state.FeatureEnabled(features.ContractUpgradeTxFeature, false) {
+		return r, fmt.Errorf("UpgradeContractTx feature hasn't been enabled")
+	}
+
+	var tx regcommon.UpgradeContractTx
+	caller, err := unmarshalMessageTx(state, txBytes, &tx)
+	if err != nil {
+		return r, err
+	}
+
+	reg := h.CreateRegistry(state)
+	contractAddr, err := reg.Resolve(tx.ContractName)
+	if err != nil {
+		return r, errors.Wrapf(err, "failed to resolve contract %s", tx.ContractName)
+	}
+	record, err := reg.GetRecord(contractAddr)
+	if err != nil {
+		return r, errors.Wrapf(err, "failed to load record of contract %s", tx.ContractName)
+	}
+	if loom.UnmarshalAddressPB(record.Owner).Compare(caller) != 0 {
+		return r, ErrNotContractOwner
+	}
+
+	// Only Go contracts can be upgraded, and only to another version of the same plugin
+	var code plugin.PluginCode
+	if err := proto.Unmarshal(state.Get(loom.TextKey(contractAddr)), &code); err != nil {
+		return r, errors.Wrapf(err, "contract %s is not a Go contract", tx.ContractName)
+	}
+	currentMeta, err := plugin.ParseMeta(code.Name)
+	if err != nil {
+		return r, errors.Wrapf(err, "contract %s is not a Go contract", tx.ContractName)
+	}
+	newMeta, err := plugin.ParseMeta(tx.PluginName)
+	if err != nil {
+		return r, errors.Wrap(err, "invalid plugin name")
+	}
+	if newMeta.Name != currentMeta.Name {
+		return r, fmt.Errorf("contract %s can't be upgraded to plugin %s", tx.ContractName, newMeta.Name)
+	}
+	if _, err := plugin.LoadContract(h.Loader, state, tx.PluginName); err != nil {
+		return r, errors.Wrapf(err, "failed to load plugin %s", tx.PluginName)
+	}
+
+	err = reg.UpgradeContract(tx.ContractName, &regcommon.ContractVersion{
+		PluginName: tx.PluginName,
+		Height:     tx.Height,
+	})
+	if err != nil {
+		return r, errors.Wrapf(err, "failed to upgrade contract %s", tx.ContractName)
+	}
+	return r, nil
+}
@@ -0,0 +1,95 @@
+package tx_handler
+
+import (
+	"context"
+	"testing"
+
+	proto "github.com/gogo/protobuf/proto"
+	loom "github.com/loomnetwork/go-loom"
+	lp "github.com/loomnetwork/go-loom/plugin"
+	"github.com/loomnetwork/go-loom/plugin/contractpb"
+	"github.com/loomnetwork/go-loom/vm"
+	"github.com/loomnetwork/loomchain"
+	"github.com/loomnetwork/loomchain/auth"
+	"github.com/loomnetwork/loomchain/features"
+	"github.com/loomnetwork/loomchain/plugin"
+	regcommon "github.com/loomnetwork/loomchain/registry"
+	registry "github.com/loomnetwork/loomchain/registry/factory"
+	"github.com/loomnetwork/loomchain/store"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/tendermint/abci/types"
+)
+
+func TestUpgradeContractTxHandler(t *testing.T) {
+	owner := loom.MustParseAddress("chain:0x5cecd1f7261e1f4c684e297be3edf03b825e01c4")
+	other := loom.MustParseAddress("chain:0xb16a379ec18d4093666f8f38b11a3071c920207d")
+	contractAddr := loom.MustParseAddress("chain:0xfa4c7920accfd66b86f5fd0e69682a79f762d49e")
+	state := loomchain.NewStoreState(context.Background(), store.NewMemStore(), abci.Header{Height: 10}, nil, nil)
+
+	createRegistry, err := registry.NewRegistryFactory(registry.LatestRegistryVersion)
+	require.NoError(t, err)
+	require.NoError(t, createRegistry(state).Register("mycontract", contractAddr, owner))
+	code, err := proto.Marshal(&plugin.PluginCode{Name: "mycontract:1.0.0"})
+	require.NoError(t, err)
+	state.Set(loom.TextKey(contractAddr), code)
+
+	loader := plugin.NewStaticLoader(
+		contractpb.MakePluginContract(&mockUpgradeContract{version: "1.1.0"}),
+		contractpb.MakePluginContract(&mockUpgradeContract{version: "1.2.0"}),
+	)
+	handler := &UpgradeContractTxHandler{CreateRegistry: createRegistry, Loader: loader}
+	upgrade := func(sender loom.Address, contractName, pluginName string, height int64) error {
+		s := state.WithContext(context.WithValue(state.Context(), auth.ContextKeyOrigin, sender))
+		_, err := handler.ProcessTx(s, mockUpgradeContractTx(t, sender, contractName, pluginName, height), false)
+		return err
+	}
+
+	// Expect an error if the feature is not enabled
+	require.Error(t, upgrade(owner, "mycontract", "mycontract:1.1.0", 20))
+
+	state.SetFeature(features.ContractUpgradeTxFeature, true)
+	require.Equal(t, ErrNotContractOwner, upgrade(other, "mycontract", "mycontract:1.1.0", 20))
+	require.Error(t, upgrade(owner, "othercontract", "othercontract:1.1.0", 20))
+	require.Error(t, upgrade(owner, "mycontract", "othercontract:1.1.0", 20))
+	require.Error(t, upgrade(owner, "mycontract", "mycontract", 20))
+	require.Error(t, upgrade(owner, "mycontract", "mycontract:1.1.0", 10))
+	// plugin must be loadable by the node
+	require.Error(t, upgrade(owner, "mycontract", "mycontract:1.3.0", 20))
+	// plugin must match the hash recorded on-chain, which the static loader can't verify
+	require.NoError(t, plugin.SetPluginHash(state, "mycontract:1.2.0", make([]byte, 32)))
+	require.Equal(t, plugin.ErrPluginHashMismatch, errors.Cause(upgrade(owner, "mycontract", "mycontract:1.2.0", 20)))
+	require.NoError(t, upgrade(owner, "mycontract", "mycontract:1.1.0", 20))
+
+	record, err := createRegistry(state).GetRecord(contractAddr)
+	require.NoError(t, err)
+	require.Equal(t, []*regcommon.ContractVersion{
+		{PluginName: "mycontract:1.1.0", Height: 20},
+	}, record.Versions)
+}
+
+type mockUpgradeContract struct {
+	version string
+}
+
+func (c *mockUpgradeContract) Meta() (lp.Meta, error) {
+	return lp.Meta{Name: "mycontract", Version: c.version}, nil
+}
+
+func mockUpgradeContractTx(
+	t *testing.T, from loom.Address, contractName, pluginName string, height int64,
+) []byte {
+	upgradeTx, err := proto.Marshal(&regcommon.UpgradeContractTx{
+		ContractName: contractName,
+		PluginName:   pluginName,
+		Height:       height,
+	})
+	require.NoError(t, err)
+
+	messageTx, err := proto.Marshal(&vm.MessageTx{
+		Data: upgradeTx,
+		From: from.MarshalPB(),
+	})
+	require.NoError(t, err)
+	return messageTx
+}
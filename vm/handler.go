@@ -12,6 +12,7 @@ import (
 	"github.com/loomnetwork/loomchain/auth"
 	"github.com/loomnetwork/loomchain/eth/utils"
 	"github.com/loomnetwork/loomchain/features"
+	"github.com/loomnetwork/loomchain/gasmeter"
 	registry "github.com/loomnetwork/loomchain/registry/factory"
 )
 
@@ -52,6 +53,11 @@ func (h *DeployTxHandler) ProcessTx(
 		return r, errors.New("named evm contracts are not allowed")
 	}
 
+	state, gasMeter, err := withGasMeter(state, tx.VmType)
+	if err != nil {
+		return r, err
+	}
+
 	vm, err := h.Manager.InitVM(tx.VmType, state)
 	if err != nil {
 		return r, err
@@ -65,6 +71,12 @@ func (h *DeployTxHandler) ProcessTx(
 	}
 
 	retCreate, addr, errCreate := vm.Create(origin, tx.Code, value)
+	if gasMeter != nil {
+		r.GasUsed = gasMeter.GasUsed()
+		if gasMeter.IsOutOfGas() {
+			errCreate = gasmeter.ErrOutOfGas
+		}
+	}
 
 	response, errMarshal := proto.Marshal(&DeployResponse{
 		Contract: &types.Address{
@@ -90,9 +102,11 @@ func (h *DeployTxHandler) ProcessTx(
 		return r, err
 	}
 
-	if tx.VmType == VMType_EVM {
+	// Unknown VM types have already been rejected by InitVM
+	switch tx.VmType {
+	case VMType_EVM:
 		r.Info = utils.DeployEvm
-	} else {
+	case VMType_PLUGIN, VMType_WASM:
 		r.Info = utils.DeployPlugin
 	}
 	return r, nil
@@ -130,6 +144,11 @@ func (h *CallTxHandler) ProcessTx(
 		return r, err
 	}
 
+	state, gasMeter, err := withGasMeter(state, tx.VmType)
+	if err != nil {
+		return r, err
+	}
+
 	vm, err := h.Manager.InitVM(tx.VmType, state)
 	if err != nil {
 		return r, err
@@ -142,13 +161,41 @@ func (h *CallTxHandler) ProcessTx(
 		value = &tx.Value.Value
 	}
 	r.Data, err = vm.Call(origin, addr, tx.Input, value)
+	if gasMeter != nil {
+		r.GasUsed = gasMeter.GasUsed()
+		if gasMeter.IsOutOfGas() {
+			err = gasmeter.ErrOutOfGas
+		}
+	}
 	if err != nil {
 		return r, err
 	}
-	if tx.VmType == VMType_EVM {
+	// Unknown VM types have already been rejected by InitVM
+	switch tx.VmType {
+	case VMType_EVM:
 		r.Info = utils.CallEVM
-	} else {
+	case VMType_PLUGIN, VMType_WASM:
 		r.Info = utils.CallPlugin
 	}
 	return r, err
 }
+
+// Attaches a gas meter to the given state if gas metering is enabled for Go contracts, the
+// returned meter is nil if the tx shouldn't be metered. Wasm contracts are always metered.
+func withGasMeter(state loomchain.State, vmType VMType) (loomchain.State, *gasmeter.GasMeter, error) {
+	switch vmType {
+	case VMType_WASM:
+	case VMType_PLUGIN:
+		if !state.FeatureEnabled(features.GoContractGasMeteringFeature, false) {
+			return state, nil, nil
+		}
+	default:
+		return state, nil, nil
+	}
+	cfg, err := gasmeter.LoadConfig(state)
+	if err != nil {
+		return nil, nil, err
+	}
+	meter := gasmeter.NewGasMeter(cfg)
+	return state.WithContext(gasmeter.WithGasMeter(state.Context(), meter)), meter, nil
+}
@@ -1,6 +1,8 @@
 package vm
 
 import (
+	"errors"
+
 	lvm "github.com/loomnetwork/go-loom/vm"
 )
 
@@ -9,10 +11,21 @@ type VMType = lvm.VMType
 const (
 	VMType_PLUGIN VMType = lvm.VMType_PLUGIN
 	VMType_EVM    VMType = lvm.VMType_EVM
+	// VMType_WASM is only defined by loomchain, go-loom's VMType enum doesn't have a value for Wasm
+	// contracts, so VMType_WASM.String() returns "2", and VMType_value doesn't map a name to it.
+	// Proto3 preserves unknown enum values, so txs & queries that carry it pass through the go-loom
+	// types unchanged. Until the value is reserved in go-loom's enum nothing stops go-loom from
+	// assigning it to another VM, so go-loom must not be upgraded without checking the enum.
+	VMType_WASM VMType = 2
 )
 
+// VMType_value maps the names of the VM types defined by go-loom to their values, it doesn't
+// include VMType_WASM.
 var VMType_value = lvm.VMType_value
 
+// ErrUnknownVMType is returned when a tx or request refers to a VM type the node doesn't support.
+var ErrUnknownVMType = errors.New("unknown VM type")
+
 type MessageTx = lvm.MessageTx
 type DeployTx = lvm.DeployTx
 type MigrationTx = lvm.MigrationTx
@@ -1,8 +1,6 @@
 package vm
 
 import (
-	"errors"
-
 	loom "github.com/loomnetwork/go-loom"
 	"github.com/loomnetwork/loomchain"
 )
@@ -34,7 +32,7 @@ func (m *Manager) Register(typ VMType, fac Factory) {
 func (m *Manager) InitVM(typ VMType, state loomchain.State) (VM, error) {
 	fac, ok := m.vms[typ]
 	if !ok {
-		return nil, errors.New("vm type not found")
+		return nil, ErrUnknownVMType
 	}
 
 	return fac(state)
@@ -0,0 +1,320 @@
+package wasm
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/loomnetwork/go-loom"
+	"github.com/loomnetwork/go-loom/plugin/types"
+	"github.com/loomnetwork/loomchain"
+	"github.com/pkg/errors"
+)
+
+const (
+	// Name of the module Wasm contracts must import the host functions from
+	hostModule = "loom"
+	// Returned by host functions to indicate failure, -1 when interpreted as a signed i32
+	hostErr = math.MaxUint32
+	// Length of the local part of an address
+	addrLen = 20
+	// Max size of the result of a range, a larger result couldn't fit in the memory of a contract
+	maxRangeResultSize = maxMemoryPages * pageSize
+)
+
+var (
+	// ErrReadOnly is returned when a Wasm contract attempts to modify state in a static call.
+	ErrReadOnly = errors.New("contract state can't be modified in a static call")
+	// ErrAbort is returned when a Wasm contract calls abort.
+	ErrAbort = errors.New("contract aborted")
+	// ErrRangeTooLarge is returned when the result of a range exceeds maxRangeResultSize.
+	ErrRangeTooLarge = errors.New("range result too large")
+)
+
+// contractContext is the environment the host functions of a Wasm contract execute in, it
+// provides the same functionality Go contracts access via plugin.Context.
+type contractContext struct {
+	vm       *WasmVM
+	caller   loom.Address
+	address  loom.Address
+	state    loomchain.State
+	readOnly bool
+	input    []byte
+	output   []byte
+	// Variable length result of the most recent host function call, the contract copies it into
+	// its memory with read_result
+	result []byte
+}
+
+func fnType(params []valueType, results ...valueType) *funcType {
+	return &funcType{params: params, results: results}
+}
+
+func (c *contractContext) hostFuncs() map[string]*hostFunc {
+	i32, i64 := valueTypeI32, valueTypeI64
+	funcs := map[string]*hostFunc{
+		"input_len":    {fnType(nil, i32), c.inputLen},
+		"read_input":   {fnType([]valueType{i32}), c.readInput},
+		"set_output":   {fnType([]valueType{i32, i32}), c.setOutput},
+		"read_result":  {fnType([]valueType{i32}), c.readResult},
+		"abort":        {fnType([]valueType{i32, i32}), c.abort},
+		"get":          {fnType([]valueType{i32, i32}, i32), c.get},
+		"has":          {fnType([]valueType{i32, i32}, i32), c.has},
+		"set":          {fnType([]valueType{i32, i32, i32, i32}), c.set},
+		"delete":       {fnType([]valueType{i32, i32}), c.delete},
+		"range":        {fnType([]valueType{i32, i32}, i32), c.rangePrefix},
+		"emit":         {fnType([]valueType{i32, i32}), c.emit},
+		"call":         {fnType([]valueType{i32, i32, i32}, i32), c.call},
+		"static_call":  {fnType([]valueType{i32, i32, i32}, i32), c.staticCall},
+		"resolve":      {fnType([]valueType{i32, i32, i32}, i32), c.resolve},
+		"caller":       {fnType([]valueType{i32}), c.callerAddress},
+		"address":      {fnType([]valueType{i32}), c.contractAddress},
+		"block_height": {fnType(nil, i64), c.blockHeight},
+		"block_time":   {fnType(nil, i64), c.blockTime},
+	}
+	hostFuncs := make(map[string]*hostFunc, len(funcs))
+	for name, fn := range funcs {
+		hostFuncs[hostModule+"."+name] = fn
+	}
+	return hostFuncs
+}
+
+// Copies the given data into memory, charging for the copy the same as memory.copy does.
+func (c *contractContext) copyToMemory(inst *Instance, ptr uint64, data []byte) error {
+	inst.gas.ConsumeInstructions(len(data) / 8)
+	return inst.writeMemory(uint32(ptr), data)
+}
+
+func (c *contractContext) readMemory(inst *Instance, ptr, size uint64) ([]byte, error) {
+	inst.gas.ConsumeInstructions(int(uint32(size) / 8))
+	return inst.readMemory(uint32(ptr), uint32(size))
+}
+
+func (c *contractContext) readAddress(inst *Instance, ptr uint64) (loom.Address, error) {
+	local, err := c.readMemory(inst, ptr, addrLen)
+	if err != nil {
+		return loom.Address{}, err
+	}
+	return loom.Address{ChainID: c.address.ChainID, Local: local}, nil
+}
+
+// input_len() -> i32: returns the length of the input the contract was called with.
+func (c *contractContext) inputLen(inst *Instance, args []uint64) (uint64, error) {
+	return uint64(len(c.input)), nil
+}
+
+// read_input(ptr): copies the input the contract was called with into memory.
+func (c *contractContext) readInput(inst *Instance, args []uint64) (uint64, error) {
+	return 0, c.copyToMemory(inst, args[0], c.input)
+}
+
+// set_output(ptr, len): sets the data returned to the caller of the contract.
+func (c *contractContext) setOutput(inst *Instance, args []uint64) (uint64, error) {
+	output, err := c.readMemory(inst, args[0], args[1])
+	if err != nil {
+		return 0, err
+	}
+	c.output = output
+	return 0, nil
+}
+
+// read_result(ptr): copies the result of the last get, range, call, or static_call into memory.
+func (c *contractContext) readResult(inst *Instance, args []uint64) (uint64, error) {
+	return 0, c.copyToMemory(inst, args[0], c.result)
+}
+
+// abort(msg_ptr, msg_len): aborts execution of the contract with the given error message.
+func (c *contractContext) abort(inst *Instance, args []uint64) (uint64, error) {
+	msg, err := c.readMemory(inst, args[0], args[1])
+	if err != nil {
+		return 0, err
+	}
+	return 0, errors.Wrap(ErrAbort, string(msg))
+}
+
+// get(key_ptr, key_len) -> i32: looks up the value of the given key, returns the length of the
+// value, or -1 if the key doesn't exist.
+func (c *contractContext) get(inst *Instance, args []uint64) (uint64, error) {
+	key, err := c.readMemory(inst, args[0], args[1])
+	if err != nil {
+		return 0, err
+	}
+	c.result = nil
+	value := c.state.Get(key)
+	c.vm.gasMeter.ConsumeRead(len(value))
+	if value == nil {
+		return hostErr, nil
+	}
+	c.result = value
+	return uint64(len(value)), nil
+}
+
+// has(key_ptr, key_len) -> i32: returns 1 if the given key exists, 0 otherwise.
+func (c *contractContext) has(inst *Instance, args []uint64) (uint64, error) {
+	key, err := c.readMemory(inst, args[0], args[1])
+	if err != nil {
+		return 0, err
+	}
+	c.vm.gasMeter.ConsumeRead(0)
+	if c.state.Has(key) {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// set(key_ptr, key_len, value_ptr, value_len): stores the given key & value.
+func (c *contractContext) set(inst *Instance, args []uint64) (uint64, error) {
+	if c.readOnly {
+		return 0, ErrReadOnly
+	}
+	key, err := c.readMemory(inst, args[0], args[1])
+	if err != nil {
+		return 0, err
+	}
+	value, err := c.readMemory(inst, args[2], args[3])
+	if err != nil {
+		return 0, err
+	}
+	c.vm.gasMeter.ConsumeWrite(len(key), len(value))
+	c.state.Set(key, value)
+	return 0, nil
+}
+
+// delete(key_ptr, key_len): deletes the given key.
+func (c *contractContext) delete(inst *Instance, args []uint64) (uint64, error) {
+	if c.readOnly {
+		return 0, ErrReadOnly
+	}
+	key, err := c.readMemory(inst, args[0], args[1])
+	if err != nil {
+		return 0, err
+	}
+	c.vm.gasMeter.ConsumeDelete()
+	c.state.Delete(key)
+	return 0, nil
+}
+
+// range(prefix_ptr, prefix_len) -> i32: looks up all the entries with keys that start with the
+// given prefix, returns the length of the result. Each entry is encoded as a little-endian u32 key
+// length, the key, a little-endian u32 value length, and the value. Each entry is charged for as
+// it's encoded, so a contract that can't afford the whole range runs out of gas before the result
+// is built.
+func (c *contractContext) rangePrefix(inst *Instance, args []uint64) (uint64, error) {
+	prefix, err := c.readMemory(inst, args[0], args[1])
+	if err != nil {
+		return 0, err
+	}
+	c.vm.gasMeter.ConsumeRange(0)
+	entries := c.state.Range(prefix)
+	var result []byte
+	var size [4]byte
+	for _, entry := range entries {
+		entryLen := len(entry.Key) + len(entry.Value)
+		c.vm.gasMeter.ConsumeRangeEntry(entryLen)
+		if len(result)+entryLen+2*len(size) > maxRangeResultSize {
+			return 0, ErrRangeTooLarge
+		}
+		binary.LittleEndian.PutUint32(size[:], uint32(len(entry.Key)))
+		result = append(result, size[:]...)
+		result = append(result, entry.Key...)
+		binary.LittleEndian.PutUint32(size[:], uint32(len(entry.Value)))
+		result = append(result, size[:]...)
+		result = append(result, entry.Value...)
+	}
+	c.result = result
+	return uint64(len(result)), nil
+}
+
+// emit(ptr, len): emits an event with the given body, events emitted by static calls are ignored.
+func (c *contractContext) emit(inst *Instance, args []uint64) (uint64, error) {
+	body, err := c.readMemory(inst, args[0], args[1])
+	if err != nil {
+		return 0, err
+	}
+	if c.readOnly || c.vm.EventHandler == nil {
+		return 0, nil
+	}
+	c.vm.gasMeter.ConsumeEvent(len(body))
+	data := types.EventData{
+		Caller:          c.caller.MarshalPB(),
+		Address:         c.address.MarshalPB(),
+		EncodedBody:     body,
+		OriginalRequest: c.input,
+	}
+	height := uint64(c.state.Block().Height)
+	c.vm.EventHandler.Post(height, &data)
+	return 0, nil
+}
+
+// call(addr_ptr, input_ptr, input_len) -> i32: calls the Wasm contract at the given address,
+// returns the length of the output, or -1 if the call failed, in which case the result is the
+// error message. If the calling contract is executing in a static call the callee will be too.
+func (c *contractContext) call(inst *Instance, args []uint64) (uint64, error) {
+	return c.callContract(inst, args, c.readOnly)
+}
+
+// static_call(addr_ptr, input_ptr, input_len) -> i32: same as call, but the callee can't modify
+// state.
+func (c *contractContext) staticCall(inst *Instance, args []uint64) (uint64, error) {
+	return c.callContract(inst, args, true)
+}
+
+func (c *contractContext) callContract(inst *Instance, args []uint64, readOnly bool) (uint64, error) {
+	addr, err := c.readAddress(inst, args[0])
+	if err != nil {
+		return 0, err
+	}
+	input, err := c.readMemory(inst, args[1], args[2])
+	if err != nil {
+		return 0, err
+	}
+	c.vm.gasMeter.ConsumeCall()
+
+	var output []byte
+	if readOnly {
+		output, err = c.vm.StaticCall(c.address, addr, input)
+	} else {
+		output, err = c.vm.Call(c.address, addr, input, loom.NewBigUIntFromInt(0))
+	}
+	if err != nil {
+		c.result = []byte(err.Error())
+		return hostErr, nil
+	}
+	c.result = output
+	return uint64(len(output)), nil
+}
+
+// resolve(name_ptr, name_len, addr_ptr) -> i32: looks up the address of the contract registered
+// under the given name & writes it to memory, returns 0 on success, or -1 if there's no such
+// contract.
+func (c *contractContext) resolve(inst *Instance, args []uint64) (uint64, error) {
+	name, err := c.readMemory(inst, args[0], args[1])
+	if err != nil {
+		return 0, err
+	}
+	c.vm.gasMeter.ConsumeRead(0)
+	addr, err := c.vm.Registry.Resolve(string(name))
+	if err != nil {
+		return hostErr, nil
+	}
+	return 0, c.copyToMemory(inst, args[2], addr.Local)
+}
+
+// caller(ptr): writes the local address of the caller to memory.
+func (c *contractContext) callerAddress(inst *Instance, args []uint64) (uint64, error) {
+	return 0, c.copyToMemory(inst, args[0], c.caller.Local)
+}
+
+// address(ptr): writes the local address of the contract to memory.
+func (c *contractContext) contractAddress(inst *Instance, args []uint64) (uint64, error) {
+	return 0, c.copyToMemory(inst, args[0], c.address.Local)
+}
+
+// block_height() -> i64: returns the height of the current block.
+func (c *contractContext) blockHeight(inst *Instance, args []uint64) (uint64, error) {
+	return uint64(c.state.Block().Height), nil
+}
+
+// block_time() -> i64: returns the time of the current block in seconds since the Unix epoch.
+func (c *contractContext) blockTime(inst *Instance, args []uint64) (uint64, error) {
+	return uint64(c.state.Block().Time), nil
+}
@@ -0,0 +1,329 @@
+package wasm
+
+import (
+	"github.com/pkg/errors"
+)
+
+const (
+	opUnreachable  = 0x00
+	opNop          = 0x01
+	opBlock        = 0x02
+	opLoop         = 0x03
+	opIf           = 0x04
+	opElse         = 0x05
+	opEnd          = 0x0b
+	opBr           = 0x0c
+	opBrIf         = 0x0d
+	opBrTable      = 0x0e
+	opReturn       = 0x0f
+	opCall         = 0x10
+	opCallIndirect = 0x11
+	opDrop         = 0x1a
+	opSelect       = 0x1b
+	opLocalGet     = 0x20
+	opLocalSet     = 0x21
+	opLocalTee     = 0x22
+	opGlobalGet    = 0x23
+	opGlobalSet    = 0x24
+
+	opI32Load    = 0x28
+	opI64Load    = 0x29
+	opI32Load8S  = 0x2c
+	opI32Load8U  = 0x2d
+	opI32Load16S = 0x2e
+	opI32Load16U = 0x2f
+	opI64Load8S  = 0x30
+	opI64Load8U  = 0x31
+	opI64Load16S = 0x32
+	opI64Load16U = 0x33
+	opI64Load32S = 0x34
+	opI64Load32U = 0x35
+	opI32Store   = 0x36
+	opI64Store   = 0x37
+	opI32Store8  = 0x3a
+	opI32Store16 = 0x3b
+	opI64Store8  = 0x3c
+	opI64Store16 = 0x3d
+	opI64Store32 = 0x3e
+	opMemorySize = 0x3f
+	opMemoryGrow = 0x40
+
+	opI32Const = 0x41
+	opI64Const = 0x42
+
+	opI32Eqz = 0x45
+	opI32Eq  = 0x46
+	opI32Ne  = 0x47
+	opI32LtS = 0x48
+	opI32LtU = 0x49
+	opI32GtS = 0x4a
+	opI32GtU = 0x4b
+	opI32LeS = 0x4c
+	opI32LeU = 0x4d
+	opI32GeS = 0x4e
+	opI32GeU = 0x4f
+	opI64Eqz = 0x50
+	opI64Eq  = 0x51
+	opI64Ne  = 0x52
+	opI64LtS = 0x53
+	opI64LtU = 0x54
+	opI64GtS = 0x55
+	opI64GtU = 0x56
+	opI64LeS = 0x57
+	opI64LeU = 0x58
+	opI64GeS = 0x59
+	opI64GeU = 0x5a
+
+	opI32Clz    = 0x67
+	opI32Ctz    = 0x68
+	opI32Popcnt = 0x69
+	opI32Add    = 0x6a
+	opI32Sub    = 0x6b
+	opI32Mul    = 0x6c
+	opI32DivS   = 0x6d
+	opI32DivU   = 0x6e
+	opI32RemS   = 0x6f
+	opI32RemU   = 0x70
+	opI32And    = 0x71
+	opI32Or     = 0x72
+	opI32Xor    = 0x73
+	opI32Shl    = 0x74
+	opI32ShrS   = 0x75
+	opI32ShrU   = 0x76
+	opI32Rotl   = 0x77
+	opI32Rotr   = 0x78
+	opI64Clz    = 0x79
+	opI64Ctz    = 0x7a
+	opI64Popcnt = 0x7b
+	opI64Add    = 0x7c
+	opI64Sub    = 0x7d
+	opI64Mul    = 0x7e
+	opI64DivS   = 0x7f
+	opI64DivU   = 0x80
+	opI64RemS   = 0x81
+	opI64RemU   = 0x82
+	opI64And    = 0x83
+	opI64Or     = 0x84
+	opI64Xor    = 0x85
+	opI64Shl    = 0x86
+	opI64ShrS   = 0x87
+	opI64ShrU   = 0x88
+	opI64Rotl   = 0x89
+	opI64Rotr   = 0x8a
+
+	opI32WrapI64     = 0xa7
+	opI64ExtendI32S  = 0xac
+	opI64ExtendI32U  = 0xad
+	opI32Extend8S    = 0xc0
+	opI32Extend16S   = 0xc1
+	opI64Extend8S    = 0xc2
+	opI64Extend16S   = 0xc3
+	opI64Extend32S   = 0xc4
+	opPrefixMisc     = 0xfc
+	miscMemoryCopy   = 0x0a
+	miscMemoryFill   = 0x0b
+	opMemoryCopy     = opPrefixMisc<<8 | miscMemoryCopy
+	opMemoryFill     = opPrefixMisc<<8 | miscMemoryFill
+	blockTypeEmpty   = 0x40
+	maxBlockNesting  = 1024
+	maxBrTableLength = 65536
+)
+
+// instruction is a decoded instruction, the targets of all the branches are resolved when the
+// function body is compiled so they don't have to be looked up during execution.
+type instruction struct {
+	op uint16
+	// Immediate argument: constant value, local/global/function/type index, label depth, memory
+	// offset, or the arity of a block.
+	arg uint64
+	// Index of the matching end of a block, loop, if, or else
+	end int
+	// Index of the matching else of an if, or -1 if there is none
+	alt int
+	// Label depths of a br_table, the last entry is the default
+	table []uint32
+}
+
+// Decodes the given function body into a sequence of instructions, rejects unknown or
+// unsupported instructions & invalid indices.
+func compile(m *Module, fn *function, r *reader) ([]instruction, error) {
+	numLocals := uint64(len(fn.typ.params) + len(fn.locals))
+	var code []instruction
+	// Indices of the instructions that opened the blocks that haven't been closed yet
+	var blocks []int
+
+	for r.err == nil {
+		op := r.byte()
+		if r.err != nil {
+			break
+		}
+		in := instruction{op: uint16(op), alt: -1}
+		idx := len(code)
+
+		switch op {
+		case opUnreachable, opNop, opReturn, opDrop, opSelect:
+		case opBlock, opLoop, opIf:
+			arity, err := decodeBlockType(r)
+			if err != nil {
+				return nil, err
+			}
+			if op != opLoop {
+				in.arg = arity
+			}
+			blocks = append(blocks, idx)
+			if len(blocks) > maxBlockNesting {
+				return nil, errors.Wrap(ErrInvalidModule, "blocks nested too deeply")
+			}
+		case opElse:
+			if len(blocks) == 0 || code[blocks[len(blocks)-1]].op != opIf || code[blocks[len(blocks)-1]].alt != -1 {
+				return nil, errors.Wrap(ErrInvalidModule, "else without matching if")
+			}
+			code[blocks[len(blocks)-1]].alt = idx
+		case opEnd:
+			if len(blocks) > 0 {
+				start := blocks[len(blocks)-1]
+				blocks = blocks[:len(blocks)-1]
+				code[start].end = idx
+				if alt := code[start].alt; alt != -1 {
+					code[alt].end = idx
+				}
+			} else {
+				// end of the function body
+				code = append(code, in)
+				if !r.done() {
+					return nil, errors.Wrap(ErrInvalidModule, "unexpected instructions after end of function")
+				}
+				return code, nil
+			}
+		case opBr, opBrIf:
+			in.arg = uint64(r.u32())
+			if in.arg > uint64(len(blocks)) {
+				return nil, errors.Wrap(ErrInvalidModule, "invalid branch depth")
+			}
+		case opBrTable:
+			n := r.u32()
+			if n > maxBrTableLength {
+				return nil, errors.Wrap(ErrInvalidModule, "branch table too large")
+			}
+			in.table = make([]uint32, 0, n+1)
+			for i := uint32(0); i <= n && r.err == nil; i++ {
+				depth := r.u32()
+				if uint64(depth) > uint64(len(blocks)) {
+					return nil, errors.Wrap(ErrInvalidModule, "invalid branch depth")
+				}
+				in.table = append(in.table, depth)
+			}
+		case opCall:
+			in.arg = uint64(r.u32())
+			if in.arg >= uint64(len(m.funcs)) {
+				return nil, errors.Wrapf(ErrInvalidModule, "invalid function index %d", in.arg)
+			}
+		case opCallIndirect:
+			in.arg = uint64(r.u32())
+			if _, err := m.typeAt(uint32(in.arg)); err != nil {
+				return nil, err
+			}
+			if r.byte() != 0x00 || m.table == nil {
+				return nil, errors.Wrap(ErrInvalidModule, "invalid call_indirect")
+			}
+		case opLocalGet, opLocalSet, opLocalTee:
+			in.arg = uint64(r.u32())
+			if in.arg >= numLocals {
+				return nil, errors.Wrapf(ErrInvalidModule, "invalid local index %d", in.arg)
+			}
+		case opGlobalGet, opGlobalSet:
+			in.arg = uint64(r.u32())
+			if in.arg >= uint64(len(m.globals)) {
+				return nil, errors.Wrapf(ErrInvalidModule, "invalid global index %d", in.arg)
+			}
+			if op == opGlobalSet && !m.globals[in.arg].mutable {
+				return nil, errors.Wrap(ErrInvalidModule, "global is immutable")
+			}
+		case opI32Load, opI64Load, opI32Load8S, opI32Load8U, opI32Load16S, opI32Load16U,
+			opI64Load8S, opI64Load8U, opI64Load16S, opI64Load16U, opI64Load32S, opI64Load32U,
+			opI32Store, opI64Store, opI32Store8, opI32Store16, opI64Store8, opI64Store16, opI64Store32:
+			r.u32() // alignment hint, ignored
+			in.arg = uint64(r.u32())
+			if m.memory == nil {
+				return nil, errors.Wrap(ErrInvalidModule, "memory instruction without memory")
+			}
+		case opMemorySize, opMemoryGrow:
+			if r.byte() != 0x00 || m.memory == nil {
+				return nil, errors.Wrap(ErrInvalidModule, "invalid memory instruction")
+			}
+		case opI32Const:
+			in.arg = uint64(uint32(r.s32()))
+		case opI64Const:
+			in.arg = uint64(r.s64())
+		case opPrefixMisc:
+			sub := r.u32()
+			switch sub {
+			case miscMemoryCopy:
+				if r.byte() != 0x00 || r.byte() != 0x00 || m.memory == nil {
+					return nil, errors.Wrap(ErrInvalidModule, "invalid memory.copy")
+				}
+			case miscMemoryFill:
+				if r.byte() != 0x00 || m.memory == nil {
+					return nil, errors.Wrap(ErrInvalidModule, "invalid memory.fill")
+				}
+			default:
+				if sub < 8 {
+					// saturating float to int conversions
+					return nil, ErrFloatNotSupported
+				}
+				return nil, errors.Wrapf(ErrInvalidModule, "unsupported instruction 0xfc %d", sub)
+			}
+			in.op = opPrefixMisc<<8 | uint16(sub)
+		default:
+			if !isIntegerOp(op) {
+				if isFloatOp(op) {
+					return nil, ErrFloatNotSupported
+				}
+				return nil, errors.Wrapf(ErrInvalidModule, "unsupported instruction 0x%x", op)
+			}
+		}
+		code = append(code, in)
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return nil, errors.Wrap(ErrInvalidModule, "missing end of function")
+}
+
+// Returns the number of values a block produces.
+func decodeBlockType(r *reader) (uint64, error) {
+	b := r.byte()
+	if b == blockTypeEmpty {
+		return 0, r.err
+	}
+	if _, err := decodeValueType(b); err != nil {
+		if err == ErrFloatNotSupported {
+			return 0, err
+		}
+		return 0, errors.Wrap(ErrInvalidModule, "multi-value blocks are not supported")
+	}
+	return 1, r.err
+}
+
+// Integer instructions that have no immediate arguments
+func isIntegerOp(op byte) bool {
+	return (op >= opI32Eqz && op <= opI64GeU) ||
+		(op >= opI32Clz && op <= opI64Rotr) ||
+		op == opI32WrapI64 || op == opI64ExtendI32S || op == opI64ExtendI32U ||
+		(op >= opI32Extend8S && op <= opI64Extend32S)
+}
+
+func isFloatOp(op byte) bool {
+	switch {
+	case op >= 0x2a && op <= 0x2b: // loads
+	case op >= 0x38 && op <= 0x39: // stores
+	case op >= 0x43 && op <= 0x44: // constants
+	case op >= 0x5b && op <= 0x66: // comparisons
+	case op >= 0x8b && op <= 0xa6: // arithmetic
+	case op >= 0xa8 && op <= 0xab: // truncations
+	case op >= 0xae && op <= 0xbf: // conversions & reinterpretations
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,737 @@
+package wasm
+
+import (
+	"math"
+	"math/bits"
+
+	"github.com/loomnetwork/loomchain/gasmeter"
+	"github.com/pkg/errors"
+)
+
+const (
+	// Max number of values (operands & locals) on the stack of an instance
+	maxStackSize = 1 << 16
+	// Max depth of nested function calls within an instance
+	maxCallDepth = 1024
+)
+
+// ErrTrap is returned when the execution of a Wasm function is aborted by a trap, e.g. division
+// by zero or an out of bounds memory access.
+var ErrTrap = errors.New("wasm trap")
+
+// Traps are raised by panicking with a trap, they're recovered by Instance.Invoke.
+type trap struct {
+	err error
+}
+
+func throw(format string, args ...interface{}) {
+	panic(trap{errors.Wrapf(ErrTrap, format, args...)})
+}
+
+// hostFunc is a Go function that can be imported by a Wasm module.
+type hostFunc struct {
+	typ *funcType
+	fn  func(inst *Instance, args []uint64) (uint64, error)
+}
+
+type label struct {
+	// Index of the instruction to continue from when branching to the label
+	cont int
+	// Height of the stack when the label was entered
+	height int
+	// Number of values the label leaves on the stack
+	arity int
+	loop  bool
+}
+
+// Instance is an instantiated Wasm module. Instances aren't safe for concurrent use.
+type Instance struct {
+	module  *Module
+	imports []*hostFunc
+	memory  []byte
+	// Max number of pages the memory can grow to
+	maxPages uint32
+	globals  []uint64
+	// Function indices of the table elements, -1 for uninitialized elements
+	table []int64
+	// Operand stack, the locals of each active function are stored on the stack too
+	stack []uint64
+	// Index of the first operand of the current function on the stack
+	base  int
+	depth int
+	gas   *gasmeter.GasMeter
+}
+
+// Instantiate creates a new instance of the given module, the functions imported by the module
+// are resolved from the given host functions, which are keyed by "<module>.<name>". All the
+// instructions executed by the instance consume gas from the given meter.
+func Instantiate(m *Module, hostFuncs map[string]*hostFunc, gas *gasmeter.GasMeter) (_ *Instance, err error) {
+	inst := &Instance{
+		module:  m,
+		globals: make([]uint64, len(m.globals)),
+		stack:   make([]uint64, 0, maxStackSize),
+		gas:     gas,
+	}
+	for _, fn := range m.funcs {
+		if !fn.isImport() {
+			break
+		}
+		host, ok := hostFuncs[fn.importModule+"."+fn.importName]
+		if !ok {
+			return nil, errors.Wrapf(ErrInvalidModule, "unknown import %s.%s", fn.importModule, fn.importName)
+		}
+		if !host.typ.equal(fn.typ) {
+			return nil, errors.Wrapf(ErrInvalidModule, "import %s.%s has the wrong type", fn.importModule, fn.importName)
+		}
+		inst.imports = append(inst.imports, host)
+	}
+
+	for i, g := range m.globals {
+		inst.globals[i] = g.init
+	}
+
+	if m.table != nil {
+		inst.table = make([]int64, m.table.min)
+		for i := range inst.table {
+			inst.table[i] = -1
+		}
+		for _, seg := range m.elems {
+			if uint64(seg.offset)+uint64(len(seg.funcs)) > uint64(len(inst.table)) {
+				return nil, errors.Wrap(ErrInvalidModule, "element segment out of bounds")
+			}
+			for j, idx := range seg.funcs {
+				inst.table[int(seg.offset)+j] = int64(idx)
+			}
+		}
+	}
+
+	if m.memory != nil {
+		inst.maxPages = maxMemoryPages
+		if m.memory.hasMax && m.memory.max < inst.maxPages {
+			inst.maxPages = m.memory.max
+		}
+		gas.ConsumeMemoryPages(int(m.memory.min))
+		inst.memory = make([]byte, int(m.memory.min)*pageSize)
+		for _, seg := range m.data {
+			if uint64(seg.offset)+uint64(len(seg.data)) > uint64(len(inst.memory)) {
+				return nil, errors.Wrap(ErrInvalidModule, "data segment out of bounds")
+			}
+			copy(inst.memory[seg.offset:], seg.data)
+		}
+	}
+
+	if m.startFunc >= 0 {
+		defer recoverTrap(&err)
+		inst.call(uint32(m.startFunc))
+	}
+	return inst, nil
+}
+
+func recoverTrap(err *error) {
+	if r := recover(); r != nil {
+		t, ok := r.(trap)
+		if !ok {
+			panic(r)
+		}
+		*err = t.err
+	}
+}
+
+// HasExport returns true if the module exports a function with the given name.
+func (inst *Instance) HasExport(name string) bool {
+	exp, ok := inst.module.exports[name]
+	return ok && exp.kind == externalFunc
+}
+
+// Invoke calls the exported function with the given name & returns its results. i32 args & results
+// are zero-extended to 64 bits.
+//
+// If the instance runs out of gas the gas meter will panic with gasmeter.ErrOutOfGas, it's up to
+// the caller to recover the panic.
+func (inst *Instance) Invoke(name string, args ...uint64) (_ []uint64, err error) {
+	exp, ok := inst.module.exports[name]
+	if !ok || exp.kind != externalFunc {
+		return nil, errors.Errorf("function %s not exported", name)
+	}
+	fn := inst.module.funcs[exp.index]
+	if len(args) != len(fn.typ.params) {
+		return nil, errors.Errorf("function %s expects %d args, got %d", name, len(fn.typ.params), len(args))
+	}
+
+	defer recoverTrap(&err)
+	inst.stack = inst.stack[:0]
+	inst.base = 0
+	inst.depth = 0
+	for i, arg := range args {
+		if fn.typ.params[i] == valueTypeI32 {
+			arg = uint64(uint32(arg))
+		}
+		inst.push(arg)
+	}
+	inst.call(exp.index)
+	results := make([]uint64, len(fn.typ.results))
+	copy(results, inst.stack[len(inst.stack)-len(results):])
+	return results, nil
+}
+
+// Calls the function with the given index, the args must already be on the stack.
+func (inst *Instance) call(idx uint32) {
+	fn := inst.module.funcs[idx]
+	numParams := len(fn.typ.params)
+	if len(inst.stack)-inst.base < numParams {
+		throw("stack underflow")
+	}
+
+	if fn.isImport() {
+		args := make([]uint64, numParams)
+		copy(args, inst.stack[len(inst.stack)-numParams:])
+		inst.stack = inst.stack[:len(inst.stack)-numParams]
+		result, err := inst.imports[idx].fn(inst, args)
+		if err != nil {
+			panic(trap{err})
+		}
+		if len(fn.typ.results) > 0 {
+			if fn.typ.results[0] == valueTypeI32 {
+				result = uint64(uint32(result))
+			}
+			inst.push(result)
+		}
+		return
+	}
+
+	inst.depth++
+	if inst.depth > maxCallDepth {
+		throw("call stack exhausted")
+	}
+
+	// Allocate the locals on the stack right after the params
+	localsStart := len(inst.stack) - numParams
+	numLocals := numParams + len(fn.locals)
+	if localsStart+numLocals > cap(inst.stack) {
+		throw("stack overflow")
+	}
+	inst.stack = inst.stack[:localsStart+numLocals]
+	for i := localsStart + numParams; i < len(inst.stack); i++ {
+		inst.stack[i] = 0
+	}
+	callerBase := inst.base
+	inst.base = len(inst.stack)
+
+	inst.execute(fn, inst.stack[localsStart:localsStart+numLocals])
+
+	// Replace the locals with the results
+	numResults := len(fn.typ.results)
+	if len(inst.stack)-inst.base < numResults {
+		throw("stack underflow")
+	}
+	copy(inst.stack[localsStart:], inst.stack[len(inst.stack)-numResults:])
+	inst.stack = inst.stack[:localsStart+numResults]
+	inst.base = callerBase
+	inst.depth--
+}
+
+func (inst *Instance) execute(fn *function, locals []uint64) {
+	code := fn.code
+	labels := make([]label, 1, 16)
+	labels[0] = label{cont: len(code), height: inst.base, arity: len(fn.typ.results)}
+
+	for pc := 0; pc < len(code); {
+		in := &code[pc]
+		pc++
+		inst.gas.ConsumeInstructions(1)
+
+		switch in.op {
+		case opUnreachable:
+			throw("unreachable executed")
+		case opNop:
+		case opBlock:
+			labels = append(labels, label{cont: in.end + 1, height: len(inst.stack), arity: int(in.arg)})
+		case opLoop:
+			labels = append(labels, label{cont: pc, height: len(inst.stack), loop: true})
+		case opIf:
+			if inst.popI32() != 0 {
+				labels = append(labels, label{cont: in.end + 1, height: len(inst.stack), arity: int(in.arg)})
+			} else if in.alt != -1 {
+				labels = append(labels, label{cont: in.end + 1, height: len(inst.stack), arity: int(in.arg)})
+				pc = in.alt + 1
+			} else {
+				pc = in.end + 1
+			}
+		case opElse:
+			// reached the end of the then branch
+			labels = labels[:len(labels)-1]
+			pc = in.end + 1
+		case opEnd:
+			labels = labels[:len(labels)-1]
+		case opBr:
+			pc = inst.branch(&labels, in.arg)
+		case opBrIf:
+			if inst.popI32() != 0 {
+				pc = inst.branch(&labels, in.arg)
+			}
+		case opBrTable:
+			i := inst.popI32()
+			depth := in.table[len(in.table)-1]
+			if uint64(i) < uint64(len(in.table)-1) {
+				depth = in.table[i]
+			}
+			pc = inst.branch(&labels, uint64(depth))
+		case opReturn:
+			pc = inst.branch(&labels, uint64(len(labels)-1))
+		case opCall:
+			inst.call(uint32(in.arg))
+		case opCallIndirect:
+			i := inst.popI32()
+			if uint64(i) >= uint64(len(inst.table)) {
+				throw("undefined table element")
+			}
+			idx := inst.table[i]
+			if idx < 0 {
+				throw("uninitialized table element")
+			}
+			if !inst.module.funcs[idx].typ.equal(inst.module.types[in.arg]) {
+				throw("indirect call type mismatch")
+			}
+			inst.call(uint32(idx))
+		case opDrop:
+			inst.pop()
+		case opSelect:
+			cond := inst.popI32()
+			b := inst.pop()
+			a := inst.pop()
+			if cond != 0 {
+				inst.push(a)
+			} else {
+				inst.push(b)
+			}
+		case opLocalGet:
+			inst.push(locals[in.arg])
+		case opLocalSet:
+			locals[in.arg] = inst.pop()
+		case opLocalTee:
+			v := inst.pop()
+			locals[in.arg] = v
+			inst.push(v)
+		case opGlobalGet:
+			inst.push(inst.globals[in.arg])
+		case opGlobalSet:
+			inst.globals[in.arg] = inst.pop()
+		case opMemorySize:
+			inst.push(uint64(len(inst.memory) / pageSize))
+		case opMemoryGrow:
+			inst.memoryGrow()
+		case opMemoryCopy:
+			n := inst.popI32()
+			src := inst.popI32()
+			dst := inst.popI32()
+			inst.checkBounds(uint64(src), uint64(n))
+			inst.checkBounds(uint64(dst), uint64(n))
+			inst.gas.ConsumeInstructions(int(n / 8))
+			copy(inst.memory[dst:dst+n], inst.memory[src:src+n])
+		case opMemoryFill:
+			n := inst.popI32()
+			val := byte(inst.popI32())
+			dst := inst.popI32()
+			inst.checkBounds(uint64(dst), uint64(n))
+			inst.gas.ConsumeInstructions(int(n / 8))
+			mem := inst.memory[dst : dst+n]
+			for i := range mem {
+				mem[i] = val
+			}
+		case opI32Const, opI64Const:
+			inst.push(in.arg)
+		default:
+			if in.op >= opI32Load && in.op <= opI64Store32 {
+				inst.execMemory(in)
+			} else {
+				inst.execNumeric(in.op)
+			}
+		}
+	}
+}
+
+// Branches to the label at the given depth & returns the index of the instruction to continue from.
+func (inst *Instance) branch(labels *[]label, depth uint64) int {
+	ls := *labels
+	l := ls[len(ls)-1-int(depth)]
+	if len(inst.stack)-l.height < l.arity {
+		throw("stack underflow")
+	}
+	copy(inst.stack[l.height:], inst.stack[len(inst.stack)-l.arity:])
+	inst.stack = inst.stack[:l.height+l.arity]
+	if l.loop {
+		// the loop label remains active
+		*labels = ls[:len(ls)-int(depth)]
+	} else {
+		*labels = ls[:len(ls)-1-int(depth)]
+	}
+	return l.cont
+}
+
+func (inst *Instance) push(v uint64) {
+	if len(inst.stack) == cap(inst.stack) {
+		throw("stack overflow")
+	}
+	inst.stack = append(inst.stack, v)
+}
+
+func (inst *Instance) pushI32(v uint32) {
+	inst.push(uint64(v))
+}
+
+func (inst *Instance) pushBool(b bool) {
+	if b {
+		inst.push(1)
+	} else {
+		inst.push(0)
+	}
+}
+
+func (inst *Instance) pop() uint64 {
+	n := len(inst.stack)
+	if n <= inst.base {
+		throw("stack underflow")
+	}
+	v := inst.stack[n-1]
+	inst.stack = inst.stack[:n-1]
+	return v
+}
+
+func (inst *Instance) popI32() uint32 {
+	return uint32(inst.pop())
+}
+
+func (inst *Instance) memoryGrow() {
+	delta := inst.popI32()
+	pages := uint32(len(inst.memory) / pageSize)
+	if uint64(pages)+uint64(delta) > uint64(inst.maxPages) {
+		inst.pushI32(math.MaxUint32) // -1
+		return
+	}
+	inst.gas.ConsumeMemoryPages(int(delta))
+	inst.memory = append(inst.memory, make([]byte, int(delta)*pageSize)...)
+	inst.pushI32(pages)
+}
+
+func (inst *Instance) checkBounds(addr, size uint64) {
+	if addr+size > uint64(len(inst.memory)) {
+		throw("out of bounds memory access")
+	}
+}
+
+// Pops the address operand of a load or store & returns the effective address.
+func (inst *Instance) effectiveAddr(offset uint64, size uint64) uint64 {
+	addr := uint64(inst.popI32()) + offset
+	inst.checkBounds(addr, size)
+	return addr
+}
+
+func (inst *Instance) execMemory(in *instruction) {
+	mem := inst.memory
+	switch in.op {
+	case opI32Load:
+		a := inst.effectiveAddr(in.arg, 4)
+		inst.pushI32(le32(mem[a:]))
+	case opI64Load:
+		a := inst.effectiveAddr(in.arg, 8)
+		inst.push(le64(mem[a:]))
+	case opI32Load8S:
+		a := inst.effectiveAddr(in.arg, 1)
+		inst.pushI32(uint32(int32(int8(mem[a]))))
+	case opI32Load8U:
+		a := inst.effectiveAddr(in.arg, 1)
+		inst.pushI32(uint32(mem[a]))
+	case opI32Load16S:
+		a := inst.effectiveAddr(in.arg, 2)
+		inst.pushI32(uint32(int32(int16(le16(mem[a:])))))
+	case opI32Load16U:
+		a := inst.effectiveAddr(in.arg, 2)
+		inst.pushI32(uint32(le16(mem[a:])))
+	case opI64Load8S:
+		a := inst.effectiveAddr(in.arg, 1)
+		inst.push(uint64(int64(int8(mem[a]))))
+	case opI64Load8U:
+		a := inst.effectiveAddr(in.arg, 1)
+		inst.push(uint64(mem[a]))
+	case opI64Load16S:
+		a := inst.effectiveAddr(in.arg, 2)
+		inst.push(uint64(int64(int16(le16(mem[a:])))))
+	case opI64Load16U:
+		a := inst.effectiveAddr(in.arg, 2)
+		inst.push(uint64(le16(mem[a:])))
+	case opI64Load32S:
+		a := inst.effectiveAddr(in.arg, 4)
+		inst.push(uint64(int64(int32(le32(mem[a:])))))
+	case opI64Load32U:
+		a := inst.effectiveAddr(in.arg, 4)
+		inst.push(uint64(le32(mem[a:])))
+	case opI32Store, opI64Store32:
+		v := inst.pop()
+		a := inst.effectiveAddr(in.arg, 4)
+		putLE(mem[a:a+4], v)
+	case opI64Store:
+		v := inst.pop()
+		a := inst.effectiveAddr(in.arg, 8)
+		putLE(mem[a:a+8], v)
+	case opI32Store8, opI64Store8:
+		v := inst.pop()
+		a := inst.effectiveAddr(in.arg, 1)
+		mem[a] = byte(v)
+	case opI32Store16, opI64Store16:
+		v := inst.pop()
+		a := inst.effectiveAddr(in.arg, 2)
+		putLE(mem[a:a+2], v)
+	default:
+		throw("unsupported instruction 0x%x", in.op)
+	}
+}
+
+func le16(b []byte) uint16 {
+	return uint16(b[0]) | uint16(b[1])<<8
+}
+
+func le32(b []byte) uint32 {
+	return uint32(le16(b)) | uint32(le16(b[2:]))<<16
+}
+
+func le64(b []byte) uint64 {
+	return uint64(le32(b)) | uint64(le32(b[4:]))<<32
+}
+
+// Stores the low len(b) bytes of v in little-endian order.
+func putLE(b []byte, v uint64) {
+	for i := range b {
+		b[i] = byte(v >> (8 * uint(i)))
+	}
+}
+
+func (inst *Instance) execNumeric(op uint16) {
+	switch {
+	case op == opI32Eqz:
+		inst.pushBool(inst.popI32() == 0)
+	case op == opI64Eqz:
+		inst.pushBool(inst.pop() == 0)
+	case op >= opI32Eq && op <= opI32GeU:
+		b := inst.popI32()
+		a := inst.popI32()
+		inst.pushBool(compareI32(op, a, b))
+	case op >= opI64Eq && op <= opI64GeU:
+		b := inst.pop()
+		a := inst.pop()
+		inst.pushBool(compareI64(op, a, b))
+	case op >= opI32Clz && op <= opI32Popcnt:
+		a := inst.popI32()
+		switch op {
+		case opI32Clz:
+			inst.pushI32(uint32(bits.LeadingZeros32(a)))
+		case opI32Ctz:
+			inst.pushI32(uint32(bits.TrailingZeros32(a)))
+		default:
+			inst.pushI32(uint32(bits.OnesCount32(a)))
+		}
+	case op >= opI32Add && op <= opI32Rotr:
+		b := inst.popI32()
+		a := inst.popI32()
+		inst.pushI32(binaryI32(op, a, b))
+	case op >= opI64Clz && op <= opI64Popcnt:
+		a := inst.pop()
+		switch op {
+		case opI64Clz:
+			inst.push(uint64(bits.LeadingZeros64(a)))
+		case opI64Ctz:
+			inst.push(uint64(bits.TrailingZeros64(a)))
+		default:
+			inst.push(uint64(bits.OnesCount64(a)))
+		}
+	case op >= opI64Add && op <= opI64Rotr:
+		b := inst.pop()
+		a := inst.pop()
+		inst.push(binaryI64(op, a, b))
+	case op == opI32WrapI64:
+		inst.pushI32(uint32(inst.pop()))
+	case op == opI64ExtendI32S:
+		inst.push(uint64(int64(int32(inst.popI32()))))
+	case op == opI64ExtendI32U:
+		inst.push(uint64(inst.popI32()))
+	case op == opI32Extend8S:
+		inst.pushI32(uint32(int32(int8(inst.popI32()))))
+	case op == opI32Extend16S:
+		inst.pushI32(uint32(int32(int16(inst.popI32()))))
+	case op == opI64Extend8S:
+		inst.push(uint64(int64(int8(inst.pop()))))
+	case op == opI64Extend16S:
+		inst.push(uint64(int64(int16(inst.pop()))))
+	case op == opI64Extend32S:
+		inst.push(uint64(int64(int32(inst.pop()))))
+	default:
+		throw("unsupported instruction 0x%x", op)
+	}
+}
+
+func compareI32(op uint16, a, b uint32) bool {
+	switch op {
+	case opI32Eq:
+		return a == b
+	case opI32Ne:
+		return a != b
+	case opI32LtS:
+		return int32(a) < int32(b)
+	case opI32LtU:
+		return a < b
+	case opI32GtS:
+		return int32(a) > int32(b)
+	case opI32GtU:
+		return a > b
+	case opI32LeS:
+		return int32(a) <= int32(b)
+	case opI32LeU:
+		return a <= b
+	case opI32GeS:
+		return int32(a) >= int32(b)
+	default: // opI32GeU
+		return a >= b
+	}
+}
+
+func compareI64(op uint16, a, b uint64) bool {
+	switch op {
+	case opI64Eq:
+		return a == b
+	case opI64Ne:
+		return a != b
+	case opI64LtS:
+		return int64(a) < int64(b)
+	case opI64LtU:
+		return a < b
+	case opI64GtS:
+		return int64(a) > int64(b)
+	case opI64GtU:
+		return a > b
+	case opI64LeS:
+		return int64(a) <= int64(b)
+	case opI64LeU:
+		return a <= b
+	case opI64GeS:
+		return int64(a) >= int64(b)
+	default: // opI64GeU
+		return a >= b
+	}
+}
+
+func binaryI32(op uint16, a, b uint32) uint32 {
+	switch op {
+	case opI32Add:
+		return a + b
+	case opI32Sub:
+		return a - b
+	case opI32Mul:
+		return a * b
+	case opI32DivS:
+		if b == 0 {
+			throw("integer divide by zero")
+		}
+		if int32(a) == math.MinInt32 && int32(b) == -1 {
+			throw("integer overflow")
+		}
+		return uint32(int32(a) / int32(b))
+	case opI32DivU:
+		if b == 0 {
+			throw("integer divide by zero")
+		}
+		return a / b
+	case opI32RemS:
+		if b == 0 {
+			throw("integer divide by zero")
+		}
+		return uint32(int32(a) % int32(b))
+	case opI32RemU:
+		if b == 0 {
+			throw("integer divide by zero")
+		}
+		return a % b
+	case opI32And:
+		return a & b
+	case opI32Or:
+		return a | b
+	case opI32Xor:
+		return a ^ b
+	case opI32Shl:
+		return a << (b & 31)
+	case opI32ShrS:
+		return uint32(int32(a) >> (b & 31))
+	case opI32ShrU:
+		return a >> (b & 31)
+	case opI32Rotl:
+		return bits.RotateLeft32(a, int(b&31))
+	default: // opI32Rotr
+		return bits.RotateLeft32(a, -int(b&31))
+	}
+}
+
+func binaryI64(op uint16, a, b uint64) uint64 {
+	switch op {
+	case opI64Add:
+		return a + b
+	case opI64Sub:
+		return a - b
+	case opI64Mul:
+		return a * b
+	case opI64DivS:
+		if b == 0 {
+			throw("integer divide by zero")
+		}
+		if int64(a) == math.MinInt64 && int64(b) == -1 {
+			throw("integer overflow")
+		}
+		return uint64(int64(a) / int64(b))
+	case opI64DivU:
+		if b == 0 {
+			throw("integer divide by zero")
+		}
+		return a / b
+	case opI64RemS:
+		if b == 0 {
+			throw("integer divide by zero")
+		}
+		return uint64(int64(a) % int64(b))
+	case opI64RemU:
+		if b == 0 {
+			throw("integer divide by zero")
+		}
+		return a % b
+	case opI64And:
+		return a & b
+	case opI64Or:
+		return a | b
+	case opI64Xor:
+		return a ^ b
+	case opI64Shl:
+		return a << (b & 63)
+	case opI64ShrS:
+		return uint64(int64(a) >> (b & 63))
+	case opI64ShrU:
+		return a >> (b & 63)
+	case opI64Rotl:
+		return bits.RotateLeft64(a, int(b&63))
+	default: // opI64Rotr
+		return bits.RotateLeft64(a, -int(b&63))
+	}
+}
+
+// Returns a copy of the given range of memory.
+func (inst *Instance) readMemory(ptr, size uint32) ([]byte, error) {
+	if uint64(ptr)+uint64(size) > uint64(len(inst.memory)) {
+		return nil, errors.Wrap(ErrTrap, "out of bounds memory access")
+	}
+	b := make([]byte, size)
+	copy(b, inst.memory[ptr:])
+	return b, nil
+}
+
+func (inst *Instance) writeMemory(ptr uint32, data []byte) error {
+	if uint64(ptr)+uint64(len(data)) > uint64(len(inst.memory)) {
+		return errors.Wrap(ErrTrap, "out of bounds memory access")
+	}
+	copy(inst.memory[ptr:], data)
+	return nil
+}
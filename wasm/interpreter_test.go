@@ -0,0 +1,203 @@
+package wasm
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/loomnetwork/loomchain/gasmeter"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// moduleBuilder assembles Wasm modules in the binary format for tests.
+type moduleBuilder struct {
+	types   [][]byte
+	imports [][]byte
+	funcs   [][]byte
+	codes   [][]byte
+	memory  []byte
+	exports [][]byte
+	data    [][]byte
+}
+
+func uleb(v uint64) []byte {
+	var b []byte
+	for {
+		c := byte(v & 0x7f)
+		v >>= 7
+		if v != 0 {
+			c |= 0x80
+		}
+		b = append(b, c)
+		if v == 0 {
+			return b
+		}
+	}
+}
+
+func vec(items [][]byte) []byte {
+	return append(uleb(uint64(len(items))), bytes.Join(items, nil)...)
+}
+
+func name(s string) []byte {
+	return append(uleb(uint64(len(s))), s...)
+}
+
+func (b *moduleBuilder) addType(params []valueType, results ...valueType) uint32 {
+	t := append([]byte{0x60}, uleb(uint64(len(params)))...)
+	t = append(t, valueTypeBytes(params)...)
+	t = append(t, uleb(uint64(len(results)))...)
+	t = append(t, valueTypeBytes(results)...)
+	b.types = append(b.types, t)
+	return uint32(len(b.types) - 1)
+}
+
+// Imports a host function from the loom module, must be called before any functions are added.
+func (b *moduleBuilder) addImport(field string, typeIdx uint32) uint32 {
+	imp := append(name(hostModule), name(field)...)
+	imp = append(imp, externalFunc)
+	b.imports = append(b.imports, append(imp, uleb(uint64(typeIdx))...))
+	return uint32(len(b.imports) - 1)
+}
+
+// Adds a function with the given locals (one i32 or i64 each) & body, the body must include the
+// final end instruction.
+func (b *moduleBuilder) addFunc(typeIdx uint32, locals []valueType, body ...byte) uint32 {
+	b.funcs = append(b.funcs, uleb(uint64(typeIdx)))
+	var groups [][]byte
+	for _, l := range locals {
+		groups = append(groups, []byte{0x01, byte(l)})
+	}
+	code := append(vec(groups), body...)
+	b.codes = append(b.codes, append(uleb(uint64(len(code))), code...))
+	return uint32(len(b.imports) + len(b.funcs) - 1)
+}
+
+func (b *moduleBuilder) export(field string, kind byte, idx uint32) {
+	exp := append(name(field), kind)
+	b.exports = append(b.exports, append(exp, uleb(uint64(idx))...))
+}
+
+func (b *moduleBuilder) setMemory(minPages uint32) {
+	b.memory = append([]byte{0x00}, uleb(uint64(minPages))...)
+}
+
+func (b *moduleBuilder) addData(offset uint32, data []byte) {
+	seg := append([]byte{0x00, opI32Const}, uleb(uint64(offset))...) // offset < 64 is the same in sleb
+	seg = append(seg, opEnd)
+	b.data = append(b.data, append(seg, name(string(data))...))
+}
+
+func (b *moduleBuilder) build() []byte {
+	module := append(append([]byte{}, wasmMagic...), wasmVersion...)
+	section := func(id byte, content []byte) {
+		module = append(module, id)
+		module = append(module, uleb(uint64(len(content)))...)
+		module = append(module, content...)
+	}
+	section(sectionType, vec(b.types))
+	if len(b.imports) > 0 {
+		section(sectionImport, vec(b.imports))
+	}
+	section(sectionFunction, vec(b.funcs))
+	if b.memory != nil {
+		section(sectionMemory, vec([][]byte{b.memory}))
+	}
+	section(sectionExport, vec(b.exports))
+	section(sectionCode, vec(b.codes))
+	if len(b.data) > 0 {
+		section(sectionData, vec(b.data))
+	}
+	return module
+}
+
+func newTestInstance(t *testing.T, code []byte, gasLimit uint64) (*Instance, *gasmeter.GasMeter) {
+	module, err := DecodeModule(code)
+	require.NoError(t, err)
+	cfg := gasmeter.DefaultConfig()
+	cfg.TxGasLimit = gasLimit
+	meter := gasmeter.NewGasMeter(cfg)
+	inst, err := Instantiate(module, nil, meter)
+	require.NoError(t, err)
+	return inst, meter
+}
+
+func TestInterpreterArithmetic(t *testing.T) {
+	i32, i64 := valueTypeI32, valueTypeI64
+	b := &moduleBuilder{}
+	// (func $fac (param i64) (result i64)
+	//   (if (result i64) (i64.eqz (local.get 0))
+	//     (then (i64.const 1))
+	//     (else (i64.mul (local.get 0) (call $fac (i64.sub (local.get 0) (i64.const 1)))))))
+	fac := b.addFunc(b.addType([]valueType{i64}, i64), nil,
+		opLocalGet, 0, opI64Eqz, opIf, byte(i64), opI64Const, 1, opElse,
+		opLocalGet, 0, opLocalGet, 0, opI64Const, 1, opI64Sub, opCall, 0, opI64Mul, opEnd, opEnd,
+	)
+	// Sums the integers from 1 to n with a loop
+	sum := b.addFunc(b.addType([]valueType{i32}, i32), []valueType{i32},
+		opBlock, blockTypeEmpty, opLoop, blockTypeEmpty,
+		opLocalGet, 0, opI32Eqz, opBrIf, 1,
+		opLocalGet, 1, opLocalGet, 0, opI32Add, opLocalSet, 1,
+		opLocalGet, 0, opI32Const, 1, opI32Sub, opLocalSet, 0,
+		opBr, 0, opEnd, opEnd, opLocalGet, 1, opEnd,
+	)
+	div := b.addFunc(b.addType([]valueType{i32, i32}, i32), nil,
+		opLocalGet, 0, opLocalGet, 1, opI32DivS, opEnd,
+	)
+	b.export("fac", externalFunc, fac)
+	b.export("sum", externalFunc, sum)
+	b.export("div", externalFunc, div)
+	inst, meter := newTestInstance(t, b.build(), 1000000)
+
+	results, err := inst.Invoke("fac", 20)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{2432902008176640000}, results)
+
+	results, err = inst.Invoke("sum", 100)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{5050}, results)
+
+	minusSeven := int32(-7)
+	results, err = inst.Invoke("div", uint64(uint32(minusSeven)), 2)
+	require.NoError(t, err)
+	require.Equal(t, int32(-3), int32(results[0]))
+
+	_, err = inst.Invoke("div", 7, 0)
+	require.Equal(t, ErrTrap, errors.Cause(err))
+
+	_, err = inst.Invoke("missing")
+	require.Error(t, err)
+	require.True(t, meter.GasUsed() > 0)
+}
+
+func TestInterpreterOutOfGas(t *testing.T) {
+	b := &moduleBuilder{}
+	loop := b.addFunc(b.addType(nil), nil, opLoop, blockTypeEmpty, opBr, 0, opEnd, opEnd)
+	b.export("loop", externalFunc, loop)
+	inst, meter := newTestInstance(t, b.build(), 1000)
+
+	invoke := func() (err error) {
+		defer gasmeter.RecoverOutOfGas(&err)
+		_, err = inst.Invoke("loop")
+		return err
+	}
+	require.Equal(t, gasmeter.ErrOutOfGas, invoke())
+	require.True(t, meter.IsOutOfGas())
+	require.Equal(t, uint64(1000), meter.GasUsed())
+}
+
+func TestDecodeModuleRejectsFloats(t *testing.T) {
+	b := &moduleBuilder{}
+	b.addFunc(b.addType([]valueType{valueTypeF64}), nil, opEnd)
+	_, err := DecodeModule(b.build())
+	require.Equal(t, ErrFloatNotSupported, errors.Cause(err))
+
+	b = &moduleBuilder{}
+	// f64.const 0; drop
+	b.addFunc(b.addType(nil), nil, 0x44, 0, 0, 0, 0, 0, 0, 0, 0, opDrop, opEnd)
+	_, err = DecodeModule(b.build())
+	require.Equal(t, ErrFloatNotSupported, errors.Cause(err))
+
+	_, err = DecodeModule([]byte("not wasm"))
+	require.Equal(t, ErrInvalidModule, errors.Cause(err))
+}
@@ -0,0 +1,641 @@
+package wasm
+
+import (
+	"bytes"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// Size of a page of linear memory
+	pageSize = 65536
+	// Max number of pages of linear memory a contract can use, regardless of the limits declared
+	// by the module.
+	maxMemoryPages = 256
+	// Max number of entries in a function table
+	maxTableSize = 65536
+	// Max number of locals (including params) a single function can declare
+	maxFunctionLocals = 50000
+)
+
+var (
+	// ErrInvalidModule is returned when a contract isn't a valid Wasm module.
+	ErrInvalidModule = errors.New("invalid Wasm module")
+	// ErrFloatNotSupported is returned when a module uses floating point types or instructions,
+	// which aren't allowed because their results aren't guaranteed to be identical on every node.
+	ErrFloatNotSupported = errors.New("floating point types & instructions are not supported")
+
+	wasmMagic   = []byte{0x00, 0x61, 0x73, 0x6d}
+	wasmVersion = []byte{0x01, 0x00, 0x00, 0x00}
+)
+
+// IsWasm returns true if the given code starts with the Wasm binary magic number.
+func IsWasm(code []byte) bool {
+	return bytes.HasPrefix(code, wasmMagic)
+}
+
+type valueType byte
+
+const (
+	valueTypeI32 valueType = 0x7f
+	valueTypeI64 valueType = 0x7e
+	valueTypeF32 valueType = 0x7d
+	valueTypeF64 valueType = 0x7c
+)
+
+const (
+	sectionCustom    = 0
+	sectionType      = 1
+	sectionImport    = 2
+	sectionFunction  = 3
+	sectionTable     = 4
+	sectionMemory    = 5
+	sectionGlobal    = 6
+	sectionExport    = 7
+	sectionStart     = 8
+	sectionElement   = 9
+	sectionCode      = 10
+	sectionData      = 11
+	sectionDataCount = 12
+)
+
+const (
+	externalFunc   = 0x00
+	externalTable  = 0x01
+	externalMemory = 0x02
+	externalGlobal = 0x03
+)
+
+type funcType struct {
+	params  []valueType
+	results []valueType
+}
+
+func (t *funcType) equal(other *funcType) bool {
+	return bytes.Equal(valueTypeBytes(t.params), valueTypeBytes(other.params)) &&
+		bytes.Equal(valueTypeBytes(t.results), valueTypeBytes(other.results))
+}
+
+func valueTypeBytes(types []valueType) []byte {
+	b := make([]byte, len(types))
+	for i, t := range types {
+		b[i] = byte(t)
+	}
+	return b
+}
+
+type function struct {
+	typ *funcType
+	// Module & field names of imported (host) functions
+	importModule string
+	importName   string
+	// Locals declared by the function body, doesn't include the params
+	locals []valueType
+	code   []instruction
+}
+
+func (f *function) isImport() bool {
+	return f.code == nil
+}
+
+type global struct {
+	typ     valueType
+	mutable bool
+	init    uint64
+}
+
+type export struct {
+	kind  byte
+	index uint32
+}
+
+type limits struct {
+	min    uint32
+	max    uint32
+	hasMax bool
+}
+
+type elemSegment struct {
+	offset uint32
+	funcs  []uint32
+}
+
+type dataSegment struct {
+	offset uint32
+	data   []byte
+}
+
+// Module is a decoded Wasm module.
+type Module struct {
+	types     []*funcType
+	funcs     []*function
+	table     *limits
+	memory    *limits
+	globals   []global
+	exports   map[string]export
+	startFunc int64
+	elems     []elemSegment
+	data      []dataSegment
+}
+
+// DecodeModule decodes & validates a Wasm module in the binary format. Only the integer subset of
+// the MVP instruction set (plus the sign-extension & bulk memory copy/fill instructions) is
+// supported, modules that use floating point types or instructions are rejected.
+func DecodeModule(code []byte) (*Module, error) {
+	if !IsWasm(code) {
+		return nil, errors.Wrap(ErrInvalidModule, "missing magic number")
+	}
+	if len(code) < 8 || !bytes.Equal(code[4:8], wasmVersion) {
+		return nil, errors.Wrap(ErrInvalidModule, "unsupported version")
+	}
+
+	m := &Module{
+		exports:   map[string]export{},
+		startFunc: -1,
+	}
+	r := newReader(code[8:])
+	var funcTypeIndices []uint32
+	lastSection := 0
+	for !r.done() && r.err == nil {
+		id := r.byte()
+		body := newReader(r.bytes(r.u32()))
+		if r.err != nil {
+			break
+		}
+		if id != sectionCustom {
+			// Sections must appear in order & at most once, the data count section is the only
+			// one that's out of numerical order.
+			order := int(id)
+			if id == sectionDataCount {
+				order = sectionCode
+			} else if id >= sectionCode {
+				order++
+			}
+			if order <= lastSection {
+				return nil, errors.Wrapf(ErrInvalidModule, "unexpected section %d", id)
+			}
+			lastSection = order
+		}
+
+		var err error
+		switch id {
+		case sectionCustom, sectionDataCount:
+			// ignored
+		case sectionType:
+			err = m.decodeTypes(body)
+		case sectionImport:
+			err = m.decodeImports(body)
+		case sectionFunction:
+			funcTypeIndices, err = m.decodeFunctions(body)
+		case sectionTable:
+			err = m.decodeTable(body)
+		case sectionMemory:
+			err = m.decodeMemory(body)
+		case sectionGlobal:
+			err = m.decodeGlobals(body)
+		case sectionExport:
+			err = m.decodeExports(body, len(funcTypeIndices))
+		case sectionStart:
+			idx := body.u32()
+			if body.err == nil && int(idx) >= len(m.funcs)+len(funcTypeIndices) {
+				err = errors.Wrap(ErrInvalidModule, "invalid start function")
+			}
+			m.startFunc = int64(idx)
+		case sectionElement:
+			err = m.decodeElements(body, len(funcTypeIndices))
+		case sectionCode:
+			err = m.decodeCode(body, funcTypeIndices)
+			funcTypeIndices = nil
+		case sectionData:
+			err = m.decodeData(body)
+		default:
+			err = errors.Wrapf(ErrInvalidModule, "unknown section %d", id)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if body.err != nil {
+			return nil, body.err
+		}
+		if id != sectionCustom && !body.done() {
+			return nil, errors.Wrapf(ErrInvalidModule, "section %d size mismatch", id)
+		}
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	if len(funcTypeIndices) > 0 {
+		return nil, errors.Wrap(ErrInvalidModule, "missing code section")
+	}
+	return m, nil
+}
+
+func (m *Module) decodeTypes(r *reader) error {
+	count := r.u32()
+	for i := uint32(0); i < count && r.err == nil; i++ {
+		if r.byte() != 0x60 {
+			return errors.Wrap(ErrInvalidModule, "invalid function type")
+		}
+		params, err := decodeValueTypes(r)
+		if err != nil {
+			return err
+		}
+		results, err := decodeValueTypes(r)
+		if err != nil {
+			return err
+		}
+		if len(results) > 1 {
+			return errors.Wrap(ErrInvalidModule, "multiple return values are not supported")
+		}
+		m.types = append(m.types, &funcType{params: params, results: results})
+	}
+	return nil
+}
+
+func decodeValueTypes(r *reader) ([]valueType, error) {
+	count := r.u32()
+	if count > maxFunctionLocals {
+		return nil, errors.Wrap(ErrInvalidModule, "too many values")
+	}
+	types := make([]valueType, 0, count)
+	for i := uint32(0); i < count && r.err == nil; i++ {
+		t, err := decodeValueType(r.byte())
+		if err != nil {
+			return nil, err
+		}
+		types = append(types, t)
+	}
+	return types, r.err
+}
+
+func decodeValueType(b byte) (valueType, error) {
+	switch valueType(b) {
+	case valueTypeI32, valueTypeI64:
+		return valueType(b), nil
+	case valueTypeF32, valueTypeF64:
+		return 0, ErrFloatNotSupported
+	}
+	return 0, errors.Wrapf(ErrInvalidModule, "unsupported value type 0x%x", b)
+}
+
+func (m *Module) typeAt(idx uint32) (*funcType, error) {
+	if int(idx) >= len(m.types) {
+		return nil, errors.Wrapf(ErrInvalidModule, "invalid type index %d", idx)
+	}
+	return m.types[idx], nil
+}
+
+func (m *Module) decodeImports(r *reader) error {
+	count := r.u32()
+	for i := uint32(0); i < count && r.err == nil; i++ {
+		module := r.name()
+		name := r.name()
+		kind := r.byte()
+		if r.err != nil {
+			break
+		}
+		if kind != externalFunc {
+			return errors.Wrapf(ErrInvalidModule, "import %s.%s: only functions can be imported", module, name)
+		}
+		typ, err := m.typeAt(r.u32())
+		if err != nil {
+			return err
+		}
+		m.funcs = append(m.funcs, &function{typ: typ, importModule: module, importName: name})
+	}
+	return nil
+}
+
+func (m *Module) decodeFunctions(r *reader) ([]uint32, error) {
+	count := r.u32()
+	var indices []uint32
+	for i := uint32(0); i < count && r.err == nil; i++ {
+		idx := r.u32()
+		if _, err := m.typeAt(idx); err != nil {
+			return nil, err
+		}
+		indices = append(indices, idx)
+	}
+	return indices, nil
+}
+
+func decodeLimits(r *reader) limits {
+	var l limits
+	flags := r.byte()
+	l.min = r.u32()
+	if flags == 0x01 {
+		l.hasMax = true
+		l.max = r.u32()
+	} else if flags != 0x00 {
+		r.fail("invalid limits")
+	}
+	return l
+}
+
+func (m *Module) decodeTable(r *reader) error {
+	if r.u32() != 1 || m.table != nil {
+		return errors.Wrap(ErrInvalidModule, "only a single table is supported")
+	}
+	if r.byte() != 0x70 {
+		return errors.Wrap(ErrInvalidModule, "unsupported table element type")
+	}
+	l := decodeLimits(r)
+	if l.min > maxTableSize {
+		return errors.Wrap(ErrInvalidModule, "table too large")
+	}
+	m.table = &l
+	return nil
+}
+
+func (m *Module) decodeMemory(r *reader) error {
+	if r.u32() != 1 || m.memory != nil {
+		return errors.Wrap(ErrInvalidModule, "only a single memory is supported")
+	}
+	l := decodeLimits(r)
+	if l.min > maxMemoryPages || (l.hasMax && l.max < l.min) {
+		return errors.Wrap(ErrInvalidModule, "invalid memory limits")
+	}
+	m.memory = &l
+	return nil
+}
+
+func (m *Module) decodeGlobals(r *reader) error {
+	count := r.u32()
+	for i := uint32(0); i < count && r.err == nil; i++ {
+		typ, err := decodeValueType(r.byte())
+		if err != nil {
+			return err
+		}
+		mut := r.byte()
+		if mut > 1 {
+			return errors.Wrap(ErrInvalidModule, "invalid global mutability")
+		}
+		init, err := decodeConstExpr(r, typ)
+		if err != nil {
+			return err
+		}
+		m.globals = append(m.globals, global{typ: typ, mutable: mut == 1, init: init})
+	}
+	return nil
+}
+
+// Decodes an initializer expression, only constants are supported since globals can't be imported.
+func decodeConstExpr(r *reader, typ valueType) (uint64, error) {
+	var v uint64
+	switch op := r.byte(); {
+	case op == opI32Const && typ == valueTypeI32:
+		v = uint64(uint32(r.s32()))
+	case op == opI64Const && typ == valueTypeI64:
+		v = uint64(r.s64())
+	case op == 0x43 || op == 0x44:
+		return 0, ErrFloatNotSupported
+	default:
+		return 0, errors.Wrap(ErrInvalidModule, "unsupported initializer expression")
+	}
+	if r.byte() != opEnd {
+		return 0, errors.Wrap(ErrInvalidModule, "unsupported initializer expression")
+	}
+	return v, r.err
+}
+
+func (m *Module) decodeExports(r *reader, numFuncDecls int) error {
+	count := r.u32()
+	for i := uint32(0); i < count && r.err == nil; i++ {
+		name := r.name()
+		exp := export{kind: r.byte(), index: r.u32()}
+		if r.err != nil {
+			break
+		}
+		if _, exists := m.exports[name]; exists {
+			return errors.Wrapf(ErrInvalidModule, "duplicate export %s", name)
+		}
+		if err := m.validateExport(name, exp, numFuncDecls); err != nil {
+			return err
+		}
+		m.exports[name] = exp
+	}
+	return nil
+}
+
+func (m *Module) validateExport(name string, exp export, numFuncDecls int) error {
+	valid := false
+	switch exp.kind {
+	case externalFunc:
+		valid = int(exp.index) < len(m.funcs)+numFuncDecls
+	case externalTable:
+		valid = exp.index == 0 && m.table != nil
+	case externalMemory:
+		valid = exp.index == 0 && m.memory != nil
+	case externalGlobal:
+		valid = int(exp.index) < len(m.globals)
+	}
+	if !valid {
+		return errors.Wrapf(ErrInvalidModule, "invalid export %s", name)
+	}
+	return nil
+}
+
+func (m *Module) decodeElements(r *reader, numFuncDecls int) error {
+	count := r.u32()
+	for i := uint32(0); i < count && r.err == nil; i++ {
+		if r.u32() != 0 || m.table == nil {
+			return errors.Wrap(ErrInvalidModule, "unsupported element segment")
+		}
+		offset, err := decodeConstExpr(r, valueTypeI32)
+		if err != nil {
+			return err
+		}
+		n := r.u32()
+		if n > maxTableSize {
+			return errors.Wrap(ErrInvalidModule, "element segment too large")
+		}
+		seg := elemSegment{offset: uint32(offset), funcs: make([]uint32, 0, n)}
+		for j := uint32(0); j < n && r.err == nil; j++ {
+			idx := r.u32()
+			if int(idx) >= len(m.funcs)+numFuncDecls {
+				return errors.Wrapf(ErrInvalidModule, "invalid function index %d", idx)
+			}
+			seg.funcs = append(seg.funcs, idx)
+		}
+		m.elems = append(m.elems, seg)
+	}
+	return nil
+}
+
+func (m *Module) decodeCode(r *reader, typeIndices []uint32) error {
+	count := r.u32()
+	if r.err == nil && int(count) != len(typeIndices) {
+		return errors.Wrap(ErrInvalidModule, "function & code section counts don't match")
+	}
+	// All the functions must be declared before any bodies are compiled so calls can be checked
+	first := len(m.funcs)
+	for _, idx := range typeIndices {
+		m.funcs = append(m.funcs, &function{typ: m.types[idx]})
+	}
+	for i := uint32(0); i < count && r.err == nil; i++ {
+		fn := m.funcs[first+int(i)]
+		body := newReader(r.bytes(r.u32()))
+		if r.err != nil {
+			break
+		}
+		numLocals := len(fn.typ.params)
+		groups := body.u32()
+		for j := uint32(0); j < groups && body.err == nil; j++ {
+			n := body.u32()
+			typ, err := decodeValueType(body.byte())
+			if err != nil {
+				return err
+			}
+			numLocals += int(n)
+			if numLocals > maxFunctionLocals {
+				return errors.Wrap(ErrInvalidModule, "too many locals")
+			}
+			for k := uint32(0); k < n; k++ {
+				fn.locals = append(fn.locals, typ)
+			}
+		}
+		if body.err != nil {
+			return body.err
+		}
+		code, err := compile(m, fn, body)
+		if err != nil {
+			return errors.Wrapf(err, "function %d", first+int(i))
+		}
+		fn.code = code
+	}
+	return nil
+}
+
+func (m *Module) decodeData(r *reader) error {
+	count := r.u32()
+	for i := uint32(0); i < count && r.err == nil; i++ {
+		if r.u32() != 0 || m.memory == nil {
+			return errors.Wrap(ErrInvalidModule, "unsupported data segment")
+		}
+		offset, err := decodeConstExpr(r, valueTypeI32)
+		if err != nil {
+			return err
+		}
+		data := r.bytes(r.u32())
+		m.data = append(m.data, dataSegment{offset: uint32(offset), data: data})
+	}
+	return nil
+}
+
+// reader decodes the primitive types used by the Wasm binary format, the first error encountered
+// is retained & all subsequent reads return zero values.
+type reader struct {
+	buf []byte
+	pos int
+	err error
+}
+
+func newReader(buf []byte) *reader {
+	return &reader{buf: buf}
+}
+
+func (r *reader) done() bool {
+	return r.pos >= len(r.buf)
+}
+
+func (r *reader) fail(msg string) {
+	if r.err == nil {
+		r.err = errors.Wrap(ErrInvalidModule, msg)
+	}
+	r.pos = len(r.buf)
+}
+
+func (r *reader) byte() byte {
+	if r.err != nil || r.pos >= len(r.buf) {
+		r.fail("unexpected end")
+		return 0
+	}
+	b := r.buf[r.pos]
+	r.pos++
+	return b
+}
+
+func (r *reader) bytes(n uint32) []byte {
+	if r.err != nil || uint64(n) > uint64(len(r.buf)-r.pos) {
+		r.fail("unexpected end")
+		return nil
+	}
+	b := r.buf[r.pos : r.pos+int(n)]
+	r.pos += int(n)
+	return b
+}
+
+func (r *reader) name() string {
+	b := r.bytes(r.u32())
+	if !utf8.Valid(b) {
+		r.fail("invalid name")
+		return ""
+	}
+	return string(b)
+}
+
+// Reads an unsigned LEB128 integer of at most the given number of bits.
+func (r *reader) uleb(bits uint) uint64 {
+	var result uint64
+	var shift uint
+	for {
+		b := r.byte()
+		if r.err != nil {
+			return 0
+		}
+		if shift+7 > bits && b>>(bits-shift) != 0 {
+			r.fail("integer too large")
+			return 0
+		}
+		result |= uint64(b&0x7f) << shift
+		if b&0x80 == 0 {
+			return result
+		}
+		shift += 7
+		if shift >= bits {
+			r.fail("integer representation too long")
+			return 0
+		}
+	}
+}
+
+// Reads a signed LEB128 integer of at most the given number of bits.
+func (r *reader) sleb(bits uint) int64 {
+	var result int64
+	var shift uint
+	for {
+		b := r.byte()
+		if r.err != nil {
+			return 0
+		}
+		result |= int64(b&0x7f) << shift
+		shift += 7
+		if b&0x80 == 0 {
+			if shift < 64 && b&0x40 != 0 {
+				result |= -1 << shift
+			}
+			if bits < 64 && (result < -(1<<(bits-1)) || result >= 1<<(bits-1)) {
+				r.fail("integer too large")
+				return 0
+			}
+			return result
+		}
+		if shift >= bits {
+			r.fail("integer representation too long")
+			return 0
+		}
+	}
+}
+
+func (r *reader) u32() uint32 {
+	return uint32(r.uleb(32))
+}
+
+func (r *reader) s32() int32 {
+	return int32(r.sleb(32))
+}
+
+func (r *reader) s64() int64 {
+	return r.sleb(64)
+}
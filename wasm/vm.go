@@ -0,0 +1,177 @@
+// Package wasm implements a VM that executes contracts compiled to WebAssembly.
+//
+// Contracts are executed by a deterministic interpreter that only supports integer instructions,
+// every instruction consumes gas from the gas meter of the tx, so contracts can't run forever.
+// The VM is invoked via the following functions exported by a contract, none of which take any
+// params or return any results:
+//   - init: optional, called when the contract is deployed.
+//   - call: called by call txs & by other contracts.
+//   - static_call: called by queries & static calls from other contracts, can't modify state.
+//
+// Contracts interact with the chain via the host functions they import from the "loom" module,
+// which provide the same functionality Go contracts access via plugin.Context, see host.go.
+package wasm
+
+import (
+	"github.com/loomnetwork/go-loom"
+	"github.com/loomnetwork/go-loom/util"
+	"github.com/loomnetwork/loomchain"
+	"github.com/loomnetwork/loomchain/auth"
+	"github.com/loomnetwork/loomchain/gasmeter"
+	"github.com/loomnetwork/loomchain/plugin"
+	"github.com/loomnetwork/loomchain/registry"
+	"github.com/loomnetwork/loomchain/vm"
+	"github.com/pkg/errors"
+)
+
+const (
+	initFunc       = "init"
+	callFunc       = "call"
+	staticCallFunc = "static_call"
+
+	// Max depth of nested contract calls
+	maxContractCallDepth = 64
+)
+
+var (
+	// ErrNotWasmContract is returned when a call is made to an address that doesn't contain a Wasm
+	// contract.
+	ErrNotWasmContract = errors.New("not a Wasm contract")
+	// ErrCallDepthExceeded is returned when the max depth of nested contract calls is exceeded.
+	ErrCallDepthExceeded = errors.New("max contract call depth exceeded")
+)
+
+type WasmVM struct {
+	State        loomchain.State
+	Registry     registry.Registry
+	EventHandler loomchain.EventHandler
+	logger       *loom.Logger
+	gasMeter     *gasmeter.GasMeter
+	callDepth    int
+}
+
+var _ vm.VM = &WasmVM{}
+
+// NewWasmVM creates a VM that executes Wasm contracts. Wasm contracts are always metered, if the
+// state doesn't carry a gas meter the VM will create one with the tx gas limit from the current
+// gas meter config.
+func NewWasmVM(
+	state loomchain.State,
+	registry registry.Registry,
+	eventHandler loomchain.EventHandler,
+	logger *loom.Logger,
+) (*WasmVM, error) {
+	gasMeter := gasmeter.FromContext(state.Context())
+	if gasMeter == nil {
+		cfg, err := gasmeter.LoadConfig(state)
+		if err != nil {
+			return nil, err
+		}
+		gasMeter = gasmeter.NewGasMeter(cfg)
+		state = state.WithContext(gasmeter.WithGasMeter(state.Context(), gasMeter))
+	}
+	return &WasmVM{
+		State:        state,
+		Registry:     registry,
+		EventHandler: eventHandler,
+		logger:       logger,
+		gasMeter:     gasMeter,
+	}, nil
+}
+
+func (vm *WasmVM) run(
+	caller,
+	addr loom.Address,
+	code,
+	input []byte,
+	entryPoint string,
+	readOnly bool,
+) (_ []byte, err error) {
+	if vm.gasMeter.IsOutOfGas() {
+		return nil, gasmeter.ErrOutOfGas
+	}
+	defer func() {
+		// The contract may have ignored the failure of a nested call that ran out of gas
+		if vm.gasMeter.IsOutOfGas() {
+			err = gasmeter.ErrOutOfGas
+		}
+	}()
+	defer gasmeter.RecoverOutOfGas(&err)
+
+	if vm.callDepth >= maxContractCallDepth {
+		return nil, ErrCallDepthExceeded
+	}
+	vm.callDepth++
+	defer func() { vm.callDepth-- }()
+
+	// Charge for loading the contract the same as for reading any other value from the store
+	vm.gasMeter.ConsumeRead(len(code))
+	module, err := DecodeModule(code)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := &contractContext{
+		vm:       vm,
+		caller:   caller,
+		address:  addr,
+		state:    vm.State.WithPrefix(loom.DataPrefix(addr)),
+		readOnly: readOnly,
+		input:    input,
+	}
+	inst, err := Instantiate(module, ctx.hostFuncs(), vm.gasMeter)
+	if err != nil {
+		return nil, err
+	}
+	if !inst.HasExport(entryPoint) {
+		if entryPoint == initFunc {
+			return nil, nil
+		}
+		return nil, errors.Errorf("contract doesn't export %s function", entryPoint)
+	}
+	if _, err := inst.Invoke(entryPoint); err != nil {
+		return nil, err
+	}
+	return ctx.output, nil
+}
+
+func (vm *WasmVM) Create(caller loom.Address, code []byte, value *loom.BigUInt) ([]byte, loom.Address, error) {
+	nonce := auth.Nonce(vm.State, caller)
+	contractAddr := plugin.CreateAddress(caller, nonce)
+
+	ret, err := vm.run(caller, contractAddr, code, nil, initFunc, false)
+	if err != nil {
+		return nil, contractAddr, err
+	}
+
+	vm.State.Set(loom.TextKey(contractAddr), code)
+	return ret, contractAddr, nil
+}
+
+func (vm *WasmVM) Call(caller, addr loom.Address, input []byte, value *loom.BigUInt) ([]byte, error) {
+	code, err := vm.GetCode(addr)
+	if err != nil {
+		return nil, err
+	}
+	return vm.run(caller, addr, code, input, callFunc, false)
+}
+
+func (vm *WasmVM) StaticCall(caller, addr loom.Address, input []byte) ([]byte, error) {
+	code, err := vm.GetCode(addr)
+	if err != nil {
+		return nil, err
+	}
+	return vm.run(caller, addr, code, input, staticCallFunc, true)
+}
+
+func (vm *WasmVM) GetCode(addr loom.Address) ([]byte, error) {
+	code := vm.State.Get(loom.TextKey(addr))
+	if !IsWasm(code) {
+		return nil, errors.Wrapf(ErrNotWasmContract, "contract %s", addr.String())
+	}
+	return code, nil
+}
+
+func (vm *WasmVM) GetStorageAt(addr loom.Address, key []byte) ([]byte, error) {
+	return vm.State.Get(util.PrefixKey(loom.DataPrefix(addr), key)), nil
+}
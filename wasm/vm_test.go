@@ -0,0 +1,190 @@
+package wasm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/loomnetwork/go-loom"
+	"github.com/loomnetwork/loomchain"
+	"github.com/loomnetwork/loomchain/gasmeter"
+	registry "github.com/loomnetwork/loomchain/registry/factory"
+	"github.com/loomnetwork/loomchain/store"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/tendermint/abci/types"
+)
+
+var vmCaller = loom.MustParseAddress("chain:0xb16a379ec18d4093666f8f38b11a3071c920207d")
+
+// Builds a contract that stores "init" under "key" when it's deployed, stores its input under
+// "key" when it's called, and returns the value of "key" when it's queried.
+func storageContract() []byte {
+	i32 := valueTypeI32
+	b := &moduleBuilder{}
+	voidType := b.addType(nil)
+	inputLen := b.addImport("input_len", b.addType(nil, i32))
+	readInput := b.addImport("read_input", b.addType([]valueType{i32}))
+	set := b.addImport("set", b.addType([]valueType{i32, i32, i32, i32}))
+	get := b.addImport("get", b.addType([]valueType{i32, i32}, i32))
+	readResult := b.addImport("read_result", b.addType([]valueType{i32}))
+	setOutput := b.addImport("set_output", b.addType([]valueType{i32, i32}))
+	b.setMemory(1)
+	b.addData(0, []byte("key"))
+	b.addData(32, []byte("init"))
+
+	const buf = 48
+	// set("key", "init")
+	initFn := b.addFunc(voidType, nil,
+		opI32Const, 0, opI32Const, 3, opI32Const, 32, opI32Const, 4, opCall, byte(set), opEnd,
+	)
+	// read_input(buf); set("key", input); set_output(input)
+	call := b.addFunc(voidType, nil,
+		opI32Const, buf, opCall, byte(readInput),
+		opI32Const, 0, opI32Const, 3, opI32Const, buf, opCall, byte(inputLen), opCall, byte(set),
+		opI32Const, buf, opCall, byte(inputLen), opCall, byte(setOutput), opEnd,
+	)
+	// n = get("key"); if n == -1 { return }; read_result(buf); set_output(buf, n)
+	staticCall := b.addFunc(voidType, []valueType{i32},
+		opI32Const, 0, opI32Const, 3, opCall, byte(get), opLocalSet, 0,
+		opLocalGet, 0, opI32Const, 0x7f, opI32Eq, opIf, blockTypeEmpty, opReturn, opEnd,
+		opI32Const, buf, opCall, byte(readResult),
+		opI32Const, buf, opLocalGet, 0, opCall, byte(setOutput), opEnd,
+	)
+	b.export("init", externalFunc, initFn)
+	b.export("call", externalFunc, call)
+	b.export("static_call", externalFunc, staticCall)
+	b.export("memory", externalMemory, 0)
+	return b.build()
+}
+
+func newTestWasmVM(t *testing.T, meter *gasmeter.GasMeter) *WasmVM {
+	block := abci.Header{
+		ChainID: "chain",
+		Height:  int64(34),
+		Time:    time.Unix(123456789, 0),
+	}
+	ctx := context.Background()
+	if meter != nil {
+		ctx = gasmeter.WithGasMeter(ctx, meter)
+	}
+	state := loomchain.NewStoreState(ctx, store.NewMemStore(), block, nil, nil)
+	createRegistry, err := registry.NewRegistryFactory(registry.LatestRegistryVersion)
+	require.NoError(t, err)
+	vm, err := NewWasmVM(state, createRegistry(state), nil, nil)
+	require.NoError(t, err)
+	return vm
+}
+
+func TestWasmVMCreateAndCall(t *testing.T) {
+	meter := gasmeter.NewGasMeter(gasmeter.DefaultConfig())
+	vm := newTestWasmVM(t, meter)
+	code := storageContract()
+
+	_, addr, err := vm.Create(vmCaller, code, loom.NewBigUIntFromInt(0))
+	require.NoError(t, err)
+	storedCode, err := vm.GetCode(addr)
+	require.NoError(t, err)
+	require.Equal(t, code, storedCode)
+
+	output, err := vm.StaticCall(vmCaller, addr, nil)
+	require.NoError(t, err)
+	require.Equal(t, []byte("init"), output)
+
+	output, err = vm.Call(vmCaller, addr, []byte("hello"), loom.NewBigUIntFromInt(0))
+	require.NoError(t, err)
+	require.Equal(t, []byte("hello"), output)
+
+	output, err = vm.StaticCall(vmCaller, addr, nil)
+	require.NoError(t, err)
+	require.Equal(t, []byte("hello"), output)
+	value, err := vm.GetStorageAt(addr, []byte("key"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("hello"), value)
+	require.True(t, meter.GasUsed() > 0)
+
+	_, err = vm.Call(vmCaller, vmCaller, []byte("hello"), loom.NewBigUIntFromInt(0))
+	require.Equal(t, ErrNotWasmContract, errors.Cause(err))
+}
+
+// Builds a contract that ranges over all of its entries when it's called.
+func rangeContract() []byte {
+	i32 := valueTypeI32
+	b := &moduleBuilder{}
+	voidType := b.addType(nil)
+	rangeFn := b.addImport("range", b.addType([]valueType{i32, i32}, i32))
+	b.setMemory(1)
+	call := b.addFunc(voidType, nil, opI32Const, 0, opI32Const, 0, opCall, byte(rangeFn), opDrop, opEnd)
+	b.export("call", externalFunc, call)
+	b.export("memory", externalMemory, 0)
+	return b.build()
+}
+
+func TestWasmVMRange(t *testing.T) {
+	cfg := gasmeter.DefaultConfig()
+	cfg.TxGasLimit = 200000
+	meter := gasmeter.NewGasMeter(cfg)
+	vm := newTestWasmVM(t, meter)
+	_, addr, err := vm.Create(vmCaller, rangeContract(), loom.NewBigUIntFromInt(0))
+	require.NoError(t, err)
+	contractState := vm.State.WithPrefix(loom.DataPrefix(addr))
+	contractState.Set([]byte("a"), make([]byte, 1000))
+
+	_, err = vm.Call(vmCaller, addr, nil, loom.NewBigUIntFromInt(0))
+	require.NoError(t, err)
+	gasUsed := meter.GasUsed()
+	require.True(t, gasUsed > cfg.RangeCost+cfg.RangeEntryCost+1000*cfg.ReadByteCost)
+
+	// Each entry is charged for, so the contract runs out of gas while iterating over a range
+	// that's larger than it can afford.
+	for i := 0; i < 100; i++ {
+		contractState.Set([]byte{'b', byte(i)}, make([]byte, 1000))
+	}
+	_, err = vm.Call(vmCaller, addr, nil, loom.NewBigUIntFromInt(0))
+	require.Equal(t, gasmeter.ErrOutOfGas, err)
+
+	// The result of a range can't exceed the max size of the memory of a contract.
+	cfg = gasmeter.DefaultConfig()
+	cfg.TxGasLimit = 1 << 40
+	vm = newTestWasmVM(t, gasmeter.NewGasMeter(cfg))
+	_, addr, err = vm.Create(vmCaller, rangeContract(), loom.NewBigUIntFromInt(0))
+	require.NoError(t, err)
+	contractState = vm.State.WithPrefix(loom.DataPrefix(addr))
+	for i := 0; i < maxRangeResultSize/pageSize+1; i++ {
+		contractState.Set([]byte{'c', byte(i)}, make([]byte, pageSize))
+	}
+	_, err = vm.Call(vmCaller, addr, nil, loom.NewBigUIntFromInt(0))
+	require.Equal(t, ErrRangeTooLarge, errors.Cause(err))
+}
+
+func TestWasmVMOutOfGas(t *testing.T) {
+	b := &moduleBuilder{}
+	voidType := b.addType(nil)
+	loop := b.addFunc(voidType, nil, opLoop, blockTypeEmpty, opBr, 0, opEnd, opEnd)
+	b.export("call", externalFunc, loop)
+
+	cfg := gasmeter.DefaultConfig()
+	cfg.TxGasLimit = 100000
+	meter := gasmeter.NewGasMeter(cfg)
+	vm := newTestWasmVM(t, meter)
+	_, addr, err := vm.Create(vmCaller, b.build(), loom.NewBigUIntFromInt(0))
+	require.NoError(t, err)
+
+	_, err = vm.Call(vmCaller, addr, nil, loom.NewBigUIntFromInt(0))
+	require.Equal(t, gasmeter.ErrOutOfGas, err)
+	require.True(t, meter.IsOutOfGas())
+	require.Equal(t, cfg.TxGasLimit, meter.GasUsed())
+}
+
+func TestWasmVMRejectsInvalidContracts(t *testing.T) {
+	vm := newTestWasmVM(t, nil)
+	b := &moduleBuilder{}
+	b.addFunc(b.addType([]valueType{valueTypeF32}), nil, opEnd)
+	_, _, err := vm.Create(vmCaller, b.build(), loom.NewBigUIntFromInt(0))
+	require.Equal(t, ErrFloatNotSupported, errors.Cause(err))
+
+	b = &moduleBuilder{}
+	b.addImport("random", b.addType(nil, valueTypeI64))
+	_, _, err = vm.Create(vmCaller, b.build(), loom.NewBigUIntFromInt(0))
+	require.Equal(t, ErrInvalidModule, errors.Cause(err))
+}